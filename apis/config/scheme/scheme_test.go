@@ -42,6 +42,10 @@ import (
 
 var testCPUQuantity, _ = resource.ParseQuantity("1000m")
 
+var testPreFilterMode = config.PreFilterMinResources
+
+var testPostFilterMode = config.PostFilterReject
+
 // TestCodecsDecodePluginConfig tests that embedded plugin args get decoded
 // into their appropriate internal types and defaults are applied.
 func TestCodecsDecodePluginConfig(t *testing.T) {
@@ -93,7 +97,10 @@ profiles:
 						{
 							Name: coscheduling.Name,
 							Args: &config.CoschedulingArgs{
-								PermitWaitingTimeSeconds: 60,
+								PermitWaitingTimeSeconds:  60,
+								PodGroupMaxBackoffSeconds: 300,
+								PreFilterMode:             &testPreFilterMode,
+								PostFilterMode:            &testPostFilterMode,
 							},
 						},
 						{
@@ -365,6 +372,7 @@ profiles:
       kind: CoschedulingArgs
       permitWaitingTimeSeconds: 10
       podGroupBackoffSeconds: 0
+      podGroupMaxBackoffSeconds: 0
     name: Coscheduling
   - args:
       apiVersion: kubescheduler.config.k8s.io/v1
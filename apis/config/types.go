@@ -31,9 +31,36 @@ type CoschedulingArgs struct {
 	// PermitWaitingTimeSeconds is the waiting timeout in seconds.
 	PermitWaitingTimeSeconds int64
 	// PodGroupBackoffSeconds is the backoff time in seconds before a pod group can be scheduled again.
+	// It doubles every time the pod group fails again, up to PodGroupMaxBackoffSeconds.
 	PodGroupBackoffSeconds int64
+	// PodGroupMaxBackoffSeconds is the max backoff time in seconds before a pod group can be scheduled again.
+	PodGroupMaxBackoffSeconds int64
+	// PreFilterMode selects how the feasibility of a pod group is checked in the PreFilter phase.
+	PreFilterMode *CoschedulingPreFilterMode
+	// PostFilterMode selects what is done in the PostFilter phase when a pod of a pod group is unschedulable.
+	PostFilterMode *CoschedulingPostFilterMode
 }
 
+// CoschedulingPreFilterMode is a "string" type.
+type CoschedulingPreFilterMode string
+
+const (
+	// PreFilterMinResources checks the sum of the free resources of the nodes against the minResources of the pod group
+	PreFilterMinResources CoschedulingPreFilterMode = "MinResources"
+	// PreFilterSimulatePlacement also simulates the placement of the pending members of the pod group on the nodes
+	PreFilterSimulatePlacement CoschedulingPreFilterMode = "SimulatePlacement"
+)
+
+// CoschedulingPostFilterMode is a "string" type.
+type CoschedulingPostFilterMode string
+
+const (
+	// PostFilterReject rejects the waiting members of the pod group and backs the pod group off
+	PostFilterReject CoschedulingPostFilterMode = "Reject"
+	// PostFilterPreempt first tries to preempt lower priority pods to make room for the whole pod group
+	PostFilterPreempt CoschedulingPostFilterMode = "Preempt"
+)
+
 // ModeType is a "string" type.
 type ModeType string
 
@@ -146,6 +173,22 @@ const (
 	LeastAllocated ScoringStrategyType = "LeastAllocated"
 	// LeastNUMANodes strategy favors nodes which requires least amount of NUMA nodes to satisfy resource requests for given pod
 	LeastNUMANodes ScoringStrategyType = "LeastNUMANodes"
+	// ClosestNUMANodes strategy favors nodes on which the NUMA nodes required to satisfy resource requests for given pod have the lowest distance
+	ClosestNUMANodes ScoringStrategyType = "ClosestNUMANodes"
+)
+
+// NUMAScoreAggregation is a "string" type.
+type NUMAScoreAggregation string
+
+const (
+	// NUMAScoreAggregationMin scores the node with the lowest score among its NUMA zones
+	NUMAScoreAggregationMin NUMAScoreAggregation = "Min"
+	// NUMAScoreAggregationMax scores the node with the highest score among its NUMA zones
+	NUMAScoreAggregationMax NUMAScoreAggregation = "Max"
+	// NUMAScoreAggregationMean scores the node with the mean of the scores of its NUMA zones
+	NUMAScoreAggregationMean NUMAScoreAggregation = "Mean"
+	// NUMAScoreAggregationBestFittingZone scores the node with the score of the NUMA zone the kubelet is expected to pick
+	NUMAScoreAggregationBestFittingZone NUMAScoreAggregation = "BestFittingZone"
 )
 
 // ScoringStrategy define ScoringStrategyType for node resource topology plugin
@@ -156,6 +199,10 @@ type ScoringStrategy struct {
 	// Resources a list of pairs <resource, weight> to be considered while scoring
 	// allowed weights start from 1.
 	Resources []schedconfig.ResourceSpec
+
+	// NUMAAggregation selects how the scores of the NUMA zones are combined in the node score
+	// by the MostAllocated, LeastAllocated and BalancedAllocation strategies. Defaults to Min.
+	NUMAAggregation NUMAScoreAggregation
 }
 
 // ForeignPodsDetectMode is a "string" type.
@@ -167,6 +214,14 @@ const (
 	ForeignPodsDetectOnlyExclusiveResources ForeignPodsDetectMode = "OnlyExclusiveResources"
 )
 
+// ForeignPodsAccountingMode is a "string" type.
+type ForeignPodsAccountingMode string
+
+const (
+	ForeignPodsAccountingResync    ForeignPodsAccountingMode = "Resync"
+	ForeignPodsAccountingReconcile ForeignPodsAccountingMode = "Reconcile"
+)
+
 // CacheResyncMethod is a "string" type.
 type CacheResyncMethod string
 
@@ -192,6 +247,62 @@ const (
 	CacheResyncScopeOnlyResources CacheResyncScope = "OnlyResources"
 )
 
+// CacheResyncTrigger is a "string" type
+type CacheResyncTrigger string
+
+const (
+	CacheResyncTriggerPeriodic CacheResyncTrigger = "Periodic"
+	CacheResyncTriggerEvent    CacheResyncTrigger = "Event"
+)
+
+// CacheCheckpointBackend is a "string" type
+type CacheCheckpointBackend string
+
+const (
+	CacheCheckpointNone      CacheCheckpointBackend = "None"
+	CacheCheckpointFile      CacheCheckpointBackend = "File"
+	CacheCheckpointConfigMap CacheCheckpointBackend = "ConfigMap"
+)
+
+// NodeResourceTopologyCacheCheckpoint define where the NodeResourceTopology cache state is persisted.
+type NodeResourceTopologyCacheCheckpoint struct {
+	// Backend selects where the cache state is persisted. "File" stores the state in a local file,
+	// "ConfigMap" stores the state in a ConfigMap object. "None" or unset disables the checkpointing.
+	Backend CacheCheckpointBackend
+	// Path is the file used by the "File" backend.
+	Path string
+	// ConfigMapNamespace is the namespace of the ConfigMap used by the "ConfigMap" backend.
+	ConfigMapNamespace string
+	// ConfigMapName is the name of the ConfigMap used by the "ConfigMap" backend.
+	// The scheduler needs the RBAC permissions to get, create and update the ConfigMap.
+	ConfigMapName string
+}
+
+// NodeResourceTopologyResourceAffinity declares how the extended resources relate to the NUMA zones.
+// CPU, memory and hugepages are always NUMA-affine; the other extended resources are considered
+// host-level unless the node reports them in its NUMA zones.
+type NodeResourceTopologyResourceAffinity struct {
+	// NUMAAffine lists the extended resources which must be allocated from the same NUMA zones
+	// as the CPUs and the memory, like SR-IOV VFs, GPUs or FPGAs.
+	NUMAAffine []v1.ResourceName
+	// HostLevel lists the extended resources which are never bound to NUMA zones,
+	// even if the node reports them in its NUMA zones.
+	HostLevel []v1.ResourceName
+}
+
+// NodeResourceTopologyPreemption configures the NUMA-aware preemption, which evicts the lower priority pods
+// holding exclusive resources on a NUMA zone to make room for pods rejected because no NUMA zone could fit them.
+type NodeResourceTopologyPreemption struct {
+	// Enabled registers the PostFilter extension point of the plugin. Disabled by default.
+	Enabled bool
+	// MinCandidateNodesPercentage is the minimum number of candidates to shortlist when dry running preemption
+	// as a percentage of number of nodes, like in the DefaultPreemption plugin.
+	MinCandidateNodesPercentage int32
+	// MinCandidateNodesAbsolute is the absolute minimum number of candidates to shortlist,
+	// like in the DefaultPreemption plugin.
+	MinCandidateNodesAbsolute int32
+}
+
 // NodeResourceTopologyCache define configuration details for the NodeResourceTopology cache.
 type NodeResourceTopologyCache struct {
 	// ForeignPodsDetect sets how foreign pods should be handled.
@@ -201,6 +312,12 @@ type NodeResourceTopologyCache struct {
 	// Has no effect if caching is disabled (CacheResyncPeriod is zero) or
 	// if DiscardReservedNodes is enabled. If unspecified, default is "All".
 	ForeignPodsDetect *ForeignPodsDetectMode
+	// ForeignPodsAccounting sets what the cache does with the detected foreign pods.
+	// "Resync" marks the node running them as dirty, so the node can't be used until it is resynced.
+	// "Reconcile" accounts the exclusive resources of the foreign pods like reservations, pessimistically
+	// on all the NUMA zones, until a resync confirms them; pods without exclusive resources are ignored.
+	// Has no effect if ForeignPodsDetect is "None". If unspecified, default is "Resync".
+	ForeignPodsAccounting *ForeignPodsAccountingMode
 	// ResyncMethod sets how the resync behaves to compute the expected node state.
 	// "All" consider all pods to compute the node state. "OnlyExclusiveResources" consider
 	// only pods regardless of their QoS which have exclusive resources assigned to their
@@ -219,6 +336,17 @@ type NodeResourceTopologyCache struct {
 	// "All" to make the code react to node config changes avoiding reboots.
 	// Use "OnlyResources" to restore the previous behavior.
 	ResyncScope *CacheResyncScope
+	// ResyncTrigger controls when the dirty nodes are resynced.
+	// "Periodic" resyncs all the dirty nodes every CacheResyncPeriod.
+	// "Event" additionally resyncs each dirty node as soon as its NRT object is updated
+	// or a pod running on it is deleted, with per-node rate limiting and backoff.
+	// The periodic resync is kept as fallback. If unspecified, default is "Periodic".
+	ResyncTrigger *CacheResyncTrigger
+	// Checkpoint enables to persist the cache state, to restore it across scheduler restarts
+	// or leader election failovers. The state is saved every CacheResyncPeriod.
+	// Has no effect if caching is disabled (CacheResyncPeriod is zero) or if DiscardReservedNodes
+	// is enabled. If unspecified, the cache state is not persisted.
+	Checkpoint *NodeResourceTopologyCacheCheckpoint
 }
 
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
@@ -237,6 +365,10 @@ type NodeResourceTopologyMatchArgs struct {
 	DiscardReservedNodes bool
 	// Cache enables to fine tune the caching behavior
 	Cache *NodeResourceTopologyCache
+	// ResourceAffinity declares which extended resources are NUMA-affine and which are not
+	ResourceAffinity *NodeResourceTopologyResourceAffinity
+	// Preemption configures the NUMA-aware preemption
+	Preemption *NodeResourceTopologyPreemption
 }
 
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
@@ -27,8 +27,11 @@ import (
 )
 
 var (
-	defaultPermitWaitingTimeSeconds int64 = 60
-	defaultPodGroupBackoffSeconds   int64 = 0
+	defaultPermitWaitingTimeSeconds  int64 = 60
+	defaultPodGroupBackoffSeconds    int64 = 0
+	defaultPodGroupMaxBackoffSeconds int64 = 300
+	defaultPreFilterMode                   = PreFilterMinResources
+	defaultPostFilterMode                  = PostFilterReject
 
 	defaultNodeResourcesAllocatableMode = Least
 
@@ -91,6 +94,11 @@ var (
 
 	defaultInformerMode = CacheInformerDedicated
 
+	// the NUMA-aware preemption is opt-in, and shortlists the candidates like the DefaultPreemption plugin does
+	defaultPreemptionEnabled                 = false
+	defaultMinCandidateNodesPercentage int32 = 10
+	defaultMinCandidateNodesAbsolute   int32 = 100
+
 	// Defaults for NetworkOverhead
 	// DefaultWeightsName contains the default costs to be used by networkAware plugins
 	DefaultWeightsName = "UserDefined"
@@ -112,6 +120,15 @@ func SetDefaults_CoschedulingArgs(obj *CoschedulingArgs) {
 	if obj.PodGroupBackoffSeconds == nil {
 		obj.PodGroupBackoffSeconds = &defaultPodGroupBackoffSeconds
 	}
+	if obj.PodGroupMaxBackoffSeconds == nil {
+		obj.PodGroupMaxBackoffSeconds = &defaultPodGroupMaxBackoffSeconds
+	}
+	if obj.PreFilterMode == nil {
+		obj.PreFilterMode = &defaultPreFilterMode
+	}
+	if obj.PostFilterMode == nil {
+		obj.PostFilterMode = &defaultPostFilterMode
+	}
 }
 
 // SetDefaults_NodeResourcesAllocatableArgs sets the defaults parameters for NodeResourceAllocatable.
@@ -211,6 +228,19 @@ func SetDefaults_NodeResourceTopologyMatchArgs(obj *NodeResourceTopologyMatchArg
 	if obj.Cache.InformerMode == nil {
 		obj.Cache.InformerMode = &defaultInformerMode
 	}
+
+	if obj.Preemption == nil {
+		obj.Preemption = &NodeResourceTopologyPreemption{}
+	}
+	if obj.Preemption.Enabled == nil {
+		obj.Preemption.Enabled = &defaultPreemptionEnabled
+	}
+	if obj.Preemption.MinCandidateNodesPercentage == nil {
+		obj.Preemption.MinCandidateNodesPercentage = &defaultMinCandidateNodesPercentage
+	}
+	if obj.Preemption.MinCandidateNodesAbsolute == nil {
+		obj.Preemption.MinCandidateNodesAbsolute = &defaultMinCandidateNodesAbsolute
+	}
 }
 
 // SetDefaults_PreemptionTolerationArgs reuses SetDefaults_DefaultPreemptionArgs
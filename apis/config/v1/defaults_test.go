@@ -30,6 +30,8 @@ import (
 )
 
 func TestSchedulingDefaults(t *testing.T) {
+	simulatePlacementMode := PreFilterSimulatePlacement
+	preemptMode := PostFilterPreempt
 	tests := []struct {
 		name   string
 		config runtime.Object
@@ -39,19 +41,28 @@ func TestSchedulingDefaults(t *testing.T) {
 			name:   "empty config CoschedulingArgs",
 			config: &CoschedulingArgs{},
 			expect: &CoschedulingArgs{
-				PermitWaitingTimeSeconds: pointer.Int64Ptr(60),
-				PodGroupBackoffSeconds:   pointer.Int64Ptr(0),
+				PermitWaitingTimeSeconds:  pointer.Int64Ptr(60),
+				PodGroupBackoffSeconds:    pointer.Int64Ptr(0),
+				PodGroupMaxBackoffSeconds: pointer.Int64Ptr(300),
+				PreFilterMode:             &defaultPreFilterMode,
+				PostFilterMode:            &defaultPostFilterMode,
 			},
 		},
 		{
 			name: "set non default CoschedulingArgs",
 			config: &CoschedulingArgs{
-				PermitWaitingTimeSeconds: pointer.Int64Ptr(60),
-				PodGroupBackoffSeconds:   pointer.Int64Ptr(20),
+				PermitWaitingTimeSeconds:  pointer.Int64Ptr(60),
+				PodGroupBackoffSeconds:    pointer.Int64Ptr(20),
+				PodGroupMaxBackoffSeconds: pointer.Int64Ptr(600),
+				PreFilterMode:             &simulatePlacementMode,
+				PostFilterMode:            &preemptMode,
 			},
 			expect: &CoschedulingArgs{
-				PermitWaitingTimeSeconds: pointer.Int64Ptr(60),
-				PodGroupBackoffSeconds:   pointer.Int64Ptr(20),
+				PermitWaitingTimeSeconds:  pointer.Int64Ptr(60),
+				PodGroupBackoffSeconds:    pointer.Int64Ptr(20),
+				PodGroupMaxBackoffSeconds: pointer.Int64Ptr(600),
+				PreFilterMode:             &simulatePlacementMode,
+				PostFilterMode:            &preemptMode,
 			},
 		},
 		{
@@ -207,6 +218,36 @@ func TestSchedulingDefaults(t *testing.T) {
 					ResyncMethod:      &defaultResyncMethod,
 					InformerMode:      &defaultInformerMode,
 				},
+				Preemption: &NodeResourceTopologyPreemption{
+					Enabled:                     pointer.Bool(false),
+					MinCandidateNodesPercentage: pointer.Int32(10),
+					MinCandidateNodesAbsolute:   pointer.Int32(100),
+				},
+			},
+		},
+		{
+			name: "set preemption enabled NodeResourceTopologyMatchArgs",
+			config: &NodeResourceTopologyMatchArgs{
+				Preemption: &NodeResourceTopologyPreemption{
+					Enabled:                   pointer.Bool(true),
+					MinCandidateNodesAbsolute: pointer.Int32(5),
+				},
+			},
+			expect: &NodeResourceTopologyMatchArgs{
+				ScoringStrategy: &ScoringStrategy{
+					Type:      LeastAllocated,
+					Resources: defaultResourceSpec,
+				},
+				Cache: &NodeResourceTopologyCache{
+					ForeignPodsDetect: &defaultForeignPodsDetect,
+					ResyncMethod:      &defaultResyncMethod,
+					InformerMode:      &defaultInformerMode,
+				},
+				Preemption: &NodeResourceTopologyPreemption{
+					Enabled:                     pointer.Bool(true),
+					MinCandidateNodesPercentage: pointer.Int32(10),
+					MinCandidateNodesAbsolute:   pointer.Int32(5),
+				},
 			},
 		},
 		{
@@ -31,9 +31,39 @@ type CoschedulingArgs struct {
 	// PermitWaitingTimeSeconds is the waiting timeout in seconds.
 	PermitWaitingTimeSeconds *int64 `json:"permitWaitingTimeSeconds,omitempty"`
 	// PodGroupBackoffSeconds is the backoff time in seconds before a pod group can be scheduled again.
+	// It doubles every time the pod group fails again, up to PodGroupMaxBackoffSeconds.
 	PodGroupBackoffSeconds *int64 `json:"podGroupBackoffSeconds,omitempty"`
+	// PodGroupMaxBackoffSeconds is the max backoff time in seconds before a pod group can be scheduled again.
+	// Defaults to 300.
+	PodGroupMaxBackoffSeconds *int64 `json:"podGroupMaxBackoffSeconds,omitempty"`
+	// PreFilterMode selects how the feasibility of a pod group is checked in the PreFilter phase.
+	// Defaults to MinResources.
+	PreFilterMode *CoschedulingPreFilterMode `json:"preFilterMode,omitempty"`
+	// PostFilterMode selects what is done in the PostFilter phase when a pod of a pod group is unschedulable.
+	// Defaults to Reject.
+	PostFilterMode *CoschedulingPostFilterMode `json:"postFilterMode,omitempty"`
 }
 
+// CoschedulingPreFilterMode is a "string" type.
+type CoschedulingPreFilterMode string
+
+const (
+	// PreFilterMinResources checks the sum of the free resources of the nodes against the minResources of the pod group
+	PreFilterMinResources CoschedulingPreFilterMode = "MinResources"
+	// PreFilterSimulatePlacement also simulates the placement of the pending members of the pod group on the nodes
+	PreFilterSimulatePlacement CoschedulingPreFilterMode = "SimulatePlacement"
+)
+
+// CoschedulingPostFilterMode is a "string" type.
+type CoschedulingPostFilterMode string
+
+const (
+	// PostFilterReject rejects the waiting members of the pod group and backs the pod group off
+	PostFilterReject CoschedulingPostFilterMode = "Reject"
+	// PostFilterPreempt first tries to preempt lower priority pods to make room for the whole pod group
+	PostFilterPreempt CoschedulingPostFilterMode = "Preempt"
+)
+
 // ModeType is a type "string".
 type ModeType string
 
@@ -149,11 +179,30 @@ const (
 	LeastAllocated ScoringStrategyType = "LeastAllocated"
 	// LeastNUMANodes strategy favors nodes which requires least amount of NUMA nodes to satisfy resource requests for given pod
 	LeastNUMANodes ScoringStrategyType = "LeastNUMANodes"
+	// ClosestNUMANodes strategy favors nodes on which the NUMA nodes required to satisfy resource requests for given pod have the lowest distance
+	ClosestNUMANodes ScoringStrategyType = "ClosestNUMANodes"
+)
+
+// NUMAScoreAggregation is a "string" type.
+type NUMAScoreAggregation string
+
+const (
+	// NUMAScoreAggregationMin scores the node with the lowest score among its NUMA zones
+	NUMAScoreAggregationMin NUMAScoreAggregation = "Min"
+	// NUMAScoreAggregationMax scores the node with the highest score among its NUMA zones
+	NUMAScoreAggregationMax NUMAScoreAggregation = "Max"
+	// NUMAScoreAggregationMean scores the node with the mean of the scores of its NUMA zones
+	NUMAScoreAggregationMean NUMAScoreAggregation = "Mean"
+	// NUMAScoreAggregationBestFittingZone scores the node with the score of the NUMA zone the kubelet is expected to pick
+	NUMAScoreAggregationBestFittingZone NUMAScoreAggregation = "BestFittingZone"
 )
 
 type ScoringStrategy struct {
 	Type      ScoringStrategyType              `json:"type,omitempty"`
 	Resources []schedulerconfigv1.ResourceSpec `json:"resources,omitempty"`
+	// NUMAAggregation selects how the scores of the NUMA zones are combined in the node score
+	// by the MostAllocated, LeastAllocated and BalancedAllocation strategies. Defaults to Min.
+	NUMAAggregation NUMAScoreAggregation `json:"numaAggregation,omitempty"`
 }
 
 // ForeignPodsDetectMode is a "string" type.
@@ -165,6 +214,14 @@ const (
 	ForeignPodsDetectOnlyExclusiveResources ForeignPodsDetectMode = "OnlyExclusiveResources"
 )
 
+// ForeignPodsAccountingMode is a "string" type.
+type ForeignPodsAccountingMode string
+
+const (
+	ForeignPodsAccountingResync    ForeignPodsAccountingMode = "Resync"
+	ForeignPodsAccountingReconcile ForeignPodsAccountingMode = "Reconcile"
+)
+
 // CacheResyncMethod is a "string" type.
 type CacheResyncMethod string
 
@@ -190,6 +247,62 @@ const (
 	CacheResyncScopeOnlyResources CacheResyncScope = "OnlyResources"
 )
 
+// CacheResyncTrigger is a "string" type
+type CacheResyncTrigger string
+
+const (
+	CacheResyncTriggerPeriodic CacheResyncTrigger = "Periodic"
+	CacheResyncTriggerEvent    CacheResyncTrigger = "Event"
+)
+
+// CacheCheckpointBackend is a "string" type
+type CacheCheckpointBackend string
+
+const (
+	CacheCheckpointNone      CacheCheckpointBackend = "None"
+	CacheCheckpointFile      CacheCheckpointBackend = "File"
+	CacheCheckpointConfigMap CacheCheckpointBackend = "ConfigMap"
+)
+
+// NodeResourceTopologyCacheCheckpoint define where the NodeResourceTopology cache state is persisted.
+type NodeResourceTopologyCacheCheckpoint struct {
+	// Backend selects where the cache state is persisted. "File" stores the state in a local file,
+	// "ConfigMap" stores the state in a ConfigMap object. "None" or unset disables the checkpointing.
+	Backend CacheCheckpointBackend `json:"backend,omitempty"`
+	// Path is the file used by the "File" backend.
+	Path string `json:"path,omitempty"`
+	// ConfigMapNamespace is the namespace of the ConfigMap used by the "ConfigMap" backend.
+	ConfigMapNamespace string `json:"configMapNamespace,omitempty"`
+	// ConfigMapName is the name of the ConfigMap used by the "ConfigMap" backend.
+	// The scheduler needs the RBAC permissions to get, create and update the ConfigMap.
+	ConfigMapName string `json:"configMapName,omitempty"`
+}
+
+// NodeResourceTopologyResourceAffinity declares how the extended resources relate to the NUMA zones.
+// CPU, memory and hugepages are always NUMA-affine; the other extended resources are considered
+// host-level unless the node reports them in its NUMA zones.
+type NodeResourceTopologyResourceAffinity struct {
+	// NUMAAffine lists the extended resources which must be allocated from the same NUMA zones
+	// as the CPUs and the memory, like SR-IOV VFs, GPUs or FPGAs.
+	NUMAAffine []v1.ResourceName `json:"numaAffine,omitempty"`
+	// HostLevel lists the extended resources which are never bound to NUMA zones,
+	// even if the node reports them in its NUMA zones.
+	HostLevel []v1.ResourceName `json:"hostLevel,omitempty"`
+}
+
+// NodeResourceTopologyPreemption configures the NUMA-aware preemption, which evicts the lower priority pods
+// holding exclusive resources on a NUMA zone to make room for pods rejected because no NUMA zone could fit them.
+type NodeResourceTopologyPreemption struct {
+	// Enabled registers the PostFilter extension point of the plugin. If unspecified, default is false.
+	Enabled *bool `json:"enabled,omitempty"`
+	// MinCandidateNodesPercentage is the minimum number of candidates to shortlist when dry running preemption
+	// as a percentage of number of nodes, like in the DefaultPreemption plugin. If unspecified, default is 10.
+	MinCandidateNodesPercentage *int32 `json:"minCandidateNodesPercentage,omitempty"`
+	// MinCandidateNodesAbsolute is the absolute minimum number of candidates to shortlist,
+	// like in the DefaultPreemption plugin. If unspecified, default is 100.
+	MinCandidateNodesAbsolute *int32 `json:"minCandidateNodesAbsolute,omitempty"`
+}
+
 // NodeResourceTopologyCache define configuration details for the NodeResourceTopology cache.
 type NodeResourceTopologyCache struct {
 	// ForeignPodsDetect sets how foreign pods should be handled.
@@ -199,6 +312,12 @@ type NodeResourceTopologyCache struct {
 	// Has no effect if caching is disabled (CacheResyncPeriod is zero) or if DiscardReservedNodes
 	// is enabled. If unspecified, default is "All". Use "None" to disable.
 	ForeignPodsDetect *ForeignPodsDetectMode `json:"foreignPodsDetect,omitempty"`
+	// ForeignPodsAccounting sets what the cache does with the detected foreign pods.
+	// "Resync" marks the node running them as dirty, so the node can't be used until it is resynced.
+	// "Reconcile" accounts the exclusive resources of the foreign pods like reservations, pessimistically
+	// on all the NUMA zones, until a resync confirms them; pods without exclusive resources are ignored.
+	// Has no effect if ForeignPodsDetect is "None". If unspecified, default is "Resync".
+	ForeignPodsAccounting *ForeignPodsAccountingMode `json:"foreignPodsAccounting,omitempty"`
 	// ResyncMethod sets how the resync behaves to compute the expected node state.
 	// "All" consider all pods to compute the node state. "OnlyExclusiveResources" consider
 	// only pods regardless of their QoS which have exclusive resources assigned to their
@@ -217,6 +336,17 @@ type NodeResourceTopologyCache struct {
 	// "All" to make the code react to node config changes avoiding reboots.
 	// Use "OnlyResources" to restore the previous behavior.
 	ResyncScope *CacheResyncScope `json:"resyncScope,omitempty"`
+	// ResyncTrigger controls when the dirty nodes are resynced.
+	// "Periodic" resyncs all the dirty nodes every CacheResyncPeriod.
+	// "Event" additionally resyncs each dirty node as soon as its NRT object is updated
+	// or a pod running on it is deleted, with per-node rate limiting and backoff.
+	// The periodic resync is kept as fallback. If unspecified, default is "Periodic".
+	ResyncTrigger *CacheResyncTrigger `json:"resyncTrigger,omitempty"`
+	// Checkpoint enables to persist the cache state, to restore it across scheduler restarts
+	// or leader election failovers. The state is saved every CacheResyncPeriod.
+	// Has no effect if caching is disabled (CacheResyncPeriod is zero) or if DiscardReservedNodes
+	// is enabled. If unspecified, the cache state is not persisted.
+	Checkpoint *NodeResourceTopologyCacheCheckpoint `json:"checkpoint,omitempty"`
 }
 
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
@@ -235,6 +365,10 @@ type NodeResourceTopologyMatchArgs struct {
 	DiscardReservedNodes bool `json:"discardReservedNodes,omitempty"`
 	// Cache enables to fine tune the caching behavior
 	Cache *NodeResourceTopologyCache `json:"cache,omitempty"`
+	// ResourceAffinity declares which extended resources are NUMA-affine and which are not
+	ResourceAffinity *NodeResourceTopologyResourceAffinity `json:"resourceAffinity,omitempty"`
+	// Preemption configures the NUMA-aware preemption
+	Preemption *NodeResourceTopologyPreemption `json:"preemption,omitempty"`
 }
 
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
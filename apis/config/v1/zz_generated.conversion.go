@@ -100,6 +100,36 @@ func RegisterConversions(s *runtime.Scheme) error {
 	}); err != nil {
 		return err
 	}
+	if err := s.AddGeneratedConversionFunc((*NodeResourceTopologyCacheCheckpoint)(nil), (*config.NodeResourceTopologyCacheCheckpoint)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1_NodeResourceTopologyCacheCheckpoint_To_config_NodeResourceTopologyCacheCheckpoint(a.(*NodeResourceTopologyCacheCheckpoint), b.(*config.NodeResourceTopologyCacheCheckpoint), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*config.NodeResourceTopologyCacheCheckpoint)(nil), (*NodeResourceTopologyCacheCheckpoint)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_config_NodeResourceTopologyCacheCheckpoint_To_v1_NodeResourceTopologyCacheCheckpoint(a.(*config.NodeResourceTopologyCacheCheckpoint), b.(*NodeResourceTopologyCacheCheckpoint), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*NodeResourceTopologyPreemption)(nil), (*config.NodeResourceTopologyPreemption)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1_NodeResourceTopologyPreemption_To_config_NodeResourceTopologyPreemption(a.(*NodeResourceTopologyPreemption), b.(*config.NodeResourceTopologyPreemption), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*config.NodeResourceTopologyPreemption)(nil), (*NodeResourceTopologyPreemption)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_config_NodeResourceTopologyPreemption_To_v1_NodeResourceTopologyPreemption(a.(*config.NodeResourceTopologyPreemption), b.(*NodeResourceTopologyPreemption), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*NodeResourceTopologyResourceAffinity)(nil), (*config.NodeResourceTopologyResourceAffinity)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1_NodeResourceTopologyResourceAffinity_To_config_NodeResourceTopologyResourceAffinity(a.(*NodeResourceTopologyResourceAffinity), b.(*config.NodeResourceTopologyResourceAffinity), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*config.NodeResourceTopologyResourceAffinity)(nil), (*NodeResourceTopologyResourceAffinity)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_config_NodeResourceTopologyResourceAffinity_To_v1_NodeResourceTopologyResourceAffinity(a.(*config.NodeResourceTopologyResourceAffinity), b.(*NodeResourceTopologyResourceAffinity), scope)
+	}); err != nil {
+		return err
+	}
 	if err := s.AddGeneratedConversionFunc((*NodeResourcesAllocatableArgs)(nil), (*config.NodeResourcesAllocatableArgs)(nil), func(a, b interface{}, scope conversion.Scope) error {
 		return Convert_v1_NodeResourcesAllocatableArgs_To_config_NodeResourcesAllocatableArgs(a.(*NodeResourcesAllocatableArgs), b.(*config.NodeResourcesAllocatableArgs), scope)
 	}); err != nil {
@@ -190,6 +220,11 @@ func autoConvert_v1_CoschedulingArgs_To_config_CoschedulingArgs(in *Coscheduling
 	if err := metav1.Convert_Pointer_int64_To_int64(&in.PodGroupBackoffSeconds, &out.PodGroupBackoffSeconds, s); err != nil {
 		return err
 	}
+	if err := metav1.Convert_Pointer_int64_To_int64(&in.PodGroupMaxBackoffSeconds, &out.PodGroupMaxBackoffSeconds, s); err != nil {
+		return err
+	}
+	out.PreFilterMode = (*config.CoschedulingPreFilterMode)(unsafe.Pointer(in.PreFilterMode))
+	out.PostFilterMode = (*config.CoschedulingPostFilterMode)(unsafe.Pointer(in.PostFilterMode))
 	return nil
 }
 
@@ -205,6 +240,11 @@ func autoConvert_config_CoschedulingArgs_To_v1_CoschedulingArgs(in *config.Cosch
 	if err := metav1.Convert_int64_To_Pointer_int64(&in.PodGroupBackoffSeconds, &out.PodGroupBackoffSeconds, s); err != nil {
 		return err
 	}
+	if err := metav1.Convert_int64_To_Pointer_int64(&in.PodGroupMaxBackoffSeconds, &out.PodGroupMaxBackoffSeconds, s); err != nil {
+		return err
+	}
+	out.PreFilterMode = (*CoschedulingPreFilterMode)(unsafe.Pointer(in.PreFilterMode))
+	out.PostFilterMode = (*CoschedulingPostFilterMode)(unsafe.Pointer(in.PostFilterMode))
 	return nil
 }
 
@@ -353,9 +393,12 @@ func Convert_config_NetworkOverheadArgs_To_v1_NetworkOverheadArgs(in *config.Net
 
 func autoConvert_v1_NodeResourceTopologyCache_To_config_NodeResourceTopologyCache(in *NodeResourceTopologyCache, out *config.NodeResourceTopologyCache, s conversion.Scope) error {
 	out.ForeignPodsDetect = (*config.ForeignPodsDetectMode)(unsafe.Pointer(in.ForeignPodsDetect))
+	out.ForeignPodsAccounting = (*config.ForeignPodsAccountingMode)(unsafe.Pointer(in.ForeignPodsAccounting))
 	out.ResyncMethod = (*config.CacheResyncMethod)(unsafe.Pointer(in.ResyncMethod))
 	out.InformerMode = (*config.CacheInformerMode)(unsafe.Pointer(in.InformerMode))
 	out.ResyncScope = (*config.CacheResyncScope)(unsafe.Pointer(in.ResyncScope))
+	out.ResyncTrigger = (*config.CacheResyncTrigger)(unsafe.Pointer(in.ResyncTrigger))
+	out.Checkpoint = (*config.NodeResourceTopologyCacheCheckpoint)(unsafe.Pointer(in.Checkpoint))
 	return nil
 }
 
@@ -366,9 +409,12 @@ func Convert_v1_NodeResourceTopologyCache_To_config_NodeResourceTopologyCache(in
 
 func autoConvert_config_NodeResourceTopologyCache_To_v1_NodeResourceTopologyCache(in *config.NodeResourceTopologyCache, out *NodeResourceTopologyCache, s conversion.Scope) error {
 	out.ForeignPodsDetect = (*ForeignPodsDetectMode)(unsafe.Pointer(in.ForeignPodsDetect))
+	out.ForeignPodsAccounting = (*ForeignPodsAccountingMode)(unsafe.Pointer(in.ForeignPodsAccounting))
 	out.ResyncMethod = (*CacheResyncMethod)(unsafe.Pointer(in.ResyncMethod))
 	out.InformerMode = (*CacheInformerMode)(unsafe.Pointer(in.InformerMode))
 	out.ResyncScope = (*CacheResyncScope)(unsafe.Pointer(in.ResyncScope))
+	out.ResyncTrigger = (*CacheResyncTrigger)(unsafe.Pointer(in.ResyncTrigger))
+	out.Checkpoint = (*NodeResourceTopologyCacheCheckpoint)(unsafe.Pointer(in.Checkpoint))
 	return nil
 }
 
@@ -377,6 +423,32 @@ func Convert_config_NodeResourceTopologyCache_To_v1_NodeResourceTopologyCache(in
 	return autoConvert_config_NodeResourceTopologyCache_To_v1_NodeResourceTopologyCache(in, out, s)
 }
 
+func autoConvert_v1_NodeResourceTopologyCacheCheckpoint_To_config_NodeResourceTopologyCacheCheckpoint(in *NodeResourceTopologyCacheCheckpoint, out *config.NodeResourceTopologyCacheCheckpoint, s conversion.Scope) error {
+	out.Backend = config.CacheCheckpointBackend(in.Backend)
+	out.Path = in.Path
+	out.ConfigMapNamespace = in.ConfigMapNamespace
+	out.ConfigMapName = in.ConfigMapName
+	return nil
+}
+
+// Convert_v1_NodeResourceTopologyCacheCheckpoint_To_config_NodeResourceTopologyCacheCheckpoint is an autogenerated conversion function.
+func Convert_v1_NodeResourceTopologyCacheCheckpoint_To_config_NodeResourceTopologyCacheCheckpoint(in *NodeResourceTopologyCacheCheckpoint, out *config.NodeResourceTopologyCacheCheckpoint, s conversion.Scope) error {
+	return autoConvert_v1_NodeResourceTopologyCacheCheckpoint_To_config_NodeResourceTopologyCacheCheckpoint(in, out, s)
+}
+
+func autoConvert_config_NodeResourceTopologyCacheCheckpoint_To_v1_NodeResourceTopologyCacheCheckpoint(in *config.NodeResourceTopologyCacheCheckpoint, out *NodeResourceTopologyCacheCheckpoint, s conversion.Scope) error {
+	out.Backend = CacheCheckpointBackend(in.Backend)
+	out.Path = in.Path
+	out.ConfigMapNamespace = in.ConfigMapNamespace
+	out.ConfigMapName = in.ConfigMapName
+	return nil
+}
+
+// Convert_config_NodeResourceTopologyCacheCheckpoint_To_v1_NodeResourceTopologyCacheCheckpoint is an autogenerated conversion function.
+func Convert_config_NodeResourceTopologyCacheCheckpoint_To_v1_NodeResourceTopologyCacheCheckpoint(in *config.NodeResourceTopologyCacheCheckpoint, out *NodeResourceTopologyCacheCheckpoint, s conversion.Scope) error {
+	return autoConvert_config_NodeResourceTopologyCacheCheckpoint_To_v1_NodeResourceTopologyCacheCheckpoint(in, out, s)
+}
+
 func autoConvert_v1_NodeResourceTopologyMatchArgs_To_config_NodeResourceTopologyMatchArgs(in *NodeResourceTopologyMatchArgs, out *config.NodeResourceTopologyMatchArgs, s conversion.Scope) error {
 	// WARNING: in.ScoringStrategy requires manual conversion: inconvertible types (*sigs.k8s.io/scheduler-plugins/apis/config/v1.ScoringStrategy vs sigs.k8s.io/scheduler-plugins/apis/config.ScoringStrategy)
 	if err := metav1.Convert_Pointer_int64_To_int64(&in.CacheResyncPeriodSeconds, &out.CacheResyncPeriodSeconds, s); err != nil {
@@ -384,6 +456,16 @@ func autoConvert_v1_NodeResourceTopologyMatchArgs_To_config_NodeResourceTopology
 	}
 	out.DiscardReservedNodes = in.DiscardReservedNodes
 	out.Cache = (*config.NodeResourceTopologyCache)(unsafe.Pointer(in.Cache))
+	out.ResourceAffinity = (*config.NodeResourceTopologyResourceAffinity)(unsafe.Pointer(in.ResourceAffinity))
+	if in.Preemption != nil {
+		in, out := &in.Preemption, &out.Preemption
+		*out = new(config.NodeResourceTopologyPreemption)
+		if err := Convert_v1_NodeResourceTopologyPreemption_To_config_NodeResourceTopologyPreemption(*in, *out, s); err != nil {
+			return err
+		}
+	} else {
+		out.Preemption = nil
+	}
 	return nil
 }
 
@@ -394,9 +476,77 @@ func autoConvert_config_NodeResourceTopologyMatchArgs_To_v1_NodeResourceTopology
 	}
 	out.DiscardReservedNodes = in.DiscardReservedNodes
 	out.Cache = (*NodeResourceTopologyCache)(unsafe.Pointer(in.Cache))
+	out.ResourceAffinity = (*NodeResourceTopologyResourceAffinity)(unsafe.Pointer(in.ResourceAffinity))
+	if in.Preemption != nil {
+		in, out := &in.Preemption, &out.Preemption
+		*out = new(NodeResourceTopologyPreemption)
+		if err := Convert_config_NodeResourceTopologyPreemption_To_v1_NodeResourceTopologyPreemption(*in, *out, s); err != nil {
+			return err
+		}
+	} else {
+		out.Preemption = nil
+	}
+	return nil
+}
+
+func autoConvert_v1_NodeResourceTopologyPreemption_To_config_NodeResourceTopologyPreemption(in *NodeResourceTopologyPreemption, out *config.NodeResourceTopologyPreemption, s conversion.Scope) error {
+	if err := metav1.Convert_Pointer_bool_To_bool(&in.Enabled, &out.Enabled, s); err != nil {
+		return err
+	}
+	if err := metav1.Convert_Pointer_int32_To_int32(&in.MinCandidateNodesPercentage, &out.MinCandidateNodesPercentage, s); err != nil {
+		return err
+	}
+	if err := metav1.Convert_Pointer_int32_To_int32(&in.MinCandidateNodesAbsolute, &out.MinCandidateNodesAbsolute, s); err != nil {
+		return err
+	}
 	return nil
 }
 
+// Convert_v1_NodeResourceTopologyPreemption_To_config_NodeResourceTopologyPreemption is an autogenerated conversion function.
+func Convert_v1_NodeResourceTopologyPreemption_To_config_NodeResourceTopologyPreemption(in *NodeResourceTopologyPreemption, out *config.NodeResourceTopologyPreemption, s conversion.Scope) error {
+	return autoConvert_v1_NodeResourceTopologyPreemption_To_config_NodeResourceTopologyPreemption(in, out, s)
+}
+
+func autoConvert_config_NodeResourceTopologyPreemption_To_v1_NodeResourceTopologyPreemption(in *config.NodeResourceTopologyPreemption, out *NodeResourceTopologyPreemption, s conversion.Scope) error {
+	if err := metav1.Convert_bool_To_Pointer_bool(&in.Enabled, &out.Enabled, s); err != nil {
+		return err
+	}
+	if err := metav1.Convert_int32_To_Pointer_int32(&in.MinCandidateNodesPercentage, &out.MinCandidateNodesPercentage, s); err != nil {
+		return err
+	}
+	if err := metav1.Convert_int32_To_Pointer_int32(&in.MinCandidateNodesAbsolute, &out.MinCandidateNodesAbsolute, s); err != nil {
+		return err
+	}
+	return nil
+}
+
+// Convert_config_NodeResourceTopologyPreemption_To_v1_NodeResourceTopologyPreemption is an autogenerated conversion function.
+func Convert_config_NodeResourceTopologyPreemption_To_v1_NodeResourceTopologyPreemption(in *config.NodeResourceTopologyPreemption, out *NodeResourceTopologyPreemption, s conversion.Scope) error {
+	return autoConvert_config_NodeResourceTopologyPreemption_To_v1_NodeResourceTopologyPreemption(in, out, s)
+}
+
+func autoConvert_v1_NodeResourceTopologyResourceAffinity_To_config_NodeResourceTopologyResourceAffinity(in *NodeResourceTopologyResourceAffinity, out *config.NodeResourceTopologyResourceAffinity, s conversion.Scope) error {
+	out.NUMAAffine = *(*[]corev1.ResourceName)(unsafe.Pointer(&in.NUMAAffine))
+	out.HostLevel = *(*[]corev1.ResourceName)(unsafe.Pointer(&in.HostLevel))
+	return nil
+}
+
+// Convert_v1_NodeResourceTopologyResourceAffinity_To_config_NodeResourceTopologyResourceAffinity is an autogenerated conversion function.
+func Convert_v1_NodeResourceTopologyResourceAffinity_To_config_NodeResourceTopologyResourceAffinity(in *NodeResourceTopologyResourceAffinity, out *config.NodeResourceTopologyResourceAffinity, s conversion.Scope) error {
+	return autoConvert_v1_NodeResourceTopologyResourceAffinity_To_config_NodeResourceTopologyResourceAffinity(in, out, s)
+}
+
+func autoConvert_config_NodeResourceTopologyResourceAffinity_To_v1_NodeResourceTopologyResourceAffinity(in *config.NodeResourceTopologyResourceAffinity, out *NodeResourceTopologyResourceAffinity, s conversion.Scope) error {
+	out.NUMAAffine = *(*[]corev1.ResourceName)(unsafe.Pointer(&in.NUMAAffine))
+	out.HostLevel = *(*[]corev1.ResourceName)(unsafe.Pointer(&in.HostLevel))
+	return nil
+}
+
+// Convert_config_NodeResourceTopologyResourceAffinity_To_v1_NodeResourceTopologyResourceAffinity is an autogenerated conversion function.
+func Convert_config_NodeResourceTopologyResourceAffinity_To_v1_NodeResourceTopologyResourceAffinity(in *config.NodeResourceTopologyResourceAffinity, out *NodeResourceTopologyResourceAffinity, s conversion.Scope) error {
+	return autoConvert_config_NodeResourceTopologyResourceAffinity_To_v1_NodeResourceTopologyResourceAffinity(in, out, s)
+}
+
 func autoConvert_v1_NodeResourcesAllocatableArgs_To_config_NodeResourcesAllocatableArgs(in *NodeResourcesAllocatableArgs, out *config.NodeResourcesAllocatableArgs, s conversion.Scope) error {
 	out.Resources = *(*[]apisconfig.ResourceSpec)(unsafe.Pointer(&in.Resources))
 	out.Mode = config.ModeType(in.Mode)
@@ -452,6 +602,7 @@ func Convert_config_PreemptionTolerationArgs_To_v1_PreemptionTolerationArgs(in *
 func autoConvert_v1_ScoringStrategy_To_config_ScoringStrategy(in *ScoringStrategy, out *config.ScoringStrategy, s conversion.Scope) error {
 	out.Type = config.ScoringStrategyType(in.Type)
 	out.Resources = *(*[]apisconfig.ResourceSpec)(unsafe.Pointer(&in.Resources))
+	out.NUMAAggregation = config.NUMAScoreAggregation(in.NUMAAggregation)
 	return nil
 }
 
@@ -463,6 +614,7 @@ func Convert_v1_ScoringStrategy_To_config_ScoringStrategy(in *ScoringStrategy, o
 func autoConvert_config_ScoringStrategy_To_v1_ScoringStrategy(in *config.ScoringStrategy, out *ScoringStrategy, s conversion.Scope) error {
 	out.Type = ScoringStrategyType(in.Type)
 	out.Resources = *(*[]configv1.ResourceSpec)(unsafe.Pointer(&in.Resources))
+	out.NUMAAggregation = NUMAScoreAggregation(in.NUMAAggregation)
 	return nil
 }
 
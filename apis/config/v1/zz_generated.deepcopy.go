@@ -41,6 +41,21 @@ func (in *CoschedulingArgs) DeepCopyInto(out *CoschedulingArgs) {
 		*out = new(int64)
 		**out = **in
 	}
+	if in.PodGroupMaxBackoffSeconds != nil {
+		in, out := &in.PodGroupMaxBackoffSeconds, &out.PodGroupMaxBackoffSeconds
+		*out = new(int64)
+		**out = **in
+	}
+	if in.PreFilterMode != nil {
+		in, out := &in.PreFilterMode, &out.PreFilterMode
+		*out = new(CoschedulingPreFilterMode)
+		**out = **in
+	}
+	if in.PostFilterMode != nil {
+		in, out := &in.PostFilterMode, &out.PostFilterMode
+		*out = new(CoschedulingPostFilterMode)
+		**out = **in
+	}
 	return
 }
 
@@ -215,6 +230,11 @@ func (in *NodeResourceTopologyCache) DeepCopyInto(out *NodeResourceTopologyCache
 		*out = new(ForeignPodsDetectMode)
 		**out = **in
 	}
+	if in.ForeignPodsAccounting != nil {
+		in, out := &in.ForeignPodsAccounting, &out.ForeignPodsAccounting
+		*out = new(ForeignPodsAccountingMode)
+		**out = **in
+	}
 	if in.ResyncMethod != nil {
 		in, out := &in.ResyncMethod, &out.ResyncMethod
 		*out = new(CacheResyncMethod)
@@ -230,6 +250,16 @@ func (in *NodeResourceTopologyCache) DeepCopyInto(out *NodeResourceTopologyCache
 		*out = new(CacheResyncScope)
 		**out = **in
 	}
+	if in.ResyncTrigger != nil {
+		in, out := &in.ResyncTrigger, &out.ResyncTrigger
+		*out = new(CacheResyncTrigger)
+		**out = **in
+	}
+	if in.Checkpoint != nil {
+		in, out := &in.Checkpoint, &out.Checkpoint
+		*out = new(NodeResourceTopologyCacheCheckpoint)
+		**out = **in
+	}
 	return
 }
 
@@ -243,6 +273,22 @@ func (in *NodeResourceTopologyCache) DeepCopy() *NodeResourceTopologyCache {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NodeResourceTopologyCacheCheckpoint) DeepCopyInto(out *NodeResourceTopologyCacheCheckpoint) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NodeResourceTopologyCacheCheckpoint.
+func (in *NodeResourceTopologyCacheCheckpoint) DeepCopy() *NodeResourceTopologyCacheCheckpoint {
+	if in == nil {
+		return nil
+	}
+	out := new(NodeResourceTopologyCacheCheckpoint)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *NodeResourceTopologyMatchArgs) DeepCopyInto(out *NodeResourceTopologyMatchArgs) {
 	*out = *in
@@ -262,6 +308,16 @@ func (in *NodeResourceTopologyMatchArgs) DeepCopyInto(out *NodeResourceTopologyM
 		*out = new(NodeResourceTopologyCache)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.ResourceAffinity != nil {
+		in, out := &in.ResourceAffinity, &out.ResourceAffinity
+		*out = new(NodeResourceTopologyResourceAffinity)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Preemption != nil {
+		in, out := &in.Preemption, &out.Preemption
+		*out = new(NodeResourceTopologyPreemption)
+		(*in).DeepCopyInto(*out)
+	}
 	return
 }
 
@@ -283,6 +339,63 @@ func (in *NodeResourceTopologyMatchArgs) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NodeResourceTopologyPreemption) DeepCopyInto(out *NodeResourceTopologyPreemption) {
+	*out = *in
+	if in.Enabled != nil {
+		in, out := &in.Enabled, &out.Enabled
+		*out = new(bool)
+		**out = **in
+	}
+	if in.MinCandidateNodesPercentage != nil {
+		in, out := &in.MinCandidateNodesPercentage, &out.MinCandidateNodesPercentage
+		*out = new(int32)
+		**out = **in
+	}
+	if in.MinCandidateNodesAbsolute != nil {
+		in, out := &in.MinCandidateNodesAbsolute, &out.MinCandidateNodesAbsolute
+		*out = new(int32)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NodeResourceTopologyPreemption.
+func (in *NodeResourceTopologyPreemption) DeepCopy() *NodeResourceTopologyPreemption {
+	if in == nil {
+		return nil
+	}
+	out := new(NodeResourceTopologyPreemption)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NodeResourceTopologyResourceAffinity) DeepCopyInto(out *NodeResourceTopologyResourceAffinity) {
+	*out = *in
+	if in.NUMAAffine != nil {
+		in, out := &in.NUMAAffine, &out.NUMAAffine
+		*out = make([]corev1.ResourceName, len(*in))
+		copy(*out, *in)
+	}
+	if in.HostLevel != nil {
+		in, out := &in.HostLevel, &out.HostLevel
+		*out = make([]corev1.ResourceName, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NodeResourceTopologyResourceAffinity.
+func (in *NodeResourceTopologyResourceAffinity) DeepCopy() *NodeResourceTopologyResourceAffinity {
+	if in == nil {
+		return nil
+	}
+	out := new(NodeResourceTopologyResourceAffinity)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *NodeResourcesAllocatableArgs) DeepCopyInto(out *NodeResourcesAllocatableArgs) {
 	*out = *in
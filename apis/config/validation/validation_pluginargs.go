@@ -17,8 +17,10 @@ limitations under the License.
 package validation
 
 import (
+	v1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/apimachinery/pkg/util/validation/field"
+	v1helper "k8s.io/kubernetes/pkg/apis/core/v1/helper"
 
 	"sigs.k8s.io/scheduler-plugins/apis/config"
 )
@@ -28,6 +30,7 @@ var validScoringStrategy = sets.NewString(
 	string(config.BalancedAllocation),
 	string(config.LeastAllocated),
 	string(config.LeastNUMANodes),
+	string(config.ClosestNUMANodes),
 )
 
 func ValidateNodeResourceTopologyMatchArgs(path *field.Path, args *config.NodeResourceTopologyMatchArgs) error {
@@ -36,10 +39,106 @@ func ValidateNodeResourceTopologyMatchArgs(path *field.Path, args *config.NodeRe
 	if err := validateScoringStrategyType(args.ScoringStrategy.Type, scoringStrategyTypePath); err != nil {
 		allErrs = append(allErrs, err)
 	}
+	if err := validateNUMAScoreAggregation(args.ScoringStrategy.NUMAAggregation, path.Child("scoringStrategy", "numaAggregation")); err != nil {
+		allErrs = append(allErrs, err)
+	}
+	if args.Cache != nil && args.Cache.ForeignPodsAccounting != nil {
+		if err := validateForeignPodsAccounting(*args.Cache.ForeignPodsAccounting, path.Child("cache", "foreignPodsAccounting")); err != nil {
+			allErrs = append(allErrs, err)
+		}
+	}
+	if args.Cache != nil && args.Cache.Checkpoint != nil {
+		allErrs = append(allErrs, validateCacheCheckpoint(args.Cache.Checkpoint, path.Child("cache", "checkpoint"))...)
+	}
+	if args.ResourceAffinity != nil {
+		allErrs = append(allErrs, validateResourceAffinity(args.ResourceAffinity, path.Child("resourceAffinity"))...)
+	}
+	if args.Preemption != nil {
+		allErrs = append(allErrs, validatePreemption(args.Preemption, path.Child("preemption"))...)
+	}
 
 	return allErrs.ToAggregate()
 }
 
+func validateResourceAffinity(ra *config.NodeResourceTopologyResourceAffinity, path *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	numaAffine := sets.New[v1.ResourceName]()
+	for idx, resName := range ra.NUMAAffine {
+		if !v1helper.IsExtendedResourceName(resName) {
+			allErrs = append(allErrs, field.Invalid(path.Child("numaAffine").Index(idx), resName, "must be an extended resource name"))
+			continue
+		}
+		numaAffine.Insert(resName)
+	}
+	for idx, resName := range ra.HostLevel {
+		if !v1helper.IsExtendedResourceName(resName) {
+			allErrs = append(allErrs, field.Invalid(path.Child("hostLevel").Index(idx), resName, "must be an extended resource name"))
+			continue
+		}
+		if numaAffine.Has(resName) {
+			allErrs = append(allErrs, field.Invalid(path.Child("hostLevel").Index(idx), resName, "resource is also declared NUMA-affine"))
+		}
+	}
+	return allErrs
+}
+
+// validatePreemption mirrors the validation of the candidate nodes of the DefaultPreemption plugin.
+func validatePreemption(pe *config.NodeResourceTopologyPreemption, path *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	percentagePath := path.Child("minCandidateNodesPercentage")
+	absolutePath := path.Child("minCandidateNodesAbsolute")
+	if pe.MinCandidateNodesPercentage < 0 || pe.MinCandidateNodesPercentage > 100 {
+		allErrs = append(allErrs, field.Invalid(percentagePath, pe.MinCandidateNodesPercentage, "not in valid range [0, 100]"))
+	}
+	if pe.MinCandidateNodesAbsolute < 0 {
+		allErrs = append(allErrs, field.Invalid(absolutePath, pe.MinCandidateNodesAbsolute, "not in valid range [0, inf)"))
+	}
+	if pe.Enabled && pe.MinCandidateNodesPercentage == 0 && pe.MinCandidateNodesAbsolute == 0 {
+		allErrs = append(allErrs,
+			field.Invalid(percentagePath, pe.MinCandidateNodesPercentage, "cannot be zero at the same time as minCandidateNodesAbsolute"),
+			field.Invalid(absolutePath, pe.MinCandidateNodesAbsolute, "cannot be zero at the same time as minCandidateNodesPercentage"))
+	}
+	return allErrs
+}
+
+func validateForeignPodsAccounting(mode config.ForeignPodsAccountingMode, path *field.Path) *field.Error {
+	switch mode {
+	case config.ForeignPodsAccountingResync, config.ForeignPodsAccountingReconcile:
+		return nil
+	}
+	return field.Invalid(path, mode, "invalid ForeignPodsAccountingMode")
+}
+
+func validateCacheCheckpoint(ckpt *config.NodeResourceTopologyCacheCheckpoint, path *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	switch ckpt.Backend {
+	case "", config.CacheCheckpointNone:
+		// nothing to do
+	case config.CacheCheckpointFile:
+		if ckpt.Path == "" {
+			allErrs = append(allErrs, field.Required(path.Child("path"), "required by the File backend"))
+		}
+	case config.CacheCheckpointConfigMap:
+		if ckpt.ConfigMapNamespace == "" {
+			allErrs = append(allErrs, field.Required(path.Child("configMapNamespace"), "required by the ConfigMap backend"))
+		}
+		if ckpt.ConfigMapName == "" {
+			allErrs = append(allErrs, field.Required(path.Child("configMapName"), "required by the ConfigMap backend"))
+		}
+	default:
+		allErrs = append(allErrs, field.Invalid(path.Child("backend"), ckpt.Backend, "invalid CacheCheckpointBackend"))
+	}
+	return allErrs
+}
+
+func validateNUMAScoreAggregation(aggregation config.NUMAScoreAggregation, path *field.Path) *field.Error {
+	switch aggregation {
+	case "", config.NUMAScoreAggregationMin, config.NUMAScoreAggregationMax, config.NUMAScoreAggregationMean, config.NUMAScoreAggregationBestFittingZone:
+		return nil
+	}
+	return field.Invalid(path, aggregation, "invalid NUMAScoreAggregation")
+}
+
 func validateScoringStrategyType(scoringStrategy config.ScoringStrategyType, path *field.Path) *field.Error {
 	if !validScoringStrategy.Has(string(scoringStrategy)) {
 		return field.Invalid(path, scoringStrategy, "invalid ScoringStrategyType")
@@ -21,6 +21,9 @@ import (
 	"strings"
 	"testing"
 
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/utils/ptr"
+
 	"sigs.k8s.io/scheduler-plugins/apis/config"
 )
 
@@ -38,6 +41,14 @@ func TestValidateNodeResourceTopologyMatchArgs(t *testing.T) {
 				},
 			},
 		},
+		{
+			description: "correct config, ClosestNUMANodes",
+			args: &config.NodeResourceTopologyMatchArgs{
+				ScoringStrategy: config.ScoringStrategy{
+					Type: config.ClosestNUMANodes,
+				},
+			},
+		},
 		{
 			description: "incorrect config, wrong ScoringStrategy type",
 			args: &config.NodeResourceTopologyMatchArgs{
@@ -47,6 +58,204 @@ func TestValidateNodeResourceTopologyMatchArgs(t *testing.T) {
 			},
 			expectedErr: fmt.Errorf("scoringStrategy.type: Invalid value:"),
 		},
+		{
+			description: "correct config, file checkpoint",
+			args: &config.NodeResourceTopologyMatchArgs{
+				ScoringStrategy: config.ScoringStrategy{
+					Type: config.MostAllocated,
+				},
+				Cache: &config.NodeResourceTopologyCache{
+					Checkpoint: &config.NodeResourceTopologyCacheCheckpoint{
+						Backend: config.CacheCheckpointFile,
+						Path:    "/run/scheduler/nrtcache.json",
+					},
+				},
+			},
+		},
+		{
+			description: "incorrect config, file checkpoint without path",
+			args: &config.NodeResourceTopologyMatchArgs{
+				ScoringStrategy: config.ScoringStrategy{
+					Type: config.MostAllocated,
+				},
+				Cache: &config.NodeResourceTopologyCache{
+					Checkpoint: &config.NodeResourceTopologyCacheCheckpoint{
+						Backend: config.CacheCheckpointFile,
+					},
+				},
+			},
+			expectedErr: fmt.Errorf("cache.checkpoint.path: Required value"),
+		},
+		{
+			description: "incorrect config, configmap checkpoint without name",
+			args: &config.NodeResourceTopologyMatchArgs{
+				ScoringStrategy: config.ScoringStrategy{
+					Type: config.MostAllocated,
+				},
+				Cache: &config.NodeResourceTopologyCache{
+					Checkpoint: &config.NodeResourceTopologyCacheCheckpoint{
+						Backend:            config.CacheCheckpointConfigMap,
+						ConfigMapNamespace: "kube-system",
+					},
+				},
+			},
+			expectedErr: fmt.Errorf("cache.checkpoint.configMapName: Required value"),
+		},
+		{
+			description: "incorrect config, wrong checkpoint backend",
+			args: &config.NodeResourceTopologyMatchArgs{
+				ScoringStrategy: config.ScoringStrategy{
+					Type: config.MostAllocated,
+				},
+				Cache: &config.NodeResourceTopologyCache{
+					Checkpoint: &config.NodeResourceTopologyCacheCheckpoint{
+						Backend: "etcd",
+					},
+				},
+			},
+			expectedErr: fmt.Errorf("cache.checkpoint.backend: Invalid value:"),
+		},
+		{
+			description: "correct config, reconcile foreign pods",
+			args: &config.NodeResourceTopologyMatchArgs{
+				ScoringStrategy: config.ScoringStrategy{
+					Type: config.MostAllocated,
+				},
+				Cache: &config.NodeResourceTopologyCache{
+					ForeignPodsAccounting: ptr.To(config.ForeignPodsAccountingReconcile),
+				},
+			},
+		},
+		{
+			description: "incorrect config, wrong foreign pods accounting",
+			args: &config.NodeResourceTopologyMatchArgs{
+				ScoringStrategy: config.ScoringStrategy{
+					Type: config.MostAllocated,
+				},
+				Cache: &config.NodeResourceTopologyCache{
+					ForeignPodsAccounting: ptr.To(config.ForeignPodsAccountingMode("Ignore")),
+				},
+			},
+			expectedErr: fmt.Errorf("cache.foreignPodsAccounting: Invalid value:"),
+		},
+		{
+			description: "correct config, resource affinity",
+			args: &config.NodeResourceTopologyMatchArgs{
+				ScoringStrategy: config.ScoringStrategy{
+					Type: config.MostAllocated,
+				},
+				ResourceAffinity: &config.NodeResourceTopologyResourceAffinity{
+					NUMAAffine: []v1.ResourceName{"nvidia.com/gpu", "intel.com/sriov_vf"},
+					HostLevel:  []v1.ResourceName{"example.com/license"},
+				},
+			},
+		},
+		{
+			description: "incorrect config, native resource declared NUMA-affine",
+			args: &config.NodeResourceTopologyMatchArgs{
+				ScoringStrategy: config.ScoringStrategy{
+					Type: config.MostAllocated,
+				},
+				ResourceAffinity: &config.NodeResourceTopologyResourceAffinity{
+					NUMAAffine: []v1.ResourceName{v1.ResourceCPU},
+				},
+			},
+			expectedErr: fmt.Errorf("resourceAffinity.numaAffine[0]: Invalid value:"),
+		},
+		{
+			description: "incorrect config, resource both NUMA-affine and host-level",
+			args: &config.NodeResourceTopologyMatchArgs{
+				ScoringStrategy: config.ScoringStrategy{
+					Type: config.MostAllocated,
+				},
+				ResourceAffinity: &config.NodeResourceTopologyResourceAffinity{
+					NUMAAffine: []v1.ResourceName{"nvidia.com/gpu"},
+					HostLevel:  []v1.ResourceName{"example.com/license", "nvidia.com/gpu"},
+				},
+			},
+			expectedErr: fmt.Errorf("resourceAffinity.hostLevel[1]: Invalid value:"),
+		},
+		{
+			description: "correct config, NUMA score aggregation",
+			args: &config.NodeResourceTopologyMatchArgs{
+				ScoringStrategy: config.ScoringStrategy{
+					Type:            config.BalancedAllocation,
+					NUMAAggregation: config.NUMAScoreAggregationBestFittingZone,
+				},
+			},
+		},
+		{
+			description: "incorrect config, unknown NUMA score aggregation",
+			args: &config.NodeResourceTopologyMatchArgs{
+				ScoringStrategy: config.ScoringStrategy{
+					Type:            config.LeastAllocated,
+					NUMAAggregation: "Median",
+				},
+			},
+			expectedErr: fmt.Errorf("scoringStrategy.numaAggregation: Invalid value:"),
+		},
+		{
+			description: "correct config, preemption enabled",
+			args: &config.NodeResourceTopologyMatchArgs{
+				ScoringStrategy: config.ScoringStrategy{
+					Type: config.LeastAllocated,
+				},
+				Preemption: &config.NodeResourceTopologyPreemption{
+					Enabled:                     true,
+					MinCandidateNodesPercentage: 10,
+					MinCandidateNodesAbsolute:   100,
+				},
+			},
+		},
+		{
+			description: "correct config, preemption disabled with zero candidates",
+			args: &config.NodeResourceTopologyMatchArgs{
+				ScoringStrategy: config.ScoringStrategy{
+					Type: config.LeastAllocated,
+				},
+				Preemption: &config.NodeResourceTopologyPreemption{},
+			},
+		},
+		{
+			description: "incorrect config, preemption percentage out of range",
+			args: &config.NodeResourceTopologyMatchArgs{
+				ScoringStrategy: config.ScoringStrategy{
+					Type: config.LeastAllocated,
+				},
+				Preemption: &config.NodeResourceTopologyPreemption{
+					Enabled:                     true,
+					MinCandidateNodesPercentage: 101,
+					MinCandidateNodesAbsolute:   100,
+				},
+			},
+			expectedErr: fmt.Errorf("preemption.minCandidateNodesPercentage: Invalid value:"),
+		},
+		{
+			description: "incorrect config, preemption negative absolute",
+			args: &config.NodeResourceTopologyMatchArgs{
+				ScoringStrategy: config.ScoringStrategy{
+					Type: config.LeastAllocated,
+				},
+				Preemption: &config.NodeResourceTopologyPreemption{
+					Enabled:                     true,
+					MinCandidateNodesPercentage: 10,
+					MinCandidateNodesAbsolute:   -1,
+				},
+			},
+			expectedErr: fmt.Errorf("preemption.minCandidateNodesAbsolute: Invalid value:"),
+		},
+		{
+			description: "incorrect config, preemption enabled with zero candidates",
+			args: &config.NodeResourceTopologyMatchArgs{
+				ScoringStrategy: config.ScoringStrategy{
+					Type: config.LeastAllocated,
+				},
+				Preemption: &config.NodeResourceTopologyPreemption{
+					Enabled: true,
+				},
+			},
+			expectedErr: fmt.Errorf("cannot be zero at the same time as minCandidateNodesAbsolute"),
+		},
 	}
 
 	for _, testCase := range testCases {
@@ -112,6 +112,22 @@ const (
 	PodGroupLabel = scheduling.GroupName + "/pod-group"
 )
 
+// These are the valid condition types of podGroups.
+const (
+	// PodGroupBackedOff means the scheduling of the pod group failed, and the scheduler does not attempt it again
+	// until the backoff deadline expires. The deadline and the last failure reason are reported in the message.
+	PodGroupBackedOff = "BackedOff"
+)
+
+// These are the reasons of the PodGroupBackedOff condition.
+const (
+	// PodGroupUnschedulableReason means the pod group is backed off because some of its pods were unschedulable.
+	PodGroupUnschedulableReason = "Unschedulable"
+
+	// PodGroupScheduledReason means the pod group is no longer backed off, because it reached its quorum.
+	PodGroupScheduledReason = "Scheduled"
+)
+
 // PodGroup is a collection of Pod; used for batch workload.
 // +genclient
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
@@ -157,6 +173,67 @@ type PodGroupSpec struct {
 
 	// ScheduleTimeoutSeconds defines the maximal time of members/tasks to wait before run the pod group;
 	ScheduleTimeoutSeconds *int32 `json:"scheduleTimeoutSeconds,omitempty"`
+
+	// TopologyConstraint, if set, places all the members of the pod group
+	// in the same topology domain, e.g. the same rack or zone.
+	// +optional
+	TopologyConstraint *TopologyConstraint `json:"topologyConstraint,omitempty"`
+
+	// Roles splits the members of the pod group in sub-groups, e.g. parameter servers and workers,
+	// each with its own minimal number of members and resources. The pod group is scheduled only
+	// when every role has enough members, besides the pod group as a whole.
+	// +optional
+	// +listType=map
+	// +listMapKey=name
+	Roles []PodGroupRole `json:"roles,omitempty"`
+}
+
+// PodGroupRole is a sub-group of the members of a pod group.
+type PodGroupRole struct {
+	// Name identifies the role in the pod group.
+	// +kubebuilder:validation:MinLength=1
+	Name string `json:"name"`
+
+	// Selector selects the members of the pod group playing the role by their labels.
+	// A pod plays the first role in the list whose selector matches its labels.
+	Selector *metav1.LabelSelector `json:"selector"`
+
+	// MinMember defines the minimal number of members playing the role to run the pod group.
+	// +kubebuilder:validation:Minimum=1
+	MinMember int32 `json:"minMember"`
+
+	// MinResources defines the minimal resource of the members playing the role to run the pod group.
+	// +optional
+	MinResources v1.ResourceList `json:"minResources,omitempty"`
+}
+
+// TopologyMode tells how strictly the members of a pod group are kept in the same topology domain.
+// +kubebuilder:validation:Enum=Required;Preferred
+type TopologyMode string
+
+const (
+	// TopologyModeRequired means the members of the pod group can only run on nodes
+	// of the same topology domain; nodes lacking the topology key label are not eligible.
+	TopologyModeRequired TopologyMode = "Required"
+
+	// TopologyModePreferred means the scheduler favors the nodes of the topology domain
+	// already chosen for the pod group, but can place members elsewhere.
+	TopologyModePreferred TopologyMode = "Preferred"
+)
+
+// TopologyConstraint defines the topology domain the members of a pod group are placed in.
+// The domain is chosen when the first member is scheduled, as the value of
+// the topology key label of its node.
+type TopologyConstraint struct {
+	// TopologyKey is the key of the node label whose values identify the topology domains,
+	// e.g. "topology.kubernetes.io/zone".
+	// +kubebuilder:validation:MinLength=1
+	TopologyKey string `json:"topologyKey"`
+
+	// Mode tells if placing all the members in the same domain is required or only preferred.
+	// Defaults to Required.
+	// +optional
+	Mode TopologyMode `json:"mode,omitempty"`
 }
 
 // PodGroupStatus represents the current state of a pod group.
@@ -182,6 +259,41 @@ type PodGroupStatus struct {
 
 	// ScheduleStartTime of the group
 	ScheduleStartTime metav1.Time `json:"scheduleStartTime,omitempty"`
+
+	// Roles reports the pod stats of every role of the pod group.
+	// +optional
+	// +listType=map
+	// +listMapKey=name
+	Roles []PodGroupRoleStatus `json:"roles,omitempty"`
+
+	// Conditions represent the latest observations of the scheduling of the pod group,
+	// e.g. why and until when the pod group is backed off.
+	// +optional
+	// +listType=map
+	// +listMapKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+// PodGroupRoleStatus represents the current state of a role of a pod group.
+type PodGroupRoleStatus struct {
+	// Name of the role.
+	Name string `json:"name"`
+
+	// The number of pods playing the role.
+	// +optional
+	Members int32 `json:"members,omitempty"`
+
+	// The number of actively running pods playing the role.
+	// +optional
+	Running int32 `json:"running,omitempty"`
+
+	// The number of pods playing the role which reached phase Succeeded.
+	// +optional
+	Succeeded int32 `json:"succeeded,omitempty"`
+
+	// The number of pods playing the role which reached phase Failed.
+	// +optional
+	Failed int32 `json:"failed,omitempty"`
 }
 
 // +kubebuilder:object:root=true
@@ -22,6 +22,7 @@ package v1alpha1
 
 import (
 	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 )
 
@@ -194,6 +195,48 @@ func (in *PodGroupList) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PodGroupRole) DeepCopyInto(out *PodGroupRole) {
+	*out = *in
+	if in.Selector != nil {
+		in, out := &in.Selector, &out.Selector
+		*out = new(metav1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.MinResources != nil {
+		in, out := &in.MinResources, &out.MinResources
+		*out = make(v1.ResourceList, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val.DeepCopy()
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PodGroupRole.
+func (in *PodGroupRole) DeepCopy() *PodGroupRole {
+	if in == nil {
+		return nil
+	}
+	out := new(PodGroupRole)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PodGroupRoleStatus) DeepCopyInto(out *PodGroupRoleStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PodGroupRoleStatus.
+func (in *PodGroupRoleStatus) DeepCopy() *PodGroupRoleStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(PodGroupRoleStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *PodGroupSpec) DeepCopyInto(out *PodGroupSpec) {
 	*out = *in
@@ -209,6 +252,18 @@ func (in *PodGroupSpec) DeepCopyInto(out *PodGroupSpec) {
 		*out = new(int32)
 		**out = **in
 	}
+	if in.TopologyConstraint != nil {
+		in, out := &in.TopologyConstraint, &out.TopologyConstraint
+		*out = new(TopologyConstraint)
+		**out = **in
+	}
+	if in.Roles != nil {
+		in, out := &in.Roles, &out.Roles
+		*out = make([]PodGroupRole, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PodGroupSpec.
@@ -225,6 +280,18 @@ func (in *PodGroupSpec) DeepCopy() *PodGroupSpec {
 func (in *PodGroupStatus) DeepCopyInto(out *PodGroupStatus) {
 	*out = *in
 	in.ScheduleStartTime.DeepCopyInto(&out.ScheduleStartTime)
+	if in.Roles != nil {
+		in, out := &in.Roles, &out.Roles
+		*out = make([]PodGroupRoleStatus, len(*in))
+		copy(*out, *in)
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]metav1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PodGroupStatus.
@@ -236,3 +303,18 @@ func (in *PodGroupStatus) DeepCopy() *PodGroupStatus {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TopologyConstraint) DeepCopyInto(out *TopologyConstraint) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TopologyConstraint.
+func (in *TopologyConstraint) DeepCopy() *TopologyConstraint {
+	if in == nil {
+		return nil
+	}
+	out := new(TopologyConstraint)
+	in.DeepCopyInto(out)
+	return out
+}
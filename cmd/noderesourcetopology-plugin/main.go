@@ -35,6 +35,7 @@ import (
 	// Ensure scheme package is initialized.
 	_ "sigs.k8s.io/scheduler-plugins/apis/config/scheme"
 
+	knicachedump "sigs.k8s.io/scheduler-plugins/pkg-kni/cachedump"
 	knifeatures "sigs.k8s.io/scheduler-plugins/pkg-kni/features"
 	knistatus "sigs.k8s.io/scheduler-plugins/pkg-kni/pfpstatus"
 )
@@ -48,13 +49,15 @@ func main() {
 	rand.Seed(time.Now().UnixNano())
 
 	knistatus.Setup(logh)
+	cacheDump := knicachedump.Setup(logh)
+	debugOpts := knidebug.SetupOptions(logh)
 
 	// Register custom plugins to the scheduler framework.
 	// Later they can consist of scheduler profile(s) and hence
 	// used by various kinds of workloads.
 	command := app.NewSchedulerCommand(
-		app.WithPlugin(noderesourcetopology.Name, noderesourcetopology.New),
-		app.WithPlugin(knidebug.Name, knidebug.New),
+		app.WithPlugin(noderesourcetopology.Name, cacheDump.WrapPluginFactory(noderesourcetopology.New)),
+		app.WithPlugin(knidebug.Name, knidebug.NewWithOptions(debugOpts)),
 	)
 
 	// TODO: once we switch everything over to Cobra commands, we can go back to calling
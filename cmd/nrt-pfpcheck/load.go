@@ -0,0 +1,112 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"io/fs"
+	"path/filepath"
+
+	"github.com/go-logr/logr"
+	topologyv1alpha2 "github.com/k8stopologyawareschedwg/noderesourcetopology-api/pkg/apis/topology/v1alpha2"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/clientcmd"
+
+	ctrlclient "sigs.k8s.io/controller-runtime/pkg/client"
+
+	"sigs.k8s.io/scheduler-plugins/cmd/internal/manifests"
+)
+
+// objects holds the data to verify, either read from a live cluster or from a dump.
+type objects struct {
+	nrts []*topologyv1alpha2.NodeResourceTopology
+	pods []*corev1.Pod
+}
+
+// loadFromCluster lists the NRT and Pod objects using the given kubeconfig.
+func loadFromCluster(ctx context.Context, lh logr.Logger, kubeconfig string) (*objects, error) {
+	cfg, err := clientcmd.BuildConfigFromFlags("", kubeconfig)
+	if err != nil {
+		return nil, err
+	}
+	cli, err := ctrlclient.New(cfg, ctrlclient.Options{Scheme: manifests.Scheme})
+	if err != nil {
+		return nil, err
+	}
+
+	nrtList := topologyv1alpha2.NodeResourceTopologyList{}
+	if err := cli.List(ctx, &nrtList); err != nil {
+		return nil, err
+	}
+	podList := corev1.PodList{}
+	if err := cli.List(ctx, &podList); err != nil {
+		return nil, err
+	}
+
+	objs := &objects{}
+	for idx := range nrtList.Items {
+		objs.nrts = append(objs.nrts, &nrtList.Items[idx])
+	}
+	for idx := range podList.Items {
+		objs.pods = append(objs.pods, &podList.Items[idx])
+	}
+	lh.V(2).Info("listed objects", "nrts", len(objs.nrts), "pods", len(objs.pods))
+	return objs, nil
+}
+
+// loadFromDir walks the given directory and decodes all the NRT and Pod objects found in the
+// YAML and JSON files, including the lists. Files may contain many YAML documents. Other objects are skipped.
+func loadFromDir(lh logr.Logger, dir string) (*objects, error) {
+	objs := &objects{}
+	err := filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() || !manifests.IsManifest(path) {
+			return nil
+		}
+		docs, err := manifests.DecodeFile(path)
+		if err != nil {
+			return err
+		}
+		for _, obj := range docs {
+			switch o := obj.(type) {
+			case *topologyv1alpha2.NodeResourceTopology:
+				objs.nrts = append(objs.nrts, o)
+			case *topologyv1alpha2.NodeResourceTopologyList:
+				for idx := range o.Items {
+					objs.nrts = append(objs.nrts, &o.Items[idx])
+				}
+			case *corev1.Pod:
+				objs.pods = append(objs.pods, o)
+			case *corev1.PodList:
+				for idx := range o.Items {
+					objs.pods = append(objs.pods, &o.Items[idx])
+				}
+			default:
+				lh.V(4).Info("skipped object", "path", path, "kind", obj.GetObjectKind().GroupVersionKind().Kind)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	lh.V(2).Info("loaded objects", "dir", dir, "nrts", len(objs.nrts), "pods", len(objs.pods))
+	return objs, nil
+}
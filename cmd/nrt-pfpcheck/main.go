@@ -0,0 +1,247 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// nrt-pfpcheck verifies the podset fingerprints reported in the NodeResourceTopology objects against
+// the pods the scheduler expects running on each node, computed like the scheduler-side cache does on resync.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/go-logr/logr"
+	"github.com/k8stopologyawareschedwg/podfingerprint"
+	"github.com/spf13/pflag"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/klog/v2"
+
+	apiconfig "sigs.k8s.io/scheduler-plugins/apis/config"
+	"sigs.k8s.io/scheduler-plugins/pkg-kni/pfpstatus"
+	nrtcache "sigs.k8s.io/scheduler-plugins/pkg/noderesourcetopology/cache"
+	"sigs.k8s.io/scheduler-plugins/pkg/noderesourcetopology/podprovider"
+)
+
+const (
+	outputText = "text"
+	outputJSON = "json"
+)
+
+const (
+	// verdictMatch means the scheduler and the exporter agree on the pods running on the node
+	verdictMatch = "match"
+	// verdictMissing means the NRT object reports no fingerprint, so the cache can't ever resync the node
+	verdictMissing = "missing-fingerprint"
+	// verdictMismatch means the fingerprints differ, but there is no exporter status to tell why
+	verdictMismatch = "mismatch"
+	// verdictExporterStale means the exporter status does not match the fingerprint reported in the NRT object
+	verdictExporterStale = "exporter-status-stale"
+	// verdictExporterBehind means the exporter misses pods the scheduler expects running on the node
+	verdictExporterBehind = "exporter-behind"
+	// verdictSchedulerBehind means the exporter reports pods the scheduler does not expect running on the node
+	verdictSchedulerBehind = "scheduler-behind"
+	// verdictDiverged means both the exporter and the scheduler miss pods the other expects
+	verdictDiverged = "diverged"
+)
+
+type options struct {
+	kubeconfig        string
+	dumpDir           string
+	exporterStatusDir string
+	resyncMethod      string
+	informerMode      string
+	output            string
+}
+
+// nodeReport is the verification outcome for a node.
+type nodeReport struct {
+	nrtcache.FingerprintVerification
+	Verdict string `json:"verdict"`
+	// ExporterComputed is the fingerprint in the exporter status, if available
+	ExporterComputed string `json:"exporterComputed,omitempty"`
+	// OnlyScheduler are the pods the scheduler expects running on the node, missing in the exporter status
+	OnlyScheduler []string `json:"onlyScheduler,omitempty"`
+	// OnlyExporter are the pods in the exporter status the scheduler does not expect running on the node
+	OnlyExporter []string `json:"onlyExporter,omitempty"`
+}
+
+func main() {
+	opts := options{}
+	klog.InitFlags(nil)
+	pflag.StringVar(&opts.kubeconfig, "kubeconfig", "", "kubeconfig to read the NodeResourceTopology and Pod objects from a live cluster.")
+	pflag.StringVar(&opts.dumpDir, "dump-dir", "", "directory containing the NodeResourceTopology and Pod objects as YAML or JSON files.")
+	pflag.StringVar(&opts.exporterStatusDir, "exporter-status-dir", "", "optional directory containing the podset fingerprint status files dumped by the exporter, to diff the pods.")
+	pflag.StringVar(&opts.resyncMethod, "resync-method", string(apiconfig.CacheResyncAutodetect), "cache resync method configured in the scheduler: Autodetect, All or OnlyExclusiveResources.")
+	pflag.StringVar(&opts.informerMode, "informer-mode", string(apiconfig.CacheInformerDedicated), "cache informer mode configured in the scheduler: Shared or Dedicated.")
+	pflag.StringVar(&opts.output, "output", outputText, "output format: text or json.")
+	pflag.CommandLine.AddGoFlagSet(flag.CommandLine)
+	pflag.Parse()
+
+	if err := run(context.Background(), opts, os.Stdout); err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		os.Exit(1)
+	}
+}
+
+func run(ctx context.Context, opts options, w io.Writer) error {
+	if (opts.kubeconfig == "") == (opts.dumpDir == "") {
+		return fmt.Errorf("exactly one of --kubeconfig and --dump-dir is required")
+	}
+	if opts.output != outputText && opts.output != outputJSON {
+		return fmt.Errorf("unsupported output format %q", opts.output)
+	}
+	resyncMethod := apiconfig.CacheResyncMethod(opts.resyncMethod)
+	switch resyncMethod {
+	case apiconfig.CacheResyncAutodetect, apiconfig.CacheResyncAll, apiconfig.CacheResyncOnlyExclusiveResources:
+	default:
+		return fmt.Errorf("unsupported resync method %q", opts.resyncMethod)
+	}
+	var isPodRelevant podprovider.PodFilterFunc
+	switch apiconfig.CacheInformerMode(opts.informerMode) {
+	case apiconfig.CacheInformerShared:
+		isPodRelevant = podprovider.IsPodRelevantShared
+	case apiconfig.CacheInformerDedicated:
+		isPodRelevant = podprovider.IsPodRelevantDedicated
+	default:
+		return fmt.Errorf("unsupported informer mode %q", opts.informerMode)
+	}
+
+	lh := klog.FromContext(ctx)
+	var objs *objects
+	var err error
+	if opts.kubeconfig != "" {
+		objs, err = loadFromCluster(ctx, lh, opts.kubeconfig)
+	} else {
+		objs, err = loadFromDir(lh, opts.dumpDir)
+	}
+	if err != nil {
+		return err
+	}
+
+	reports := make([]nodeReport, 0, len(objs.nrts))
+	for _, nrt := range objs.nrts {
+		rep := nodeReport{
+			FingerprintVerification: nrtcache.VerifyNodeFingerprint(lh, nrt, objs.pods, resyncMethod, isPodRelevant),
+		}
+		st, err := loadExporterStatus(lh, opts.exporterStatusDir, nrt.Name)
+		if err != nil {
+			return err
+		}
+		diagnose(&rep, st)
+		reports = append(reports, rep)
+	}
+	sort.Slice(reports, func(i, j int) bool {
+		return reports[i].NodeName < reports[j].NodeName
+	})
+
+	if opts.output == outputJSON {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(reports)
+	}
+	return writeText(w, reports)
+}
+
+// loadExporterStatus returns the exporter status for the given node, or nil if not available.
+func loadExporterStatus(lh logr.Logger, statusDir, nodeName string) (*podfingerprint.Status, error) {
+	if statusDir == "" {
+		return nil, nil
+	}
+	st, err := pfpstatus.LoadNodeStatus(statusDir, nodeName)
+	if errors.Is(err, fs.ErrNotExist) {
+		lh.V(2).Info("missing exporter status", "node", nodeName)
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("loading the exporter status for node %q: %w", nodeName, err)
+	}
+	return &st.Status, nil
+}
+
+// diagnose sets the verdict of the report, and when the exporter status is available and consistent
+// with the NRT object, the pods which make the scheduler and the exporter disagree.
+func diagnose(rep *nodeReport, st *podfingerprint.Status) {
+	switch {
+	case rep.Expected == "":
+		rep.Verdict = verdictMissing
+		return
+	case rep.Match:
+		rep.Verdict = verdictMatch
+		return
+	case st == nil:
+		rep.Verdict = verdictMismatch
+		return
+	}
+
+	rep.ExporterComputed = st.FingerprintComputed
+	if st.FingerprintComputed != rep.Expected {
+		rep.Verdict = verdictExporterStale
+		return
+	}
+
+	schedPods := podNames(rep.Pods)
+	exporterPods := podNames(st.Pods)
+	rep.OnlyScheduler = sets.List(schedPods.Difference(exporterPods))
+	rep.OnlyExporter = sets.List(exporterPods.Difference(schedPods))
+	switch {
+	case len(rep.OnlyScheduler) > 0 && len(rep.OnlyExporter) > 0:
+		rep.Verdict = verdictDiverged
+	case len(rep.OnlyScheduler) > 0:
+		rep.Verdict = verdictExporterBehind
+	case len(rep.OnlyExporter) > 0:
+		rep.Verdict = verdictSchedulerBehind
+	default:
+		// same pods, different fingerprint: the exporter and the scheduler don't agree on the method
+		rep.Verdict = verdictMismatch
+	}
+}
+
+func podNames(pods []podfingerprint.NamespacedName) sets.Set[string] {
+	names := sets.New[string]()
+	for _, pod := range pods {
+		names.Insert(pod.String())
+	}
+	return names
+}
+
+func writeText(w io.Writer, reports []nodeReport) error {
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(tw, "NODE\tVERDICT\tEXPECTED\tCOMPUTED\tPODS\tONLY SCHEDULER\tONLY EXPORTER")
+	for _, rep := range reports {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%d\t%s\t%s\n", rep.NodeName, rep.Verdict, orDash(rep.Expected), rep.Computed, len(rep.Pods), joinOrDash(rep.OnlyScheduler), joinOrDash(rep.OnlyExporter))
+	}
+	return tw.Flush()
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+func joinOrDash(items []string) string {
+	return orDash(strings.Join(items, ","))
+}
@@ -0,0 +1,219 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/k8stopologyawareschedwg/podfingerprint"
+
+	"sigs.k8s.io/scheduler-plugins/pkg-kni/pfpstatus"
+)
+
+const nrtTemplate = `apiVersion: topology.node.k8s.io/v1alpha2
+kind: NodeResourceTopology
+metadata:
+  name: %s
+attributes:
+- name: nodeTopologyPodsFingerprint
+  value: %q
+zones:
+- name: node-0
+  type: Node
+---
+`
+
+const podTemplate = `apiVersion: v1
+kind: Pod
+metadata:
+  name: %s
+  namespace: default
+spec:
+  nodeName: %s
+  containers:
+  - name: cnt
+    image: pause
+status:
+  phase: %s
+---
+`
+
+func fingerprintOf(names ...string) string {
+	pfp := podfingerprint.NewFingerprint(len(names))
+	for _, name := range names {
+		pfp.Add("default", name)
+	}
+	return pfp.Sign()
+}
+
+// writeDump creates a dump in which:
+// - node-match reports the fingerprint of the pods running on it
+// - node-sched reports also a pod the scheduler ignores, because still pending
+// - node-nopfp reports no fingerprint
+// - node-nostatus reports a stale fingerprint, and has no exporter status
+func writeDump(t *testing.T) (string, string) {
+	t.Helper()
+	dir := t.TempDir()
+	dumpDir := filepath.Join(dir, "dump")
+	statusDir := filepath.Join(dir, "status")
+	for _, path := range []string{dumpDir, statusDir} {
+		if err := os.MkdirAll(path, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var nrts strings.Builder
+	fmt.Fprintf(&nrts, nrtTemplate, "node-match", fingerprintOf("pod-0"))
+	fmt.Fprintf(&nrts, nrtTemplate, "node-sched", fingerprintOf("pod-1", "pod-2"))
+	fmt.Fprintf(&nrts, nrtTemplate, "node-nopfp", "")
+	fmt.Fprintf(&nrts, nrtTemplate, "node-nostatus", fingerprintOf())
+	var pods strings.Builder
+	fmt.Fprintf(&pods, podTemplate, "pod-0", "node-match", "Running")
+	fmt.Fprintf(&pods, podTemplate, "pod-1", "node-sched", "Running")
+	fmt.Fprintf(&pods, podTemplate, "pod-2", "node-sched", "Pending")
+	fmt.Fprintf(&pods, podTemplate, "pod-3", "node-nostatus", "Running")
+
+	if err := os.WriteFile(filepath.Join(dumpDir, "nrts.yaml"), []byte(nrts.String()), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dumpDir, "pods.yaml"), []byte(pods.String()), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	st := pfpstatus.StatusInfo{
+		Status: podfingerprint.Status{
+			NodeName:            "node-sched",
+			FingerprintComputed: fingerprintOf("pod-1", "pod-2"),
+			Pods: []podfingerprint.NamespacedName{
+				{Namespace: "default", Name: "pod-1"},
+				{Namespace: "default", Name: "pod-2"},
+			},
+		},
+	}
+	if err := pfpstatus.DumpNodeStatus(statusDir, st); err != nil {
+		t.Fatal(err)
+	}
+	return dumpDir, statusDir
+}
+
+func TestRunJSON(t *testing.T) {
+	dumpDir, statusDir := writeDump(t)
+
+	var out bytes.Buffer
+	err := run(context.Background(), options{
+		dumpDir:           dumpDir,
+		exporterStatusDir: statusDir,
+		resyncMethod:      "Autodetect",
+		informerMode:      "Dedicated",
+		output:            outputJSON,
+	}, &out)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var reports []nodeReport
+	if err := json.Unmarshal(out.Bytes(), &reports); err != nil {
+		t.Fatalf("cannot decode output %q: %v", out.String(), err)
+	}
+	expected := map[string]string{
+		"node-match":    verdictMatch,
+		"node-nopfp":    verdictMissing,
+		"node-nostatus": verdictMismatch,
+		"node-sched":    verdictSchedulerBehind,
+	}
+	if len(reports) != len(expected) {
+		t.Fatalf("unexpected reports: %+v", reports)
+	}
+	for _, rep := range reports {
+		if rep.Verdict != expected[rep.NodeName] {
+			t.Errorf("node %q: unexpected verdict %q expected %q", rep.NodeName, rep.Verdict, expected[rep.NodeName])
+		}
+		if rep.NodeName == "node-sched" && (len(rep.OnlyExporter) != 1 || rep.OnlyExporter[0] != "default/pod-2" || len(rep.OnlyScheduler) != 0) {
+			t.Errorf("unexpected pods diff: onlyScheduler=%v onlyExporter=%v", rep.OnlyScheduler, rep.OnlyExporter)
+		}
+	}
+}
+
+func TestRunText(t *testing.T) {
+	dumpDir, _ := writeDump(t)
+
+	var out bytes.Buffer
+	err := run(context.Background(), options{
+		dumpDir:      dumpDir,
+		resyncMethod: "All",
+		informerMode: "Shared",
+		output:       outputText,
+	}, &out)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 5 {
+		t.Fatalf("unexpected output:\n%s", out.String())
+	}
+	if !strings.HasPrefix(lines[1], "node-match") || !strings.Contains(lines[1], verdictMatch) {
+		t.Errorf("unexpected line for node-match: %q", lines[1])
+	}
+	if !strings.HasPrefix(lines[4], "node-sched") || !strings.Contains(lines[4], verdictMismatch) {
+		t.Errorf("unexpected line for node-sched: %q", lines[4])
+	}
+}
+
+func TestRunErrors(t *testing.T) {
+	dumpDir, _ := writeDump(t)
+
+	tests := []struct {
+		name string
+		opts options
+	}{
+		{
+			name: "missing source",
+			opts: options{resyncMethod: "All", informerMode: "Shared", output: outputText},
+		},
+		{
+			name: "both sources",
+			opts: options{kubeconfig: "kubeconfig", dumpDir: dumpDir, resyncMethod: "All", informerMode: "Shared", output: outputText},
+		},
+		{
+			name: "bad output format",
+			opts: options{dumpDir: dumpDir, resyncMethod: "All", informerMode: "Shared", output: "xml"},
+		},
+		{
+			name: "bad resync method",
+			opts: options{dumpDir: dumpDir, resyncMethod: "Sometimes", informerMode: "Shared", output: outputText},
+		},
+		{
+			name: "bad informer mode",
+			opts: options{dumpDir: dumpDir, resyncMethod: "All", informerMode: "Private", output: outputText},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			if err := run(context.Background(), tt.opts, &out); err == nil {
+				t.Errorf("expected error, got output:\n%s", out.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,92 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"io/fs"
+	"path/filepath"
+
+	"github.com/go-logr/logr"
+	topologyv1alpha2 "github.com/k8stopologyawareschedwg/noderesourcetopology-api/pkg/apis/topology/v1alpha2"
+
+	corev1 "k8s.io/api/core/v1"
+
+	"sigs.k8s.io/scheduler-plugins/cmd/internal/manifests"
+)
+
+// objects holds the data found in a must-gather or similar dump.
+type objects struct {
+	nrts  []*topologyv1alpha2.NodeResourceTopology
+	nodes []*corev1.Node
+}
+
+// loadObjects walks the given directory and decodes all the NRT and Node objects found in the
+// YAML and JSON files, including the lists. Files may contain many YAML documents. Other objects are skipped.
+func loadObjects(lh logr.Logger, dir string) (*objects, error) {
+	objs := &objects{}
+	err := filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() || !manifests.IsManifest(path) {
+			return nil
+		}
+		docs, err := manifests.DecodeFile(path)
+		if err != nil {
+			return err
+		}
+		for _, obj := range docs {
+			switch o := obj.(type) {
+			case *topologyv1alpha2.NodeResourceTopology:
+				objs.nrts = append(objs.nrts, o)
+			case *topologyv1alpha2.NodeResourceTopologyList:
+				for idx := range o.Items {
+					objs.nrts = append(objs.nrts, &o.Items[idx])
+				}
+			case *corev1.Node:
+				objs.nodes = append(objs.nodes, o)
+			case *corev1.NodeList:
+				for idx := range o.Items {
+					objs.nodes = append(objs.nodes, &o.Items[idx])
+				}
+			default:
+				lh.V(4).Info("skipped object", "path", path, "kind", obj.GetObjectKind().GroupVersionKind().Kind)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	lh.V(2).Info("loaded objects", "dir", dir, "nrts", len(objs.nrts), "nodes", len(objs.nodes))
+	return objs, nil
+}
+
+// loadPod reads the pod to evaluate from the given file.
+func loadPod(path string) (*corev1.Pod, error) {
+	docs, err := manifests.DecodeFile(path)
+	if err != nil {
+		return nil, err
+	}
+	for _, obj := range docs {
+		if pod, ok := obj.(*corev1.Pod); ok {
+			return pod, nil
+		}
+	}
+	return nil, fmt.Errorf("no pod found in %q", path)
+}
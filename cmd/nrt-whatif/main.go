@@ -0,0 +1,146 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// nrt-whatif evaluates offline how the NodeResourceTopologyMatch plugin would place a pod,
+// given the NodeResourceTopology objects collected from a cluster, for example by a must-gather.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/pflag"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/klog/v2"
+	schedconfig "k8s.io/kubernetes/pkg/scheduler/apis/config"
+
+	apiconfig "sigs.k8s.io/scheduler-plugins/apis/config"
+	"sigs.k8s.io/scheduler-plugins/pkg/noderesourcetopology"
+)
+
+const (
+	outputText = "text"
+	outputJSON = "json"
+)
+
+type options struct {
+	nrtDir          string
+	podPath         string
+	scoringStrategy string
+	output          string
+}
+
+func main() {
+	opts := options{}
+	klog.InitFlags(nil)
+	pflag.StringVar(&opts.nrtDir, "nrt-dir", "", "directory containing the NodeResourceTopology objects, and optionally the Node objects, as YAML or JSON files.")
+	pflag.StringVar(&opts.podPath, "pod", "", "file containing the pod to evaluate.")
+	pflag.StringVar(&opts.scoringStrategy, "scoring-strategy", string(apiconfig.LeastAllocated), "scoring strategy to use.")
+	pflag.StringVar(&opts.output, "output", outputText, "output format: text or json.")
+	pflag.CommandLine.AddGoFlagSet(flag.CommandLine)
+	pflag.Parse()
+
+	if err := run(context.Background(), opts, os.Stdout); err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		os.Exit(1)
+	}
+}
+
+func run(ctx context.Context, opts options, w io.Writer) error {
+	if opts.nrtDir == "" || opts.podPath == "" {
+		return fmt.Errorf("both --nrt-dir and --pod are required")
+	}
+	if opts.output != outputText && opts.output != outputJSON {
+		return fmt.Errorf("unsupported output format %q", opts.output)
+	}
+
+	lh := klog.FromContext(ctx)
+	objs, err := loadObjects(lh, opts.nrtDir)
+	if err != nil {
+		return err
+	}
+	pod, err := loadPod(opts.podPath)
+	if err != nil {
+		return err
+	}
+
+	args := &apiconfig.NodeResourceTopologyMatchArgs{
+		ScoringStrategy: apiconfig.ScoringStrategy{
+			Type: apiconfig.ScoringStrategyType(opts.scoringStrategy),
+			Resources: []schedconfig.ResourceSpec{
+				{Name: string(corev1.ResourceCPU), Weight: 1},
+				{Name: string(corev1.ResourceMemory), Weight: 1},
+			},
+		},
+	}
+	wi, err := noderesourcetopology.NewWhatIf(args, objs.nrts, objs.nodes)
+	if err != nil {
+		return err
+	}
+	results := wi.Evaluate(ctx, pod)
+
+	if opts.output == outputJSON {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(results)
+	}
+	return writeText(w, results)
+}
+
+func writeText(w io.Writer, results []noderesourcetopology.WhatIfResult) error {
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(tw, "NODE\tFITS\tPOLICY/SCOPE\tNUMA ZONES\tSCORE\tREASON")
+	for _, res := range results {
+		conf := "-"
+		if res.HasTopology {
+			conf = res.Policy + "/" + res.Scope
+		}
+		score := "-"
+		if res.Fits {
+			score = fmt.Sprintf("%d", res.Score)
+		}
+		reason := "-"
+		if len(res.Reasons) > 0 {
+			reason = strings.Join(res.Reasons, "; ")
+		}
+		fmt.Fprintf(tw, "%s\t%t\t%s\t%s\t%s\t%s\n", res.NodeName, res.Fits, conf, numaZones(res), score, reason)
+	}
+	return tw.Flush()
+}
+
+func numaZones(res noderesourcetopology.WhatIfResult) string {
+	if len(res.NUMAAssignment) == 0 {
+		return "-"
+	}
+	ids := make([]int, 0, len(res.NUMAAssignment))
+	for numaID := range res.NUMAAssignment {
+		ids = append(ids, numaID)
+	}
+	sort.Ints(ids)
+	zones := make([]string, 0, len(ids))
+	for _, numaID := range ids {
+		zones = append(zones, fmt.Sprintf("%d", numaID))
+	}
+	return strings.Join(zones, ",")
+}
@@ -0,0 +1,230 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"sigs.k8s.io/scheduler-plugins/pkg/noderesourcetopology"
+)
+
+const nrtsManifest = `apiVersion: topology.node.k8s.io/v1alpha2
+kind: NodeResourceTopology
+metadata:
+  name: node-small
+attributes:
+- name: topologyManagerPolicy
+  value: single-numa-node
+- name: topologyManagerScope
+  value: pod
+zones:
+- name: node-0
+  type: Node
+  resources:
+  - name: cpu
+    capacity: "4"
+    allocatable: "4"
+    available: "2"
+  - name: memory
+    capacity: 8Gi
+    allocatable: 8Gi
+    available: 8Gi
+- name: node-1
+  type: Node
+  resources:
+  - name: cpu
+    capacity: "4"
+    allocatable: "4"
+    available: "2"
+  - name: memory
+    capacity: 8Gi
+    allocatable: 8Gi
+    available: 8Gi
+---
+apiVersion: topology.node.k8s.io/v1alpha2
+kind: NodeResourceTopology
+metadata:
+  name: node-big
+attributes:
+- name: topologyManagerPolicy
+  value: single-numa-node
+- name: topologyManagerScope
+  value: pod
+zones:
+- name: node-0
+  type: Node
+  resources:
+  - name: cpu
+    capacity: "16"
+    allocatable: "16"
+    available: "2"
+  - name: memory
+    capacity: 32Gi
+    allocatable: 32Gi
+    available: 32Gi
+- name: node-1
+  type: Node
+  resources:
+  - name: cpu
+    capacity: "16"
+    allocatable: "16"
+    available: "12"
+  - name: memory
+    capacity: 32Gi
+    allocatable: 32Gi
+    available: 32Gi
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: ignored
+`
+
+const podManifest = `apiVersion: v1
+kind: Pod
+metadata:
+  name: guaranteed
+  namespace: default
+spec:
+  containers:
+  - name: cnt
+    image: pause
+    resources:
+      requests:
+        cpu: "4"
+        memory: 1Gi
+      limits:
+        cpu: "4"
+        memory: 1Gi
+`
+
+func writeManifests(t *testing.T) (string, string) {
+	t.Helper()
+	dir := t.TempDir()
+	nrtDir := filepath.Join(dir, "nrts")
+	if err := os.MkdirAll(filepath.Join(nrtDir, "nested"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(nrtDir, "nested", "nrts.yaml"), []byte(nrtsManifest), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(nrtDir, "README.txt"), []byte("not a manifest"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	podPath := filepath.Join(dir, "pod.yaml")
+	if err := os.WriteFile(podPath, []byte(podManifest), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return nrtDir, podPath
+}
+
+func TestRunJSON(t *testing.T) {
+	nrtDir, podPath := writeManifests(t)
+
+	var out bytes.Buffer
+	err := run(context.Background(), options{
+		nrtDir:          nrtDir,
+		podPath:         podPath,
+		scoringStrategy: "LeastAllocated",
+		output:          outputJSON,
+	}, &out)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var results []noderesourcetopology.WhatIfResult
+	if err := json.Unmarshal(out.Bytes(), &results); err != nil {
+		t.Fatalf("cannot decode output %q: %v", out.String(), err)
+	}
+	if len(results) != 2 {
+		t.Fatalf("unexpected results: %+v", results)
+	}
+	if results[0].NodeName != "node-big" || !results[0].Fits {
+		t.Errorf("expected node-big to fit first, got %+v", results[0])
+	}
+	if _, ok := results[0].NUMAAssignment[1]; !ok || len(results[0].NUMAAssignment) != 1 {
+		t.Errorf("expected the pod on NUMA node 1, got %v", results[0].NUMAAssignment)
+	}
+	if results[1].NodeName != "node-small" || results[1].Fits || len(results[1].Reasons) == 0 {
+		t.Errorf("expected node-small to be rejected with reasons, got %+v", results[1])
+	}
+}
+
+func TestRunText(t *testing.T) {
+	nrtDir, podPath := writeManifests(t)
+
+	var out bytes.Buffer
+	err := run(context.Background(), options{
+		nrtDir:          nrtDir,
+		podPath:         podPath,
+		scoringStrategy: "MostAllocated",
+		output:          outputText,
+	}, &out)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("unexpected output:\n%s", out.String())
+	}
+	if !strings.HasPrefix(lines[1], "node-big") || !strings.Contains(lines[1], "true") {
+		t.Errorf("unexpected line for node-big: %q", lines[1])
+	}
+	if !strings.HasPrefix(lines[2], "node-small") || !strings.Contains(lines[2], "false") {
+		t.Errorf("unexpected line for node-small: %q", lines[2])
+	}
+}
+
+func TestRunErrors(t *testing.T) {
+	nrtDir, podPath := writeManifests(t)
+
+	tests := []struct {
+		name string
+		opts options
+	}{
+		{
+			name: "missing pod",
+			opts: options{nrtDir: nrtDir, scoringStrategy: "LeastAllocated", output: outputText},
+		},
+		{
+			name: "bad output format",
+			opts: options{nrtDir: nrtDir, podPath: podPath, scoringStrategy: "LeastAllocated", output: "xml"},
+		},
+		{
+			name: "bad scoring strategy",
+			opts: options{nrtDir: nrtDir, podPath: podPath, scoringStrategy: "Random", output: outputText},
+		},
+		{
+			name: "pod file without pods",
+			opts: options{nrtDir: nrtDir, podPath: filepath.Join(nrtDir, "nested", "nrts.yaml"), scoringStrategy: "LeastAllocated", output: outputText},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			if err := run(context.Background(), tt.opts, &out); err == nil {
+				t.Errorf("expected error, got output:\n%s", out.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,211 @@
+/*
+ * Copyright 2024 Red Hat, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cachedump
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"os"
+	"sort"
+	"sync"
+
+	"github.com/go-logr/logr"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/kubernetes/pkg/scheduler/framework"
+	frameworkruntime "k8s.io/kubernetes/pkg/scheduler/framework/runtime"
+
+	nrtcache "sigs.k8s.io/scheduler-plugins/pkg/noderesourcetopology/cache"
+
+	"sigs.k8s.io/scheduler-plugins/pkg-kni/pfpstatus"
+)
+
+const (
+	CacheDumpAddressEnvVar string = "NRT_CACHE_DUMP_ADDRESS"
+
+	EndpointPath string = "/debug/nrtcache"
+)
+
+// CacheProvider is implemented by the plugins which own a NRT cache.
+type CacheProvider interface {
+	NRTCache() nrtcache.Interface
+}
+
+// NodeInfo is the troubleshooting data about a node, as seen by all the scheduler profiles.
+type NodeInfo struct {
+	NodeName string `json:"nodeName"`
+	// Profiles maps the scheduler profile name -> cache state of the node
+	Profiles map[string]nrtcache.NodeDump `json:"profiles,omitempty"`
+	// PodFingerprintStatus is the last podset fingerprint computation for the node, if the pfpstatus dump is enabled
+	PodFingerprintStatus *pfpstatus.StatusInfo `json:"podFingerprintStatus,omitempty"`
+}
+
+// Server exposes the NRT cache state of all the registered plugin instances over HTTP.
+type Server struct {
+	lh logr.Logger
+	// statusDir is where pfpstatus dumps the podset fingerprint status. Empty if disabled.
+	statusDir string
+	lock      sync.Mutex
+	dumpers   map[string]nrtcache.Dumper // scheduler profile name -> cache
+}
+
+func NewServer(lh logr.Logger, statusDir string) *Server {
+	return &Server{
+		lh:        lh,
+		statusDir: statusDir,
+		dumpers:   make(map[string]nrtcache.Dumper),
+	}
+}
+
+// Setup creates the Server and starts listening on the address set in the environment.
+// Returns nil if the endpoint is disabled. All the Server methods can be called on nil.
+func Setup(logh logr.Logger) *Server {
+	addr, ok := os.LookupEnv(CacheDumpAddressEnvVar)
+	if !ok || addr == "" {
+		logh.Info("NRT cache dump disabled", "variableFound", ok, "valueGiven", addr != "")
+		return nil
+	}
+
+	// same data source pfpstatus writes into, if enabled
+	statusDir := os.Getenv(pfpstatus.PFPStatusDumpEnvVar)
+	logh.Info("NRT cache dump enabled", "address", addr, "statusDirectory", statusDir)
+
+	srv := NewServer(logh, statusDir)
+	mux := http.NewServeMux()
+	mux.Handle(EndpointPath, srv)
+	go func() {
+		// intentionally not fatal: troubleshooting aids must not break the scheduler
+		err := http.ListenAndServe(addr, mux)
+		logh.Error(err, "NRT cache dump endpoint stopped")
+	}()
+	return srv
+}
+
+// Register adds the cache of the given scheduler profile to the ones exposed by the Server.
+func (srv *Server) Register(profileName string, dumper nrtcache.Dumper) {
+	if srv == nil {
+		return
+	}
+	srv.lock.Lock()
+	defer srv.lock.Unlock()
+	srv.dumpers[profileName] = dumper
+	srv.lh.V(2).Info("NRT cache dump registered", "profile", profileName)
+}
+
+// WrapPluginFactory returns a plugin factory which registers the cache of the created plugin instances,
+// if they expose a cache which can be dumped.
+func (srv *Server) WrapPluginFactory(factory frameworkruntime.PluginFactory) frameworkruntime.PluginFactory {
+	if srv == nil {
+		return factory
+	}
+	return func(ctx context.Context, args runtime.Object, handle framework.Handle) (framework.Plugin, error) {
+		plugin, err := factory(ctx, args, handle)
+		if err != nil {
+			return plugin, err
+		}
+		cp, ok := plugin.(CacheProvider)
+		if !ok {
+			return plugin, nil
+		}
+		dumper, ok := cp.NRTCache().(nrtcache.Dumper)
+		if !ok {
+			srv.lh.Info("NRT cache cannot be dumped", "plugin", plugin.Name())
+			return plugin, nil
+		}
+		profileName := ""
+		if fwk, ok := handle.(framework.Framework); ok {
+			profileName = fwk.ProfileName()
+		}
+		srv.Register(profileName, dumper)
+		return plugin, nil
+	}
+}
+
+// ServeHTTP returns the sorted list of the known node names, or the NodeInfo of the node given in the "node" query parameter.
+func (srv *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	nodeName := r.URL.Query().Get("node")
+	if nodeName == "" {
+		srv.writeJSON(w, srv.NodeNames())
+		return
+	}
+
+	info, ok := srv.NodeInfo(nodeName)
+	if !ok {
+		http.Error(w, "node not found", http.StatusNotFound)
+		return
+	}
+	srv.writeJSON(w, info)
+}
+
+// NodeNames returns the sorted names of the nodes known to any registered cache.
+func (srv *Server) NodeNames() []string {
+	srv.lock.Lock()
+	defer srv.lock.Unlock()
+
+	names := make(map[string]struct{})
+	for _, dumper := range srv.dumpers {
+		for _, nodeName := range dumper.DumpNodeNames() {
+			names[nodeName] = struct{}{}
+		}
+	}
+	res := make([]string, 0, len(names))
+	for nodeName := range names {
+		res = append(res, nodeName)
+	}
+	sort.Strings(res)
+	return res
+}
+
+// NodeInfo collects the troubleshooting data about a node. Returns false if no data is available.
+func (srv *Server) NodeInfo(nodeName string) (NodeInfo, bool) {
+	info := NodeInfo{
+		NodeName: nodeName,
+		Profiles: make(map[string]nrtcache.NodeDump),
+	}
+
+	srv.lock.Lock()
+	for profileName, dumper := range srv.dumpers {
+		if dump, ok := dumper.DumpNode(nodeName); ok {
+			info.Profiles[profileName] = dump
+		}
+	}
+	srv.lock.Unlock()
+
+	if srv.statusDir != "" {
+		st, err := pfpstatus.LoadNodeStatus(srv.statusDir, nodeName)
+		if err == nil {
+			info.PodFingerprintStatus = &st
+		} else {
+			srv.lh.V(4).Info("cannot load podset fingerprint status", "node", nodeName, "error", err)
+		}
+	}
+
+	return info, len(info.Profiles) > 0 || info.PodFingerprintStatus != nil
+}
+
+func (srv *Server) writeJSON(w http.ResponseWriter, obj interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(obj); err != nil {
+		srv.lh.Error(err, "cannot encode NRT cache dump")
+	}
+}
@@ -0,0 +1,121 @@
+/*
+ * Copyright 2024 Red Hat, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cachedump
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/k8stopologyawareschedwg/podfingerprint"
+
+	"k8s.io/klog/v2"
+
+	nrtcache "sigs.k8s.io/scheduler-plugins/pkg/noderesourcetopology/cache"
+
+	"sigs.k8s.io/scheduler-plugins/pkg-kni/pfpstatus"
+)
+
+type fakeDumper struct {
+	nodes map[string]nrtcache.NodeDump
+}
+
+func (fd fakeDumper) DumpNodeNames() []string {
+	var names []string
+	for name := range fd.nodes {
+		names = append(names, name)
+	}
+	return names
+}
+
+func (fd fakeDumper) DumpNode(nodeName string) (nrtcache.NodeDump, bool) {
+	dump, ok := fd.nodes[nodeName]
+	return dump, ok
+}
+
+func TestServeHTTP(t *testing.T) {
+	statusDir := t.TempDir()
+	st := pfpstatus.StatusInfo{
+		Status: podfingerprint.Status{
+			NodeName:            "node-1",
+			FingerprintExpected: "PFPExpected",
+			FingerprintComputed: "PFPComputed",
+		},
+		SeqNo: 42,
+	}
+	if err := pfpstatus.DumpNodeStatus(statusDir, st); err != nil {
+		t.Fatalf("dump status failed: %v", err)
+	}
+
+	srv := NewServer(klog.Background(), statusDir)
+	srv.Register("profile-a", fakeDumper{
+		nodes: map[string]nrtcache.NodeDump{
+			"node-1": {NodeName: "node-1", DirtyCount: 3},
+		},
+	})
+	srv.Register("profile-b", fakeDumper{
+		nodes: map[string]nrtcache.NodeDump{
+			"node-0": {NodeName: "node-0"},
+			"node-1": {NodeName: "node-1"},
+		},
+	})
+
+	rr := httptest.NewRecorder()
+	srv.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, EndpointPath, nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("unexpected status: %d", rr.Code)
+	}
+	var names []string
+	if err := json.NewDecoder(rr.Body).Decode(&names); err != nil {
+		t.Fatalf("decode failed: %v", err)
+	}
+	if diff := cmp.Diff(names, []string{"node-0", "node-1"}); diff != "" {
+		t.Errorf("unexpected node names: %s", diff)
+	}
+
+	rr = httptest.NewRecorder()
+	srv.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, EndpointPath+"?node=node-1", nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("unexpected status: %d", rr.Code)
+	}
+	var info NodeInfo
+	if err := json.NewDecoder(rr.Body).Decode(&info); err != nil {
+		t.Fatalf("decode failed: %v", err)
+	}
+	if len(info.Profiles) != 2 || info.Profiles["profile-a"].DirtyCount != 3 {
+		t.Errorf("unexpected profiles data: %+v", info.Profiles)
+	}
+	if info.PodFingerprintStatus == nil || info.PodFingerprintStatus.SeqNo != 42 || info.PodFingerprintStatus.FingerprintComputed != "PFPComputed" {
+		t.Errorf("unexpected podset fingerprint status: %+v", info.PodFingerprintStatus)
+	}
+
+	rr = httptest.NewRecorder()
+	srv.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, EndpointPath+"?node=node-9", nil))
+	if rr.Code != http.StatusNotFound {
+		t.Errorf("unexpected status for unknown node: %d", rr.Code)
+	}
+}
+
+func TestNilServer(t *testing.T) {
+	var srv *Server
+	srv.Register("profile", fakeDumper{})
+	if srv.WrapPluginFactory(nil) != nil {
+		t.Errorf("nil server must not wrap the factory")
+	}
+}
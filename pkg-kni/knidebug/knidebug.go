@@ -27,21 +27,38 @@ import (
 
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/klog/v2"
 	resourcehelper "k8s.io/kubernetes/pkg/api/v1/resource"
 	"k8s.io/kubernetes/pkg/scheduler/framework"
+	frameworkruntime "k8s.io/kubernetes/pkg/scheduler/framework/runtime"
 
 	knifeatures "sigs.k8s.io/scheduler-plugins/pkg-kni/features"
 )
 
-type KNIDebug struct{}
+// KNIDebug logs the resource requests of the pods at high verbosity and explains each scheduling attempt:
+// which plugin rejected which node, and optionally the scores of the feasible nodes. The explanation of the
+// unschedulable pods is emitted as an Event on the pod, the explanation of the scheduled pods only if enabled
+// in the Options. All the explanations are optionally sent to a RecordSink.
+// To see all the rejections, KNIDebug must be the last Filter plugin.
+type KNIDebug struct {
+	handle  framework.Handle
+	opts    Options
+	profile string
+}
 
+var _ framework.PreFilterPlugin = &KNIDebug{}
 var _ framework.FilterPlugin = &KNIDebug{}
+var _ framework.PostFilterPlugin = &KNIDebug{}
+var _ framework.ReservePlugin = &KNIDebug{}
 
 const (
 	// Name is the name of the plugin used in the plugin registry and configurations.
 	Name     string = "KNIDebug"
 	LogLevel int    = 6
+
+	EventReason string = "SchedulingExplanation"
+	EventAction string = "Scheduling"
 )
 
 // Name returns name of the plugin. It is used in logs, etc.
@@ -50,10 +67,24 @@ func (kd *KNIDebug) Name() string {
 }
 
 // New initializes a new plugin and returns it.
-func New(_ context.Context, args runtime.Object, handle framework.Handle) (framework.Plugin, error) {
-	klog.V(6).InfoS("Creating new KNIDebug plugin")
-	knifeatures.LogState(Name, 2, knifeatures.Names())
-	return &KNIDebug{}, nil
+func New(ctx context.Context, args runtime.Object, handle framework.Handle) (framework.Plugin, error) {
+	return NewWithOptions(Options{})(ctx, args, handle)
+}
+
+// NewWithOptions returns a plugin factory which explains the scheduling attempts as the given options tell.
+func NewWithOptions(opts Options) frameworkruntime.PluginFactory {
+	return func(_ context.Context, args runtime.Object, handle framework.Handle) (framework.Plugin, error) {
+		klog.V(6).InfoS("Creating new KNIDebug plugin")
+		knifeatures.LogState(Name, 2, knifeatures.Names())
+		kd := &KNIDebug{
+			handle: handle,
+			opts:   opts,
+		}
+		if fwk, ok := handle.(framework.Framework); ok {
+			kd.profile = fwk.ProfileName()
+		}
+		return kd, nil
+	}
 }
 
 func (kd *KNIDebug) EventsToRegister() []framework.ClusterEvent {
@@ -65,6 +96,15 @@ func (kd *KNIDebug) EventsToRegister() []framework.ClusterEvent {
 	}
 }
 
+func (kd *KNIDebug) PreFilter(ctx context.Context, cycleState *framework.CycleState, pod *corev1.Pod) (*framework.PreFilterResult, *framework.Status) {
+	cycleState.Write(traceStateKey(), newAttemptTrace())
+	return nil, nil // must never fail
+}
+
+func (kd *KNIDebug) PreFilterExtensions() framework.PreFilterExtensions {
+	return nil
+}
+
 func (kd *KNIDebug) Filter(ctx context.Context, cycleState *framework.CycleState, pod *corev1.Pod, nodeInfo *framework.NodeInfo) *framework.Status {
 	lh := klog.FromContext(ctx)
 	node := nodeInfo.Node()
@@ -75,9 +115,124 @@ func (kd *KNIDebug) Filter(ctx context.Context, cycleState *framework.CycleState
 
 	// note the fit.go plugin computes this in the prefilter stage. Does this make any practical difference in our context?
 	checkRequest(lh.V(LogLevel), pod, nodeInfo)
+
+	// being the last filter, getting here means all the other filters accepted the node
+	if at := readAttemptTrace(cycleState); at != nil {
+		at.markFeasible(node.Name)
+	}
 	return nil // must never fail
 }
 
+// PostFilter explains why the pod can't be scheduled. It never makes the pod schedulable,
+// so the other PostFilter plugins, e.g. preemption, always run after it.
+func (kd *KNIDebug) PostFilter(ctx context.Context, cycleState *framework.CycleState, pod *corev1.Pod, filteredNodeStatusMap framework.NodeToStatusMap) (*framework.PostFilterResult, *framework.Status) {
+	rec := newRecord(pod, kd.profile, OutcomeUnschedulable)
+	rec.Nodes = explainRejections(filteredNodeStatusMap)
+	kd.emit(klog.FromContext(ctx), pod, rec)
+	return nil, framework.NewStatus(framework.Unschedulable)
+}
+
+// Reserve explains why the pod was assigned to the given node. If enabled, the scores are computed again
+// running the Score plugins, because the plugins can't access the scores computed by the framework.
+// Likewise, the Filter plugins run again on the nodes not found feasible to explain their rejection.
+func (kd *KNIDebug) Reserve(ctx context.Context, cycleState *framework.CycleState, pod *corev1.Pod, nodeName string) *framework.Status {
+	lh := klog.FromContext(ctx)
+	at := readAttemptTrace(cycleState)
+	if at == nil {
+		lh.V(4).Info("missing scheduling attempt trace", "pod", klog.KObj(pod))
+		return nil // must never fail
+	}
+
+	rec := newRecord(pod, kd.profile, OutcomeScheduled)
+	rec.SelectedNode = nodeName
+	nodeNames := at.feasibleNodes()
+	var nodeScores []framework.NodePluginScores
+	if kd.opts.Scores {
+		nodeScores = kd.computeScores(ctx, lh, cycleState, pod, nodeNames)
+	}
+	rec.Nodes = explainScores(nodeNames, nodeScores)
+	if kd.wantsRecord(rec) {
+		kd.traceRejections(ctx, lh, cycleState, pod, at)
+		rec.Nodes = append(rec.Nodes, explainRejections(at.rejections())...)
+		sortNodeExplanations(rec.Nodes)
+	}
+	kd.emit(lh, pod, rec)
+	return nil // must never fail
+}
+
+func (kd *KNIDebug) Unreserve(ctx context.Context, cycleState *framework.CycleState, pod *corev1.Pod, nodeName string) {
+}
+
+func (kd *KNIDebug) computeScores(ctx context.Context, lh logr.Logger, cycleState *framework.CycleState, pod *corev1.Pod, nodeNames []string) []framework.NodePluginScores {
+	// the framework skips scoring entirely if there is only a feasible node, so the PreScore data is missing
+	if len(nodeNames) < 2 || kd.handle == nil || kd.handle.SnapshotSharedLister() == nil {
+		return nil
+	}
+	nodeInfos := make([]*framework.NodeInfo, 0, len(nodeNames))
+	for _, nodeName := range nodeNames {
+		nodeInfo, err := kd.handle.SnapshotSharedLister().NodeInfos().Get(nodeName)
+		if err != nil {
+			lh.V(4).Info("cannot get node info", "node", nodeName, "error", err)
+			continue
+		}
+		nodeInfos = append(nodeInfos, nodeInfo)
+	}
+	nodeScores, status := kd.handle.RunScorePlugins(ctx, cycleState, pod, nodeInfos)
+	if !status.IsSuccess() {
+		lh.V(4).Info("cannot compute scores", "pod", klog.KObj(pod), "status", status.Message())
+		return nil
+	}
+	return nodeScores
+}
+
+// traceRejections records in the attempt trace why the Filter plugins rejected the nodes not found feasible.
+// The framework only hands the rejections to the PostFilter plugins, which don't run for scheduled pods.
+func (kd *KNIDebug) traceRejections(ctx context.Context, lh logr.Logger, cycleState *framework.CycleState, pod *corev1.Pod, at *attemptTrace) {
+	if kd.handle == nil || kd.handle.SnapshotSharedLister() == nil {
+		return
+	}
+	nodeInfos, err := kd.handle.SnapshotSharedLister().NodeInfos().List()
+	if err != nil {
+		lh.V(4).Info("cannot list node infos", "error", err)
+		return
+	}
+	feasible := sets.New[string](at.feasibleNodes()...)
+	// the Reserve plugins may have changed the state already, and the dry runs must not alter it further
+	state := cycleState.Clone()
+	for _, nodeInfo := range nodeInfos {
+		node := nodeInfo.Node()
+		if node == nil || feasible.Has(node.Name) {
+			continue
+		}
+		status := kd.handle.RunFilterPluginsWithNominatedPods(ctx, state, pod, nodeInfo)
+		if status.IsSuccess() {
+			continue // not evaluated by the framework, which stops once it finds enough feasible nodes
+		}
+		at.markRejected(node.Name, status)
+	}
+}
+
+func (kd *KNIDebug) wantsEvent(rec Record) bool {
+	return rec.Outcome != OutcomeScheduled || kd.opts.ScheduledEvents
+}
+
+// wantsRecord tells if the record is going to be emitted anywhere, to avoid computing it in vain.
+func (kd *KNIDebug) wantsRecord(rec Record) bool {
+	return kd.wantsEvent(rec) || kd.opts.Sink != nil
+}
+
+func (kd *KNIDebug) emit(lh logr.Logger, pod *corev1.Pod, rec Record) {
+	if kd.wantsEvent(rec) && kd.handle != nil && kd.handle.EventRecorder() != nil {
+		kd.handle.EventRecorder().Eventf(pod, nil, corev1.EventTypeNormal, EventReason, EventAction, "%s", rec.EventNote())
+	}
+	if kd.opts.Sink == nil {
+		return
+	}
+	if err := kd.opts.Sink.Write(rec); err != nil {
+		lh.V(2).Info("cannot write the scheduling explanation", "pod", klog.KObj(pod), "error", err)
+	}
+}
+
 func frameworkResourceToLoggable(req *framework.Resource) []interface{} {
 	items := []interface{}{
 		"cpu", humanCPU(req.MilliCPU),
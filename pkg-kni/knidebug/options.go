@@ -0,0 +1,67 @@
+/*
+ * Copyright 2024 Red Hat, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package knidebug
+
+import (
+	"os"
+	"strconv"
+
+	"github.com/go-logr/logr"
+)
+
+const (
+	ScheduledEventsEnvVar string = "KNIDEBUG_SCHEDULED_EVENTS"
+	ScoresEnvVar          string = "KNIDEBUG_SCORES"
+)
+
+// Options tunes the explanations of KNIDebug.
+type Options struct {
+	// Sink receives all the explanations, if not nil.
+	Sink RecordSink
+	// ScheduledEvents enables the Events explaining the scheduled pods, one for each pod.
+	ScheduledEvents bool
+	// Scores enables the scores of the feasible nodes in the explanation of the scheduled pods.
+	// The plugins can't access the scores computed by the framework, so the Score plugins run again in Reserve:
+	// this doubles the scoring cost, and the scores are approximate, because the state of the plugins
+	// may have changed meanwhile.
+	Scores bool
+}
+
+// SetupOptions creates the options from the environment. Everything but the explanation of the unschedulable
+// pods is disabled unless explicitly enabled.
+func SetupOptions(logh logr.Logger) Options {
+	opts := Options{
+		Sink:            SetupSink(logh),
+		ScheduledEvents: envBool(logh, ScheduledEventsEnvVar),
+		Scores:          envBool(logh, ScoresEnvVar),
+	}
+	logh.Info("KNIDebug options", "scheduledEvents", opts.ScheduledEvents, "scores", opts.Scores)
+	return opts
+}
+
+func envBool(logh logr.Logger, name string) bool {
+	val, ok := os.LookupEnv(name)
+	if !ok || val == "" {
+		return false
+	}
+	enabled, err := strconv.ParseBool(val)
+	if err != nil {
+		logh.Info("invalid value, disabled", "variable", name, "value", val)
+		return false
+	}
+	return enabled
+}
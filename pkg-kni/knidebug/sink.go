@@ -0,0 +1,73 @@
+/*
+ * Copyright 2024 Red Hat, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package knidebug
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"sync"
+
+	"github.com/go-logr/logr"
+)
+
+const (
+	TraceFileEnvVar string = "KNIDEBUG_TRACE_FILE"
+)
+
+// RecordSink consumes the explanation of the scheduling attempts.
+type RecordSink interface {
+	Write(rec Record) error
+}
+
+// JSONSink writes each record as a line of JSON. Safe for concurrent use.
+type JSONSink struct {
+	lock sync.Mutex
+	enc  *json.Encoder
+}
+
+var _ RecordSink = &JSONSink{}
+
+func NewJSONSink(w io.Writer) *JSONSink {
+	return &JSONSink{
+		enc: json.NewEncoder(w),
+	}
+}
+
+func (js *JSONSink) Write(rec Record) error {
+	js.lock.Lock()
+	defer js.lock.Unlock()
+	return js.enc.Encode(rec)
+}
+
+// SetupSink creates the JSON sink appending to the file set in the environment.
+// Returns nil if the sink is disabled or the file can't be opened.
+func SetupSink(logh logr.Logger) RecordSink {
+	path, ok := os.LookupEnv(TraceFileEnvVar)
+	if !ok || path == "" {
+		logh.Info("KNIDebug trace file disabled", "variableFound", ok, "valueGiven", path != "")
+		return nil
+	}
+	dst, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		logh.Error(err, "cannot open the KNIDebug trace file, disabled", "path", path)
+		return nil
+	}
+	logh.Info("KNIDebug trace file enabled", "path", path)
+	// the file is meant to stay open for the whole lifetime of the process
+	return NewJSONSink(dst)
+}
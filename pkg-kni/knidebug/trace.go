@@ -0,0 +1,249 @@
+/*
+ * Copyright 2024 Red Hat, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package knidebug
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/kubernetes/pkg/scheduler/framework"
+)
+
+const (
+	OutcomeScheduled     string = "Scheduled"
+	OutcomeUnschedulable string = "Unschedulable"
+
+	// maxEventNoteLength is the limit enforced by the API server on the note of the events.k8s.io/v1 Events
+	maxEventNoteLength = 1024
+)
+
+// PluginScore is the score, already weighted, a plugin gave to a node.
+type PluginScore struct {
+	Name  string `json:"name"`
+	Score int64  `json:"score"`
+}
+
+// NodeExplanation tells what happened to a node during a scheduling attempt.
+type NodeExplanation struct {
+	NodeName string `json:"nodeName"`
+	Feasible bool   `json:"feasible"`
+	// Plugin is the plugin which rejected the node, if any.
+	Plugin  string   `json:"plugin,omitempty"`
+	Reasons []string `json:"reasons,omitempty"`
+	// Scores are reported only for feasible nodes, if scoring happened.
+	Scores     []PluginScore `json:"scores,omitempty"`
+	TotalScore int64         `json:"totalScore,omitempty"`
+}
+
+// Record is the explanation of a scheduling attempt of a pod.
+type Record struct {
+	Timestamp    time.Time         `json:"timestamp"`
+	Profile      string            `json:"profile,omitempty"`
+	Namespace    string            `json:"namespace"`
+	Name         string            `json:"name"`
+	UID          string            `json:"uid"`
+	Outcome      string            `json:"outcome"`
+	SelectedNode string            `json:"selectedNode,omitempty"`
+	Nodes        []NodeExplanation `json:"nodes,omitempty"`
+}
+
+// attemptTrace collects the data during a scheduling cycle. Filter runs concurrently on many nodes.
+type attemptTrace struct {
+	lock     sync.Mutex
+	feasible map[string]struct{}
+	rejected framework.NodeToStatusMap
+}
+
+var _ framework.StateData = &attemptTrace{}
+
+func newAttemptTrace() *attemptTrace {
+	return &attemptTrace{
+		feasible: make(map[string]struct{}),
+		rejected: make(framework.NodeToStatusMap),
+	}
+}
+
+// Clone returns an empty trace: the preemption dry runs must not alter the data of the actual attempt.
+func (at *attemptTrace) Clone() framework.StateData {
+	return newAttemptTrace()
+}
+
+func (at *attemptTrace) markFeasible(nodeName string) {
+	at.lock.Lock()
+	defer at.lock.Unlock()
+	at.feasible[nodeName] = struct{}{}
+}
+
+func (at *attemptTrace) feasibleNodes() []string {
+	at.lock.Lock()
+	defer at.lock.Unlock()
+	nodeNames := make([]string, 0, len(at.feasible))
+	for nodeName := range at.feasible {
+		nodeNames = append(nodeNames, nodeName)
+	}
+	sort.Strings(nodeNames)
+	return nodeNames
+}
+
+func (at *attemptTrace) markRejected(nodeName string, status *framework.Status) {
+	at.lock.Lock()
+	defer at.lock.Unlock()
+	at.rejected[nodeName] = status
+}
+
+func (at *attemptTrace) rejections() framework.NodeToStatusMap {
+	at.lock.Lock()
+	defer at.lock.Unlock()
+	nodeStatuses := make(framework.NodeToStatusMap, len(at.rejected))
+	for nodeName, status := range at.rejected {
+		nodeStatuses[nodeName] = status
+	}
+	return nodeStatuses
+}
+
+func traceStateKey() framework.StateKey {
+	return framework.StateKey(Name + "/trace")
+}
+
+func readAttemptTrace(state *framework.CycleState) *attemptTrace {
+	data, err := state.Read(traceStateKey())
+	if err != nil {
+		return nil
+	}
+	at, ok := data.(*attemptTrace)
+	if !ok {
+		return nil
+	}
+	return at
+}
+
+func newRecord(pod *corev1.Pod, profile, outcome string) Record {
+	return Record{
+		Timestamp: time.Now(),
+		Profile:   profile,
+		Namespace: pod.Namespace,
+		Name:      pod.Name,
+		UID:       string(pod.UID),
+		Outcome:   outcome,
+	}
+}
+
+// explainRejections fills the explanation of the nodes rejected by the Filter plugins.
+func explainRejections(nodeStatuses framework.NodeToStatusMap) []NodeExplanation {
+	nodes := make([]NodeExplanation, 0, len(nodeStatuses))
+	for nodeName, status := range nodeStatuses {
+		nodes = append(nodes, NodeExplanation{
+			NodeName: nodeName,
+			Plugin:   status.Plugin(),
+			Reasons:  status.Reasons(),
+		})
+	}
+	sortNodeExplanations(nodes)
+	return nodes
+}
+
+// explainScores fills the explanation of the feasible nodes. The scores may be empty if scoring was skipped.
+func explainScores(nodeNames []string, nodeScores []framework.NodePluginScores) []NodeExplanation {
+	scoresByNode := make(map[string]framework.NodePluginScores, len(nodeScores))
+	for _, ns := range nodeScores {
+		scoresByNode[ns.Name] = ns
+	}
+	nodes := make([]NodeExplanation, 0, len(nodeNames))
+	for _, nodeName := range nodeNames {
+		ne := NodeExplanation{
+			NodeName: nodeName,
+			Feasible: true,
+		}
+		if ns, ok := scoresByNode[nodeName]; ok {
+			for _, ps := range ns.Scores {
+				ne.Scores = append(ne.Scores, PluginScore{Name: ps.Name, Score: ps.Score})
+			}
+			ne.TotalScore = ns.TotalScore
+		}
+		nodes = append(nodes, ne)
+	}
+	sortNodeExplanations(nodes)
+	return nodes
+}
+
+// sortNodeExplanations sorts the feasible nodes first, by decreasing score, then all the others by name.
+func sortNodeExplanations(nodes []NodeExplanation) {
+	sort.SliceStable(nodes, func(i, j int) bool {
+		if nodes[i].Feasible != nodes[j].Feasible {
+			return nodes[i].Feasible
+		}
+		if nodes[i].TotalScore != nodes[j].TotalScore {
+			return nodes[i].TotalScore > nodes[j].TotalScore
+		}
+		return nodes[i].NodeName < nodes[j].NodeName
+	})
+}
+
+// EventNote summarizes the record in a form suitable for the note of a Kubernetes Event.
+func (rec Record) EventNote() string {
+	var sb strings.Builder
+	if rec.Outcome == OutcomeScheduled {
+		fmt.Fprintf(&sb, "selected node %s", rec.SelectedNode)
+	} else {
+		sb.WriteString("no feasible node")
+	}
+
+	var scored, rejected []string
+	for _, ne := range rec.Nodes {
+		if ne.Feasible {
+			scored = append(scored, formatScores(ne))
+			continue
+		}
+		rejected = append(rejected, fmt.Sprintf("%s by %s (%s)", ne.NodeName, ne.Plugin, strings.Join(ne.Reasons, ", ")))
+	}
+	if len(scored) > 0 {
+		fmt.Fprintf(&sb, "; scores: %s", strings.Join(scored, "; "))
+	}
+	if len(rejected) > 0 {
+		fmt.Fprintf(&sb, "; rejected: %s", strings.Join(rejected, "; "))
+	}
+	return truncate(sb.String(), maxEventNoteLength)
+}
+
+func formatScores(ne NodeExplanation) string {
+	if len(ne.Scores) == 0 {
+		return ne.NodeName + " not scored"
+	}
+	items := make([]string, 0, len(ne.Scores))
+	for _, ps := range ne.Scores {
+		items = append(items, fmt.Sprintf("%s=%d", ps.Name, ps.Score))
+	}
+	return fmt.Sprintf("%s=%d [%s]", ne.NodeName, ne.TotalScore, strings.Join(items, " "))
+}
+
+// truncate cuts the string to at most maxLen bytes, never splitting a multi-byte rune.
+func truncate(s string, maxLen int) string {
+	const ellipsis = "..."
+	if len(s) <= maxLen {
+		return s
+	}
+	cut := maxLen - len(ellipsis)
+	for cut > 0 && !utf8.RuneStart(s[cut]) {
+		cut--
+	}
+	return s[:cut] + ellipsis
+}
@@ -0,0 +1,295 @@
+/*
+ * Copyright 2024 Red Hat, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package knidebug
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/events"
+	"k8s.io/kubernetes/pkg/scheduler/framework"
+	"k8s.io/kubernetes/pkg/scheduler/framework/plugins/defaultbinder"
+	"k8s.io/kubernetes/pkg/scheduler/framework/plugins/queuesort"
+	frameworkruntime "k8s.io/kubernetes/pkg/scheduler/framework/runtime"
+	tf "k8s.io/kubernetes/pkg/scheduler/testing/framework"
+
+	tu "sigs.k8s.io/scheduler-plugins/test/util"
+)
+
+type traceTestEnv struct {
+	fwk      framework.Framework
+	kd       *KNIDebug
+	recorder *events.FakeRecorder
+	sinkBuf  *bytes.Buffer
+	nodes    []*corev1.Node
+}
+
+func newTraceTestEnv(t *testing.T, ctx context.Context, failedNodes map[string]framework.Code, opts Options) *traceTestEnv {
+	t.Helper()
+	env := &traceTestEnv{
+		recorder: events.NewFakeRecorder(10),
+		sinkBuf:  &bytes.Buffer{},
+	}
+	for _, nodeName := range []string{"node1", "node2", "node3"} {
+		env.nodes = append(env.nodes, &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: nodeName}})
+	}
+
+	opts.Sink = NewJSONSink(env.sinkBuf)
+	factory := NewWithOptions(opts)
+	fwk, err := tf.NewFramework(
+		ctx,
+		[]tf.RegisterPluginFunc{
+			tf.RegisterQueueSortPlugin(queuesort.Name, queuesort.New),
+			tf.RegisterBindPlugin(defaultbinder.Name, defaultbinder.New),
+			tf.RegisterFilterPlugin("FakeFilter", tf.NewFakeFilterPlugin(failedNodes)),
+			tf.RegisterScorePlugin("Node2Prioritizer", tf.NewNode2PrioritizerPlugin(), 1),
+			tf.RegisterPluginAsExtensions(Name, func(ctx context.Context, args runtime.Object, handle framework.Handle) (framework.Plugin, error) {
+				pl, err := factory(ctx, args, handle)
+				if err == nil {
+					env.kd = pl.(*KNIDebug)
+				}
+				return pl, err
+			}, "PreFilter", "Filter", "Reserve"),
+		},
+		"test-profile",
+		frameworkruntime.WithEventRecorder(env.recorder),
+		frameworkruntime.WithPodNominator(tu.NewPodNominator(nil)),
+		frameworkruntime.WithSnapshotSharedLister(tu.NewFakeSharedLister(nil, env.nodes)),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	env.fwk = fwk
+	return env
+}
+
+// runFilters mimics what the scheduler does until the filtering
+func (env *traceTestEnv) runFilters(t *testing.T, ctx context.Context, state *framework.CycleState, pod *corev1.Pod) ([]*framework.NodeInfo, framework.NodeToStatusMap) {
+	t.Helper()
+	if _, status := env.fwk.RunPreFilterPlugins(ctx, state, pod); !status.IsSuccess() {
+		t.Fatalf("unexpected prefilter status: %v", status)
+	}
+	var feasible []*framework.NodeInfo
+	rejected := framework.NodeToStatusMap{}
+	for _, node := range env.nodes {
+		nodeInfo, err := env.fwk.SnapshotSharedLister().NodeInfos().Get(node.Name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		status := env.fwk.RunFilterPlugins(ctx, state, pod, nodeInfo)
+		if status.IsSuccess() {
+			feasible = append(feasible, nodeInfo)
+			continue
+		}
+		rejected[node.Name] = status
+	}
+	return feasible, rejected
+}
+
+func (env *traceTestEnv) sinkRecords(t *testing.T) []Record {
+	t.Helper()
+	var recs []Record
+	dec := json.NewDecoder(env.sinkBuf)
+	for dec.More() {
+		var rec Record
+		if err := dec.Decode(&rec); err != nil {
+			t.Fatal(err)
+		}
+		recs = append(recs, rec)
+	}
+	return recs
+}
+
+func TestExplainScheduled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	env := newTraceTestEnv(t, ctx, map[string]framework.Code{"node3": framework.Unschedulable}, Options{ScheduledEvents: true, Scores: true})
+
+	pod := makePod("uid0", "ns0", "pod0")
+	state := framework.NewCycleState()
+	feasible, _ := env.runFilters(t, ctx, state, pod)
+	if status := env.fwk.RunPreScorePlugins(ctx, state, pod, feasible); !status.IsSuccess() {
+		t.Fatalf("unexpected prescore status: %v", status)
+	}
+	if status := env.fwk.RunReservePluginsReserve(ctx, state, pod, "node2"); !status.IsSuccess() {
+		t.Fatalf("unexpected reserve status: %v", status)
+	}
+
+	recs := env.sinkRecords(t)
+	if len(recs) != 1 {
+		t.Fatalf("expected 1 record, got %d", len(recs))
+	}
+	rec := recs[0]
+	if rec.Outcome != OutcomeScheduled || rec.SelectedNode != "node2" || rec.Profile != "test-profile" || rec.UID != "uid0" {
+		t.Errorf("unexpected record: %+v", rec)
+	}
+	if len(rec.Nodes) != 3 {
+		t.Fatalf("expected 2 feasible nodes and 1 rejected node, got %+v", rec.Nodes)
+	}
+	if rec.Nodes[0].NodeName != "node2" || rec.Nodes[0].TotalScore != 100 || rec.Nodes[1].NodeName != "node1" || rec.Nodes[1].TotalScore != 10 {
+		t.Errorf("unexpected node explanations: %+v", rec.Nodes)
+	}
+	if ne := rec.Nodes[2]; ne.NodeName != "node3" || ne.Feasible || ne.Plugin != "FakeFilter" || len(ne.Reasons) != 1 {
+		t.Errorf("unexpected rejected node explanation: %+v", ne)
+	}
+	if len(rec.Nodes[0].Scores) != 1 || rec.Nodes[0].Scores[0].Name != "Node2Prioritizer" {
+		t.Errorf("unexpected plugin scores: %+v", rec.Nodes[0].Scores)
+	}
+
+	select {
+	case ev := <-env.recorder.Events:
+		expected := "Normal SchedulingExplanation selected node node2; scores: node2=100 [Node2Prioritizer=100]; node1=10 [Node2Prioritizer=10]; rejected: node3 by FakeFilter (injecting failure for pod pod0)"
+		if ev != expected {
+			t.Errorf("unexpected event: got=%q expected=%q", ev, expected)
+		}
+	default:
+		t.Errorf("missing event")
+	}
+}
+
+func TestExplainScheduledDefaults(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	env := newTraceTestEnv(t, ctx, map[string]framework.Code{"node3": framework.Unschedulable}, Options{})
+
+	pod := makePod("uid0", "ns0", "pod0")
+	state := framework.NewCycleState()
+	feasible, _ := env.runFilters(t, ctx, state, pod)
+	if status := env.fwk.RunPreScorePlugins(ctx, state, pod, feasible); !status.IsSuccess() {
+		t.Fatalf("unexpected prescore status: %v", status)
+	}
+	if status := env.fwk.RunReservePluginsReserve(ctx, state, pod, "node2"); !status.IsSuccess() {
+		t.Fatalf("unexpected reserve status: %v", status)
+	}
+
+	recs := env.sinkRecords(t)
+	if len(recs) != 1 {
+		t.Fatalf("expected 1 record, got %d", len(recs))
+	}
+	if len(recs[0].Nodes) != 3 {
+		t.Fatalf("expected 2 feasible nodes and 1 rejected node, got %+v", recs[0].Nodes)
+	}
+	for _, ne := range recs[0].Nodes {
+		if !ne.Feasible && ne.NodeName != "node3" {
+			t.Errorf("unexpected rejected node: %+v", ne)
+		}
+		if ne.TotalScore != 0 || len(ne.Scores) != 0 {
+			t.Errorf("unexpected scores computed: %+v", ne)
+		}
+	}
+
+	select {
+	case ev := <-env.recorder.Events:
+		t.Errorf("unexpected event: %q", ev)
+	default:
+	}
+}
+
+func TestExplainUnschedulable(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	env := newTraceTestEnv(t, ctx, map[string]framework.Code{
+		"node1": framework.Unschedulable,
+		"node2": framework.Unschedulable,
+		"node3": framework.UnschedulableAndUnresolvable,
+	}, Options{})
+
+	pod := makePod("uid1", "ns1", "pod1")
+	state := framework.NewCycleState()
+	_, rejected := env.runFilters(t, ctx, state, pod)
+	_, status := env.kd.PostFilter(ctx, state, pod, rejected)
+	if status.Code() != framework.Unschedulable {
+		t.Fatalf("PostFilter must never make pods schedulable, got %v", status)
+	}
+
+	recs := env.sinkRecords(t)
+	if len(recs) != 1 {
+		t.Fatalf("expected 1 record, got %d", len(recs))
+	}
+	rec := recs[0]
+	if rec.Outcome != OutcomeUnschedulable || rec.SelectedNode != "" {
+		t.Errorf("unexpected record: %+v", rec)
+	}
+	if len(rec.Nodes) != 3 {
+		t.Fatalf("expected 3 rejected nodes, got %+v", rec.Nodes)
+	}
+	for _, ne := range rec.Nodes {
+		if ne.Feasible || ne.Plugin != "FakeFilter" || len(ne.Reasons) != 1 {
+			t.Errorf("unexpected node explanation: %+v", ne)
+		}
+	}
+
+	select {
+	case ev := <-env.recorder.Events:
+		if !strings.HasPrefix(ev, "Normal SchedulingExplanation no feasible node; rejected: node1 by FakeFilter (injecting failure for pod pod1)") {
+			t.Errorf("unexpected event: %q", ev)
+		}
+	default:
+		t.Errorf("missing event")
+	}
+}
+
+func TestAttemptTraceClone(t *testing.T) {
+	at := newAttemptTrace()
+	at.markFeasible("node1")
+	cloned := at.Clone().(*attemptTrace)
+	cloned.markFeasible("node2")
+	cloned.markRejected("node3", framework.NewStatus(framework.Unschedulable))
+	if got := at.feasibleNodes(); len(got) != 1 || got[0] != "node1" {
+		t.Errorf("dry runs altered the original trace: %v", got)
+	}
+	if got := at.rejections(); len(got) != 0 {
+		t.Errorf("dry runs altered the original trace: %v", got)
+	}
+}
+
+func TestEventNoteTruncated(t *testing.T) {
+	rec := Record{Outcome: OutcomeUnschedulable}
+	for i := 0; i < 100; i++ {
+		rec.Nodes = append(rec.Nodes, NodeExplanation{
+			NodeName: "a-node-with-a-quite-long-name",
+			Plugin:   "NodeResourceTopologyMatch",
+			Reasons:  []string{"cannot align pod"},
+		})
+	}
+	note := rec.EventNote()
+	if len(note) != maxEventNoteLength || !strings.HasSuffix(note, "...") {
+		t.Errorf("unexpected note length %d: %q", len(note), note)
+	}
+}
+
+func TestTruncateRuneBoundary(t *testing.T) {
+	// "é" takes 2 bytes: a byte-based cut would leave half of the last rune
+	s := strings.Repeat("é", 10)
+	got := truncate(s, 10)
+	if !utf8.ValidString(got) || len(got) > 10 {
+		t.Errorf("unexpected truncated string %d bytes: %q", len(got), got)
+	}
+	if expected := strings.Repeat("é", 3) + "..."; got != expected {
+		t.Errorf("unexpected truncated string: got=%q expected=%q", got, expected)
+	}
+	if got := truncate("short", 10); got != "short" {
+		t.Errorf("unexpected truncated string: %q", got)
+	}
+}
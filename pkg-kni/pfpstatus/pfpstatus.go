@@ -19,8 +19,11 @@ package pfpstatus
 import (
 	"context"
 	"encoding/json"
+	"fmt"
+	"net/http"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"time"
 
@@ -31,6 +34,20 @@ import (
 
 const (
 	PFPStatusDumpEnvVar string = "PFP_STATUS_DUMP"
+
+	PFPStatusHistoryAddressEnvVar   string = "PFP_STATUS_HISTORY_ADDRESS"
+	PFPStatusHistoryLengthEnvVar    string = "PFP_STATUS_HISTORY_LENGTH"
+	PFPStatusHistoryRetentionEnvVar string = "PFP_STATUS_HISTORY_RETENTION"
+
+	PFPStatusLogEnvVar           string = "PFP_STATUS_LOG"
+	PFPStatusLogMaxSizeEnvVar    string = "PFP_STATUS_LOG_MAX_SIZE"
+	PFPStatusLogMaxBackupsEnvVar string = "PFP_STATUS_LOG_MAX_BACKUPS"
+
+	HistoryEndpointPath string = "/debug/pfpstatus"
+
+	DefaultHistoryLength int   = 16
+	DefaultLogMaxSize    int64 = 16 * 1024 * 1024
+	DefaultLogMaxBackups int   = 3
 )
 
 type StatusInfo struct {
@@ -39,29 +56,89 @@ type StatusInfo struct {
 	SeqNo     int64     `json:"seqNo"`
 }
 
+// Sink consumes the podset fingerprint status updates. RunForever calls Write sequentially.
+type Sink interface {
+	Write(st StatusInfo) error
+}
+
+// DirSink dumps the last status of each node in a JSON file in the given directory.
+type DirSink struct {
+	dir string
+}
+
+var _ Sink = DirSink{}
+
+func NewDirSink(dir string) DirSink {
+	return DirSink{dir: dir}
+}
+
+func (ds DirSink) Write(st StatusInfo) error {
+	// checked every time, so the directory can be mounted after the startup
+	if !existsBaseDirectory(ds.dir) {
+		return fmt.Errorf("base directory %q not found", ds.dir)
+	}
+	return DumpNodeStatus(ds.dir, st)
+}
+
+// Setup enables the sinks configured in the environment. If no sink is enabled, the status updates are not collected at all.
 func Setup(logh logr.Logger) {
-	dumpDir, ok := os.LookupEnv(PFPStatusDumpEnvVar)
-	if !ok || dumpDir == "" {
-		logh.Info("PFP Status dump disabled", "variableFound", ok, "valueGiven", dumpDir != "")
+	sinks := setupSinks(logh)
+	if len(sinks) == 0 {
+		logh.Info("PFP Status collection disabled")
 		return
 	}
 
-	logh.Info("PFP Status dump enabled", "statusDirectory", dumpDir)
-
 	ch := make(chan podfingerprint.Status)
 
 	podfingerprint.SetCompletionSink(ch)
-	go RunForever(context.Background(), logh, dumpDir, ch)
+	go RunForever(context.Background(), logh, ch, sinks...)
 }
 
-func RunForever(ctx context.Context, logger logr.Logger, baseDirectory string, updates <-chan podfingerprint.Status) {
-	// let's try to keep the amount of code we do in init() at minimum.
-	// This may happen if the container didn't have the directory mounted
-	discard := !existsBaseDirectory(baseDirectory)
-	if discard {
-		logger.Info("base directory not found, will discard everything", "baseDirectory", baseDirectory)
+func setupSinks(logh logr.Logger) []Sink {
+	var sinks []Sink
+
+	dumpDir, ok := os.LookupEnv(PFPStatusDumpEnvVar)
+	if !ok || dumpDir == "" {
+		logh.Info("PFP Status dump disabled", "variableFound", ok, "valueGiven", dumpDir != "")
+	} else {
+		logh.Info("PFP Status dump enabled", "statusDirectory", dumpDir)
+		sinks = append(sinks, NewDirSink(dumpDir))
+	}
+
+	addr, ok := os.LookupEnv(PFPStatusHistoryAddressEnvVar)
+	if !ok || addr == "" {
+		logh.Info("PFP Status history disabled", "variableFound", ok, "valueGiven", addr != "")
+	} else {
+		length := envInt(logh, PFPStatusHistoryLengthEnvVar, DefaultHistoryLength, 1)
+		retention := envDuration(logh, PFPStatusHistoryRetentionEnvVar, 0)
+		logh.Info("PFP Status history enabled", "address", addr, "length", length, "retention", retention)
+
+		ring := NewRingSink(length, retention)
+		mux := http.NewServeMux()
+		mux.Handle(HistoryEndpointPath, ring)
+		go func() {
+			err := http.ListenAndServe(addr, mux)
+			logh.Error(err, "PFP Status history endpoint stopped")
+		}()
+		sinks = append(sinks, ring)
 	}
 
+	logPath, ok := os.LookupEnv(PFPStatusLogEnvVar)
+	if !ok || logPath == "" {
+		logh.Info("PFP Status log disabled", "variableFound", ok, "valueGiven", logPath != "")
+	} else {
+		maxSize := int64(envInt(logh, PFPStatusLogMaxSizeEnvVar, int(DefaultLogMaxSize), 1))
+		maxBackups := envInt(logh, PFPStatusLogMaxBackupsEnvVar, DefaultLogMaxBackups, 0)
+		logh.Info("PFP Status log enabled", "path", logPath, "maxSize", maxSize, "maxBackups", maxBackups)
+		sinks = append(sinks, NewRotatingLogSink(logPath, maxSize, maxBackups))
+	}
+
+	return sinks
+}
+
+// RunForever sends the status updates to all the given sinks until the context is done.
+// The sender is blocked until the update is consumed, so errors are only logged and the loop must keep going.
+func RunForever(ctx context.Context, logger logr.Logger, updates <-chan podfingerprint.Status, sinks ...Sink) {
 	var seqNo int64 // 63 bits ought to be enough for anybody
 	logger.V(4).Info("status update loop started")
 	defer logger.V(4).Info("status update loop finished")
@@ -71,17 +148,17 @@ func RunForever(ctx context.Context, logger logr.Logger, baseDirectory string, u
 			return
 		// always keep dequeueing messages to not block the sender
 		case st := <-updates:
-			if discard {
-				return
-			}
 			seqNo += 1
-			// intentionally ignore errors, must keep going
 			sti := StatusInfo{
 				Status:    st,
 				LastWrite: time.Now(),
 				SeqNo:     seqNo,
 			}
-			DumpNodeStatus(baseDirectory, sti)
+			for _, sink := range sinks {
+				if err := sink.Write(sti); err != nil {
+					logger.V(4).Info("status update not written", "node", st.NodeName, "seqNo", seqNo, "error", err)
+				}
+			}
 		}
 	}
 }
@@ -127,3 +204,31 @@ func existsBaseDirectory(baseDir string) bool {
 	}
 	return info.IsDir()
 }
+
+// envInt returns the value of the given environment variable, or the default value if the variable
+// is unset or its value is not an integer greater than or equal to minValue.
+func envInt(logh logr.Logger, name string, defaultValue, minValue int) int {
+	val, ok := os.LookupEnv(name)
+	if !ok || val == "" {
+		return defaultValue
+	}
+	num, err := strconv.Atoi(val)
+	if err != nil || num < minValue {
+		logh.Info("invalid value, using default", "variable", name, "value", val, "default", defaultValue)
+		return defaultValue
+	}
+	return num
+}
+
+func envDuration(logh logr.Logger, name string, defaultValue time.Duration) time.Duration {
+	val, ok := os.LookupEnv(name)
+	if !ok || val == "" {
+		return defaultValue
+	}
+	dur, err := time.ParseDuration(val)
+	if err != nil || dur < 0 {
+		logh.Info("invalid value, using default", "variable", name, "value", val, "default", defaultValue)
+		return defaultValue
+	}
+	return dur
+}
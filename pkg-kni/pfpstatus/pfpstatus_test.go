@@ -17,11 +17,13 @@
 package pfpstatus
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"testing"
 	"time"
 
+	"github.com/go-logr/logr"
 	"github.com/google/go-cmp/cmp"
 	"github.com/k8stopologyawareschedwg/podfingerprint"
 )
@@ -75,3 +77,60 @@ func TestDumpNodeStatus(t *testing.T) {
 		t.Errorf("RTT check failed:\n%v", diff)
 	}
 }
+
+type recordingSink struct {
+	seqNos []int64
+}
+
+func (rs *recordingSink) Write(st StatusInfo) error {
+	rs.seqNos = append(rs.seqNos, st.SeqNo)
+	return nil
+}
+
+func TestRunForeverKeepsGoingWithMissingDirectory(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	updates := make(chan podfingerprint.Status)
+	rec := &recordingSink{}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		RunForever(ctx, logr.Discard(), updates, NewDirSink("/does/not/exist"), rec)
+	}()
+
+	for idx := 0; idx < 3; idx++ {
+		// would block forever if the loop stopped dequeueing
+		updates <- podfingerprint.Status{NodeName: "testNode-1"}
+	}
+	cancel()
+	<-done
+
+	if diff := cmp.Diff([]int64{1, 2, 3}, rec.seqNos); diff != "" {
+		t.Errorf("unexpected updates:\n%v", diff)
+	}
+}
+
+func TestEnvInt(t *testing.T) {
+	const name = "PFP_STATUS_TEST_INT"
+	tcases := []struct {
+		value    string
+		minValue int
+		expected int
+	}{
+		{value: "", minValue: 1, expected: 7},
+		{value: "3", minValue: 1, expected: 3},
+		{value: "0", minValue: 1, expected: 7},
+		{value: "0", minValue: 0, expected: 0},
+		{value: "-1", minValue: 0, expected: 7},
+		{value: "foo", minValue: 0, expected: 7},
+	}
+	for _, tcase := range tcases {
+		t.Run(fmt.Sprintf("value=%q min=%d", tcase.value, tcase.minValue), func(t *testing.T) {
+			t.Setenv(name, tcase.value)
+			if got := envInt(logr.Discard(), name, 7, tcase.minValue); got != tcase.expected {
+				t.Errorf("got %d expected %d", got, tcase.expected)
+			}
+		})
+	}
+}
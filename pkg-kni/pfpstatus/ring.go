@@ -0,0 +1,132 @@
+/*
+ * Copyright 2024 Red Hat, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package pfpstatus
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+// RingSink keeps in memory the last status updates of each node, bounded both in number and in age.
+type RingSink struct {
+	lock sync.Mutex
+	// length is the max amount of updates kept per node
+	length int
+	// retention is the max age of the updates kept; 0 means no limit
+	retention time.Duration
+	history   map[string][]StatusInfo // nodeName -> updates, oldest first
+	now       func() time.Time
+}
+
+var _ Sink = &RingSink{}
+
+func NewRingSink(length int, retention time.Duration) *RingSink {
+	if length <= 0 {
+		length = DefaultHistoryLength
+	}
+	return &RingSink{
+		length:    length,
+		retention: retention,
+		history:   make(map[string][]StatusInfo),
+		now:       time.Now,
+	}
+}
+
+func (rs *RingSink) Write(st StatusInfo) error {
+	rs.lock.Lock()
+	defer rs.lock.Unlock()
+	hist := append(rs.history[st.NodeName], st)
+	if len(hist) > rs.length {
+		// copy to let the dropped updates be garbage collected
+		hist = append([]StatusInfo{}, hist[len(hist)-rs.length:]...)
+	}
+	rs.history[st.NodeName] = hist
+	rs.expireLocked()
+	return nil
+}
+
+// NodeNames returns the sorted names of the nodes with at least a status update.
+func (rs *RingSink) NodeNames() []string {
+	rs.lock.Lock()
+	defer rs.lock.Unlock()
+	rs.expireLocked()
+	names := make([]string, 0, len(rs.history))
+	for nodeName := range rs.history {
+		names = append(names, nodeName)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// History returns a copy of the status updates of the given node, oldest first.
+func (rs *RingSink) History(nodeName string) []StatusInfo {
+	rs.lock.Lock()
+	defer rs.lock.Unlock()
+	rs.expireLocked()
+	hist, ok := rs.history[nodeName]
+	if !ok {
+		return nil
+	}
+	return append([]StatusInfo{}, hist...)
+}
+
+func (rs *RingSink) expireLocked() {
+	if rs.retention == 0 {
+		return
+	}
+	deadline := rs.now().Add(-rs.retention)
+	for nodeName, hist := range rs.history {
+		idx := sort.Search(len(hist), func(i int) bool {
+			return hist[i].LastWrite.After(deadline)
+		})
+		if idx == len(hist) {
+			delete(rs.history, nodeName)
+			continue
+		}
+		if idx > 0 {
+			rs.history[nodeName] = append([]StatusInfo{}, hist[idx:]...)
+		}
+	}
+}
+
+// ServeHTTP returns the sorted list of the known node names, or the history of the node given in the "node" query parameter.
+func (rs *RingSink) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var data interface{}
+	nodeName := r.URL.Query().Get("node")
+	if nodeName == "" {
+		data = rs.NodeNames()
+	} else {
+		hist := rs.History(nodeName)
+		if hist == nil {
+			http.Error(w, "node not found", http.StatusNotFound)
+			return
+		}
+		data = hist
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	// intentionally ignore errors, the client may have gone away
+	_ = json.NewEncoder(w).Encode(data)
+}
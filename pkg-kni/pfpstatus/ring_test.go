@@ -0,0 +1,127 @@
+/*
+ * Copyright 2024 Red Hat, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package pfpstatus
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/k8stopologyawareschedwg/podfingerprint"
+)
+
+func makeStatusInfo(nodeName string, seqNo int64, lastWrite time.Time) StatusInfo {
+	return StatusInfo{
+		Status: podfingerprint.Status{
+			NodeName:            nodeName,
+			FingerprintExpected: "PFPExpected",
+			FingerprintComputed: "PFPComputed",
+		},
+		LastWrite: lastWrite,
+		SeqNo:     seqNo,
+	}
+}
+
+func seqNosOf(hist []StatusInfo) []int64 {
+	var ret []int64
+	for _, st := range hist {
+		ret = append(ret, st.SeqNo)
+	}
+	return ret
+}
+
+func TestRingSinkLength(t *testing.T) {
+	now := time.Now()
+	rs := NewRingSink(3, 0)
+	for seqNo := int64(1); seqNo <= 5; seqNo++ {
+		rs.Write(makeStatusInfo("node-a", seqNo, now))
+	}
+	rs.Write(makeStatusInfo("node-b", 6, now))
+
+	if diff := cmp.Diff([]int64{3, 4, 5}, seqNosOf(rs.History("node-a"))); diff != "" {
+		t.Errorf("unexpected history of node-a:\n%v", diff)
+	}
+	if diff := cmp.Diff([]int64{6}, seqNosOf(rs.History("node-b"))); diff != "" {
+		t.Errorf("unexpected history of node-b:\n%v", diff)
+	}
+	if diff := cmp.Diff([]string{"node-a", "node-b"}, rs.NodeNames()); diff != "" {
+		t.Errorf("unexpected node names:\n%v", diff)
+	}
+	if hist := rs.History("node-c"); hist != nil {
+		t.Errorf("unexpected history of unknown node: %v", hist)
+	}
+}
+
+func TestRingSinkRetention(t *testing.T) {
+	now := time.Now()
+	rs := NewRingSink(10, time.Minute)
+	rs.now = func() time.Time { return now }
+
+	rs.Write(makeStatusInfo("node-a", 1, now.Add(-3*time.Minute)))
+	rs.Write(makeStatusInfo("node-a", 2, now.Add(-30*time.Second)))
+	rs.Write(makeStatusInfo("node-b", 3, now.Add(-2*time.Minute)))
+	rs.Write(makeStatusInfo("node-a", 4, now))
+
+	if diff := cmp.Diff([]int64{2, 4}, seqNosOf(rs.History("node-a"))); diff != "" {
+		t.Errorf("unexpected history of node-a:\n%v", diff)
+	}
+	if diff := cmp.Diff([]string{"node-a"}, rs.NodeNames()); diff != "" {
+		t.Errorf("unexpected node names:\n%v", diff)
+	}
+}
+
+func TestRingSinkServeHTTP(t *testing.T) {
+	now := time.Now().UTC().Truncate(time.Second)
+	rs := NewRingSink(4, 0)
+	rs.Write(makeStatusInfo("node-a", 1, now))
+	rs.Write(makeStatusInfo("node-a", 2, now))
+
+	rr := httptest.NewRecorder()
+	rs.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, HistoryEndpointPath, nil))
+	var names []string
+	if err := json.NewDecoder(rr.Body).Decode(&names); err != nil {
+		t.Fatalf("cannot decode node names: %v", err)
+	}
+	if diff := cmp.Diff([]string{"node-a"}, names); diff != "" {
+		t.Errorf("unexpected node names:\n%v", diff)
+	}
+
+	rr = httptest.NewRecorder()
+	rs.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, HistoryEndpointPath+"?node=node-a", nil))
+	var hist []StatusInfo
+	if err := json.NewDecoder(rr.Body).Decode(&hist); err != nil {
+		t.Fatalf("cannot decode history: %v", err)
+	}
+	if diff := cmp.Diff(rs.History("node-a"), hist); diff != "" {
+		t.Errorf("unexpected history:\n%v", diff)
+	}
+
+	rr = httptest.NewRecorder()
+	rs.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, HistoryEndpointPath+"?node=node-x", nil))
+	if rr.Code != http.StatusNotFound {
+		t.Errorf("unexpected status for unknown node: %d", rr.Code)
+	}
+
+	rr = httptest.NewRecorder()
+	rs.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, HistoryEndpointPath, nil))
+	if rr.Code != http.StatusMethodNotAllowed {
+		t.Errorf("unexpected status for POST: %d", rr.Code)
+	}
+}
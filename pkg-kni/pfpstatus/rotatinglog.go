@@ -0,0 +1,139 @@
+/*
+ * Copyright 2024 Red Hat, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package pfpstatus
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"sync"
+)
+
+// RotatingLogSink appends the status updates of all the nodes as JSON lines to a file.
+// When the file would grow past maxSize, it is renamed to path.1, path.1 to path.2 and so forth,
+// keeping at most maxBackups old files.
+type RotatingLogSink struct {
+	lock       sync.Mutex
+	path       string
+	maxSize    int64
+	maxBackups int
+	dst        *os.File
+	size       int64
+}
+
+var _ Sink = &RotatingLogSink{}
+
+func NewRotatingLogSink(path string, maxSize int64, maxBackups int) *RotatingLogSink {
+	if maxSize <= 0 {
+		maxSize = DefaultLogMaxSize
+	}
+	if maxBackups < 0 {
+		maxBackups = 0
+	}
+	return &RotatingLogSink{
+		path:       path,
+		maxSize:    maxSize,
+		maxBackups: maxBackups,
+	}
+}
+
+func (rl *RotatingLogSink) Write(st StatusInfo) error {
+	line, err := json.Marshal(st)
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+
+	rl.lock.Lock()
+	defer rl.lock.Unlock()
+
+	if err := rl.openLocked(); err != nil {
+		return err
+	}
+	// a single line bigger than maxSize still gets its own file
+	if rl.size > 0 && rl.size+int64(len(line)) > rl.maxSize {
+		if err := rl.rotateLocked(); err != nil {
+			return err
+		}
+	}
+	n, err := rl.dst.Write(line)
+	rl.size += int64(n)
+	return err
+}
+
+// Close closes the current file. Further writes will reopen it.
+func (rl *RotatingLogSink) Close() error {
+	rl.lock.Lock()
+	defer rl.lock.Unlock()
+	return rl.closeLocked()
+}
+
+func (rl *RotatingLogSink) openLocked() error {
+	if rl.dst != nil {
+		return nil
+	}
+	dst, err := os.OpenFile(rl.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	info, err := dst.Stat()
+	if err != nil {
+		dst.Close() // swallow error because we want to bubble up the stat error
+		return err
+	}
+	rl.dst = dst
+	rl.size = info.Size()
+	return nil
+}
+
+func (rl *RotatingLogSink) closeLocked() error {
+	if rl.dst == nil {
+		return nil
+	}
+	err := rl.dst.Close()
+	rl.dst = nil
+	rl.size = 0
+	return err
+}
+
+func (rl *RotatingLogSink) rotateLocked() error {
+	if err := rl.closeLocked(); err != nil {
+		return err
+	}
+	if rl.maxBackups == 0 {
+		if err := os.Remove(rl.path); err != nil {
+			return err
+		}
+		return rl.openLocked()
+	}
+	for idx := rl.maxBackups - 1; idx >= 1; idx-- {
+		err := os.Rename(rl.backupPath(idx), rl.backupPath(idx+1))
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+	}
+	if err := os.Rename(rl.path, rl.backupPath(1)); err != nil {
+		return err
+	}
+	return rl.openLocked()
+}
+
+func (rl *RotatingLogSink) backupPath(idx int) string {
+	return fmt.Sprintf("%s.%d", rl.path, idx)
+}
@@ -0,0 +1,102 @@
+/*
+ * Copyright 2024 Red Hat, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package pfpstatus
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func readLogSeqNos(t *testing.T, path string) []int64 {
+	t.Helper()
+	src, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("cannot open %q: %v", path, err)
+	}
+	defer src.Close()
+	var ret []int64
+	scanner := bufio.NewScanner(src)
+	for scanner.Scan() {
+		var st StatusInfo
+		if err := json.Unmarshal(scanner.Bytes(), &st); err != nil {
+			t.Fatalf("cannot decode line %q: %v", scanner.Text(), err)
+		}
+		ret = append(ret, st.SeqNo)
+	}
+	return ret
+}
+
+func TestRotatingLogSink(t *testing.T) {
+	now := time.Now()
+	path := filepath.Join(t.TempDir(), "pfpstatus.jsonl")
+
+	line, err := json.Marshal(makeStatusInfo("node-a", 1, now))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// room for two lines per file
+	rl := NewRotatingLogSink(path, int64(2*(len(line)+1)), 2)
+	defer rl.Close()
+
+	for seqNo := int64(1); seqNo <= 7; seqNo++ {
+		if err := rl.Write(makeStatusInfo("node-a", seqNo, now)); err != nil {
+			t.Fatalf("write %d failed: %v", seqNo, err)
+		}
+	}
+
+	if diff := cmp.Diff([]int64{7}, readLogSeqNos(t, path)); diff != "" {
+		t.Errorf("unexpected current file:\n%v", diff)
+	}
+	if diff := cmp.Diff([]int64{5, 6}, readLogSeqNos(t, path+".1")); diff != "" {
+		t.Errorf("unexpected first backup:\n%v", diff)
+	}
+	if diff := cmp.Diff([]int64{3, 4}, readLogSeqNos(t, path+".2")); diff != "" {
+		t.Errorf("unexpected second backup:\n%v", diff)
+	}
+	if _, err := os.Stat(path + ".3"); !os.IsNotExist(err) {
+		t.Errorf("unexpected third backup: %v", err)
+	}
+}
+
+func TestRotatingLogSinkReopen(t *testing.T) {
+	now := time.Now()
+	path := filepath.Join(t.TempDir(), "pfpstatus.jsonl")
+
+	rl := NewRotatingLogSink(path, 0, 0)
+	if err := rl.Write(makeStatusInfo("node-a", 1, now)); err != nil {
+		t.Fatal(err)
+	}
+	if err := rl.Close(); err != nil {
+		t.Fatal(err)
+	}
+	// like after a restart
+	rl = NewRotatingLogSink(path, 0, 0)
+	defer rl.Close()
+	if err := rl.Write(makeStatusInfo("node-b", 2, now)); err != nil {
+		t.Fatal(err)
+	}
+
+	if diff := cmp.Diff([]int64{1, 2}, readLogSeqNos(t, path)); diff != "" {
+		t.Errorf("unexpected file content:\n%v", diff)
+	}
+}
@@ -105,22 +105,25 @@ func (r *PodGroupReconciler) Reconcile(ctx context.Context, req ctrl.Request) (c
 	case "":
 		pgCopy.Status.Phase = schedv1alpha1.PodGroupPending
 	case schedv1alpha1.PodGroupPending:
-		if len(pods) >= int(pg.Spec.MinMember) {
+		pgCopy.Status.Roles = getRoleStats(pg, pods)
+		if len(pods) >= int(pg.Spec.MinMember) && rolesReached(pg, pgCopy.Status.Roles, roleMembers) {
 			pgCopy.Status.Phase = schedv1alpha1.PodGroupScheduling
 			fillOccupiedObj(pgCopy, &pods[0])
 		}
 	default:
 		pgCopy.Status.Running, pgCopy.Status.Succeeded, pgCopy.Status.Failed = getCurrentPodStats(pods)
-		if len(pods) < int(pg.Spec.MinMember) {
+		pgCopy.Status.Roles = getRoleStats(pg, pods)
+		if len(pods) < int(pg.Spec.MinMember) || !rolesReached(pg, pgCopy.Status.Roles, roleMembers) {
 			pgCopy.Status.Phase = schedv1alpha1.PodGroupPending
 			break
 		}
 
-		if pgCopy.Status.Succeeded+pgCopy.Status.Running < pg.Spec.MinMember {
+		rolesStarted := rolesReached(pg, pgCopy.Status.Roles, roleStarted)
+		if pgCopy.Status.Succeeded+pgCopy.Status.Running < pg.Spec.MinMember || !rolesStarted {
 			pgCopy.Status.Phase = schedv1alpha1.PodGroupScheduling
 		}
 
-		if pgCopy.Status.Succeeded+pgCopy.Status.Running >= pg.Spec.MinMember {
+		if pgCopy.Status.Succeeded+pgCopy.Status.Running >= pg.Spec.MinMember && rolesStarted {
 			pgCopy.Status.Phase = schedv1alpha1.PodGroupRunning
 		}
 		// Final state of pod group
@@ -128,7 +131,7 @@ func (r *PodGroupReconciler) Reconcile(ctx context.Context, req ctrl.Request) (c
 			pgCopy.Status.Failed+pgCopy.Status.Running+pgCopy.Status.Succeeded >= pg.Spec.MinMember {
 			pgCopy.Status.Phase = schedv1alpha1.PodGroupFailed
 		}
-		if pgCopy.Status.Succeeded >= pg.Spec.MinMember {
+		if pgCopy.Status.Succeeded >= pg.Spec.MinMember && rolesReached(pg, pgCopy.Status.Roles, roleSucceeded) {
 			pgCopy.Status.Phase = schedv1alpha1.PodGroupFinished
 		}
 	}
@@ -168,6 +171,59 @@ func getCurrentPodStats(pods []v1.Pod) (int32, int32, int32) {
 	return running, succeeded, failed
 }
 
+// getRoleStats returns the pod stats of every role of the given pod group, in the order of the spec.
+// It returns nil if the pod group has no roles.
+func getRoleStats(pg *schedv1alpha1.PodGroup, pods []v1.Pod) []schedv1alpha1.PodGroupRoleStatus {
+	if len(pg.Spec.Roles) == 0 {
+		return nil
+	}
+	stats := make([]schedv1alpha1.PodGroupRoleStatus, len(pg.Spec.Roles))
+	idxs := make(map[string]int, len(pg.Spec.Roles))
+	for idx, role := range pg.Spec.Roles {
+		stats[idx].Name = role.Name
+		idxs[role.Name] = idx
+	}
+	for idx := range pods {
+		role := util.GetPodGroupRole(pg, &pods[idx])
+		if role == "" {
+			continue
+		}
+		stat := &stats[idxs[role]]
+		stat.Members++
+		switch pods[idx].Status.Phase {
+		case v1.PodRunning:
+			stat.Running++
+		case v1.PodSucceeded:
+			stat.Succeeded++
+		case v1.PodFailed:
+			stat.Failed++
+		}
+	}
+	return stats
+}
+
+func roleMembers(stat *schedv1alpha1.PodGroupRoleStatus) int32 {
+	return stat.Members
+}
+
+func roleStarted(stat *schedv1alpha1.PodGroupRoleStatus) int32 {
+	return stat.Running + stat.Succeeded
+}
+
+func roleSucceeded(stat *schedv1alpha1.PodGroupRoleStatus) int32 {
+	return stat.Succeeded
+}
+
+// rolesReached tells if, for every role of the given pod group, the pods counted from the stats reach the minMember of the role.
+func rolesReached(pg *schedv1alpha1.PodGroup, stats []schedv1alpha1.PodGroupRoleStatus, count func(*schedv1alpha1.PodGroupRoleStatus) int32) bool {
+	for idx, role := range pg.Spec.Roles {
+		if count(&stats[idx]) < role.MinMember {
+			return false
+		}
+	}
+	return true
+}
+
 func fillOccupiedObj(pg *schedv1alpha1.PodGroup, pod *v1.Pod) {
 	if len(pod.OwnerReferences) == 0 {
 		return
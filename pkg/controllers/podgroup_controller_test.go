@@ -18,6 +18,7 @@ package controllers
 
 import (
 	"context"
+	"reflect"
 	"sort"
 	"strings"
 	"testing"
@@ -268,6 +269,120 @@ func TestFillGroupStatusOccupied(t *testing.T) {
 	}
 }
 
+func TestRoleStatus(t *testing.T) {
+	ctx := context.TODO()
+	makeRolePod := func(name, role string, phase v1.PodPhase) *v1.Pod {
+		pod := st.MakePod().Namespace("default").Name(name).Label(v1alpha1.PodGroupLabel, "pg").Label("role", role).Obj()
+		pod.Status.Phase = phase
+		return pod
+	}
+	cases := []struct {
+		name              string
+		pods              []*v1.Pod
+		previousPhase     v1alpha1.PodGroupPhase
+		desiredGroupPhase v1alpha1.PodGroupPhase
+		desiredRoles      []v1alpha1.PodGroupRoleStatus
+	}{
+		{
+			name: "Group status convert from pending to scheduling",
+			pods: []*v1.Pod{
+				makeRolePod("ps", "ps", v1.PodPending),
+				makeRolePod("worker1", "worker", v1.PodPending),
+				makeRolePod("worker2", "worker", v1.PodPending),
+			},
+			previousPhase:     v1alpha1.PodGroupPending,
+			desiredGroupPhase: v1alpha1.PodGroupScheduling,
+			desiredRoles: []v1alpha1.PodGroupRoleStatus{
+				{Name: "ps", Members: 1},
+				{Name: "worker", Members: 2},
+			},
+		},
+		{
+			name: "Group status keeps pending, role min member more than Pod number",
+			pods: []*v1.Pod{
+				makeRolePod("worker1", "worker", v1.PodPending),
+				makeRolePod("worker2", "worker", v1.PodPending),
+				makeRolePod("worker3", "worker", v1.PodPending),
+			},
+			previousPhase:     v1alpha1.PodGroupPending,
+			desiredGroupPhase: v1alpha1.PodGroupPending,
+			desiredRoles: []v1alpha1.PodGroupRoleStatus{
+				{Name: "ps"},
+				{Name: "worker", Members: 3},
+			},
+		},
+		{
+			name: "Group running",
+			pods: []*v1.Pod{
+				makeRolePod("ps", "ps", v1.PodRunning),
+				makeRolePod("worker1", "worker", v1.PodRunning),
+				makeRolePod("worker2", "worker", v1.PodRunning),
+			},
+			previousPhase:     v1alpha1.PodGroupScheduling,
+			desiredGroupPhase: v1alpha1.PodGroupRunning,
+			desiredRoles: []v1alpha1.PodGroupRoleStatus{
+				{Name: "ps", Members: 1, Running: 1},
+				{Name: "worker", Members: 2, Running: 2},
+			},
+		},
+		{
+			name: "Group keeps scheduling, role running pods less than min member",
+			pods: []*v1.Pod{
+				makeRolePod("ps", "ps", v1.PodPending),
+				makeRolePod("worker1", "worker", v1.PodRunning),
+				makeRolePod("worker2", "worker", v1.PodRunning),
+				makeRolePod("worker3", "worker", v1.PodSucceeded),
+			},
+			previousPhase:     v1alpha1.PodGroupScheduling,
+			desiredGroupPhase: v1alpha1.PodGroupScheduling,
+			desiredRoles: []v1alpha1.PodGroupRoleStatus{
+				{Name: "ps", Members: 1},
+				{Name: "worker", Members: 3, Running: 2, Succeeded: 1},
+			},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			s := scheme.Scheme
+			pg := makePG("pg", 3, c.previousPhase, nil)
+			pg.Spec.Roles = []v1alpha1.PodGroupRole{
+				{Name: "ps", Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"role": "ps"}}, MinMember: 1},
+				{Name: "worker", Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"role": "worker"}}, MinMember: 2},
+			}
+			s.AddKnownTypes(v1alpha1.SchemeGroupVersion, pg)
+			objs := []runtime.Object{pg}
+			for _, pod := range c.pods {
+				objs = append(objs, pod)
+			}
+			kClient := fake.NewClientBuilder().
+				WithScheme(s).
+				WithStatusSubresource(&v1alpha1.PodGroup{}).
+				WithRuntimeObjects(objs...).
+				Build()
+			controller := &PodGroupReconciler{
+				Client:   kClient,
+				Scheme:   s,
+				recorder: record.NewFakeRecorder(3),
+				log:      klogr.New().WithName("podGroupTest"),
+			}
+
+			req := ctrl.Request{NamespacedName: types.NamespacedName{Name: "pg", Namespace: metav1.NamespaceDefault}}
+			if _, err := controller.Reconcile(ctx, req); err != nil {
+				t.Fatalf("reconcile: (%v)", err)
+			}
+			if err := kClient.Get(ctx, req.NamespacedName, pg); err != nil {
+				t.Fatal(err)
+			}
+			if pg.Status.Phase != c.desiredGroupPhase {
+				t.Errorf("want %v, got %v", c.desiredGroupPhase, pg.Status.Phase)
+			}
+			if !reflect.DeepEqual(pg.Status.Roles, c.desiredRoles) {
+				t.Errorf("want roles %+v, got %+v", c.desiredRoles, pg.Status.Roles)
+			}
+		})
+	}
+}
+
 func setUp(ctx context.Context,
 	podNames []string,
 	pgName string,
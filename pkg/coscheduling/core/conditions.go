@@ -0,0 +1,97 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package core
+
+import (
+	"context"
+	"sync"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"sigs.k8s.io/scheduler-plugins/apis/scheduling/v1alpha1"
+)
+
+// conditionPatcher sets the conditions in the status of the podgroups asynchronously, so the scheduling
+// cycles don't wait for the API server. The requests for a podgroup received while a patch of the same podgroup
+// is in flight are coalesced, and only the latest one is patched, if the condition is not set already.
+type conditionPatcher struct {
+	client client.Client
+	lock   sync.Mutex
+	// pending stores the latest condition requested by podgroup full name, nil if none is requested after the
+	// one in flight. The podgroups without a patch in flight are missing.
+	pending map[string]*metav1.Condition
+	// wg tracks the patches in flight. Used only in tests.
+	wg sync.WaitGroup
+}
+
+func newConditionPatcher(client client.Client) *conditionPatcher {
+	return &conditionPatcher{
+		client:  client,
+		pending: make(map[string]*metav1.Condition),
+	}
+}
+
+// Patch requests the given condition to be set in the status of the given pg.
+func (cp *conditionPatcher) Patch(pg *v1alpha1.PodGroup, condition metav1.Condition) {
+	key := types.NamespacedName{Namespace: pg.Namespace, Name: pg.Name}
+	cp.lock.Lock()
+	_, inFlight := cp.pending[key.String()]
+	cp.pending[key.String()] = &condition
+	cp.lock.Unlock()
+	if inFlight {
+		return
+	}
+	cp.wg.Add(1)
+	go cp.run(key)
+}
+
+func (cp *conditionPatcher) run(key types.NamespacedName) {
+	defer cp.wg.Done()
+	for {
+		cp.lock.Lock()
+		condition := cp.pending[key.String()]
+		if condition == nil {
+			delete(cp.pending, key.String())
+			cp.lock.Unlock()
+			return
+		}
+		cp.pending[key.String()] = nil
+		cp.lock.Unlock()
+		cp.patch(key, *condition)
+	}
+}
+
+func (cp *conditionPatcher) patch(key types.NamespacedName, condition metav1.Condition) {
+	ctx := context.Background()
+	pg := &v1alpha1.PodGroup{}
+	if err := cp.client.Get(ctx, key, pg); err != nil {
+		klog.ErrorS(err, "Failed to get the pod group to patch its conditions", "podGroup", key)
+		return
+	}
+	pgCopy := pg.DeepCopy()
+	condition.ObservedGeneration = pg.Generation
+	if !meta.SetStatusCondition(&pgCopy.Status.Conditions, condition) {
+		return
+	}
+	if err := cp.client.Status().Patch(ctx, pgCopy, client.MergeFrom(pg)); err != nil {
+		klog.ErrorS(err, "Failed to patch the conditions of the pod group", "podGroup", key)
+	}
+}
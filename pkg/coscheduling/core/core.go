@@ -24,6 +24,8 @@ import (
 
 	gocache "github.com/patrickmn/go-cache"
 	corev1 "k8s.io/api/core/v1"
+	policy "k8s.io/api/policy/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
@@ -34,6 +36,7 @@ import (
 	"k8s.io/kubernetes/pkg/scheduler/framework"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
+	"sigs.k8s.io/scheduler-plugins/apis/config"
 	"sigs.k8s.io/scheduler-plugins/apis/scheduling/v1alpha1"
 	"sigs.k8s.io/scheduler-plugins/pkg/util"
 )
@@ -68,8 +71,13 @@ type Manager interface {
 	GetCreationTimestamp(*corev1.Pod, time.Time) time.Time
 	DeletePermittedPodGroup(string)
 	CalculateAssignedPods(string, string) int
+	QuorumReached(*v1alpha1.PodGroup) bool
 	ActivateSiblings(pod *corev1.Pod, state *framework.CycleState)
-	BackoffPodGroup(string, time.Duration)
+	BackoffPodGroup(context.Context, *v1alpha1.PodGroup, time.Duration, time.Duration, string)
+	GetTopologyDomain(*v1alpha1.PodGroup) (string, bool)
+	ReserveTopologyDomain(*v1alpha1.PodGroup, string)
+	ReleaseTopologyDomain(string)
+	SelectVictims(context.Context, *corev1.Pod, *v1alpha1.PodGroup, []*policy.PodDisruptionBudget) ([]*corev1.Pod, string, error)
 }
 
 // PodGroupManager defines the scheduling operation called
@@ -83,31 +91,115 @@ type PodGroupManager struct {
 	scheduleTimeout *time.Duration
 	// permittedPG stores the podgroup name which has passed the pre resource check.
 	permittedPG *gocache.Cache
-	// backedOffPG stores the podgorup name which failed scheudling recently.
-	backedOffPG *gocache.Cache
+	// backoffs stores the consecutive scheduling failures of the podgroups, by podgroup full name.
+	// The entries expire when the failures are no longer consecutive, so the deleted podgroups are forgotten.
+	backoffs *gocache.Cache
 	// podLister is pod lister
 	podLister listerv1.PodLister
+	// preFilterMode selects how the feasibility of a podgroup is checked in PreFilter.
+	preFilterMode config.CoschedulingPreFilterMode
+	// topologyDomains stores the topology domain chosen for the podgroups having a topology constraint.
+	topologyDomains map[string]string
+	// conditions patches the conditions of the podgroups.
+	conditions *conditionPatcher
 	sync.RWMutex
 }
 
+// podGroupBackoff records the consecutive scheduling failures of a podgroup.
+type podGroupBackoff struct {
+	// failures is the number of consecutive scheduling failures.
+	failures int32
+	// until is the time the podgroup is backed off until.
+	until time.Time
+}
+
 // NewPodGroupManager creates a new operation object.
-func NewPodGroupManager(client client.Client, snapshotSharedLister framework.SharedLister, scheduleTimeout *time.Duration, podInformer informerv1.PodInformer) *PodGroupManager {
+func NewPodGroupManager(client client.Client, snapshotSharedLister framework.SharedLister, scheduleTimeout *time.Duration, podInformer informerv1.PodInformer, preFilterMode config.CoschedulingPreFilterMode) *PodGroupManager {
 	pgMgr := &PodGroupManager{
 		client:               client,
 		snapshotSharedLister: snapshotSharedLister,
 		scheduleTimeout:      scheduleTimeout,
+		preFilterMode:        preFilterMode,
 		podLister:            podInformer.Lister(),
 		permittedPG:          gocache.New(3*time.Second, 3*time.Second),
-		backedOffPG:          gocache.New(10*time.Second, 10*time.Second),
+		backoffs:             gocache.New(time.Minute, time.Minute),
+		topologyDomains:      make(map[string]string),
+		conditions:           newConditionPatcher(client),
 	}
 	return pgMgr
 }
 
-func (pgMgr *PodGroupManager) BackoffPodGroup(pgName string, backoff time.Duration) {
-	if backoff == time.Duration(0) {
+// BackoffPodGroup backs off the given pg after it failed scheduling for the given reason.
+// The backoff starts from initialBackoff and doubles at every consecutive failure, up to maxBackoff.
+// The failures are no longer consecutive if the pg does not fail again within maxBackoff after its backoff expires.
+// The backoff deadline and the reason are reported asynchronously in the PodGroupBackedOff condition of the pg.
+func (pgMgr *PodGroupManager) BackoffPodGroup(ctx context.Context, pg *v1alpha1.PodGroup, initialBackoff, maxBackoff time.Duration, reason string) {
+	if initialBackoff == time.Duration(0) {
 		return
 	}
-	pgMgr.backedOffPG.Add(pgName, nil, backoff)
+	pgFullName := GetNamespacedName(pg)
+	now := time.Now()
+	pgMgr.Lock()
+	b, ok := pgMgr.getBackoff(pgFullName)
+	if !ok || now.After(b.until.Add(maxBackoff)) {
+		b = &podGroupBackoff{}
+	}
+	b.failures++
+	backoff := getBackoffDuration(b.failures, initialBackoff, maxBackoff)
+	b.until = now.Add(backoff)
+	pgMgr.backoffs.Set(pgFullName, b, backoff+maxBackoff)
+	failures, until := b.failures, b.until
+	pgMgr.Unlock()
+
+	klog.V(3).InfoS("Backoff pod group", "podGroup", klog.KObj(pg), "failures", failures, "backoff", backoff)
+	pgMgr.conditions.Patch(pg, metav1.Condition{
+		Type:   v1alpha1.PodGroupBackedOff,
+		Status: metav1.ConditionTrue,
+		Reason: v1alpha1.PodGroupUnschedulableReason,
+		Message: fmt.Sprintf("backed off until %v after %v consecutive scheduling failures, last failure: %v",
+			until.UTC().Format(time.RFC3339), failures, reason),
+	})
+}
+
+// resetBackoff forgets the scheduling failures of the given pg, which reached its quorum,
+// and clears the PodGroupBackedOff condition of the pg, if set.
+func (pgMgr *PodGroupManager) resetBackoff(pg *v1alpha1.PodGroup) {
+	pgFullName := GetNamespacedName(pg)
+	pgMgr.Lock()
+	_, ok := pgMgr.getBackoff(pgFullName)
+	pgMgr.backoffs.Delete(pgFullName)
+	pgMgr.Unlock()
+	// The condition may be left over by a previous scheduler instance.
+	if !ok && !meta.IsStatusConditionTrue(pg.Status.Conditions, v1alpha1.PodGroupBackedOff) {
+		return
+	}
+	pgMgr.conditions.Patch(pg, metav1.Condition{
+		Type:    v1alpha1.PodGroupBackedOff,
+		Status:  metav1.ConditionFalse,
+		Reason:  v1alpha1.PodGroupScheduledReason,
+		Message: "the pod group reached its quorum",
+	})
+}
+
+// getBackoff returns the consecutive scheduling failures of the given pg, if any.
+func (pgMgr *PodGroupManager) getBackoff(pgFullName string) (*podGroupBackoff, bool) {
+	obj, ok := pgMgr.backoffs.Get(pgFullName)
+	if !ok {
+		return nil, false
+	}
+	return obj.(*podGroupBackoff), true
+}
+
+// getBackoffDuration returns the backoff of a podgroup after the given number of consecutive failures.
+func getBackoffDuration(failures int32, initialBackoff, maxBackoff time.Duration) time.Duration {
+	backoff := initialBackoff
+	for i := int32(1); i < failures; i++ {
+		backoff *= 2
+		if backoff >= maxBackoff {
+			return max(maxBackoff, initialBackoff)
+		}
+	}
+	return backoff
 }
 
 // ActivateSiblings stashes the pods belonging to the same PodGroup of the given pod
@@ -165,7 +257,11 @@ func (pgMgr *PodGroupManager) PreFilter(ctx context.Context, pod *corev1.Pod) er
 		return nil
 	}
 
-	if _, exist := pgMgr.backedOffPG.Get(pgFullName); exist {
+	pgMgr.RLock()
+	b, backedOff := pgMgr.getBackoff(pgFullName)
+	backedOff = backedOff && time.Now().Before(b.until)
+	pgMgr.RUnlock()
+	if backedOff {
 		return fmt.Errorf("podGroup %v failed recently", pgFullName)
 	}
 
@@ -181,13 +277,24 @@ func (pgMgr *PodGroupManager) PreFilter(ctx context.Context, pod *corev1.Pod) er
 			"current pods number: %v, minMember of group: %v", pod.Name, len(pods), pg.Spec.MinMember)
 	}
 
-	if pg.Spec.MinResources == nil {
+	roleMembers := countRoleMembers(pg, pods)
+	for _, role := range pg.Spec.Roles {
+		if roleMembers[role.Name] < role.MinMember {
+			return fmt.Errorf("pre-filter pod %v cannot find enough sibling pods of role %v, "+
+				"current pods number: %v, minMember of role: %v", pod.Name, role.Name, roleMembers[role.Name], role.MinMember)
+		}
+	}
+
+	minResources := getMinResources(pg)
+	simulatePlacement := pgMgr.preFilterMode == config.PreFilterSimulatePlacement
+	if minResources == nil && !simulatePlacement {
 		return nil
 	}
 
 	// TODO(cwdsuzhou): This resource check may not always pre-catch unschedulable pod group.
 	// It only tries to PreFilter resource constraints so even if a PodGroup passed here,
-	// it may not necessarily pass Filter due to other constraints such as affinity/taints.
+	// it may not necessarily pass Filter due to other constraints such as affinity/taints,
+	// unless the placement of the pending members is simulated too.
 	if _, ok := pgMgr.permittedPG.Get(pgFullName); ok {
 		return nil
 	}
@@ -197,13 +304,21 @@ func (pgMgr *PodGroupManager) PreFilter(ctx context.Context, pod *corev1.Pod) er
 		return err
 	}
 
-	minResources := pg.Spec.MinResources.DeepCopy()
-	podQuantity := resource.NewQuantity(int64(pg.Spec.MinMember), resource.DecimalSI)
-	minResources[corev1.ResourcePods] = *podQuantity
-	err = CheckClusterResource(ctx, nodes, minResources, pgFullName)
-	if err != nil {
-		klog.ErrorS(err, "Failed to PreFilter", "podGroup", klog.KObj(pg))
-		return err
+	if minResources != nil {
+		podQuantity := resource.NewQuantity(int64(getMinMember(pg)), resource.DecimalSI)
+		minResources[corev1.ResourcePods] = *podQuantity
+		err = CheckClusterResource(ctx, nodes, minResources, pgFullName)
+		if err != nil {
+			klog.ErrorS(err, "Failed to PreFilter", "podGroup", klog.KObj(pg))
+			return err
+		}
+	}
+	if simulatePlacement {
+		err = pgMgr.checkPlacement(pg, pods, nodes)
+		if err != nil {
+			klog.ErrorS(err, "Failed to PreFilter", "podGroup", klog.KObj(pg))
+			return err
+		}
 	}
 	pgMgr.permittedPG.Add(pgFullName, pgFullName, *pgMgr.scheduleTimeout)
 	return nil
@@ -220,10 +335,12 @@ func (pgMgr *PodGroupManager) Permit(ctx context.Context, state *framework.Cycle
 		return PodGroupNotFound
 	}
 
-	assigned := pgMgr.CalculateAssignedPods(pg.Name, pg.Namespace)
+	assignedPods := pgMgr.getAssignedPods(pg.Name, pg.Namespace)
+	assigned := len(assignedPods)
 	// The number of pods that have been assigned nodes is calculated from the snapshot.
 	// The current pod in not included in the snapshot during the current scheduling cycle.
-	if int32(assigned)+1 >= pg.Spec.MinMember {
+	if quorumReached(pg, append(assignedPods, pod)) {
+		pgMgr.resetBackoff(pg)
 		return Success
 	}
 
@@ -275,22 +392,185 @@ func (pgMgr *PodGroupManager) GetPodGroup(ctx context.Context, pod *corev1.Pod)
 
 // CalculateAssignedPods returns the number of pods that has been assigned nodes: assumed or bound.
 func (pgMgr *PodGroupManager) CalculateAssignedPods(podGroupName, namespace string) int {
+	return len(pgMgr.getAssignedPods(podGroupName, namespace))
+}
+
+// QuorumReached tells if the pods of the given pg that has been assigned nodes reach the minMember
+// of the pg and of every its role.
+func (pgMgr *PodGroupManager) QuorumReached(pg *v1alpha1.PodGroup) bool {
+	return quorumReached(pg, pgMgr.getAssignedPods(pg.Name, pg.Namespace))
+}
+
+// getAssignedPods returns the pods that has been assigned nodes: assumed or bound.
+func (pgMgr *PodGroupManager) getAssignedPods(podGroupName, namespace string) []*corev1.Pod {
 	nodeInfos, err := pgMgr.snapshotSharedLister.NodeInfos().List()
 	if err != nil {
 		klog.ErrorS(err, "Cannot get nodeInfos from frameworkHandle")
-		return 0
+		return nil
 	}
-	var count int
+	var pods []*corev1.Pod
 	for _, nodeInfo := range nodeInfos {
 		for _, podInfo := range nodeInfo.Pods {
 			pod := podInfo.Pod
 			if util.GetPodGroupLabel(pod) == podGroupName && pod.Namespace == namespace && pod.Spec.NodeName != "" {
-				count++
+				pods = append(pods, pod)
+			}
+		}
+	}
+
+	return pods
+}
+
+// quorumReached tells if the given pods reach the minMember of the given pg and of every its role.
+func quorumReached(pg *v1alpha1.PodGroup, pods []*corev1.Pod) bool {
+	if int32(len(pods)) < pg.Spec.MinMember {
+		return false
+	}
+	roleMembers := countRoleMembers(pg, pods)
+	for _, role := range pg.Spec.Roles {
+		if roleMembers[role.Name] < role.MinMember {
+			klog.V(4).InfoS("Not enough pods of role", "podGroup", klog.KObj(pg), "role", role.Name, "pods", roleMembers[role.Name], "minMember", role.MinMember)
+			return false
+		}
+	}
+	return true
+}
+
+// countRoleMembers returns the number of the given pods playing each role of the given pg.
+func countRoleMembers(pg *v1alpha1.PodGroup, pods []*corev1.Pod) map[string]int32 {
+	roleMembers := make(map[string]int32, len(pg.Spec.Roles))
+	if len(pg.Spec.Roles) == 0 {
+		return roleMembers
+	}
+	for _, pod := range pods {
+		if role := util.GetPodGroupRole(pg, pod); role != "" {
+			roleMembers[role]++
+		}
+	}
+	return roleMembers
+}
+
+// getMinMember returns the minimal number of members to run the given pg:
+// the larger between its minMember and the sum of the minMember of its roles.
+func getMinMember(pg *v1alpha1.PodGroup) int32 {
+	var minMember int32
+	for _, role := range pg.Spec.Roles {
+		minMember += role.MinMember
+	}
+	if pg.Spec.MinMember > minMember {
+		return pg.Spec.MinMember
+	}
+	return minMember
+}
+
+// getMinResources returns the minimal resources to run the given pg: for each resource, the larger
+// between the minResources of the pg and the sum of the minResources of its roles.
+// It returns nil if neither the pg nor its roles set minResources.
+func getMinResources(pg *v1alpha1.PodGroup) corev1.ResourceList {
+	var minResources corev1.ResourceList
+	for _, role := range pg.Spec.Roles {
+		if role.MinResources == nil {
+			continue
+		}
+		if minResources == nil {
+			minResources = make(corev1.ResourceList)
+		}
+		for name, quant := range role.MinResources {
+			sum := minResources[name]
+			sum.Add(quant)
+			minResources[name] = sum
+		}
+	}
+	if minResources == nil {
+		return pg.Spec.MinResources.DeepCopy()
+	}
+	for name, quant := range pg.Spec.MinResources {
+		if sum, ok := minResources[name]; !ok || quant.Cmp(sum) > 0 {
+			minResources[name] = quant.DeepCopy()
+		}
+	}
+	return minResources
+}
+
+// GetTopologyDomain returns the topology domain chosen for the given PodGroup, if any.
+// If no domain was chosen yet but some members are already assigned, e.g. after a scheduler
+// restart, the domain is recovered from the node of an assigned member. A chosen domain is
+// forgotten once no member is assigned anymore, including the assumed ones waiting on Permit,
+// e.g. when the PodGroup was deleted and created again.
+func (pgMgr *PodGroupManager) GetTopologyDomain(pg *v1alpha1.PodGroup) (string, bool) {
+	if pg.Spec.TopologyConstraint == nil {
+		return "", false
+	}
+	pgFullName := GetNamespacedName(pg)
+	pgMgr.RLock()
+	domain, ok := pgMgr.topologyDomains[pgFullName]
+	pgMgr.RUnlock()
+	if ok {
+		if len(pgMgr.getAssignedPods(pg.Name, pg.Namespace)) > 0 {
+			return domain, true
+		}
+		klog.V(4).InfoS("Forgot stale topology domain", "podGroup", klog.KObj(pg), "domain", domain)
+		pgMgr.ReleaseTopologyDomain(pgFullName)
+		return "", false
+	}
+
+	nodeInfos, err := pgMgr.snapshotSharedLister.NodeInfos().List()
+	if err != nil {
+		klog.ErrorS(err, "Cannot get nodeInfos from frameworkHandle")
+		return "", false
+	}
+	topologyKey := pg.Spec.TopologyConstraint.TopologyKey
+	for _, nodeInfo := range nodeInfos {
+		if nodeInfo.Node() == nil {
+			continue
+		}
+		domain, ok := nodeInfo.Node().Labels[topologyKey]
+		if !ok {
+			continue
+		}
+		for _, podInfo := range nodeInfo.Pods {
+			pod := podInfo.Pod
+			if util.GetPodGroupLabel(pod) == pg.Name && pod.Namespace == pg.Namespace && pod.Spec.NodeName != "" {
+				klog.V(4).InfoS("Recovered topology domain from assigned pod", "podGroup", klog.KObj(pg), "pod", klog.KObj(pod), "domain", domain)
+				pgMgr.ReserveTopologyDomain(pg, nodeInfo.Node().Name)
+				return domain, true
 			}
 		}
 	}
+	return "", false
+}
+
+// ReserveTopologyDomain chooses the topology domain of the given node for the PodGroup,
+// unless a domain was already chosen. Nodes lacking the topology key label are ignored.
+func (pgMgr *PodGroupManager) ReserveTopologyDomain(pg *v1alpha1.PodGroup, nodeName string) {
+	if pg.Spec.TopologyConstraint == nil {
+		return
+	}
+	nodeInfo, err := pgMgr.snapshotSharedLister.NodeInfos().Get(nodeName)
+	if err != nil || nodeInfo.Node() == nil {
+		klog.ErrorS(err, "Cannot get nodeInfo from frameworkHandle", "node", nodeName)
+		return
+	}
+	domain, ok := nodeInfo.Node().Labels[pg.Spec.TopologyConstraint.TopologyKey]
+	if !ok {
+		return
+	}
+	pgFullName := GetNamespacedName(pg)
+	pgMgr.Lock()
+	defer pgMgr.Unlock()
+	if _, ok := pgMgr.topologyDomains[pgFullName]; ok {
+		return
+	}
+	klog.V(3).InfoS("Chose topology domain", "podGroup", klog.KObj(pg), "node", nodeName, "domain", domain)
+	pgMgr.topologyDomains[pgFullName] = domain
+}
 
-	return count
+// ReleaseTopologyDomain forgets the topology domain chosen for the given PodGroup,
+// so that a new one can be chosen on the next scheduling attempt.
+func (pgMgr *PodGroupManager) ReleaseTopologyDomain(pgFullName string) {
+	pgMgr.Lock()
+	defer pgMgr.Unlock()
+	delete(pgMgr.topologyDomains, pgFullName)
 }
 
 // CheckClusterResource checks if resource capacity of the cluster can satisfy <resourceRequest>.
@@ -18,18 +18,29 @@ package core
 
 import (
 	"context"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
 	gocache "github.com/patrickmn/go-cache"
 	corev1 "k8s.io/api/core/v1"
+	policy "k8s.io/api/policy/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
 	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/client-go/informers"
 	clientsetfake "k8s.io/client-go/kubernetes/fake"
 	clicache "k8s.io/client-go/tools/cache"
 	"k8s.io/kubernetes/pkg/scheduler/framework"
 	st "k8s.io/kubernetes/pkg/scheduler/testing"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+	"sigs.k8s.io/scheduler-plugins/apis/config"
 	"sigs.k8s.io/scheduler-plugins/apis/scheduling/v1alpha1"
 	tu "sigs.k8s.io/scheduler-plugins/test/util"
 )
@@ -138,6 +149,51 @@ func TestPreFilter(t *testing.T) {
 			},
 			expectedSuccess: false,
 		},
+		{
+			name: "pod count of a role less than its minMember",
+			pod:  st.MakePod().Name("p1a").Namespace("ns").UID("p1a").Label(v1alpha1.PodGroupLabel, "pg1").Label("role", "worker").Obj(),
+			pendingPods: []*corev1.Pod{
+				st.MakePod().Name("p1b").Namespace("ns").UID("p1b").Label(v1alpha1.PodGroupLabel, "pg1").Label("role", "worker").Obj(),
+				st.MakePod().Name("p1c").Namespace("ns").UID("p1c").Label(v1alpha1.PodGroupLabel, "pg1").Label("role", "worker").Obj(),
+			},
+			pgs: []*v1alpha1.PodGroup{
+				tu.MakePodGroup().Name("pg1").Namespace("ns").MinMember(2).
+					Role("ps", map[string]string{"role": "ps"}, 1, nil).
+					Role("worker", map[string]string{"role": "worker"}, 2, nil).Obj(),
+			},
+			expectedSuccess: false,
+		},
+		{
+			name: "pod count of every role equal its minMember",
+			pod:  st.MakePod().Name("p1a").Namespace("ns").UID("p1a").Label(v1alpha1.PodGroupLabel, "pg1").Label("role", "worker").Obj(),
+			pendingPods: []*corev1.Pod{
+				st.MakePod().Name("p1b").Namespace("ns").UID("p1b").Label(v1alpha1.PodGroupLabel, "pg1").Label("role", "ps").Obj(),
+				st.MakePod().Name("p1c").Namespace("ns").UID("p1c").Label(v1alpha1.PodGroupLabel, "pg1").Label("role", "worker").Obj(),
+				st.MakePod().Name("p1d").Namespace("ns").UID("p1d").Label(v1alpha1.PodGroupLabel, "pg1").Label("role", "worker").Obj(),
+			},
+			pgs: []*v1alpha1.PodGroup{
+				tu.MakePodGroup().Name("pg1").Namespace("ns").MinMember(2).
+					Role("ps", map[string]string{"role": "ps"}, 1, map[corev1.ResourceName]string{corev1.ResourceCPU: "2"}).
+					Role("worker", map[string]string{"role": "worker"}, 2, map[corev1.ResourceName]string{corev1.ResourceCPU: "4"}).Obj(),
+			},
+			expectedSuccess: true,
+		},
+		{
+			// Previously we defined 2 nodes, each with 4 cpus. Now the roles' minResources req is 10 cpus in total.
+			name: "cluster's resource cannot satisfy the minResources of the roles",
+			pod:  st.MakePod().Name("p1a").Namespace("ns").UID("p1a").Label(v1alpha1.PodGroupLabel, "pg1").Label("role", "worker").Obj(),
+			pendingPods: []*corev1.Pod{
+				st.MakePod().Name("p1b").Namespace("ns").UID("p1b").Label(v1alpha1.PodGroupLabel, "pg1").Label("role", "ps").Obj(),
+				st.MakePod().Name("p1c").Namespace("ns").UID("p1c").Label(v1alpha1.PodGroupLabel, "pg1").Label("role", "worker").Obj(),
+			},
+			pgs: []*v1alpha1.PodGroup{
+				tu.MakePodGroup().Name("pg1").Namespace("ns").MinMember(2).
+					MinResources(map[corev1.ResourceName]string{corev1.ResourceCPU: "6"}).
+					Role("ps", map[string]string{"role": "ps"}, 1, map[corev1.ResourceName]string{corev1.ResourceCPU: "4"}).
+					Role("worker", map[string]string{"role": "worker"}, 1, map[corev1.ResourceName]string{corev1.ResourceCPU: "6"}).Obj(),
+			},
+			expectedSuccess: false,
+		},
 	}
 
 	for _, tt := range tests {
@@ -169,7 +225,7 @@ func TestPreFilter(t *testing.T) {
 				podLister:            podInformer.Lister(),
 				scheduleTimeout:      &scheduleTimeout,
 				permittedPG:          newCache(),
-				backedOffPG:          newCache(),
+				backoffs:             newCache(),
 			}
 
 			informerFactory.Start(ctx.Done())
@@ -239,6 +295,32 @@ func TestPermit(t *testing.T) {
 			},
 			want: Success,
 		},
+		{
+			name: "pod belongs to a pg that have quorum satisfied, but not for every role",
+			pod:  st.MakePod().Name("p1a").Namespace("ns").UID("p1a").Label(v1alpha1.PodGroupLabel, "pg1").Label("role", "worker").Obj(),
+			existingPods: []*corev1.Pod{
+				st.MakePod().Name("p1b").Namespace("ns").UID("p1b").Label(v1alpha1.PodGroupLabel, "pg1").Label("role", "worker").Node("node").Obj(),
+			},
+			pgs: []*v1alpha1.PodGroup{
+				tu.MakePodGroup().Name("pg1").Namespace("ns").MinMember(2).
+					Role("ps", map[string]string{"role": "ps"}, 1, nil).
+					Role("worker", map[string]string{"role": "worker"}, 1, nil).Obj(),
+			},
+			want: Wait,
+		},
+		{
+			name: "pod belongs to a pg that have quorum satisfied for every role",
+			pod:  st.MakePod().Name("p1a").Namespace("ns").UID("p1a").Label(v1alpha1.PodGroupLabel, "pg1").Label("role", "ps").Obj(),
+			existingPods: []*corev1.Pod{
+				st.MakePod().Name("p1b").Namespace("ns").UID("p1b").Label(v1alpha1.PodGroupLabel, "pg1").Label("role", "worker").Node("node").Obj(),
+			},
+			pgs: []*v1alpha1.PodGroup{
+				tu.MakePodGroup().Name("pg1").Namespace("ns").MinMember(2).
+					Role("ps", map[string]string{"role": "ps"}, 1, nil).
+					Role("worker", map[string]string{"role": "worker"}, 1, nil).Obj(),
+			},
+			want: Success,
+		},
 	}
 
 	for _, tt := range tests {
@@ -269,6 +351,7 @@ func TestPermit(t *testing.T) {
 				snapshotSharedLister: tu.NewFakeSharedLister(tt.existingPods, nodes),
 				podLister:            podInformer.Lister(),
 				scheduleTimeout:      &scheduleTimeout,
+				backoffs:             newCache(),
 			}
 
 			informerFactory.Start(ctx.Done())
@@ -286,6 +369,613 @@ func TestPermit(t *testing.T) {
 	}
 }
 
+func TestPreFilterSimulatePlacement(t *testing.T) {
+	scheduleTimeout := 10 * time.Second
+	capacity := map[corev1.ResourceName]string{
+		corev1.ResourceCPU:  "4",
+		corev1.ResourcePods: "10",
+	}
+	nodes := []*corev1.Node{
+		st.MakeNode().Name("node-a").Capacity(capacity).Label("zone", "zone-1").Label("disk", "ssd").Obj(),
+		st.MakeNode().Name("node-b").Capacity(capacity).Label("zone", "zone-2").Obj(),
+		st.MakeNode().Name("node-c").Capacity(capacity).Label("zone", "zone-3").
+			Taints([]corev1.Taint{{Key: "dedicated", Effect: corev1.TaintEffectNoSchedule}}).Obj(),
+	}
+	makePod := func(name string, cpu string) *st.PodWrapper {
+		return st.MakePod().Name(name).Namespace("ns").UID(name).Label(v1alpha1.PodGroupLabel, "pg1").
+			Req(map[corev1.ResourceName]string{corev1.ResourceCPU: cpu})
+	}
+
+	tests := []struct {
+		name            string
+		preFilterMode   config.CoschedulingPreFilterMode
+		pendingPods     []*corev1.Pod
+		assignedPods    []*corev1.Pod
+		pg              *v1alpha1.PodGroup
+		expectedSuccess bool
+	}{
+		{
+			name:          "fragmented resources pass the minResources check",
+			preFilterMode: config.PreFilterMinResources,
+			pendingPods:   []*corev1.Pod{makePod("p1", "3").Obj(), makePod("p2", "3").Obj(), makePod("p3", "3").Obj()},
+			pg: tu.MakePodGroup().Name("pg1").Namespace("ns").MinMember(3).
+				MinResources(map[corev1.ResourceName]string{corev1.ResourceCPU: "9"}).Obj(),
+			expectedSuccess: true,
+		},
+		{
+			name:            "pods fit the nodes",
+			preFilterMode:   config.PreFilterSimulatePlacement,
+			pendingPods:     []*corev1.Pod{makePod("p1", "2").Obj(), makePod("p2", "2").Obj(), makePod("p3", "2").Obj()},
+			pg:              tu.MakePodGroup().Name("pg1").Namespace("ns").MinMember(3).Obj(),
+			expectedSuccess: true,
+		},
+		{
+			name:          "fragmented resources and taints",
+			preFilterMode: config.PreFilterSimulatePlacement,
+			pendingPods:   []*corev1.Pod{makePod("p1", "3").Obj(), makePod("p2", "3").Obj(), makePod("p3", "3").Obj()},
+			pg: tu.MakePodGroup().Name("pg1").Namespace("ns").MinMember(3).
+				MinResources(map[corev1.ResourceName]string{corev1.ResourceCPU: "9"}).Obj(),
+			expectedSuccess: false,
+		},
+		{
+			name:          "pods tolerating the taints",
+			preFilterMode: config.PreFilterSimulatePlacement,
+			pendingPods: []*corev1.Pod{
+				makePod("p1", "3").Toleration("dedicated").Obj(),
+				makePod("p2", "3").Toleration("dedicated").Obj(),
+				makePod("p3", "3").Toleration("dedicated").Obj(),
+			},
+			pg:              tu.MakePodGroup().Name("pg1").Namespace("ns").MinMember(3).Obj(),
+			expectedSuccess: true,
+		},
+		{
+			name:          "pods selecting a single node",
+			preFilterMode: config.PreFilterSimulatePlacement,
+			pendingPods: []*corev1.Pod{
+				makePod("p1", "2").NodeSelector(map[string]string{"disk": "ssd"}).Obj(),
+				makePod("p2", "2").NodeSelector(map[string]string{"disk": "ssd"}).Obj(),
+				makePod("p3", "2").NodeSelector(map[string]string{"disk": "ssd"}).Obj(),
+			},
+			pg:              tu.MakePodGroup().Name("pg1").Namespace("ns").MinMember(3).Obj(),
+			expectedSuccess: false,
+		},
+		{
+			name:            "pods fit the nodes along with the assigned ones",
+			preFilterMode:   config.PreFilterSimulatePlacement,
+			pendingPods:     []*corev1.Pod{makePod("p1", "2").Obj(), makePod("p2", "2").Obj()},
+			assignedPods:    []*corev1.Pod{makePod("p0", "2").Node("node-a").Obj()},
+			pg:              tu.MakePodGroup().Name("pg1").Namespace("ns").MinMember(3).Obj(),
+			expectedSuccess: true,
+		},
+		{
+			name:          "pods of a role do not fit",
+			preFilterMode: config.PreFilterSimulatePlacement,
+			pendingPods: []*corev1.Pod{
+				makePod("p1", "5").Label("role", "ps").Obj(),
+				makePod("p2", "1").Label("role", "worker").Obj(),
+				makePod("p3", "1").Label("role", "worker").Obj(),
+			},
+			pg: tu.MakePodGroup().Name("pg1").Namespace("ns").MinMember(2).
+				Role("ps", map[string]string{"role": "ps"}, 1, nil).
+				Role("worker", map[string]string{"role": "worker"}, 1, nil).Obj(),
+			expectedSuccess: false,
+		},
+		{
+			name:            "pods do not fit a single topology domain",
+			preFilterMode:   config.PreFilterSimulatePlacement,
+			pendingPods:     []*corev1.Pod{makePod("p1", "3").Obj(), makePod("p2", "3").Obj()},
+			pg:              tu.MakePodGroup().Name("pg1").Namespace("ns").MinMember(2).TopologyConstraint("zone", v1alpha1.TopologyModeRequired).Obj(),
+			expectedSuccess: false,
+		},
+		{
+			name:            "pods fit a single topology domain",
+			preFilterMode:   config.PreFilterSimulatePlacement,
+			pendingPods:     []*corev1.Pod{makePod("p1", "2").Obj(), makePod("p2", "2").Obj()},
+			pg:              tu.MakePodGroup().Name("pg1").Namespace("ns").MinMember(2).TopologyConstraint("zone", v1alpha1.TopologyModeRequired).Obj(),
+			expectedSuccess: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			client, err := tu.NewFakeClient(tt.pg)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			cs := clientsetfake.NewSimpleClientset()
+			informerFactory := informers.NewSharedInformerFactory(cs, 0)
+			podInformer := informerFactory.Core().V1().Pods()
+
+			pgMgr := NewPodGroupManager(client, tu.NewFakeSharedLister(tt.assignedPods, nodes), &scheduleTimeout, podInformer, tt.preFilterMode)
+
+			informerFactory.Start(ctx.Done())
+			if !clicache.WaitForCacheSync(ctx.Done(), podInformer.Informer().HasSynced) {
+				t.Fatal("WaitForCacheSync failed")
+			}
+			for _, p := range append(tt.pendingPods, tt.assignedPods...) {
+				podInformer.Informer().GetStore().Add(p)
+			}
+
+			err = pgMgr.PreFilter(ctx, tt.pendingPods[0])
+			if (err == nil) != tt.expectedSuccess {
+				t.Errorf("Want %v, but got %v", tt.expectedSuccess, err)
+			}
+		})
+	}
+}
+
+func TestSelectVictims(t *testing.T) {
+	scheduleTimeout := 10 * time.Second
+	capacity := map[corev1.ResourceName]string{
+		corev1.ResourceCPU:  "4",
+		corev1.ResourcePods: "10",
+	}
+	nodes := []*corev1.Node{
+		st.MakeNode().Name("node-a").Capacity(capacity).Obj(),
+		st.MakeNode().Name("node-b").Capacity(capacity).Obj(),
+		st.MakeNode().Name("node-c").Capacity(capacity).Unschedulable(true).Obj(),
+	}
+	makeMember := func(name string) *corev1.Pod {
+		return st.MakePod().Name(name).Namespace("ns").UID(name).Label(v1alpha1.PodGroupLabel, "pg1").Priority(100).
+			Req(map[corev1.ResourceName]string{corev1.ResourceCPU: "3"}).Obj()
+	}
+	makePod := func(name string, priority int32, cpu string) *st.PodWrapper {
+		return st.MakePod().Name(name).Namespace("ns").UID(name).Label("app", name).Priority(priority).
+			Req(map[corev1.ResourceName]string{corev1.ResourceCPU: cpu})
+	}
+	makePDB := func(app string, allowed int32) *policy.PodDisruptionBudget {
+		return &policy.PodDisruptionBudget{
+			ObjectMeta: metav1.ObjectMeta{Name: "pdb-" + app, Namespace: "ns"},
+			Spec: policy.PodDisruptionBudgetSpec{
+				Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": app}},
+			},
+			Status: policy.PodDisruptionBudgetStatus{DisruptionsAllowed: allowed},
+		}
+	}
+	pg1 := tu.MakePodGroup().Name("pg1").Namespace("ns").MinMember(2).Obj()
+
+	tests := []struct {
+		name            string
+		existingPods    []*corev1.Pod
+		pgs             []*v1alpha1.PodGroup
+		pdbs            []*policy.PodDisruptionBudget
+		expectedVictims []string
+		expectedNode    string
+		expectedSuccess bool
+	}{
+		{
+			name: "no lower priority pods",
+			existingPods: []*corev1.Pod{
+				makePod("v1", 100, "1").Node("node-a").Obj(),
+				makePod("v2", 100, "3").Node("node-a").Obj(),
+			},
+			pgs:             []*v1alpha1.PodGroup{pg1},
+			expectedSuccess: false,
+		},
+		{
+			name: "lower priority pods not needed are reprieved",
+			existingPods: []*corev1.Pod{
+				makePod("v1", 10, "1").Node("node-a").Obj(),
+				makePod("v2", 20, "3").Node("node-a").Obj(),
+			},
+			pgs:             []*v1alpha1.PodGroup{pg1},
+			expectedVictims: []string{"v2"},
+			expectedNode:    "node-a",
+			expectedSuccess: true,
+		},
+		{
+			name: "preempt pods on many nodes",
+			existingPods: []*corev1.Pod{
+				makePod("v1", 10, "2").Node("node-a").Obj(),
+				makePod("v2", 20, "2").Node("node-a").Obj(),
+				makePod("v3", 30, "2").Node("node-b").Obj(),
+				makePod("v4", 100, "1").Node("node-b").Obj(),
+			},
+			pgs:             []*v1alpha1.PodGroup{pg1},
+			expectedVictims: []string{"v1", "v2", "v3"},
+			expectedSuccess: true,
+		},
+		{
+			name: "pods on nodes the members cannot run on are not preempted",
+			existingPods: []*corev1.Pod{
+				makePod("v1", 10, "4").Node("node-c").Obj(),
+				makePod("v2", 20, "2").Node("node-a").Obj(),
+				makePod("v3", 30, "2").Node("node-b").Obj(),
+			},
+			pgs:             []*v1alpha1.PodGroup{pg1},
+			expectedVictims: []string{"v2", "v3"},
+			expectedSuccess: true,
+		},
+		{
+			name: "pods fit without preemption",
+			existingPods: []*corev1.Pod{
+				makePod("v1", 10, "1").Node("node-a").Obj(),
+			},
+			pgs:             []*v1alpha1.PodGroup{pg1},
+			expectedSuccess: false,
+		},
+		{
+			name: "pods fit once the pods being deleted are gone",
+			existingPods: []*corev1.Pod{
+				makePod("v1", 10, "1").Node("node-a").Obj(),
+				makePod("v2", 20, "3").Node("node-a").Terminating().Obj(),
+			},
+			pgs:             []*v1alpha1.PodGroup{pg1},
+			expectedSuccess: false,
+		},
+		{
+			name: "preemption violating the PDB",
+			existingPods: []*corev1.Pod{
+				makePod("v1", 10, "1").Node("node-a").Obj(),
+				makePod("v2", 20, "3").Node("node-a").Obj(),
+			},
+			pgs:             []*v1alpha1.PodGroup{pg1},
+			pdbs:            []*policy.PodDisruptionBudget{makePDB("v2", 0)},
+			expectedSuccess: false,
+		},
+		{
+			name: "preemption allowed by the PDB",
+			existingPods: []*corev1.Pod{
+				makePod("v1", 10, "1").Node("node-a").Obj(),
+				makePod("v2", 20, "3").Node("node-a").Obj(),
+			},
+			pgs:             []*v1alpha1.PodGroup{pg1},
+			pdbs:            []*policy.PodDisruptionBudget{makePDB("v2", 1)},
+			expectedVictims: []string{"v2"},
+			expectedSuccess: true,
+		},
+		{
+			name: "preemption breaking the quorum of another pod group",
+			existingPods: []*corev1.Pod{
+				makePod("v1", 10, "1").Node("node-a").Obj(),
+				makePod("v2", 20, "3").Node("node-a").Label(v1alpha1.PodGroupLabel, "pg2").Obj(),
+			},
+			pgs:             []*v1alpha1.PodGroup{pg1, tu.MakePodGroup().Name("pg2").Namespace("ns").MinMember(1).Obj()},
+			expectedSuccess: false,
+		},
+		{
+			name: "preemption keeping the quorum of another pod group",
+			existingPods: []*corev1.Pod{
+				makePod("v1", 10, "1").Node("node-a").Obj(),
+				makePod("v2", 20, "3").Node("node-a").Label(v1alpha1.PodGroupLabel, "pg2").Obj(),
+				makePod("v3", 50, "0").Node("node-b").Label(v1alpha1.PodGroupLabel, "pg2").Obj(),
+			},
+			pgs:             []*v1alpha1.PodGroup{pg1, tu.MakePodGroup().Name("pg2").Namespace("ns").MinMember(1).Obj()},
+			expectedVictims: []string{"v2"},
+			expectedSuccess: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			var objs []runtime.Object
+			for _, pg := range tt.pgs {
+				objs = append(objs, pg)
+			}
+			client, err := tu.NewFakeClient(objs...)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			cs := clientsetfake.NewSimpleClientset()
+			informerFactory := informers.NewSharedInformerFactory(cs, 0)
+			podInformer := informerFactory.Core().V1().Pods()
+
+			pgMgr := NewPodGroupManager(client, tu.NewFakeSharedLister(tt.existingPods, nodes), &scheduleTimeout, podInformer, config.PreFilterMinResources)
+
+			informerFactory.Start(ctx.Done())
+			if !clicache.WaitForCacheSync(ctx.Done(), podInformer.Informer().HasSynced) {
+				t.Fatal("WaitForCacheSync failed")
+			}
+			pod := makeMember("p1")
+			// The pod being scheduled is a different object than the one in the lister.
+			for _, p := range append([]*corev1.Pod{pod.DeepCopy(), makeMember("p2")}, tt.existingPods...) {
+				podInformer.Informer().GetStore().Add(p)
+			}
+
+			victims, nodeName, err := pgMgr.SelectVictims(ctx, pod, pg1, tt.pdbs)
+			if (err == nil) != tt.expectedSuccess {
+				t.Fatalf("Want %v, but got %v", tt.expectedSuccess, err)
+			}
+			var got []string
+			for _, victim := range victims {
+				got = append(got, victim.Name)
+			}
+			sort.Strings(got)
+			if !reflect.DeepEqual(got, tt.expectedVictims) {
+				t.Errorf("Want victims %v, but got %v", tt.expectedVictims, got)
+			}
+			if tt.expectedSuccess && nodeName == "" {
+				t.Errorf("Want the pod to be nominated to a node")
+			}
+			if tt.expectedNode != "" && nodeName != tt.expectedNode {
+				t.Errorf("Want the pod to be nominated to %v, but got %v", tt.expectedNode, nodeName)
+			}
+		})
+	}
+}
+
+func TestGetBackoffDuration(t *testing.T) {
+	tests := []struct {
+		name           string
+		failures       int32
+		initialBackoff time.Duration
+		maxBackoff     time.Duration
+		want           time.Duration
+	}{
+		{
+			name:           "first failure",
+			failures:       1,
+			initialBackoff: 10 * time.Second,
+			maxBackoff:     time.Minute,
+			want:           10 * time.Second,
+		},
+		{
+			name:           "third failure",
+			failures:       3,
+			initialBackoff: 10 * time.Second,
+			maxBackoff:     time.Minute,
+			want:           40 * time.Second,
+		},
+		{
+			name:           "backoff capped",
+			failures:       4,
+			initialBackoff: 10 * time.Second,
+			maxBackoff:     time.Minute,
+			want:           time.Minute,
+		},
+		{
+			name:           "many failures",
+			failures:       100,
+			initialBackoff: 10 * time.Second,
+			maxBackoff:     time.Minute,
+			want:           time.Minute,
+		},
+		{
+			name:           "max backoff lower than the initial one",
+			failures:       2,
+			initialBackoff: 10 * time.Second,
+			want:           10 * time.Second,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := getBackoffDuration(tt.failures, tt.initialBackoff, tt.maxBackoff); got != tt.want {
+				t.Errorf("Want %v, but got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestBackoffPodGroup(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	scheduleTimeout := 10 * time.Second
+	nodes := []*corev1.Node{st.MakeNode().Name("node-a").Obj()}
+	pod := st.MakePod().Name("p1").Namespace("ns").UID("p1").Label(v1alpha1.PodGroupLabel, "pg1").Obj()
+	pg := tu.MakePodGroup().Name("pg1").Namespace("ns").MinMember(1).Obj()
+
+	scheme := runtime.NewScheme()
+	if err := v1alpha1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	client := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithStatusSubresource(&v1alpha1.PodGroup{}).
+		WithRuntimeObjects(pg).
+		Build()
+	cs := clientsetfake.NewSimpleClientset()
+	informerFactory := informers.NewSharedInformerFactory(cs, 0)
+	podInformer := informerFactory.Core().V1().Pods()
+	pgMgr := NewPodGroupManager(client, tu.NewFakeSharedLister(nil, nodes), &scheduleTimeout, podInformer, config.PreFilterMinResources)
+	informerFactory.Start(ctx.Done())
+	if !clicache.WaitForCacheSync(ctx.Done(), podInformer.Informer().HasSynced) {
+		t.Fatal("WaitForCacheSync failed")
+	}
+	podInformer.Informer().GetStore().Add(pod)
+
+	getCondition := func() *metav1.Condition {
+		pgMgr.conditions.wg.Wait()
+		_, pg := pgMgr.GetPodGroup(ctx, pod)
+		return meta.FindStatusCondition(pg.Status.Conditions, v1alpha1.PodGroupBackedOff)
+	}
+
+	for i, want := range []time.Duration{time.Second, 2 * time.Second, 3 * time.Second} {
+		_, pg := pgMgr.GetPodGroup(ctx, pod)
+		start := time.Now()
+		pgMgr.BackoffPodGroup(ctx, pg, time.Second, 3*time.Second, "pod p1 is unschedulable")
+		b, _ := pgMgr.getBackoff("ns/pg1")
+		if b.failures != int32(i+1) {
+			t.Errorf("Want %v failures, but got %v", i+1, b.failures)
+		}
+		if got := b.until.Sub(start); got < want || got > want+time.Second {
+			t.Errorf("Want backoff %v, but got %v", want, got)
+		}
+		cond := getCondition()
+		if cond == nil || cond.Status != metav1.ConditionTrue || cond.Reason != v1alpha1.PodGroupUnschedulableReason ||
+			!strings.Contains(cond.Message, b.until.UTC().Format(time.RFC3339)) || !strings.Contains(cond.Message, "pod p1 is unschedulable") {
+			t.Errorf("Unexpected condition %+v", cond)
+		}
+	}
+
+	if err := pgMgr.PreFilter(ctx, pod); err == nil || err.Error() != "podGroup ns/pg1 failed recently" {
+		t.Errorf("Want the pod group to be backed off, but got %v", err)
+	}
+
+	// The failures are not consecutive if the pod group does not fail again long after its backoff.
+	b, _ := pgMgr.getBackoff("ns/pg1")
+	b.until = time.Now().Add(-4 * time.Second)
+	if err := pgMgr.PreFilter(ctx, pod); err != nil {
+		t.Errorf("Want the pod group not to be backed off, but got %v", err)
+	}
+	_, pg = pgMgr.GetPodGroup(ctx, pod)
+	pgMgr.BackoffPodGroup(ctx, pg, time.Second, 3*time.Second, "pod p1 is unschedulable")
+	if b, _ := pgMgr.getBackoff("ns/pg1"); b.failures != 1 {
+		t.Errorf("Want 1 failure, but got %v", b.failures)
+	}
+	if _, expiration, _ := pgMgr.backoffs.GetWithExpiration("ns/pg1"); time.Until(expiration) > 4*time.Second {
+		t.Errorf("Want the failures to expire with the backoff, but they expire at %v", expiration)
+	}
+
+	if got := pgMgr.Permit(ctx, &framework.CycleState{}, pod); got != Success {
+		t.Errorf("Want %v, but got %v", Success, got)
+	}
+	if _, ok := pgMgr.getBackoff("ns/pg1"); ok {
+		t.Errorf("Want the failures to be reset")
+	}
+	if cond := getCondition(); cond == nil || cond.Status != metav1.ConditionFalse || cond.Reason != v1alpha1.PodGroupScheduledReason {
+		t.Errorf("Unexpected condition %+v", cond)
+	}
+}
+
+func TestConditionPatcher(t *testing.T) {
+	pg := tu.MakePodGroup().Name("pg1").Namespace("ns").MinMember(1).Obj()
+	scheme := runtime.NewScheme()
+	if err := v1alpha1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	var lock sync.Mutex
+	patches := 0
+	c := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithStatusSubresource(&v1alpha1.PodGroup{}).
+		WithRuntimeObjects(pg).
+		WithInterceptorFuncs(interceptor.Funcs{
+			SubResourcePatch: func(ctx context.Context, c client.Client, subResourceName string, obj client.Object, patch client.Patch, opts ...client.SubResourcePatchOption) error {
+				lock.Lock()
+				patches++
+				lock.Unlock()
+				return c.SubResource(subResourceName).Patch(ctx, obj, patch, opts...)
+			},
+		}).
+		Build()
+	cp := newConditionPatcher(c)
+
+	condition := metav1.Condition{
+		Type:    v1alpha1.PodGroupBackedOff,
+		Status:  metav1.ConditionFalse,
+		Reason:  v1alpha1.PodGroupScheduledReason,
+		Message: "the pod group reached its quorum",
+	}
+	// Every member passing Permit requests the same condition.
+	for i := 0; i < 10; i++ {
+		cp.Patch(pg, condition)
+	}
+	cp.wg.Wait()
+	cp.Patch(pg, condition)
+	cp.wg.Wait()
+
+	if patches != 1 {
+		t.Errorf("Want 1 patch, but got %v", patches)
+	}
+	got := &v1alpha1.PodGroup{}
+	if err := c.Get(context.Background(), client.ObjectKeyFromObject(pg), got); err != nil {
+		t.Fatal(err)
+	}
+	if !meta.IsStatusConditionFalse(got.Status.Conditions, v1alpha1.PodGroupBackedOff) {
+		t.Errorf("Unexpected conditions %+v", got.Status.Conditions)
+	}
+}
+
+func TestTopologyDomain(t *testing.T) {
+	nodes := []*corev1.Node{
+		st.MakeNode().Name("node-a").Label("zone", "zone-a").Obj(),
+		st.MakeNode().Name("node-b").Label("zone", "zone-b").Obj(),
+		st.MakeNode().Name("node-c").Obj(),
+	}
+	pg := tu.MakePodGroup().Name("pg1").Namespace("ns").MinMember(2).TopologyConstraint("zone", v1alpha1.TopologyModeRequired).Obj()
+	member := func(nodeName string) *corev1.Pod {
+		return st.MakePod().Name("m1").Namespace("ns").UID("m1").Label(v1alpha1.PodGroupLabel, "pg1").Node(nodeName).Obj()
+	}
+
+	tests := []struct {
+		name         string
+		pg           *v1alpha1.PodGroup
+		existingPods []*corev1.Pod
+		reserveNodes []string
+		release      bool
+		wantDomain   string
+		wantFound    bool
+	}{
+		{
+			name: "pg without topology constraint",
+			pg:   tu.MakePodGroup().Name("pg1").Namespace("ns").MinMember(2).Obj(),
+		},
+		{
+			name: "no domain chosen yet",
+			pg:   pg,
+		},
+		{
+			name:         "domain chosen by the first reserved node",
+			pg:           pg,
+			existingPods: []*corev1.Pod{member("node-a")},
+			reserveNodes: []string{"node-a", "node-b"},
+			wantDomain:   "zone-a",
+			wantFound:    true,
+		},
+		{
+			name:         "node lacking the topology key does not choose the domain",
+			pg:           pg,
+			existingPods: []*corev1.Pod{member("node-b")},
+			reserveNodes: []string{"node-c", "node-b"},
+			wantDomain:   "zone-b",
+			wantFound:    true,
+		},
+		{
+			name:         "released domain",
+			pg:           pg,
+			existingPods: []*corev1.Pod{member("node-a")},
+			reserveNodes: []string{"node-b"},
+			release:      true,
+			wantDomain:   "zone-a",
+			wantFound:    true,
+		},
+		{
+			name:         "domain without assigned members is forgotten",
+			pg:           pg,
+			reserveNodes: []string{"node-a"},
+		},
+		{
+			name: "domain recovered from an assigned pod",
+			pg:   pg,
+			existingPods: []*corev1.Pod{
+				st.MakePod().Name("p1").Namespace("ns").UID("p1").Label(v1alpha1.PodGroupLabel, "pg2").Node("node-a").Obj(),
+				st.MakePod().Name("p2").Namespace("ns").UID("p2").Label(v1alpha1.PodGroupLabel, "pg1").Node("node-b").Obj(),
+			},
+			release:    true,
+			wantDomain: "zone-b",
+			wantFound:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pgMgr := &PodGroupManager{
+				snapshotSharedLister: tu.NewFakeSharedLister(tt.existingPods, nodes),
+				topologyDomains:      make(map[string]string),
+			}
+			for _, nodeName := range tt.reserveNodes {
+				pgMgr.ReserveTopologyDomain(tt.pg, nodeName)
+			}
+			if tt.release {
+				pgMgr.ReleaseTopologyDomain("ns/pg1")
+			}
+			domain, found := pgMgr.GetTopologyDomain(tt.pg)
+			if domain != tt.wantDomain || found != tt.wantFound {
+				t.Errorf("Want domain %q found %v, but got %q found %v", tt.wantDomain, tt.wantFound, domain, found)
+			}
+		})
+	}
+}
+
 func TestCheckClusterResource(t *testing.T) {
 	capacity := map[corev1.ResourceName]string{
 		corev1.ResourceCPU: "3",
@@ -0,0 +1,186 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package core
+
+import (
+	"fmt"
+	"sort"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+	corev1helpers "k8s.io/component-helpers/scheduling/corev1"
+	"k8s.io/component-helpers/scheduling/corev1/nodeaffinity"
+	"k8s.io/klog/v2"
+	resourcehelper "k8s.io/kubernetes/pkg/api/v1/resource"
+	"k8s.io/kubernetes/pkg/scheduler/framework"
+	"k8s.io/kubernetes/pkg/scheduler/framework/plugins/noderesources"
+
+	"sigs.k8s.io/scheduler-plugins/apis/scheduling/v1alpha1"
+	"sigs.k8s.io/scheduler-plugins/pkg/util"
+)
+
+// checkPlacement simulates the placement of the pending members of the given pg on the given nodes,
+// and returns an error if the members that fit, together with the ones already assigned,
+// can't reach the minMember of the pg and of its roles.
+// The members are placed first-fit, in decreasing order of their requests, checking their node selectors,
+// required node affinity, taint tolerations and resource requests against the free resources of the nodes.
+// If the pg has a required topology constraint, the members are placed in a single topology domain.
+func (pgMgr *PodGroupManager) checkPlacement(pg *v1alpha1.PodGroup, pods []*corev1.Pod, nodeInfos []*framework.NodeInfo) error {
+	assignedPods := pgMgr.getAssignedPods(pg.Name, pg.Namespace)
+	pendingPods := pendingMembers(pods, assignedPods)
+	if _, ok := pgMgr.placeGroup(pg, pendingPods, assignedPods, nodeInfos); ok {
+		return nil
+	}
+	return fmt.Errorf("simulated placement of the %v pending pods cannot reach the quorum of the group, "+
+		"assigned pods: %v, minMember of group: %v", len(pendingPods), len(assignedPods), pg.Spec.MinMember)
+}
+
+// pendingMembers returns the given pods which are not assigned nor being deleted,
+// in decreasing order of their requests.
+func pendingMembers(pods, assignedPods []*corev1.Pod) []*corev1.Pod {
+	assignedUIDs := sets.New[types.UID]()
+	for _, pod := range assignedPods {
+		assignedUIDs.Insert(pod.UID)
+	}
+	var pendingPods []*corev1.Pod
+	for _, pod := range pods {
+		if pod.Spec.NodeName != "" || pod.DeletionTimestamp != nil || assignedUIDs.Has(pod.UID) {
+			continue
+		}
+		pendingPods = append(pendingPods, pod)
+	}
+	sortByRequests(pendingPods)
+	return pendingPods
+}
+
+// placeGroup simulates the placement of the given pending members of the pg on the given nodes, one topology
+// domain at a time, and returns the node of every placed member, by pod UID, for the first domain in which the placed
+// members, together with the assigned ones, reach the quorum of the pg. It returns false if there is no such domain.
+func (pgMgr *PodGroupManager) placeGroup(pg *v1alpha1.PodGroup, pendingPods, assignedPods []*corev1.Pod, nodeInfos []*framework.NodeInfo) (map[types.UID]string, bool) {
+	domains := placementDomains(pgMgr, pg, nodeInfos)
+	for _, domain := range sets.List(sets.KeySet(domains)) {
+		placement := simulatePlacement(domains[domain], pendingPods)
+		klog.V(4).InfoS("Simulated placement", "podGroup", klog.KObj(pg), "domain", domain, "assigned", len(assignedPods), "pending", len(pendingPods), "placed", len(placement))
+		members := make([]*corev1.Pod, 0, len(placement)+len(assignedPods))
+		for _, pod := range pendingPods {
+			if _, ok := placement[pod.UID]; ok {
+				members = append(members, pod)
+			}
+		}
+		if quorumReached(pg, append(members, assignedPods...)) {
+			return placement, true
+		}
+	}
+	return nil, false
+}
+
+// placementDomains returns the nodes in which the members of the given pg can be placed altogether,
+// indexed by their topology domain. All the nodes are in the same unnamed domain
+// unless the pg has a required topology constraint.
+func placementDomains(pgMgr *PodGroupManager, pg *v1alpha1.PodGroup, nodeInfos []*framework.NodeInfo) map[string][]*framework.NodeInfo {
+	if util.GetTopologyMode(pg) != v1alpha1.TopologyModeRequired {
+		return map[string][]*framework.NodeInfo{"": nodeInfos}
+	}
+	chosenDomain, chosen := pgMgr.GetTopologyDomain(pg)
+	domains := make(map[string][]*framework.NodeInfo)
+	for _, info := range nodeInfos {
+		if info == nil || info.Node() == nil {
+			continue
+		}
+		domain, ok := info.Node().Labels[pg.Spec.TopologyConstraint.TopologyKey]
+		if !ok || (chosen && domain != chosenDomain) {
+			continue
+		}
+		domains[domain] = append(domains[domain], info)
+	}
+	return domains
+}
+
+// simulatePlacement places the given pods first-fit on copies of the given nodes, in name order,
+// and returns the name of the node of every pod that fits, by pod UID.
+func simulatePlacement(nodeInfos []*framework.NodeInfo, pods []*corev1.Pod) map[types.UID]string {
+	nodes := make([]*framework.NodeInfo, 0, len(nodeInfos))
+	for _, info := range nodeInfos {
+		if info == nil || info.Node() == nil {
+			continue
+		}
+		nodes = append(nodes, info.Snapshot())
+	}
+	sort.Slice(nodes, func(i, j int) bool {
+		return nodes[i].Node().Name < nodes[j].Node().Name
+	})
+
+	placement := make(map[types.UID]string)
+	for _, pod := range pods {
+		for _, node := range nodes {
+			if !podFitsNode(pod, node) {
+				continue
+			}
+			node.AddPod(pod)
+			placement[pod.UID] = node.Node().Name
+			break
+		}
+	}
+	return placement
+}
+
+// podFitsNode tells if the given pod can run on the given node, considering the node unschedulable flag,
+// the pod node selector and required node affinity, the node taints and the free resources of the node.
+func podFitsNode(pod *corev1.Pod, nodeInfo *framework.NodeInfo) bool {
+	return podMatchesNode(pod, nodeInfo.Node()) && len(noderesources.Fits(pod, nodeInfo)) == 0
+}
+
+// podMatchesNode is like podFitsNode, but it ignores the resources.
+func podMatchesNode(pod *corev1.Pod, node *corev1.Node) bool {
+	unschedulableTaint := &corev1.Taint{Key: corev1.TaintNodeUnschedulable, Effect: corev1.TaintEffectNoSchedule}
+	if node.Spec.Unschedulable && !corev1helpers.TolerationsTolerateTaint(pod.Spec.Tolerations, unschedulableTaint) {
+		return false
+	}
+	if match, _ := nodeaffinity.GetRequiredNodeAffinity(pod).Match(node); !match {
+		return false
+	}
+	_, untolerated := corev1helpers.FindMatchingUntoleratedTaint(node.Spec.Taints, pod.Spec.Tolerations, func(t *corev1.Taint) bool {
+		return t.Effect == corev1.TaintEffectNoSchedule || t.Effect == corev1.TaintEffectNoExecute
+	})
+	return !untolerated
+}
+
+// sortByRequests sorts the given pods in decreasing order of their cpu requests, then of their memory requests.
+// The pods with the same requests are sorted by name, so the simulated placement doesn't depend on the listing order.
+func sortByRequests(pods []*corev1.Pod) {
+	type podRequests struct {
+		pod      *corev1.Pod
+		requests corev1.ResourceList
+	}
+	items := make([]podRequests, 0, len(pods))
+	for _, pod := range pods {
+		items = append(items, podRequests{pod: pod, requests: resourcehelper.PodRequests(pod, resourcehelper.PodResourcesOptions{})})
+	}
+	sort.SliceStable(items, func(i, j int) bool {
+		if cmp := items[i].requests.Cpu().Cmp(*items[j].requests.Cpu()); cmp != 0 {
+			return cmp > 0
+		}
+		if cmp := items[i].requests.Memory().Cmp(*items[j].requests.Memory()); cmp != 0 {
+			return cmp > 0
+		}
+		return items[i].pod.Name < items[j].pod.Name
+	})
+	for idx := range items {
+		pods[idx] = items[idx].pod
+	}
+}
@@ -0,0 +1,264 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package core
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	corev1 "k8s.io/api/core/v1"
+	policy "k8s.io/api/policy/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	corev1helpers "k8s.io/component-helpers/scheduling/corev1"
+	"k8s.io/klog/v2"
+	"k8s.io/kubernetes/pkg/scheduler/framework"
+	schedutil "k8s.io/kubernetes/pkg/scheduler/util"
+
+	"sigs.k8s.io/scheduler-plugins/apis/scheduling/v1alpha1"
+	"sigs.k8s.io/scheduler-plugins/pkg/util"
+)
+
+// potentialVictim is a pod which may be preempted, along with the copy of the node it runs on.
+type potentialVictim struct {
+	pod  *corev1.Pod
+	node *framework.NodeInfo
+}
+
+// SelectVictims returns the pods to preempt so that the pending members of the given pg, together with the
+// assigned ones, reach the quorum of the pg, and the name of the node the given pod is expected to run on.
+// Only the pods with a lower priority than the given pod are preempted, as long as their PDBs allow it and
+// their own PodGroups, if any, keep their quorum. Only the pods running on nodes matching at least a pending member
+// are considered. The least important pods are preempted first, looking for the fewest of them making room for
+// the pg, then the pods which turn out not to be needed are reprieved, starting from the most important ones.
+// An error is returned if no set of pods is enough to place the pg, or if no pod needs to be preempted,
+// in which case nothing should be preempted. The pods already being deleted are considered gone,
+// so the members of the pg do not preempt more pods while the victims chosen for a sibling are terminating.
+func (pgMgr *PodGroupManager) SelectVictims(ctx context.Context, pod *corev1.Pod, pg *v1alpha1.PodGroup, pdbs []*policy.PodDisruptionBudget) ([]*corev1.Pod, string, error) {
+	logger := klog.FromContext(ctx)
+	pods, err := pgMgr.podLister.Pods(pg.Namespace).List(
+		labels.SelectorFromSet(labels.Set{v1alpha1.PodGroupLabel: pg.Name}),
+	)
+	if err != nil {
+		return nil, "", fmt.Errorf("podLister list pods failed: %w", err)
+	}
+	nodeInfos, err := pgMgr.snapshotSharedLister.NodeInfos().List()
+	if err != nil {
+		return nil, "", err
+	}
+	assignedPods := pgMgr.getAssignedPods(pg.Name, pg.Namespace)
+	pendingPods := pendingMembers(pods, assignedPods)
+
+	priority := corev1helpers.PodPriority(pod)
+	nodes := make([]*framework.NodeInfo, 0, len(nodeInfos))
+	var candidates []potentialVictim
+	for _, info := range nodeInfos {
+		if info == nil || info.Node() == nil {
+			continue
+		}
+		node := info.Snapshot()
+		matching := matchesAnyPod(info.Node(), pendingPods)
+		for _, podInfo := range info.Pods {
+			p := podInfo.Pod
+			if corev1helpers.PodPriority(p) >= priority || (p.Namespace == pg.Namespace && util.GetPodGroupLabel(p) == pg.Name) {
+				continue
+			}
+			if p.DeletionTimestamp != nil {
+				if err := node.RemovePod(logger, p); err != nil {
+					return nil, "", err
+				}
+				continue
+			}
+			if matching {
+				candidates = append(candidates, potentialVictim{pod: p, node: node})
+			}
+		}
+		nodes = append(nodes, node)
+	}
+	if _, ok := pgMgr.placeGroup(pg, pendingPods, assignedPods, nodes); ok {
+		return nil, "", fmt.Errorf("the pending pods of the group fit without preempting more pods")
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return schedutil.MoreImportantPod(candidates[j].pod, candidates[i].pod)
+	})
+	budget := newDisruptionBudget(pgMgr, pdbs)
+	var allowed []potentialVictim
+	for _, candidate := range candidates {
+		if budget.allows(ctx, candidate.pod) {
+			allowed = append(allowed, candidate)
+		}
+	}
+
+	// Find the fewest least important pods to preempt, preempting the first `removed` allowed pods.
+	removed := 0
+	preemptFirst := func(count int) error {
+		for ; removed < count; removed++ {
+			if err := allowed[removed].node.RemovePod(logger, allowed[removed].pod); err != nil {
+				return err
+			}
+		}
+		for ; removed > count; removed-- {
+			allowed[removed-1].node.AddPod(allowed[removed-1].pod)
+		}
+		return nil
+	}
+	var searchErr error
+	count := sort.Search(len(allowed)+1, func(count int) bool {
+		if searchErr != nil {
+			return true
+		}
+		if searchErr = preemptFirst(count); searchErr != nil {
+			return true
+		}
+		_, placed := pgMgr.placeGroup(pg, pendingPods, assignedPods, nodes)
+		return placed
+	})
+	if searchErr != nil {
+		return nil, "", searchErr
+	}
+	if count > len(allowed) {
+		return nil, "", fmt.Errorf("preempting the lower priority pods cannot make room for the pending pods of the group, "+
+			"pending pods: %v, assigned pods: %v, minMember of group: %v", len(pendingPods), len(assignedPods), pg.Spec.MinMember)
+	}
+	if err := preemptFirst(count); err != nil {
+		return nil, "", err
+	}
+	victims := append([]potentialVictim(nil), allowed[:count]...)
+
+	for i := len(victims) - 1; i >= 0; i-- {
+		victims[i].node.AddPod(victims[i].pod)
+		if _, ok := pgMgr.placeGroup(pg, pendingPods, assignedPods, nodes); ok {
+			klog.V(5).InfoS("Reprieved pod", "podGroup", klog.KObj(pg), "pod", klog.KObj(victims[i].pod))
+			victims = append(victims[:i], victims[i+1:]...)
+			continue
+		}
+		if err := victims[i].node.RemovePod(logger, victims[i].pod); err != nil {
+			return nil, "", err
+		}
+	}
+
+	placement, _ := pgMgr.placeGroup(pg, pendingPods, assignedPods, nodes)
+	ret := make([]*corev1.Pod, 0, len(victims))
+	for _, victim := range victims {
+		ret = append(ret, victim.pod)
+	}
+	return ret, placement[pod.UID], nil
+}
+
+// matchesAnyPod tells if any of the given pods can run on the given node, ignoring the resources.
+func matchesAnyPod(node *corev1.Node, pods []*corev1.Pod) bool {
+	for _, pod := range pods {
+		if podMatchesNode(pod, node) {
+			return true
+		}
+	}
+	return false
+}
+
+// disruptionBudget tracks the pods which can still be preempted without violating
+// their PDBs or breaking the quorum of their own PodGroups.
+type disruptionBudget struct {
+	pgMgr       *PodGroupManager
+	pdbs        []*policy.PodDisruptionBudget
+	pdbsAllowed []int32
+	// podGroups caches the PodGroups of the pods, nil if not found.
+	podGroups map[string]*v1alpha1.PodGroup
+	// podGroupPods stores the assigned pods of the PodGroups which are not preempted yet.
+	podGroupPods map[string][]*corev1.Pod
+}
+
+func newDisruptionBudget(pgMgr *PodGroupManager, pdbs []*policy.PodDisruptionBudget) *disruptionBudget {
+	pdbsAllowed := make([]int32, len(pdbs))
+	for i, pdb := range pdbs {
+		pdbsAllowed[i] = pdb.Status.DisruptionsAllowed
+	}
+	return &disruptionBudget{
+		pgMgr:        pgMgr,
+		pdbs:         pdbs,
+		pdbsAllowed:  pdbsAllowed,
+		podGroups:    make(map[string]*v1alpha1.PodGroup),
+		podGroupPods: make(map[string][]*corev1.Pod),
+	}
+}
+
+// allows tells if the given pod can be preempted, and if so it takes the pod out of the budget.
+func (b *disruptionBudget) allows(ctx context.Context, pod *corev1.Pod) bool {
+	var pg *v1alpha1.PodGroup
+	pgFullName := util.GetPodGroupFullName(pod)
+	if pgFullName != "" {
+		var ok bool
+		if pg, ok = b.podGroups[pgFullName]; !ok {
+			_, pg = b.pgMgr.GetPodGroup(ctx, pod)
+			b.podGroups[pgFullName] = pg
+		}
+	}
+	var remainingPods []*corev1.Pod
+	if pg != nil {
+		pods, ok := b.podGroupPods[pgFullName]
+		if !ok {
+			for _, p := range b.pgMgr.getAssignedPods(pg.Name, pg.Namespace) {
+				if p.DeletionTimestamp == nil {
+					pods = append(pods, p)
+				}
+			}
+		}
+		for _, p := range pods {
+			if p.UID != pod.UID {
+				remainingPods = append(remainingPods, p)
+			}
+		}
+		if !quorumReached(pg, remainingPods) {
+			klog.V(5).InfoS("Preemption would break the quorum of the pod group", "pod", klog.KObj(pod), "podGroup", klog.KObj(pg))
+			return false
+		}
+	}
+
+	var matchingPDBs []int
+	for i, pdb := range b.pdbs {
+		if pdb.Namespace != pod.Namespace {
+			continue
+		}
+		selector, err := metav1.LabelSelectorAsSelector(pdb.Spec.Selector)
+		if err != nil {
+			continue
+		}
+		// A PDB with a nil or empty selector matches nothing.
+		if selector.Empty() || !selector.Matches(labels.Set(pod.Labels)) {
+			continue
+		}
+		// Existing in DisruptedPods means it has been processed in API server,
+		// we don't treat it as a violating case.
+		if _, exist := pdb.Status.DisruptedPods[pod.Name]; exist {
+			continue
+		}
+		if b.pdbsAllowed[i] <= 0 {
+			klog.V(5).InfoS("Preemption would violate the PDB", "pod", klog.KObj(pod), "pdb", klog.KObj(pdb))
+			return false
+		}
+		matchingPDBs = append(matchingPDBs, i)
+	}
+
+	for _, i := range matchingPDBs {
+		b.pdbsAllowed[i]--
+	}
+	if pg != nil {
+		b.podGroupPods[pgFullName] = remainingPods
+	}
+	return true
+}
@@ -24,11 +24,14 @@ import (
 	v1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	clientscheme "k8s.io/client-go/kubernetes/scheme"
+	policylisters "k8s.io/client-go/listers/policy/v1"
 	"k8s.io/client-go/tools/cache"
 	corev1helpers "k8s.io/component-helpers/scheduling/corev1"
 	"k8s.io/klog/v2"
 	"k8s.io/kubernetes/pkg/scheduler/framework"
+	ctrlruntimecache "sigs.k8s.io/controller-runtime/pkg/cache"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	"sigs.k8s.io/scheduler-plugins/apis/config"
@@ -44,11 +47,17 @@ type Coscheduling struct {
 	pgMgr            core.Manager
 	scheduleTimeout  *time.Duration
 	pgBackoff        *time.Duration
+	pgMaxBackoff     time.Duration
+	postFilterMode   config.CoschedulingPostFilterMode
+	pdbLister        policylisters.PodDisruptionBudgetLister
 }
 
 var _ framework.QueueSortPlugin = &Coscheduling{}
 var _ framework.PreFilterPlugin = &Coscheduling{}
+var _ framework.FilterPlugin = &Coscheduling{}
 var _ framework.PostFilterPlugin = &Coscheduling{}
+var _ framework.PreScorePlugin = &Coscheduling{}
+var _ framework.ScorePlugin = &Coscheduling{}
 var _ framework.PermitPlugin = &Coscheduling{}
 var _ framework.ReservePlugin = &Coscheduling{}
 
@@ -57,10 +66,35 @@ var _ framework.EnqueueExtensions = &Coscheduling{}
 const (
 	// Name is the name of the plugin used in Registry and configurations.
 	Name = "Coscheduling"
+
+	topologyStateKey = Name + "Topology"
 )
 
+// topologyState holds the topology constraint of the PodGroup of the pod being scheduled.
+type topologyState struct {
+	pg *v1alpha1.PodGroup
+	// domain is the topology domain chosen for the PodGroup, empty if not chosen yet.
+	domain string
+}
+
+func (s *topologyState) Clone() framework.StateData {
+	return s
+}
+
+func getTopologyState(state *framework.CycleState) (*topologyState, error) {
+	c, err := state.Read(topologyStateKey)
+	if err != nil {
+		return nil, err
+	}
+	s, ok := c.(*topologyState)
+	if !ok {
+		return nil, fmt.Errorf("%+v cannot be converted to topologyState", c)
+	}
+	return s, nil
+}
+
 // New initializes and returns a new Coscheduling plugin.
-func New(_ context.Context, obj runtime.Object, handle framework.Handle) (framework.Plugin, error) {
+func New(ctx context.Context, obj runtime.Object, handle framework.Handle) (framework.Plugin, error) {
 	args, ok := obj.(*config.CoschedulingArgs)
 	if !ok {
 		return nil, fmt.Errorf("want args to be of type CoschedulingArgs, got %T", obj)
@@ -78,6 +112,25 @@ func New(_ context.Context, obj runtime.Object, handle framework.Handle) (framew
 	// Performance improvement when retrieving list of objects by namespace or we'll log 'index not exist' warning.
 	handle.SharedInformerFactory().Core().V1().Pods().Informer().AddIndexers(cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
 
+	preFilterMode := config.PreFilterMinResources
+	if args.PreFilterMode != nil {
+		preFilterMode = *args.PreFilterMode
+	}
+	if preFilterMode != config.PreFilterMinResources && preFilterMode != config.PreFilterSimulatePlacement {
+		err := fmt.Errorf("parse arguments failed")
+		klog.ErrorS(err, "PreFilterMode is not supported", "preFilterMode", preFilterMode)
+		return nil, err
+	}
+	postFilterMode := config.PostFilterReject
+	if args.PostFilterMode != nil {
+		postFilterMode = *args.PostFilterMode
+	}
+	if postFilterMode != config.PostFilterReject && postFilterMode != config.PostFilterPreempt {
+		err := fmt.Errorf("parse arguments failed")
+		klog.ErrorS(err, "PostFilterMode is not supported", "postFilterMode", postFilterMode)
+		return nil, err
+	}
+
 	scheduleTimeDuration := time.Duration(args.PermitWaitingTimeSeconds) * time.Second
 	pgMgr := core.NewPodGroupManager(
 		client,
@@ -85,11 +138,16 @@ func New(_ context.Context, obj runtime.Object, handle framework.Handle) (framew
 		&scheduleTimeDuration,
 		// Keep the podInformer (from frameworkHandle) as the single source of Pods.
 		handle.SharedInformerFactory().Core().V1().Pods(),
+		preFilterMode,
 	)
 	plugin := &Coscheduling{
 		frameworkHandler: handle,
 		pgMgr:            pgMgr,
 		scheduleTimeout:  &scheduleTimeDuration,
+		postFilterMode:   postFilterMode,
+	}
+	if postFilterMode == config.PostFilterPreempt {
+		plugin.pdbLister = handle.SharedInformerFactory().Policy().V1().PodDisruptionBudgets().Lister()
 	}
 	if args.PodGroupBackoffSeconds < 0 {
 		err := fmt.Errorf("parse arguments failed")
@@ -99,9 +157,56 @@ func New(_ context.Context, obj runtime.Object, handle framework.Handle) (framew
 		pgBackoff := time.Duration(args.PodGroupBackoffSeconds) * time.Second
 		plugin.pgBackoff = &pgBackoff
 	}
+	if args.PodGroupMaxBackoffSeconds < 0 {
+		err := fmt.Errorf("parse arguments failed")
+		klog.ErrorS(err, "PodGroupMaxBackoffSeconds cannot be negative")
+		return nil, err
+	}
+	plugin.pgMaxBackoff = time.Duration(args.PodGroupMaxBackoffSeconds) * time.Second
+
+	// The topology domain chosen for a PodGroup must not outlive it, or a PodGroup created again
+	// with the same name would be pinned to the domain of the deleted one.
+	dynamicCache, err := ctrlruntimecache.New(handle.KubeConfig(), ctrlruntimecache.Options{Scheme: scheme})
+	if err != nil {
+		return nil, err
+	}
+	pgInformer, err := dynamicCache.GetInformer(ctx, &v1alpha1.PodGroup{})
+	if err != nil {
+		return nil, err
+	}
+	if _, err := pgInformer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		DeleteFunc: plugin.deletePodGroup,
+	}); err != nil {
+		return nil, err
+	}
+	go func() {
+		if err := dynamicCache.Start(ctx); err != nil {
+			klog.ErrorS(err, "Failed to start the PodGroup cache")
+		}
+	}()
 	return plugin, nil
 }
 
+// deletePodGroup forgets the topology domain chosen for the deleted PodGroup.
+func (cs *Coscheduling) deletePodGroup(obj interface{}) {
+	var pg *v1alpha1.PodGroup
+	switch t := obj.(type) {
+	case *v1alpha1.PodGroup:
+		pg = t
+	case cache.DeletedFinalStateUnknown:
+		var ok bool
+		if pg, ok = t.Obj.(*v1alpha1.PodGroup); !ok {
+			utilruntime.HandleError(fmt.Errorf("cannot convert to *v1alpha1.PodGroup: %v", t.Obj))
+			return
+		}
+	default:
+		utilruntime.HandleError(fmt.Errorf("cannot convert to *v1alpha1.PodGroup: %v", t))
+		return
+	}
+	klog.V(5).InfoS("PodGroup deleted", "podGroup", klog.KObj(pg))
+	cs.pgMgr.ReleaseTopologyDomain(core.GetNamespacedName(pg))
+}
+
 func (cs *Coscheduling) EventsToRegister() []framework.ClusterEventWithHint {
 	// To register a custom event, follow the naming convention at:
 	// https://github.com/kubernetes/kubernetes/pull/101394
@@ -110,6 +215,8 @@ func (cs *Coscheduling) EventsToRegister() []framework.ClusterEventWithHint {
 	return []framework.ClusterEventWithHint{
 		{Event: framework.ClusterEvent{Resource: framework.Pod, ActionType: framework.Add}},
 		{Event: framework.ClusterEvent{Resource: framework.GVK(pgGVK), ActionType: framework.Add | framework.Update}},
+		// The topology domains are defined by the node labels.
+		{Event: framework.ClusterEvent{Resource: framework.Node, ActionType: framework.Add | framework.UpdateNodeLabel}},
 	}
 }
 
@@ -139,6 +246,8 @@ func (cs *Coscheduling) Less(podInfo1, podInfo2 *framework.QueuedPodInfo) bool {
 // PreFilter performs the following validations.
 // 1. Whether the PodGroup that the Pod belongs to is on the deny list.
 // 2. Whether the total number of pods in a PodGroup is less than its `minMember`.
+// If the PodGroup has a topology constraint, it also records the topology domain
+// chosen for the PodGroup, if any, for the Filter and Score phases.
 func (cs *Coscheduling) PreFilter(ctx context.Context, state *framework.CycleState, pod *v1.Pod) (*framework.PreFilterResult, *framework.Status) {
 	// If PreFilter fails, return framework.UnschedulableAndUnresolvable to avoid
 	// any preemption attempts.
@@ -146,10 +255,44 @@ func (cs *Coscheduling) PreFilter(ctx context.Context, state *framework.CycleSta
 		klog.ErrorS(err, "PreFilter failed", "pod", klog.KObj(pod))
 		return nil, framework.NewStatus(framework.UnschedulableAndUnresolvable, err.Error())
 	}
+
+	_, pg := cs.pgMgr.GetPodGroup(ctx, pod)
+	if pg == nil || pg.Spec.TopologyConstraint == nil {
+		return nil, framework.NewStatus(framework.Skip)
+	}
+	domain, _ := cs.pgMgr.GetTopologyDomain(pg)
+	klog.V(5).InfoS("Topology domain of the pod group", "pod", klog.KObj(pod), "podGroup", klog.KObj(pg), "domain", domain)
+	state.Write(topologyStateKey, &topologyState{pg: pg, domain: domain})
+	if util.GetTopologyMode(pg) != v1alpha1.TopologyModeRequired {
+		return nil, framework.NewStatus(framework.Skip)
+	}
 	return nil, framework.NewStatus(framework.Success, "")
 }
 
+// Filter rejects the nodes outside the topology domain chosen for the PodGroup, if its topology
+// constraint is required. Until a domain is chosen, only nodes lacking the topology key label are rejected.
+func (cs *Coscheduling) Filter(ctx context.Context, state *framework.CycleState, pod *v1.Pod, nodeInfo *framework.NodeInfo) *framework.Status {
+	s, err := getTopologyState(state)
+	if err != nil {
+		return framework.AsStatus(err)
+	}
+	node := nodeInfo.Node()
+	if node == nil {
+		return framework.NewStatus(framework.Error, "node not found")
+	}
+	domain, ok := node.Labels[s.pg.Spec.TopologyConstraint.TopologyKey]
+	if !ok {
+		return framework.NewStatus(framework.UnschedulableAndUnresolvable, "node(s) didn't have the topology key of the pod group")
+	}
+	if s.domain != "" && domain != s.domain {
+		return framework.NewStatus(framework.UnschedulableAndUnresolvable, "node(s) didn't match the topology domain of the pod group")
+	}
+	return nil
+}
+
 // PostFilter is used to reject a group of pods if a pod does not pass PreFilter or Filter.
+// In the Preempt mode, it first tries to preempt lower priority pods to make room for the whole group,
+// and rejects the group only if that's not possible.
 func (cs *Coscheduling) PostFilter(ctx context.Context, state *framework.CycleState, pod *v1.Pod,
 	filteredNodeStatusMap framework.NodeToStatusMap) (*framework.PostFilterResult, *framework.Status) {
 	pgName, pg := cs.pgMgr.GetPodGroup(ctx, pod)
@@ -161,15 +304,24 @@ func (cs *Coscheduling) PostFilter(ctx context.Context, state *framework.CycleSt
 	// This indicates there are already enough Pods satisfying the PodGroup,
 	// so don't bother to reject the whole PodGroup.
 	assigned := cs.pgMgr.CalculateAssignedPods(pg.Name, pod.Namespace)
-	if assigned >= int(pg.Spec.MinMember) {
+	if cs.pgMgr.QuorumReached(pg) {
 		klog.V(4).InfoS("Assigned pods", "podGroup", klog.KObj(pg), "assigned", assigned)
 		return &framework.PostFilterResult{}, framework.NewStatus(framework.Unschedulable)
 	}
 
+	if cs.postFilterMode == config.PostFilterPreempt {
+		result, err := cs.preempt(ctx, pod, pg)
+		if err == nil {
+			return result, framework.NewStatus(framework.Success)
+		}
+		klog.V(4).InfoS("Cannot preempt pods for the pod group", "podGroup", klog.KObj(pg), "pod", klog.KObj(pod), "err", err)
+	}
+
 	// If the gap is less than/equal 10%, we may want to try subsequent Pods
-	// to see they can satisfy the PodGroup
+	// to see they can satisfy the PodGroup. There is no gap if enough pods are assigned
+	// but some roles miss members, since the remaining pods may not play those roles.
 	notAssignedPercentage := float32(int(pg.Spec.MinMember)-assigned) / float32(pg.Spec.MinMember)
-	if notAssignedPercentage <= 0.1 {
+	if assigned < int(pg.Spec.MinMember) && notAssignedPercentage <= 0.1 {
 		klog.V(4).InfoS("A small gap of pods to reach the quorum", "podGroup", klog.KObj(pg), "percentage", notAssignedPercentage)
 		return &framework.PostFilterResult{}, framework.NewStatus(framework.Unschedulable)
 	}
@@ -188,11 +340,12 @@ func (cs *Coscheduling) PostFilter(ctx context.Context, state *framework.CycleSt
 			labels.SelectorFromSet(labels.Set{v1alpha1.PodGroupLabel: util.GetPodGroupLabel(pod)}),
 		)
 		if err == nil && len(pods) >= int(pg.Spec.MinMember) {
-			cs.pgMgr.BackoffPodGroup(pgName, *cs.pgBackoff)
+			cs.pgMgr.BackoffPodGroup(ctx, pg, *cs.pgBackoff, cs.pgMaxBackoff, unschedulableReason(pod, filteredNodeStatusMap))
 		}
 	}
 
 	cs.pgMgr.DeletePermittedPodGroup(pgName)
+	cs.pgMgr.ReleaseTopologyDomain(pgName)
 	return &framework.PostFilterResult{}, framework.NewStatus(framework.Unschedulable,
 		fmt.Sprintf("PodGroup %v gets rejected due to Pod %v is unschedulable even after PostFilter", pgName, pod.Name))
 }
@@ -202,6 +355,36 @@ func (cs *Coscheduling) PreFilterExtensions() framework.PreFilterExtensions {
 	return nil
 }
 
+// PreScore skips the Score phase unless the PodGroup prefers a topology domain and the domain was already chosen.
+func (cs *Coscheduling) PreScore(ctx context.Context, state *framework.CycleState, pod *v1.Pod, nodes []*framework.NodeInfo) *framework.Status {
+	s, err := getTopologyState(state)
+	if err != nil || s.domain == "" || util.GetTopologyMode(s.pg) != v1alpha1.TopologyModePreferred {
+		return framework.NewStatus(framework.Skip)
+	}
+	return nil
+}
+
+// Score favors the nodes in the topology domain chosen for the PodGroup.
+func (cs *Coscheduling) Score(ctx context.Context, state *framework.CycleState, pod *v1.Pod, nodeName string) (int64, *framework.Status) {
+	s, err := getTopologyState(state)
+	if err != nil {
+		return 0, framework.AsStatus(err)
+	}
+	nodeInfo, err := cs.frameworkHandler.SnapshotSharedLister().NodeInfos().Get(nodeName)
+	if err != nil {
+		return 0, framework.AsStatus(fmt.Errorf("getting node %q from Snapshot: %w", nodeName, err))
+	}
+	if nodeInfo.Node().Labels[s.pg.Spec.TopologyConstraint.TopologyKey] != s.domain {
+		return 0, nil
+	}
+	return framework.MaxNodeScore, nil
+}
+
+// ScoreExtensions returns a ScoreExtensions interface if the plugin implements one.
+func (cs *Coscheduling) ScoreExtensions() framework.ScoreExtensions {
+	return nil
+}
+
 // Permit is the functions invoked by the framework at "Permit" extension point.
 func (cs *Coscheduling) Permit(ctx context.Context, state *framework.CycleState, pod *v1.Pod, nodeName string) (*framework.Status, time.Duration) {
 	waitTime := *cs.scheduleTimeout
@@ -238,11 +421,16 @@ func (cs *Coscheduling) Permit(ctx context.Context, state *framework.CycleState,
 }
 
 // Reserve is the functions invoked by the framework at "reserve" extension point.
+// If the PodGroup has a topology constraint and no domain was chosen yet, it chooses the domain of the given node.
 func (cs *Coscheduling) Reserve(ctx context.Context, state *framework.CycleState, pod *v1.Pod, nodeName string) *framework.Status {
+	if s, err := getTopologyState(state); err == nil && s.domain == "" {
+		cs.pgMgr.ReserveTopologyDomain(s.pg, nodeName)
+	}
 	return nil
 }
 
-// Unreserve rejects all other Pods in the PodGroup when one of the pods in the group times out.
+// Unreserve rejects all other Pods in the PodGroup when one of the pods in the group times out,
+// and releases the topology domain chosen for the PodGroup, if any.
 func (cs *Coscheduling) Unreserve(ctx context.Context, state *framework.CycleState, pod *v1.Pod, nodeName string) {
 	pgName, pg := cs.pgMgr.GetPodGroup(ctx, pod)
 	if pg == nil {
@@ -255,4 +443,18 @@ func (cs *Coscheduling) Unreserve(ctx context.Context, state *framework.CycleSta
 		}
 	})
 	cs.pgMgr.DeletePermittedPodGroup(pgName)
+	cs.pgMgr.ReleaseTopologyDomain(pgName)
+}
+
+// unschedulableReason returns why the given pod is unschedulable, summarizing the statuses of the nodes.
+func unschedulableReason(pod *v1.Pod, filteredNodeStatusMap framework.NodeToStatusMap) string {
+	if len(filteredNodeStatusMap) == 0 {
+		return fmt.Sprintf("pod %v is unschedulable", pod.Name)
+	}
+	fitErr := &framework.FitError{
+		Pod:         pod,
+		NumAllNodes: len(filteredNodeStatusMap),
+		Diagnosis:   framework.Diagnosis{NodeToStatusMap: filteredNodeStatusMap},
+	}
+	return fmt.Sprintf("pod %v is unschedulable: %v", pod.Name, fitErr.Error())
 }
@@ -26,10 +26,14 @@ import (
 
 	"github.com/google/go-cmp/cmp"
 	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/client-go/informers"
 	clientsetfake "k8s.io/client-go/kubernetes/fake"
+	clienttesting "k8s.io/client-go/testing"
 	clicache "k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/events"
+	"k8s.io/klog/v2"
 	"k8s.io/kubernetes/pkg/scheduler/framework"
 	"k8s.io/kubernetes/pkg/scheduler/framework/plugins/defaultbinder"
 	"k8s.io/kubernetes/pkg/scheduler/framework/plugins/queuesort"
@@ -38,6 +42,7 @@ import (
 	tf "k8s.io/kubernetes/pkg/scheduler/testing/framework"
 	"k8s.io/utils/pointer"
 
+	"sigs.k8s.io/scheduler-plugins/apis/config"
 	_ "sigs.k8s.io/scheduler-plugins/apis/config/scheme"
 	"sigs.k8s.io/scheduler-plugins/apis/scheduling/v1alpha1"
 	"sigs.k8s.io/scheduler-plugins/pkg/coscheduling/core"
@@ -113,6 +118,7 @@ func TestPodGroupBackoffTime(t *testing.T) {
 				// In this UT, 5 seconds should suffice to test the PreFilter's return code.
 				pointer.Duration(5*time.Second),
 				podInformer,
+				config.PreFilterMinResources,
 			)
 			pl := &Coscheduling{
 				frameworkHandler: f,
@@ -415,7 +421,7 @@ func TestLess(t *testing.T) {
 			informerFactory := informers.NewSharedInformerFactory(cs, 0)
 			podInformer := informerFactory.Core().V1().Pods()
 
-			pl := &Coscheduling{pgMgr: core.NewPodGroupManager(client, nil, nil, podInformer)}
+			pl := &Coscheduling{pgMgr: core.NewPodGroupManager(client, nil, nil, podInformer, config.PreFilterMinResources)}
 
 			informerFactory.Start(ctx.Done())
 			if !clicache.WaitForCacheSync(ctx.Done(), podInformer.Informer().HasSynced) {
@@ -505,7 +511,7 @@ func TestPermit(t *testing.T) {
 
 			pl := &Coscheduling{
 				frameworkHandler: f,
-				pgMgr:            core.NewPodGroupManager(client, tu.NewFakeSharedLister(nil, nodes), nil, podInformer),
+				pgMgr:            core.NewPodGroupManager(client, tu.NewFakeSharedLister(nil, nodes), nil, podInformer, config.PreFilterMinResources),
 				scheduleTimeout:  &scheduleTimeout,
 			}
 
@@ -572,6 +578,26 @@ func TestPostFilter(t *testing.T) {
 				"PodGroup ns/pg1 gets rejected due to Pod p is unschedulable even after PostFilter",
 			),
 		},
+		{
+			name: "enough pods assigned but a role below its minMember, reject all pods",
+			pod: st.MakePod().Name("p").Namespace("ns").UID("p").Label(v1alpha1.PodGroupLabel, "pg1").
+				Label("role", "worker").Obj(),
+			existingPods: []*v1.Pod{
+				st.MakePod().Name("p1").Namespace("ns").UID("p1").Node("node").Label(v1alpha1.PodGroupLabel, "pg1").
+					Label("role", "ps").Obj(),
+				st.MakePod().Name("p2").Namespace("ns").UID("p2").Node("node").Label(v1alpha1.PodGroupLabel, "pg1").
+					Label("role", "ps").Obj(),
+			},
+			pgs: []*v1alpha1.PodGroup{
+				tu.MakePodGroup().Name("pg1").Namespace("ns").MinMember(2).
+					Role("ps", map[string]string{"role": "ps"}, 1, nil).
+					Role("worker", map[string]string{"role": "worker"}, 1, nil).Obj(),
+			},
+			want: framework.NewStatus(
+				framework.Unschedulable,
+				"PodGroup ns/pg1 gets rejected due to Pod p is unschedulable even after PostFilter",
+			),
+		},
 	}
 
 	for _, tt := range tests {
@@ -614,6 +640,7 @@ func TestPostFilter(t *testing.T) {
 					tu.NewFakeSharedLister(tt.existingPods, nodes),
 					&scheduleTimeout,
 					podInformer,
+					config.PreFilterMinResources,
 				),
 				scheduleTimeout: &scheduleTimeout,
 			}
@@ -633,3 +660,466 @@ func TestPostFilter(t *testing.T) {
 		})
 	}
 }
+
+func TestPostFilterPreemption(t *testing.T) {
+	scheduleTimeout := 10 * time.Second
+	capacity := map[v1.ResourceName]string{
+		v1.ResourceCPU:  "4",
+		v1.ResourcePods: "10",
+	}
+	nodes := []*v1.Node{
+		st.MakeNode().Name("node-a").Capacity(capacity).Obj(),
+		st.MakeNode().Name("node-b").Capacity(capacity).Obj(),
+	}
+	nodeStatusMap := framework.NodeToStatusMap{
+		"node-a": framework.NewStatus(framework.Unschedulable),
+		"node-b": framework.NewStatus(framework.Unschedulable),
+	}
+	makeMember := func(name string) *v1.Pod {
+		return st.MakePod().Name(name).Namespace("ns").UID(name).Label(v1alpha1.PodGroupLabel, "pg1").Priority(100).
+			Req(map[v1.ResourceName]string{v1.ResourceCPU: "3"}).Obj()
+	}
+	pod := makeMember("p1")
+
+	tests := []struct {
+		name           string
+		existingPods   []*v1.Pod
+		failDelete     string
+		want           *framework.Status
+		wantPreempted  []string
+		wantNominating bool
+	}{
+		{
+			name: "preempt the lower priority pods",
+			existingPods: []*v1.Pod{
+				st.MakePod().Name("v1").Namespace("ns").UID("v1").Node("node-a").Priority(10).
+					Req(map[v1.ResourceName]string{v1.ResourceCPU: "2"}).Obj(),
+				st.MakePod().Name("v2").Namespace("ns").UID("v2").Node("node-b").Priority(10).
+					Req(map[v1.ResourceName]string{v1.ResourceCPU: "2"}).Obj(),
+			},
+			want:           framework.NewStatus(framework.Success),
+			wantPreempted:  []string{"v1", "v2"},
+			wantNominating: true,
+		},
+		{
+			name: "a failed eviction does not stop the others, nor nominates the pod",
+			existingPods: []*v1.Pod{
+				st.MakePod().Name("v1").Namespace("ns").UID("v1").Node("node-a").Priority(10).
+					Req(map[v1.ResourceName]string{v1.ResourceCPU: "2"}).Obj(),
+				st.MakePod().Name("v2").Namespace("ns").UID("v2").Node("node-b").Priority(10).
+					Req(map[v1.ResourceName]string{v1.ResourceCPU: "2"}).Obj(),
+			},
+			failDelete: "v1",
+			want: framework.NewStatus(
+				framework.Unschedulable,
+				"PodGroup ns/pg1 gets rejected due to Pod p1 is unschedulable even after PostFilter",
+			),
+			wantPreempted: []string{"v2"},
+		},
+		{
+			name: "not enough lower priority pods, reject all pods",
+			existingPods: []*v1.Pod{
+				st.MakePod().Name("v1").Namespace("ns").UID("v1").Node("node-a").Priority(10).
+					Req(map[v1.ResourceName]string{v1.ResourceCPU: "2"}).Obj(),
+				st.MakePod().Name("v2").Namespace("ns").UID("v2").Node("node-b").Priority(200).
+					Req(map[v1.ResourceName]string{v1.ResourceCPU: "2"}).Obj(),
+			},
+			want: framework.NewStatus(
+				framework.Unschedulable,
+				"PodGroup ns/pg1 gets rejected due to Pod p1 is unschedulable even after PostFilter",
+			),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			client, err := tu.NewFakeClient(tu.MakePodGroup().Name("pg1").Namespace("ns").MinMember(2).Obj())
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var objs []runtime.Object
+			for _, p := range tt.existingPods {
+				objs = append(objs, p)
+			}
+			cs := clientsetfake.NewSimpleClientset(objs...)
+			cs.PrependReactor("delete", "pods", func(action clienttesting.Action) (bool, runtime.Object, error) {
+				if action.(clienttesting.DeleteAction).GetName() == tt.failDelete {
+					return true, nil, fmt.Errorf("injected failure")
+				}
+				return false, nil, nil
+			})
+			informerFactory := informers.NewSharedInformerFactory(cs, 0)
+			podInformer := informerFactory.Core().V1().Pods()
+
+			registeredPlugins := []tf.RegisterPluginFunc{
+				tf.RegisterQueueSortPlugin(queuesort.Name, queuesort.New),
+				tf.RegisterBindPlugin(defaultbinder.Name, defaultbinder.New),
+			}
+			f, err := tf.NewFramework(ctx, registeredPlugins, "default-scheduler",
+				fwkruntime.WithClientSet(cs),
+				fwkruntime.WithEventRecorder(&events.FakeRecorder{}),
+			)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			pl := &Coscheduling{
+				frameworkHandler: f,
+				pgMgr: core.NewPodGroupManager(
+					client,
+					tu.NewFakeSharedLister(tt.existingPods, nodes),
+					&scheduleTimeout,
+					podInformer,
+					config.PreFilterMinResources,
+				),
+				scheduleTimeout: &scheduleTimeout,
+				postFilterMode:  config.PostFilterPreempt,
+				pdbLister:       informerFactory.Policy().V1().PodDisruptionBudgets().Lister(),
+			}
+
+			informerFactory.Start(ctx.Done())
+			informerFactory.WaitForCacheSync(ctx.Done())
+			// The pod being scheduled is a different object than the one in the lister.
+			for _, p := range []*v1.Pod{pod.DeepCopy(), makeMember("p2")} {
+				podInformer.Informer().GetStore().Add(p)
+			}
+
+			result, got := pl.PostFilter(ctx, framework.NewCycleState(), pod, nodeStatusMap)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Want %v, but got %v", tt.want, got)
+			}
+			if nominating := result != nil && result.NominatingInfo != nil && result.NominatedNodeName != ""; nominating != tt.wantNominating {
+				t.Errorf("Want nominating %v, but got %+v", tt.wantNominating, result)
+			}
+
+			var preempted []string
+			for _, p := range tt.existingPods {
+				if _, err := cs.CoreV1().Pods(p.Namespace).Get(ctx, p.Name, metav1.GetOptions{}); err != nil {
+					preempted = append(preempted, p.Name)
+				}
+			}
+			if !reflect.DeepEqual(preempted, tt.wantPreempted) {
+				t.Errorf("Want preempted pods %v, but got %v", tt.wantPreempted, preempted)
+			}
+		})
+	}
+}
+
+func TestUnschedulableReason(t *testing.T) {
+	pod := st.MakePod().Name("p").Namespace("ns").UID("p").Obj()
+	tests := []struct {
+		name          string
+		nodeStatusMap framework.NodeToStatusMap
+		want          string
+	}{
+		{
+			name: "no node statuses",
+			want: "pod p is unschedulable",
+		},
+		{
+			name: "node statuses",
+			nodeStatusMap: framework.NodeToStatusMap{
+				"node-a": framework.NewStatus(framework.Unschedulable, "Insufficient cpu"),
+				"node-b": framework.NewStatus(framework.Unschedulable, "Insufficient cpu"),
+				"node-c": framework.NewStatus(framework.UnschedulableAndUnresolvable, "node(s) had untolerated taint"),
+			},
+			want: "pod p is unschedulable: 0/3 nodes are available: 1 node(s) had untolerated taint, 2 Insufficient cpu.",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := unschedulableReason(pod, tt.nodeStatusMap); got != tt.want {
+				t.Errorf("Want %q, but got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestTopologyConstraint(t *testing.T) {
+	scheduleTimeout := 10 * time.Second
+	nodes := []*v1.Node{
+		st.MakeNode().Name("node-a").Label("zone", "zone-a").Obj(),
+		st.MakeNode().Name("node-b").Label("zone", "zone-b").Obj(),
+		st.MakeNode().Name("node-c").Obj(),
+	}
+	pod := st.MakePod().Name("p").Namespace("ns").UID("p").Label(v1alpha1.PodGroupLabel, "pg1").Obj()
+	assignedPods := []*v1.Pod{
+		st.MakePod().Name("p1").Namespace("ns").UID("p1").Label(v1alpha1.PodGroupLabel, "pg1").Node("node-a").Obj(),
+	}
+
+	tests := []struct {
+		name          string
+		pg            *v1alpha1.PodGroup
+		existingPods  []*v1.Pod
+		wantPreFilter framework.Code
+		// wantFilter is nil if Filter is skipped
+		wantFilter map[string]framework.Code
+		// wantScores is nil if Score is skipped
+		wantScores map[string]int64
+	}{
+		{
+			name:          "no topology constraint",
+			pg:            tu.MakePodGroup().Name("pg1").Namespace("ns").MinMember(1).Obj(),
+			wantPreFilter: framework.Skip,
+		},
+		{
+			name:          "required domain not chosen yet",
+			pg:            tu.MakePodGroup().Name("pg1").Namespace("ns").MinMember(1).TopologyConstraint("zone", "").Obj(),
+			wantPreFilter: framework.Success,
+			wantFilter: map[string]framework.Code{
+				"node-a": framework.Success,
+				"node-b": framework.Success,
+				"node-c": framework.UnschedulableAndUnresolvable,
+			},
+		},
+		{
+			name:          "required domain chosen",
+			pg:            tu.MakePodGroup().Name("pg1").Namespace("ns").MinMember(1).TopologyConstraint("zone", v1alpha1.TopologyModeRequired).Obj(),
+			existingPods:  assignedPods,
+			wantPreFilter: framework.Success,
+			wantFilter: map[string]framework.Code{
+				"node-a": framework.Success,
+				"node-b": framework.UnschedulableAndUnresolvable,
+				"node-c": framework.UnschedulableAndUnresolvable,
+			},
+		},
+		{
+			name:          "preferred domain not chosen yet",
+			pg:            tu.MakePodGroup().Name("pg1").Namespace("ns").MinMember(1).TopologyConstraint("zone", v1alpha1.TopologyModePreferred).Obj(),
+			wantPreFilter: framework.Skip,
+		},
+		{
+			name:          "preferred domain chosen",
+			pg:            tu.MakePodGroup().Name("pg1").Namespace("ns").MinMember(1).TopologyConstraint("zone", v1alpha1.TopologyModePreferred).Obj(),
+			existingPods:  assignedPods,
+			wantPreFilter: framework.Skip,
+			wantScores: map[string]int64{
+				"node-a": framework.MaxNodeScore,
+				"node-b": 0,
+				"node-c": 0,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			var objs []runtime.Object
+			for _, p := range append(tt.existingPods, pod) {
+				objs = append(objs, p)
+			}
+			objs = append(objs, tt.pg)
+			client, err := tu.NewFakeClient(objs...)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			snapshot := tu.NewFakeSharedLister(tt.existingPods, nodes)
+			registeredPlugins := []tf.RegisterPluginFunc{
+				tf.RegisterQueueSortPlugin(queuesort.Name, queuesort.New),
+				tf.RegisterBindPlugin(defaultbinder.Name, defaultbinder.New),
+			}
+			f, err := tf.NewFramework(ctx, registeredPlugins, "default-scheduler", fwkruntime.WithSnapshotSharedLister(snapshot))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			cs := clientsetfake.NewSimpleClientset()
+			informerFactory := informers.NewSharedInformerFactory(cs, 0)
+			podInformer := informerFactory.Core().V1().Pods()
+			pl := &Coscheduling{
+				frameworkHandler: f,
+				pgMgr:            core.NewPodGroupManager(client, snapshot, &scheduleTimeout, podInformer, config.PreFilterMinResources),
+				scheduleTimeout:  &scheduleTimeout,
+			}
+			informerFactory.Start(ctx.Done())
+			if !clicache.WaitForCacheSync(ctx.Done(), podInformer.Informer().HasSynced) {
+				t.Fatal("WaitForCacheSync failed")
+			}
+			podInformer.Informer().GetStore().Add(pod)
+
+			state := framework.NewCycleState()
+			if _, got := pl.PreFilter(ctx, state, pod); got.Code() != tt.wantPreFilter {
+				t.Fatalf("Want PreFilter %v, but got %v", tt.wantPreFilter, got)
+			}
+			for nodeName, want := range tt.wantFilter {
+				nodeInfo, err := snapshot.NodeInfos().Get(nodeName)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if got := pl.Filter(ctx, state, pod, nodeInfo); got.Code() != want {
+					t.Errorf("Want Filter %v on node %q, but got %v", want, nodeName, got)
+				}
+			}
+			preScore := pl.PreScore(ctx, state, pod, nil)
+			if (tt.wantScores == nil) != preScore.IsSkip() {
+				t.Fatalf("Unexpected PreScore status %v", preScore)
+			}
+			for nodeName, want := range tt.wantScores {
+				got, status := pl.Score(ctx, state, pod, nodeName)
+				if !status.IsSuccess() || got != want {
+					t.Errorf("Want score %d on node %q, but got %d (%v)", want, nodeName, got, status)
+				}
+			}
+		})
+	}
+}
+
+func TestTopologyDomainLifecycle(t *testing.T) {
+	scheduleTimeout := 10 * time.Second
+	nodes := []*v1.Node{
+		st.MakeNode().Name("node-a").Label("zone", "zone-a").Obj(),
+		st.MakeNode().Name("node-b").Label("zone", "zone-b").Obj(),
+	}
+	pod := st.MakePod().Name("p").Namespace("ns").UID("p").Label(v1alpha1.PodGroupLabel, "pg1").Obj()
+	pg := tu.MakePodGroup().Name("pg1").Namespace("ns").MinMember(1).TopologyConstraint("zone", v1alpha1.TopologyModeRequired).Obj()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	client, err := tu.NewFakeClient(pod, pg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	snapshot := tu.NewFakeSharedLister(nil, nodes)
+	registeredPlugins := []tf.RegisterPluginFunc{
+		tf.RegisterQueueSortPlugin(queuesort.Name, queuesort.New),
+		tf.RegisterBindPlugin(defaultbinder.Name, defaultbinder.New),
+	}
+	f, err := tf.NewFramework(ctx, registeredPlugins, "default-scheduler", fwkruntime.WithSnapshotSharedLister(snapshot))
+	if err != nil {
+		t.Fatal(err)
+	}
+	cs := clientsetfake.NewSimpleClientset()
+	informerFactory := informers.NewSharedInformerFactory(cs, 0)
+	podInformer := informerFactory.Core().V1().Pods()
+	pl := &Coscheduling{
+		frameworkHandler: f,
+		pgMgr:            core.NewPodGroupManager(client, snapshot, &scheduleTimeout, podInformer, config.PreFilterMinResources),
+		scheduleTimeout:  &scheduleTimeout,
+	}
+	informerFactory.Start(ctx.Done())
+	if !clicache.WaitForCacheSync(ctx.Done(), podInformer.Informer().HasSynced) {
+		t.Fatal("WaitForCacheSync failed")
+	}
+	podInformer.Informer().GetStore().Add(pod)
+
+	// filterNodeA runs a scheduling cycle up to Filter on node-a.
+	filterNodeA := func() (*framework.CycleState, *framework.Status) {
+		state := framework.NewCycleState()
+		if _, status := pl.PreFilter(ctx, state, pod); !status.IsSuccess() {
+			t.Fatalf("Unexpected PreFilter status %v", status)
+		}
+		nodeInfo, err := snapshot.NodeInfos().Get("node-a")
+		if err != nil {
+			t.Fatal(err)
+		}
+		return state, pl.Filter(ctx, state, pod, nodeInfo)
+	}
+
+	// The scheduler assumes the reserved pod on its node, so the next cycles see it as assigned.
+	nodeB, err := snapshot.NodeInfos().Get("node-b")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assumed := pod.DeepCopy()
+	assumed.Spec.NodeName = "node-b"
+
+	state, status := filterNodeA()
+	if !status.IsSuccess() {
+		t.Fatalf("Want node-a feasible before the domain is chosen, but got %v", status)
+	}
+	pl.Reserve(ctx, state, pod, "node-b")
+	nodeB.AddPod(assumed)
+	if _, status := filterNodeA(); status.Code() != framework.UnschedulableAndUnresolvable {
+		t.Errorf("Want node-a filtered out after reserving node-b, but got %v", status)
+	}
+	pl.Unreserve(ctx, state, pod, "node-b")
+	if err := nodeB.RemovePod(klog.Background(), assumed); err != nil {
+		t.Fatal(err)
+	}
+	if _, status := filterNodeA(); !status.IsSuccess() {
+		t.Errorf("Want node-a feasible after the domain is released, but got %v", status)
+	}
+}
+
+func TestTopologyDomainPodGroupRecreated(t *testing.T) {
+	scheduleTimeout := 10 * time.Second
+	nodes := []*v1.Node{
+		st.MakeNode().Name("node-a").Label("zone", "zone-a").Obj(),
+		st.MakeNode().Name("node-b").Label("zone", "zone-b").Obj(),
+		st.MakeNode().Name("node-c").Obj(),
+	}
+	pod := st.MakePod().Name("p").Namespace("ns").UID("p").Label(v1alpha1.PodGroupLabel, "pg1").Obj()
+	// A member left over from the deleted PodGroup, on a node lacking the topology key.
+	leftover := st.MakePod().Name("p0").Namespace("ns").UID("p0").Label(v1alpha1.PodGroupLabel, "pg1").Node("node-c").Obj()
+	pg := tu.MakePodGroup().Name("pg1").Namespace("ns").MinMember(1).TopologyConstraint("zone", v1alpha1.TopologyModeRequired).Obj()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	client, err := tu.NewFakeClient(pod, leftover, pg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	snapshot := tu.NewFakeSharedLister([]*v1.Pod{leftover}, nodes)
+	registeredPlugins := []tf.RegisterPluginFunc{
+		tf.RegisterQueueSortPlugin(queuesort.Name, queuesort.New),
+		tf.RegisterBindPlugin(defaultbinder.Name, defaultbinder.New),
+	}
+	f, err := tf.NewFramework(ctx, registeredPlugins, "default-scheduler", fwkruntime.WithSnapshotSharedLister(snapshot))
+	if err != nil {
+		t.Fatal(err)
+	}
+	cs := clientsetfake.NewSimpleClientset()
+	informerFactory := informers.NewSharedInformerFactory(cs, 0)
+	podInformer := informerFactory.Core().V1().Pods()
+	pl := &Coscheduling{
+		frameworkHandler: f,
+		pgMgr:            core.NewPodGroupManager(client, snapshot, &scheduleTimeout, podInformer, config.PreFilterMinResources),
+		scheduleTimeout:  &scheduleTimeout,
+	}
+	informerFactory.Start(ctx.Done())
+	if !clicache.WaitForCacheSync(ctx.Done(), podInformer.Informer().HasSynced) {
+		t.Fatal("WaitForCacheSync failed")
+	}
+	podInformer.Informer().GetStore().Add(pod)
+
+	filterNodeA := func() (*framework.CycleState, *framework.Status) {
+		state := framework.NewCycleState()
+		if _, status := pl.PreFilter(ctx, state, pod); !status.IsSuccess() {
+			t.Fatalf("Unexpected PreFilter status %v", status)
+		}
+		nodeInfo, err := snapshot.NodeInfos().Get("node-a")
+		if err != nil {
+			t.Fatal(err)
+		}
+		return state, pl.Filter(ctx, state, pod, nodeInfo)
+	}
+
+	state, _ := filterNodeA()
+	pl.Reserve(ctx, state, pod, "node-b")
+	if _, status := filterNodeA(); status.Code() != framework.UnschedulableAndUnresolvable {
+		t.Fatalf("Want node-a filtered out after reserving node-b, but got %v", status)
+	}
+
+	if err := client.Delete(ctx, pg); err != nil {
+		t.Fatal(err)
+	}
+	pl.deletePodGroup(clicache.DeletedFinalStateUnknown{Key: "ns/pg1", Obj: pg})
+	recreated := pg.DeepCopy()
+	recreated.ResourceVersion = ""
+	recreated.UID = "recreated"
+	if err := client.Create(ctx, recreated); err != nil {
+		t.Fatal(err)
+	}
+	if _, status := filterNodeA(); !status.IsSuccess() {
+		t.Errorf("Want node-a feasible for the recreated PodGroup, but got %v", status)
+	}
+}
@@ -0,0 +1,67 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package coscheduling
+
+import (
+	"context"
+	"fmt"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/klog/v2"
+	"k8s.io/kubernetes/pkg/scheduler/framework"
+	schedutil "k8s.io/kubernetes/pkg/scheduler/util"
+
+	"sigs.k8s.io/scheduler-plugins/apis/scheduling/v1alpha1"
+)
+
+// preempt evicts the pods selected to make room for the whole PodGroup of the given pod, and nominates the pod
+// to the node it's expected to run on. Nothing is evicted if the pods that can be preempted are not enough.
+// Evictions cannot be rolled back, so if some of them fail the others are still carried out and the pod is not
+// nominated: the next attempt considers the pods being deleted as gone and only preempts the missing ones.
+func (cs *Coscheduling) preempt(ctx context.Context, pod *v1.Pod, pg *v1alpha1.PodGroup) (*framework.PostFilterResult, error) {
+	if pod.Spec.PreemptionPolicy != nil && *pod.Spec.PreemptionPolicy == v1.PreemptNever {
+		return nil, fmt.Errorf("pod %v is not allowed to preempt other pods", pod.Name)
+	}
+	pdbs, err := cs.pdbLister.List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+	victims, nodeName, err := cs.pgMgr.SelectVictims(ctx, pod, pg, pdbs)
+	if err != nil {
+		return nil, err
+	}
+
+	var errs []error
+	for _, victim := range victims {
+		// If the victim is a WaitingPod, reject it instead of deleting it.
+		if waitingPod := cs.frameworkHandler.GetWaitingPod(victim.UID); waitingPod != nil {
+			waitingPod.Reject(cs.Name(), "preempted")
+		} else if err := schedutil.DeletePod(ctx, cs.frameworkHandler.ClientSet(), victim); err != nil {
+			klog.ErrorS(err, "Failed to preempt pod", "pod", klog.KObj(victim), "podGroup", klog.KObj(pg))
+			errs = append(errs, err)
+			continue
+		}
+		klog.V(2).InfoS("Preempted pod", "pod", klog.KObj(victim), "node", victim.Spec.NodeName, "preemptor", klog.KObj(pod), "podGroup", klog.KObj(pg))
+		cs.frameworkHandler.EventRecorder().Eventf(victim, pod, v1.EventTypeNormal, "Preempted", "Preempting", "Preempted by pod group %v on node %v", pg.Name, victim.Spec.NodeName)
+	}
+	if len(errs) > 0 {
+		return nil, utilerrors.NewAggregate(errs)
+	}
+	return framework.NewPostFilterResultWithNominatedNode(nodeName), nil
+}
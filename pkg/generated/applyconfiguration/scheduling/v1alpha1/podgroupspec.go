@@ -25,9 +25,11 @@ import (
 // PodGroupSpecApplyConfiguration represents an declarative configuration of the PodGroupSpec type for use
 // with apply.
 type PodGroupSpecApplyConfiguration struct {
-	MinMember              *int32           `json:"minMember,omitempty"`
-	MinResources           *v1.ResourceList `json:"minResources,omitempty"`
-	ScheduleTimeoutSeconds *int32           `json:"scheduleTimeoutSeconds,omitempty"`
+	MinMember              *int32                                `json:"minMember,omitempty"`
+	MinResources           *v1.ResourceList                      `json:"minResources,omitempty"`
+	ScheduleTimeoutSeconds *int32                                `json:"scheduleTimeoutSeconds,omitempty"`
+	TopologyConstraint     *TopologyConstraintApplyConfiguration `json:"topologyConstraint,omitempty"`
+	Roles                  []PodGroupRoleApplyConfiguration      `json:"roles,omitempty"`
 }
 
 // PodGroupSpecApplyConfiguration constructs an declarative configuration of the PodGroupSpec type for use with
@@ -59,3 +61,24 @@ func (b *PodGroupSpecApplyConfiguration) WithScheduleTimeoutSeconds(value int32)
 	b.ScheduleTimeoutSeconds = &value
 	return b
 }
+
+// WithTopologyConstraint sets the TopologyConstraint field in the declarative configuration to the given value
+// and returns the receiver, so that objects can be built by chaining "With" function invocations.
+// If called multiple times, the TopologyConstraint field is set to the value of the last call.
+func (b *PodGroupSpecApplyConfiguration) WithTopologyConstraint(value *TopologyConstraintApplyConfiguration) *PodGroupSpecApplyConfiguration {
+	b.TopologyConstraint = value
+	return b
+}
+
+// WithRoles adds the given value to the Roles field in the declarative configuration
+// and returns the receiver, so that objects can be build by chaining "With" function invocations.
+// If called multiple times, values provided by each call will be appended to the Roles field.
+func (b *PodGroupSpecApplyConfiguration) WithRoles(values ...*PodGroupRoleApplyConfiguration) *PodGroupSpecApplyConfiguration {
+	for i := range values {
+		if values[i] == nil {
+			panic("nil value passed to WithRoles")
+		}
+		b.Roles = append(b.Roles, *values[i])
+	}
+	return b
+}
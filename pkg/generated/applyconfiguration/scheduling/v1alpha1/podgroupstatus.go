@@ -20,18 +20,21 @@ package v1alpha1
 
 import (
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	metav1 "k8s.io/client-go/applyconfigurations/meta/v1"
 	v1alpha1 "sigs.k8s.io/scheduler-plugins/apis/scheduling/v1alpha1"
 )
 
 // PodGroupStatusApplyConfiguration represents an declarative configuration of the PodGroupStatus type for use
 // with apply.
 type PodGroupStatusApplyConfiguration struct {
-	Phase             *v1alpha1.PodGroupPhase `json:"phase,omitempty"`
-	OccupiedBy        *string                 `json:"occupiedBy,omitempty"`
-	Running           *int32                  `json:"running,omitempty"`
-	Succeeded         *int32                  `json:"succeeded,omitempty"`
-	Failed            *int32                  `json:"failed,omitempty"`
-	ScheduleStartTime *v1.Time                `json:"scheduleStartTime,omitempty"`
+	Phase             *v1alpha1.PodGroupPhase                `json:"phase,omitempty"`
+	OccupiedBy        *string                                `json:"occupiedBy,omitempty"`
+	Running           *int32                                 `json:"running,omitempty"`
+	Succeeded         *int32                                 `json:"succeeded,omitempty"`
+	Failed            *int32                                 `json:"failed,omitempty"`
+	ScheduleStartTime *v1.Time                               `json:"scheduleStartTime,omitempty"`
+	Roles             []PodGroupRoleStatusApplyConfiguration `json:"roles,omitempty"`
+	Conditions        []metav1.ConditionApplyConfiguration   `json:"conditions,omitempty"`
 }
 
 // PodGroupStatusApplyConfiguration constructs an declarative configuration of the PodGroupStatus type for use with
@@ -87,3 +90,29 @@ func (b *PodGroupStatusApplyConfiguration) WithScheduleStartTime(value v1.Time)
 	b.ScheduleStartTime = &value
 	return b
 }
+
+// WithRoles adds the given value to the Roles field in the declarative configuration
+// and returns the receiver, so that objects can be build by chaining "With" function invocations.
+// If called multiple times, values provided by each call will be appended to the Roles field.
+func (b *PodGroupStatusApplyConfiguration) WithRoles(values ...*PodGroupRoleStatusApplyConfiguration) *PodGroupStatusApplyConfiguration {
+	for i := range values {
+		if values[i] == nil {
+			panic("nil value passed to WithRoles")
+		}
+		b.Roles = append(b.Roles, *values[i])
+	}
+	return b
+}
+
+// WithConditions adds the given value to the Conditions field in the declarative configuration
+// and returns the receiver, so that objects can be build by chaining "With" function invocations.
+// If called multiple times, values provided by each call will be appended to the Conditions field.
+func (b *PodGroupStatusApplyConfiguration) WithConditions(values ...*metav1.ConditionApplyConfiguration) *PodGroupStatusApplyConfiguration {
+	for i := range values {
+		if values[i] == nil {
+			panic("nil value passed to WithConditions")
+		}
+		b.Conditions = append(b.Conditions, *values[i])
+	}
+	return b
+}
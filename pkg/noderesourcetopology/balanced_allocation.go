@@ -26,6 +26,8 @@ import (
 
 func balancedAllocationScoreStrategy(requested, allocatable v1.ResourceList, resourceToWeightMap resourceToWeightMap) int64 {
 	resourceFractions := make([]float64, 0)
+	resourceWeights := make([]float64, 0)
+	totalWeight := 0.0
 
 	// We don't care what kind of resources are being requested, we just iterate all of them.
 	// If NUMA zone doesn't have the requested resource, the score for that resource will be 0.
@@ -36,9 +38,18 @@ func balancedAllocationScoreStrategy(requested, allocatable v1.ResourceList, res
 			return 0
 		}
 		resourceFractions = append(resourceFractions, resourceFraction)
+		weight := float64(resourceToWeightMap.weight(resourceName))
+		resourceWeights = append(resourceWeights, weight)
+		totalWeight += weight
 	}
 
-	variance := stat.Variance(resourceFractions, nil)
+	// the weights let the imbalance of the most important resources count more. They are normalized to add up
+	// to the number of resources, so only their ratios matter and uniform weights score like no weights at all:
+	// stat.Variance corrects the bias dividing by the sum of the weights minus one.
+	for i := range resourceWeights {
+		resourceWeights[i] *= float64(len(resourceWeights)) / totalWeight
+	}
+	variance := stat.Variance(resourceFractions, resourceWeights)
 
 	// Since the variance is between positive fractions, it will be positive fraction. 1-variance lets the
 	// score to be higher for node which has least variance and multiplying it with `MaxNodeScore` provides the scaling
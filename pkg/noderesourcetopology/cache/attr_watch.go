@@ -18,9 +18,12 @@ package cache
 
 import (
 	"context"
+	"fmt"
+	"time"
 
 	"github.com/go-logr/logr"
 
+	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/apimachinery/pkg/watch"
 
 	ctrlclient "sigs.k8s.io/controller-runtime/pkg/client"
@@ -31,37 +34,51 @@ import (
 	"sigs.k8s.io/scheduler-plugins/pkg/noderesourcetopology/nodeconfig"
 )
 
+// watchRetryInterval is the time to wait before watching the NRT objects again once the watch ended
+const watchRetryInterval = 1 * time.Second
+
 type Watcher struct {
-	lh    logr.Logger
-	nrts  *nrtStore
+	lh   logr.Logger
+	nrts *nrtStore
+	// nodes tracks the nodes whose attributes changed. If nil, attribute changes are not tracked.
 	nodes counter
+	// onUpdate, if not nil, is called with the node name on every NRT object update,
+	// after the attribute changes are tracked.
+	onUpdate func(nodeName string)
 }
 
+// NodeResourceTopologies watches the NRT objects until the context is done.
+// The watch is established again whenever it ends, e.g. because the server closed it.
 func (wt Watcher) NodeResourceTopologies(ctx context.Context, client ctrlclient.WithWatch) {
-	done := false
-	for !done {
-		wt.lh.Info("start watching NRT objects")
-
-		nrtObjs := topologyv1alpha2.NodeResourceTopologyList{}
-		wa, err := client.Watch(ctx, &nrtObjs)
-		if err != nil {
-			wt.lh.Error(err, "cannot watch NRT objects")
-			return
-		}
+	wait.UntilWithContext(ctx, func(ctx context.Context) {
+		wt.watchNodeResourceTopologies(ctx, client)
+	}, watchRetryInterval)
+	wt.lh.Info("stop watching NRT objects")
+}
 
-		for !done {
-			select {
-			case ev := <-wa.ResultChan():
-				wt.ProcessEvent(ev)
+func (wt Watcher) watchNodeResourceTopologies(ctx context.Context, client ctrlclient.WithWatch) {
+	wt.lh.Info("start watching NRT objects")
 
-			case <-ctx.Done():
-				wt.lh.Info("stop watching NRT objects")
-				wa.Stop()
-				done = true
+	nrtObjs := topologyv1alpha2.NodeResourceTopologyList{}
+	wa, err := client.Watch(ctx, &nrtObjs)
+	if err != nil {
+		wt.lh.Error(err, "cannot watch NRT objects")
+		return
+	}
+	defer wa.Stop()
+
+	for {
+		select {
+		case ev, ok := <-wa.ResultChan():
+			if !ok {
+				wt.lh.Info("NRT objects watch closed")
+				return
 			}
-		}
+			wt.ProcessEvent(ev)
 
-		wt.lh.Info("done watching NRT objects")
+		case <-ctx.Done():
+			return
+		}
 	}
 }
 
@@ -72,7 +89,21 @@ func (wt Watcher) ProcessEvent(ev watch.Event) bool {
 
 	nrtObj, ok := ev.Object.(*topologyv1alpha2.NodeResourceTopology)
 	if !ok {
-		wt.lh.Info("unexpected object %T", ev.Object)
+		wt.lh.Info("unexpected object", "type", fmt.Sprintf("%T", ev.Object))
+		return false
+	}
+
+	// the attribute changes must be tracked before the update is notified, so the resync
+	// triggered by the notification can tell the node configuration changed.
+	changed := wt.trackAttrsChange(nrtObj)
+	if wt.onUpdate != nil {
+		wt.onUpdate(nrtObj.Name)
+	}
+	return changed
+}
+
+func (wt Watcher) trackAttrsChange(nrtObj *topologyv1alpha2.NodeResourceTopology) bool {
+	if wt.nodes == nil {
 		return false
 	}
 
@@ -17,14 +17,19 @@ limitations under the License.
 package cache
 
 import (
+	"context"
 	"reflect"
+	"sync"
 	"testing"
+	"time"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/watch"
 
 	"k8s.io/klog/v2"
 
+	ctrlclient "sigs.k8s.io/controller-runtime/pkg/client"
+
 	topologyv1alpha2 "github.com/k8stopologyawareschedwg/noderesourcetopology-api/pkg/apis/topology/v1alpha2"
 )
 
@@ -151,3 +156,103 @@ func TestWatcherProcessEvent(t *testing.T) {
 		})
 	}
 }
+
+func TestWatcherOnUpdateAfterAttrsTracked(t *testing.T) {
+	nrt := &topologyv1alpha2.NodeResourceTopology{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "node-0",
+		},
+		Attributes: []topologyv1alpha2.AttributeInfo{
+			{
+				Name:  "topologyManagerScope",
+				Value: "pod",
+			},
+		},
+	}
+	updated := nrt.DeepCopy()
+	updated.Attributes[0].Value = "container"
+
+	tracked := false
+	wt := Watcher{
+		lh:    klog.Background(),
+		nrts:  newNrtStore(klog.Background(), []topologyv1alpha2.NodeResourceTopology{*nrt}),
+		nodes: newCounter(),
+	}
+	wt.onUpdate = func(nodeName string) {
+		tracked = wt.nodes.IsSet(nodeName)
+	}
+
+	if !wt.ProcessEvent(watch.Event{Type: watch.Modified, Object: updated}) {
+		t.Fatalf("attribute change not detected")
+	}
+	if !tracked {
+		t.Errorf("update notified before the attribute change was tracked")
+	}
+}
+
+// rewatchClient hands out a new fake watch on every Watch call.
+type rewatchClient struct {
+	ctrlclient.WithWatch
+	mu       sync.Mutex
+	watchers []*watch.FakeWatcher
+}
+
+func (rc *rewatchClient) Watch(ctx context.Context, obj ctrlclient.ObjectList, opts ...ctrlclient.ListOption) (watch.Interface, error) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	fw := watch.NewFake()
+	rc.watchers = append(rc.watchers, fw)
+	return fw, nil
+}
+
+func (rc *rewatchClient) waitWatchers(t *testing.T, count int) *watch.FakeWatcher {
+	t.Helper()
+	deadline := time.Now().Add(5 * watchRetryInterval)
+	for time.Now().Before(deadline) {
+		rc.mu.Lock()
+		if len(rc.watchers) >= count {
+			fw := rc.watchers[count-1]
+			rc.mu.Unlock()
+			return fw
+		}
+		rc.mu.Unlock()
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("watch not established %d times", count)
+	return nil
+}
+
+func TestWatcherRewatchOnClose(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	updated := make(chan string, 1)
+	wt := Watcher{
+		lh:   klog.Background(),
+		nrts: newNrtStore(klog.Background(), nil),
+		onUpdate: func(nodeName string) {
+			updated <- nodeName
+		},
+	}
+	rc := &rewatchClient{}
+	go wt.NodeResourceTopologies(ctx, rc)
+
+	// the server closes the watch
+	rc.waitWatchers(t, 1).Stop()
+
+	fw := rc.waitWatchers(t, 2)
+	fw.Modify(&topologyv1alpha2.NodeResourceTopology{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "node-0",
+		},
+	})
+
+	select {
+	case nodeName := <-updated:
+		if nodeName != "node-0" {
+			t.Errorf("unexpected update notification: %q", nodeName)
+		}
+	case <-time.After(5 * watchRetryInterval):
+		t.Errorf("missing update notification after watching again")
+	}
+}
@@ -38,6 +38,36 @@ type CachedNRTInfo struct {
 	Fresh bool
 }
 
+// NUMAAssignment maps the NUMA cell ID to the resources a pod is expected to get allocated from it,
+// as computed by the filter step. The same resources may be listed on more NUMA cells if the exact split
+// cannot be predicted. A nil NUMAAssignment means the placement is unknown.
+type NUMAAssignment map[int]corev1.ResourceList
+
+// Add accumulates the given resources on the given NUMA cell.
+func (na NUMAAssignment) Add(numaID int, resources corev1.ResourceList) {
+	cur, ok := na[numaID]
+	if !ok {
+		cur = corev1.ResourceList{}
+		na[numaID] = cur
+	}
+	for resName, qty := range resources {
+		val := cur[resName]
+		val.Add(qty)
+		cur[resName] = val
+	}
+}
+
+func (na NUMAAssignment) DeepCopy() NUMAAssignment {
+	if na == nil {
+		return nil
+	}
+	ret := make(NUMAAssignment, len(na))
+	for numaID, res := range na {
+		ret[numaID] = res.DeepCopy()
+	}
+	return ret
+}
+
 type Interface interface {
 	// GetCachedNRTCopy retrieves a NRT copy from cache, and then deducts over-reserved resources if necessary.
 	// It will be used as the source of truth across the Pod's scheduling cycle.
@@ -68,11 +98,19 @@ type Interface interface {
 	// Additionally, this function resets the discarded counter for the same node. Being able to handle a pod means
 	// that this node has still available resources. If a node was previously discarded and then cleared, we interpret
 	// this sequence of events as the previous pod required too much - a possible and benign condition.
-	ReserveNodeResources(nodeName string, pod *corev1.Pod)
+	// If `assignment` is not nil, the resources are accounted only on the NUMA cells it lists; otherwise, they are
+	// pessimistically accounted on all the NUMA cells.
+	ReserveNodeResources(nodeName string, pod *corev1.Pod, assignment NUMAAssignment)
 
 	// UnreserveNodeResources decrement from the node assumed resources the resources required by the given pod.
+	// Implementations aware of the PodGroups may release the resources reserved by all the members of the group
+	// of the pod, if none of them is permitted yet.
 	UnreserveNodeResources(nodeName string, pod *corev1.Pod)
 
+	// PreBind is called once a pod is permitted, before it is bound.
+	// Implementations aware of the PodGroups use it to know the group of the pod can't be rejected as whole anymore.
+	PreBind(nodeName string, pod *corev1.Pod)
+
 	// PostBind is called after a pod is successfully bound. These plugins are
 	// informational. A common application of this extension point is for cleaning
 	// up. If a plugin needs to clean up its state after a pod is scheduled and
@@ -0,0 +1,206 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/go-logr/logr"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	ctrlclient "sigs.k8s.io/controller-runtime/pkg/client"
+
+	apiconfig "sigs.k8s.io/scheduler-plugins/apis/config"
+)
+
+const (
+	// CheckpointConfigMapKey is the ConfigMap data key holding the serialized checkpoint
+	CheckpointConfigMapKey = "checkpoint.json"
+)
+
+// Checkpoint is the serializable representation of the OverReserve cache state.
+// NRT objects are intentionally not part of the checkpoint: on restore they are listed again
+// from the apiserver, and the assumed resources are replayed on top of them. Should the
+// NRT objects be already up to date, this can only overestimate the resource usage, which
+// is the safe direction. The affected nodes are marked for resync, so the condition is transient.
+// Foreign pods are not tracked either, because the pod informer replays them on startup.
+type Checkpoint struct {
+	// Generation is the cache generation when the checkpoint was taken. Used only for logging.
+	Generation uint64 `json:"generation"`
+	// AssumedResources maps node name -> pod key (namespace/name) -> resources assumed for the pod
+	AssumedResources map[string]map[string]corev1.ResourceList `json:"assumedResources,omitempty"`
+	// NUMAAssignments maps node name -> pod key (namespace/name) -> NUMA cells the assumed resources are expected
+	// to be allocated from. Pods missing here have unknown NUMA placement and are accounted on all the NUMA cells.
+	NUMAAssignments map[string]map[string]NUMAAssignment `json:"numaAssignments,omitempty"`
+	// NodesMaybeOverReserved maps node name -> how many times the node was filtered out
+	NodesMaybeOverReserved map[string]int `json:"nodesMaybeOverReserved,omitempty"`
+}
+
+// Checkpointer persists and retrieves cache checkpoints.
+type Checkpointer interface {
+	// Save persists the given checkpoint, replacing any previous one.
+	Save(ctx context.Context, ckpt *Checkpoint) error
+	// Load retrieves the last saved checkpoint. Returns nil, nil if no checkpoint was ever saved.
+	Load(ctx context.Context) (*Checkpoint, error)
+}
+
+// NewCheckpointerFromConfig creates the Checkpointer requested by the configuration.
+// Returns nil, nil if the checkpointing is disabled.
+func NewCheckpointerFromConfig(lh logr.Logger, cfg *apiconfig.NodeResourceTopologyCache, client ctrlclient.Client) (Checkpointer, error) {
+	if cfg == nil || cfg.Checkpoint == nil {
+		return nil, nil
+	}
+	switch cfg.Checkpoint.Backend {
+	case "", apiconfig.CacheCheckpointNone:
+		return nil, nil
+	case apiconfig.CacheCheckpointFile:
+		lh.V(2).Info("checkpoint enabled", "backend", cfg.Checkpoint.Backend, "path", cfg.Checkpoint.Path)
+		return NewFileCheckpointer(cfg.Checkpoint.Path), nil
+	case apiconfig.CacheCheckpointConfigMap:
+		lh.V(2).Info("checkpoint enabled", "backend", cfg.Checkpoint.Backend, "namespace", cfg.Checkpoint.ConfigMapNamespace, "name", cfg.Checkpoint.ConfigMapName)
+		return NewConfigMapCheckpointer(client, cfg.Checkpoint.ConfigMapNamespace, cfg.Checkpoint.ConfigMapName), nil
+	default:
+		return nil, fmt.Errorf("unsupported checkpoint backend %q", cfg.Checkpoint.Backend)
+	}
+}
+
+// FileCheckpointer stores the checkpoint as JSON in a local file.
+type FileCheckpointer struct {
+	path string
+}
+
+func NewFileCheckpointer(path string) *FileCheckpointer {
+	return &FileCheckpointer{
+		path: path,
+	}
+}
+
+func (fc *FileCheckpointer) Save(ctx context.Context, ckpt *Checkpoint) error {
+	data, err := json.Marshal(ckpt)
+	if err != nil {
+		return err
+	}
+	// write and rename, so readers never observe a partially written checkpoint
+	tmp, err := os.CreateTemp(filepath.Dir(fc.path), filepath.Base(fc.path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name()) // no-op after a successful rename
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), fc.path)
+}
+
+func (fc *FileCheckpointer) Load(ctx context.Context) (*Checkpoint, error) {
+	data, err := os.ReadFile(fc.path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return decodeCheckpoint(data)
+}
+
+// ConfigMapCheckpointer stores the checkpoint as JSON in a ConfigMap.
+type ConfigMapCheckpointer struct {
+	client ctrlclient.Client
+	key    types.NamespacedName
+}
+
+func NewConfigMapCheckpointer(client ctrlclient.Client, namespace, name string) *ConfigMapCheckpointer {
+	return &ConfigMapCheckpointer{
+		client: client,
+		key: types.NamespacedName{
+			Namespace: namespace,
+			Name:      name,
+		},
+	}
+}
+
+func (cc *ConfigMapCheckpointer) Save(ctx context.Context, ckpt *Checkpoint) error {
+	data, err := json.Marshal(ckpt)
+	if err != nil {
+		return err
+	}
+
+	cm := corev1.ConfigMap{}
+	err = cc.client.Get(ctx, cc.key, &cm)
+	if apierrors.IsNotFound(err) {
+		cm = corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: cc.key.Namespace,
+				Name:      cc.key.Name,
+			},
+			Data: map[string]string{
+				CheckpointConfigMapKey: string(data),
+			},
+		}
+		return cc.client.Create(ctx, &cm)
+	}
+	if err != nil {
+		return err
+	}
+
+	if cm.Data == nil {
+		cm.Data = make(map[string]string)
+	}
+	cm.Data[CheckpointConfigMapKey] = string(data)
+	return cc.client.Update(ctx, &cm)
+}
+
+func (cc *ConfigMapCheckpointer) Load(ctx context.Context) (*Checkpoint, error) {
+	cm := corev1.ConfigMap{}
+	err := cc.client.Get(ctx, cc.key, &cm)
+	if apierrors.IsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	data, ok := cm.Data[CheckpointConfigMapKey]
+	if !ok {
+		return nil, nil
+	}
+	return decodeCheckpoint([]byte(data))
+}
+
+func decodeCheckpoint(data []byte) (*Checkpoint, error) {
+	ckpt := Checkpoint{}
+	if err := json.Unmarshal(data, &ckpt); err != nil {
+		return nil, fmt.Errorf("malformed checkpoint: %w", err)
+	}
+	return &ckpt, nil
+}
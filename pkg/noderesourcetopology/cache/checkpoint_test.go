@@ -0,0 +1,317 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cache
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog/v2"
+
+	apiconfig "sigs.k8s.io/scheduler-plugins/apis/config"
+	"sigs.k8s.io/scheduler-plugins/pkg/noderesourcetopology/podprovider"
+	tu "sigs.k8s.io/scheduler-plugins/test/util"
+)
+
+func makeTestCheckpoint() *Checkpoint {
+	return &Checkpoint{
+		Generation: 7,
+		AssumedResources: map[string]map[string]corev1.ResourceList{
+			"node1": {
+				"ns1/pod1": corev1.ResourceList{
+					corev1.ResourceCPU:    resource.MustParse("8"),
+					corev1.ResourceMemory: resource.MustParse("16Gi"),
+				},
+			},
+		},
+		NodesMaybeOverReserved: map[string]int{
+			"node1": 2,
+		},
+	}
+}
+
+func TestCheckpointerRoundTrip(t *testing.T) {
+	fakeClient, err := tu.NewFakeClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tcases := []struct {
+		name string
+		ckp  Checkpointer
+	}{
+		{
+			name: "file",
+			ckp:  NewFileCheckpointer(filepath.Join(t.TempDir(), "nrtcache.json")),
+		},
+		{
+			name: "configmap",
+			ckp:  NewConfigMapCheckpointer(fakeClient, "kube-system", "nrtcache-checkpoint"),
+		},
+	}
+
+	for _, tcase := range tcases {
+		t.Run(tcase.name, func(t *testing.T) {
+			ctx := context.Background()
+			got, err := tcase.ckp.Load(ctx)
+			if err != nil || got != nil {
+				t.Fatalf("expected no checkpoint before save, got %v err %v", got, err)
+			}
+
+			expected := makeTestCheckpoint()
+			// save twice to exercise both the create and the update flow
+			for i := 0; i < 2; i++ {
+				if err := tcase.ckp.Save(ctx, expected); err != nil {
+					t.Fatalf("save failed: %v", err)
+				}
+			}
+
+			got, err = tcase.ckp.Load(ctx)
+			if err != nil {
+				t.Fatalf("load failed: %v", err)
+			}
+			if !equality.Semantic.DeepEqual(got, expected) {
+				t.Errorf("checkpoint mismatch: got %+v expected %+v", got, expected)
+			}
+		})
+	}
+}
+
+func TestFileCheckpointerMalformed(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nrtcache.json")
+	if err := os.WriteFile(path, []byte("{not json"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	_, err := NewFileCheckpointer(path).Load(context.Background())
+	if err == nil {
+		t.Fatalf("expected error loading malformed checkpoint")
+	}
+}
+
+func TestNewCheckpointerFromConfig(t *testing.T) {
+	tcases := []struct {
+		name        string
+		cfg         *apiconfig.NodeResourceTopologyCache
+		expectNil   bool
+		expectedErr bool
+	}{
+		{
+			name:      "nil config",
+			expectNil: true,
+		},
+		{
+			name:      "unset checkpoint",
+			cfg:       &apiconfig.NodeResourceTopologyCache{},
+			expectNil: true,
+		},
+		{
+			name: "disabled",
+			cfg: &apiconfig.NodeResourceTopologyCache{
+				Checkpoint: &apiconfig.NodeResourceTopologyCacheCheckpoint{
+					Backend: apiconfig.CacheCheckpointNone,
+				},
+			},
+			expectNil: true,
+		},
+		{
+			name: "file",
+			cfg: &apiconfig.NodeResourceTopologyCache{
+				Checkpoint: &apiconfig.NodeResourceTopologyCacheCheckpoint{
+					Backend: apiconfig.CacheCheckpointFile,
+					Path:    "/var/lib/scheduler/nrtcache.json",
+				},
+			},
+		},
+		{
+			name: "unknown",
+			cfg: &apiconfig.NodeResourceTopologyCache{
+				Checkpoint: &apiconfig.NodeResourceTopologyCacheCheckpoint{
+					Backend: "Etcd",
+				},
+			},
+			expectNil:   true,
+			expectedErr: true,
+		},
+	}
+
+	for _, tcase := range tcases {
+		t.Run(tcase.name, func(t *testing.T) {
+			ckp, err := NewCheckpointerFromConfig(klog.Background(), tcase.cfg, nil)
+			if (err != nil) != tcase.expectedErr {
+				t.Fatalf("unexpected error state: %v", err)
+			}
+			if (ckp == nil) != tcase.expectNil {
+				t.Fatalf("unexpected checkpointer: %v", ckp)
+			}
+		})
+	}
+}
+
+func TestOverReserveCheckpointRestore(t *testing.T) {
+	fakeClient, err := tu.NewFakeClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	for _, obj := range makeDefaultTestTopology() {
+		if err := fakeClient.Create(ctx, obj); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cfg := &apiconfig.NodeResourceTopologyCache{
+		Checkpoint: &apiconfig.NodeResourceTopologyCacheCheckpoint{
+			Backend: apiconfig.CacheCheckpointFile,
+			Path:    filepath.Join(t.TempDir(), "nrtcache.json"),
+		},
+	}
+
+	nrtCache, err := NewOverReserve(ctx, klog.Background(), "", cfg, fakeClient, &fakePodLister{}, podprovider.IsPodRelevantAlways)
+	if err != nil {
+		t.Fatalf("unexpected error creating cache: %v", err)
+	}
+
+	testPod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "ns1",
+			Name:      "pod1",
+		},
+		Spec: corev1.PodSpec{
+			Containers: []corev1.Container{
+				{
+					Resources: corev1.ResourceRequirements{
+						Requests: corev1.ResourceList{
+							corev1.ResourceCPU:    resource.MustParse("8"),
+							corev1.ResourceMemory: resource.MustParse("16Gi"),
+						},
+					},
+				},
+			},
+		},
+	}
+	nrtCache.ReserveNodeResources("node1", testPod, nil)
+	nrtCache.SaveCheckpoint()
+
+	// simulate a restart: a new cache instance sharing the same checkpoint
+	restoredCache, err := NewOverReserve(ctx, klog.Background(), "", cfg, fakeClient, &fakePodLister{}, podprovider.IsPodRelevantAlways)
+	if err != nil {
+		t.Fatalf("unexpected error creating cache: %v", err)
+	}
+
+	nrtObj, _ := restoredCache.GetCachedNRTCopy(ctx, "node1", testPod)
+	for _, zone := range nrtObj.Zones {
+		for _, zoneRes := range zone.Resources {
+			switch zoneRes.Name {
+			case cpu:
+				if zoneRes.Available.Cmp(resource.MustParse("22")) != 0 {
+					t.Errorf("quantity mismatch in zone %q resource %q: %v", zone.Name, zoneRes.Name, zoneRes.Available)
+				}
+			case memory:
+				if zoneRes.Available.Cmp(resource.MustParse("44Gi")) != 0 {
+					t.Errorf("quantity mismatch in zone %q resource %q: %v", zone.Name, zoneRes.Name, zoneRes.Available)
+				}
+			}
+		}
+	}
+
+	dirtyNodes := restoredCache.GetDesyncedNodes(klog.Background())
+	if len(dirtyNodes.MaybeOverReserved) != 1 || dirtyNodes.MaybeOverReserved[0] != "node1" {
+		t.Errorf("restored node not marked for resync: %v", dirtyNodes.MaybeOverReserved)
+	}
+}
+
+func TestOverReserveCheckpointRestoreNUMAAssignment(t *testing.T) {
+	fakeClient, err := tu.NewFakeClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	for _, obj := range makeDefaultTestTopology() {
+		if err := fakeClient.Create(ctx, obj); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cfg := &apiconfig.NodeResourceTopologyCache{
+		Checkpoint: &apiconfig.NodeResourceTopologyCacheCheckpoint{
+			Backend: apiconfig.CacheCheckpointFile,
+			Path:    filepath.Join(t.TempDir(), "nrtcache.json"),
+		},
+	}
+
+	nrtCache, err := NewOverReserve(ctx, klog.Background(), "", cfg, fakeClient, &fakePodLister{}, podprovider.IsPodRelevantAlways)
+	if err != nil {
+		t.Fatalf("unexpected error creating cache: %v", err)
+	}
+
+	podRes := corev1.ResourceList{
+		corev1.ResourceCPU:    resource.MustParse("8"),
+		corev1.ResourceMemory: resource.MustParse("16Gi"),
+	}
+	testPod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "ns1",
+			Name:      "pod1",
+		},
+		Spec: corev1.PodSpec{
+			Containers: []corev1.Container{
+				{
+					Resources: corev1.ResourceRequirements{
+						Requests: podRes,
+					},
+				},
+			},
+		},
+	}
+	nrtCache.ReserveNodeResources("node1", testPod, NUMAAssignment{1: podRes})
+	nrtCache.SaveCheckpoint()
+
+	// simulate a restart: a new cache instance sharing the same checkpoint
+	restoredCache, err := NewOverReserve(ctx, klog.Background(), "", cfg, fakeClient, &fakePodLister{}, podprovider.IsPodRelevantAlways)
+	if err != nil {
+		t.Fatalf("unexpected error creating cache: %v", err)
+	}
+
+	expected := map[string]map[string]resource.Quantity{
+		"node-0": {
+			cpu:    resource.MustParse("30"),
+			memory: resource.MustParse("60Gi"),
+		},
+		"node-1": {
+			cpu:    resource.MustParse("22"),
+			memory: resource.MustParse("44Gi"),
+		},
+	}
+	nrtObj, _ := restoredCache.GetCachedNRTCopy(ctx, "node1", testPod)
+	for _, zone := range nrtObj.Zones {
+		for _, zoneRes := range zone.Resources {
+			qty, ok := expected[zone.Name][zoneRes.Name]
+			if !ok {
+				continue
+			}
+			if zoneRes.Available.Cmp(qty) != 0 {
+				t.Errorf("quantity mismatch in zone %q resource %q: %v expected %v", zone.Name, zoneRes.Name, zoneRes.Available, qty)
+			}
+		}
+	}
+}
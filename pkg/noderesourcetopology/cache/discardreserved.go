@@ -78,7 +78,7 @@ func (pt *DiscardReserved) GetCachedNRTCopy(ctx context.Context, nodeName string
 func (pt *DiscardReserved) NodeMaybeOverReserved(nodeName string, pod *corev1.Pod) {}
 func (pt *DiscardReserved) NodeHasForeignPods(nodeName string, pod *corev1.Pod)    {}
 
-func (pt *DiscardReserved) ReserveNodeResources(nodeName string, pod *corev1.Pod) {
+func (pt *DiscardReserved) ReserveNodeResources(nodeName string, pod *corev1.Pod, assignment NUMAAssignment) {
 	pt.lh.V(5).Info("NRT Reserve", logging.KeyPod, klog.KObj(pod), logging.KeyPodUID, logging.PodUID(pod), logging.KeyNode, nodeName)
 	pt.rMutex.Lock()
 	defer pt.rMutex.Unlock()
@@ -95,6 +95,8 @@ func (pt *DiscardReserved) UnreserveNodeResources(nodeName string, pod *corev1.P
 	pt.removeReservationForNode(nodeName, pod)
 }
 
+func (pt *DiscardReserved) PreBind(nodeName string, pod *corev1.Pod) {}
+
 // PostBind is invoked to cleanup reservationMap
 func (pt *DiscardReserved) PostBind(nodeName string, pod *corev1.Pod) {
 	pt.lh.V(5).Info("NRT PostBind", logging.KeyPod, klog.KObj(pod), logging.KeyPodUID, logging.PodUID(pod), logging.KeyNode, nodeName)
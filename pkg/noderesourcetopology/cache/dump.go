@@ -0,0 +1,104 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cache
+
+import (
+	"sort"
+	"time"
+
+	topologyv1alpha2 "github.com/k8stopologyawareschedwg/noderesourcetopology-api/pkg/apis/topology/v1alpha2"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// ResyncResult describes the outcome of the last resync attempt of a node.
+type ResyncResult struct {
+	Time    time.Time `json:"time"`
+	Trigger string    `json:"trigger"`
+	Success bool      `json:"success"`
+	Error   string    `json:"error,omitempty"`
+}
+
+// NodeDump is a point-in-time snapshot of what the cache knows about a node. Meant for troubleshooting only.
+type NodeDump struct {
+	NodeName string `json:"nodeName"`
+	// Zones are the cached NRT zones, without the assumed resources deducted
+	Zones topologyv1alpha2.ZoneList `json:"zones,omitempty"`
+	// ExpectedFingerprint is the podset fingerprint reported by the cached NRT object
+	ExpectedFingerprint string `json:"expectedFingerprint,omitempty"`
+	// AssumedResources maps pod key (namespace/name) -> resources assumed for the pod
+	AssumedResources map[string]corev1.ResourceList `json:"assumedResources,omitempty"`
+	// DirtyCount is how many times the node was filtered out since the last flush
+	DirtyCount       int `json:"dirtyCount"`
+	ForeignPodsCount int `json:"foreignPodsCount"`
+	// ForeignReservationsCount is how many foreign pods are accounted like reservations since the last flush
+	ForeignReservationsCount int           `json:"foreignReservationsCount"`
+	ConfigChanged            bool          `json:"configChanged"`
+	LastResync               *ResyncResult `json:"lastResync,omitempty"`
+}
+
+// Dumper is implemented by the caches which can expose their internal state.
+type Dumper interface {
+	// DumpNodeNames returns the sorted names of all the nodes known to the cache.
+	DumpNodeNames() []string
+	// DumpNode returns the snapshot of a node state. Returns false if the node is not known to the cache.
+	DumpNode(nodeName string) (NodeDump, bool)
+}
+
+var _ Dumper = &OverReserve{}
+
+func (ov *OverReserve) DumpNodeNames() []string {
+	ov.lock.Lock()
+	defer ov.lock.Unlock()
+	names := make([]string, 0, len(ov.nrts.data))
+	for nodeName := range ov.nrts.data {
+		names = append(names, nodeName)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (ov *OverReserve) DumpNode(nodeName string) (NodeDump, bool) {
+	ov.lock.Lock()
+	defer ov.lock.Unlock()
+
+	nrt, ok := ov.nrts.data[nodeName]
+	if !ok {
+		return NodeDump{}, false
+	}
+
+	dump := NodeDump{
+		NodeName:                 nodeName,
+		Zones:                    nrt.Zones.DeepCopy(),
+		DirtyCount:               ov.nodesMaybeOverreserved[nodeName],
+		ForeignPodsCount:         ov.nodesWithForeignPods[nodeName],
+		ForeignReservationsCount: ov.nodesWithForeignReservations[nodeName],
+		ConfigChanged:            ov.nodesWithAttrUpdate.IsSet(nodeName),
+	}
+	dump.ExpectedFingerprint, _ = podFingerprintForNodeTopology(nrt, ov.resyncMethod)
+
+	if rs, ok := ov.assumedResources[nodeName]; ok && len(rs.data) > 0 {
+		dump.AssumedResources = make(map[string]corev1.ResourceList, len(rs.data))
+		for podKey, res := range rs.data {
+			dump.AssumedResources[podKey] = res.DeepCopy()
+		}
+	}
+	if res, ok := ov.lastResync[nodeName]; ok {
+		dump.LastResync = &res
+	}
+	return dump, true
+}
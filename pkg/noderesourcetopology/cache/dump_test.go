@@ -0,0 +1,69 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cache
+
+import (
+	"reflect"
+	"testing"
+
+	tu "sigs.k8s.io/scheduler-plugins/test/util"
+)
+
+func TestOverReserveDumpNode(t *testing.T) {
+	fakeClient, err := tu.NewFakeClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	nrtCache := mustOverReserve(t, fakeClient, &fakePodLister{})
+	nrtCache.Store().Update(makeResyncTestNRT())
+
+	if names := nrtCache.DumpNodeNames(); !reflect.DeepEqual(names, []string{"node1"}) {
+		t.Fatalf("unexpected node names: %v", names)
+	}
+
+	if _, ok := nrtCache.DumpNode("node2"); ok {
+		t.Fatalf("unexpected dump for unknown node")
+	}
+
+	testPod := makeResyncTestPod()
+	nrtCache.ReserveNodeResources("node1", testPod, nil)
+	nrtCache.NodeMaybeOverReserved("node1", testPod)
+	nrtCache.NodeMaybeOverReserved("node1", testPod)
+	// no pods known to the lister, so the resync must fail
+	nrtCache.Resync()
+
+	dump, ok := nrtCache.DumpNode("node1")
+	if !ok {
+		t.Fatalf("missing dump for known node")
+	}
+	if dump.ExpectedFingerprint != "pfp0v0019e0420efb37746c6" {
+		t.Errorf("unexpected fingerprint: %q", dump.ExpectedFingerprint)
+	}
+	if dump.DirtyCount != 2 {
+		t.Errorf("unexpected dirty count: %d", dump.DirtyCount)
+	}
+	if len(dump.Zones) != 2 {
+		t.Errorf("unexpected zones: %v", dump.Zones)
+	}
+	if _, ok := dump.AssumedResources["namespace1/pod1"]; !ok || len(dump.AssumedResources) != 1 {
+		t.Errorf("unexpected assumed resources: %v", dump.AssumedResources)
+	}
+	if dump.LastResync == nil || dump.LastResync.Success || dump.LastResync.Error == "" {
+		t.Errorf("unexpected last resync: %+v", dump.LastResync)
+	}
+}
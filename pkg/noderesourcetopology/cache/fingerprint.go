@@ -0,0 +1,67 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cache
+
+import (
+	"github.com/go-logr/logr"
+	topologyv1alpha2 "github.com/k8stopologyawareschedwg/noderesourcetopology-api/pkg/apis/topology/v1alpha2"
+	"github.com/k8stopologyawareschedwg/podfingerprint"
+
+	corev1 "k8s.io/api/core/v1"
+
+	apiconfig "sigs.k8s.io/scheduler-plugins/apis/config"
+	"sigs.k8s.io/scheduler-plugins/pkg/noderesourcetopology/podprovider"
+)
+
+// FingerprintVerification is the outcome of the comparison between the podset fingerprint reported
+// in a NRT object and the one computed from the pods the scheduler expects running on the node.
+type FingerprintVerification struct {
+	NodeName string `json:"nodeName"`
+	// Expected is the fingerprint reported in the NRT object. Empty if missing.
+	Expected string `json:"expected,omitempty"`
+	// Computed is the fingerprint computed from the pods the scheduler expects running on the node
+	Computed               string `json:"computed"`
+	OnlyExclusiveResources bool   `json:"onlyExclusiveResources"`
+	// Pods are the pods used to compute the fingerprint, in the order they were processed
+	Pods  []podfingerprint.NamespacedName `json:"pods,omitempty"`
+	Match bool                            `json:"match"`
+}
+
+// VerifyNodeFingerprint checks the podset fingerprint of the given NRT object against the given pods,
+// selecting and processing them exactly like the cache does on resync. Pods running on other nodes are ignored.
+func VerifyNodeFingerprint(lh logr.Logger, nrt *topologyv1alpha2.NodeResourceTopology, pods []*corev1.Pod, method apiconfig.CacheResyncMethod, isPodRelevant podprovider.PodFilterFunc) FingerprintVerification {
+	ver := FingerprintVerification{
+		NodeName: nrt.Name,
+	}
+	ver.Expected, ver.OnlyExclusiveResources = podFingerprintForNodeTopology(nrt, method)
+
+	var objs []podData
+	for _, pod := range pods {
+		if pod.Spec.NodeName != nrt.Name || !isPodRelevant(lh, pod) {
+			continue
+		}
+		objs = append(objs, makePodData(pod))
+	}
+
+	st := podfingerprint.MakeStatus(nrt.Name)
+	pfp := fingerprintPodData(objs, ver.OnlyExclusiveResources, &st)
+	ver.Computed = pfp.Sign()
+	ver.Pods = st.Pods
+	ver.Match = ver.Expected != "" && pfp.Check(ver.Expected) == nil
+	lh.V(4).Info("podset fingerprint verification", "node", nrt.Name, "expected", ver.Expected, "computed", ver.Computed, "onlyExclusiveResources", ver.OnlyExclusiveResources)
+	return ver
+}
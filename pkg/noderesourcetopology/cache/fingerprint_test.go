@@ -0,0 +1,105 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cache
+
+import (
+	"testing"
+
+	topologyv1alpha2 "github.com/k8stopologyawareschedwg/noderesourcetopology-api/pkg/apis/topology/v1alpha2"
+	"github.com/k8stopologyawareschedwg/podfingerprint"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog/v2"
+
+	apiconfig "sigs.k8s.io/scheduler-plugins/apis/config"
+	"sigs.k8s.io/scheduler-plugins/pkg/noderesourcetopology/podprovider"
+)
+
+func TestVerifyNodeFingerprint(t *testing.T) {
+	makeRunningPod := func(nodeName, name string, phase corev1.PodPhase) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: name},
+			Spec:       corev1.PodSpec{NodeName: nodeName},
+			Status:     corev1.PodStatus{Phase: phase},
+		}
+	}
+	fingerprintOf := func(names ...string) string {
+		pfp := podfingerprint.NewFingerprint(len(names))
+		for _, name := range names {
+			pfp.Add("ns", name)
+		}
+		return pfp.Sign()
+	}
+	makeNRT := func(pfp string) *topologyv1alpha2.NodeResourceTopology {
+		nrt := &topologyv1alpha2.NodeResourceTopology{
+			ObjectMeta: metav1.ObjectMeta{Name: "node1"},
+		}
+		if pfp != "" {
+			nrt.Attributes = topologyv1alpha2.AttributeList{
+				{Name: podfingerprint.Attribute, Value: pfp},
+			}
+		}
+		return nrt
+	}
+
+	pods := []*corev1.Pod{
+		makeRunningPod("node1", "pod-0", corev1.PodRunning),
+		makeRunningPod("node1", "pod-1", corev1.PodRunning),
+		makeRunningPod("node1", "pod-2", corev1.PodPending),
+		makeRunningPod("node2", "pod-3", corev1.PodRunning),
+	}
+
+	tcases := []struct {
+		description  string
+		nrt          *topologyv1alpha2.NodeResourceTopology
+		expectedPods int
+		expectMatch  bool
+	}{
+		{
+			description:  "matching fingerprint",
+			nrt:          makeNRT(fingerprintOf("pod-0", "pod-1")),
+			expectedPods: 2,
+			expectMatch:  true,
+		},
+		{
+			description:  "exporter reporting a pod not relevant for the scheduler",
+			nrt:          makeNRT(fingerprintOf("pod-0", "pod-1", "pod-2")),
+			expectedPods: 2,
+		},
+		{
+			description:  "missing fingerprint",
+			nrt:          makeNRT(""),
+			expectedPods: 2,
+		},
+	}
+
+	for _, tcase := range tcases {
+		t.Run(tcase.description, func(t *testing.T) {
+			ver := VerifyNodeFingerprint(klog.Background(), tcase.nrt, pods, apiconfig.CacheResyncAll, podprovider.IsPodRelevantDedicated)
+			if ver.Match != tcase.expectMatch {
+				t.Errorf("unexpected match: got %v expected %v", ver.Match, tcase.expectMatch)
+			}
+			if len(ver.Pods) != tcase.expectedPods {
+				t.Errorf("unexpected pods: got %v expected %d", ver.Pods, tcase.expectedPods)
+			}
+			if ver.Computed != fingerprintOf("pod-0", "pod-1") {
+				t.Errorf("unexpected computed fingerprint %q", ver.Computed)
+			}
+		})
+	}
+}
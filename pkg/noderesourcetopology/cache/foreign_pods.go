@@ -29,24 +29,48 @@ import (
 	"sigs.k8s.io/scheduler-plugins/pkg/noderesourcetopology/resourcerequests"
 )
 
-// The nodeIndexer and the go client facilities are global objects, so we need this to be global as well.
-// Each profile must register its name in order to support foreign pods detection. Foreign pods are pods
-// which are scheduled to nodes without the caching machinery knowning.
-// Note this is NOT lock-protected because the scheduling framework calls New() sequentially,
-// and plugin instances are meant to register their name using SetupForeignPodsDetector inside their New()
-var (
-	schedProfileNames      = sets.String{}
-	onlyExclusiveResources = false
-)
+// ForeignPodsDetector tells apart the foreign pods, which are pods scheduled to nodes without
+// the caching machinery knowing, from the pods scheduled by the profiles it was created for.
+// Each plugin instance owns its detector, so instances with different settings don't interfere.
+// The detector is immutable once created, so it is safe to use concurrently.
+type ForeignPodsDetector struct {
+	profileNames           sets.Set[string]
+	onlyExclusiveResources bool
+}
+
+func NewForeignPodsDetector(lh logr.Logger, onlyExclusiveResources bool, schedProfileNames ...string) *ForeignPodsDetector {
+	fd := &ForeignPodsDetector{
+		profileNames:           sets.New[string](schedProfileNames...),
+		onlyExclusiveResources: onlyExclusiveResources,
+	}
+	lh.Info("setting up detection", "profiles", sets.List(fd.profileNames), "onlyExclusiveResources", onlyExclusiveResources)
+	return fd
+}
+
+func (fd *ForeignPodsDetector) IsForeignPod(pod *corev1.Pod) bool {
+	if pod.Spec.NodeName == "" {
+		// nothing to do yet
+		return false
+	}
+	if fd.profileNames.Has(pod.Spec.SchedulerName) {
+		// nothing to do here - we know already about this pod
+		return false
+	}
+	if !fd.onlyExclusiveResources {
+		return true
+	}
+	return resourcerequests.AreExclusiveForPod(pod)
+}
 
-func SetupForeignPodsDetector(lh logr.Logger, schedProfileName string, podInformer k8scache.SharedInformer, cc Interface) {
+// SetupNotifier makes the cache aware of the nodes running foreign pods.
+func (fd *ForeignPodsDetector) SetupNotifier(lh logr.Logger, podInformer k8scache.SharedInformer, cc Interface) {
 	foreignCache := func(obj interface{}) {
 		pod, ok := obj.(*corev1.Pod)
 		if !ok {
 			lh.V(3).Info("unsupported object", "kind", fmt.Sprintf("%T", obj))
 			return
 		}
-		if !IsForeignPod(pod) {
+		if !fd.IsForeignPod(pod) {
 			return
 		}
 
@@ -63,37 +87,51 @@ func SetupForeignPodsDetector(lh logr.Logger, schedProfileName string, podInform
 	})
 }
 
-func TrackOnlyForeignPodsWithExclusiveResources() {
-	onlyExclusiveResources = true
+// ForeignPodsReconciler is implemented by the caches which can account the resources of the foreign pods.
+type ForeignPodsReconciler interface {
+	// ReconcileForeignPod accounts the resources of a foreign pod running on the given node.
+	ReconcileForeignPod(nodeName string, pod *corev1.Pod)
+	// ForgetForeignPod drops the accounting of a foreign pod which is gone.
+	ForgetForeignPod(nodeName string, pod *corev1.Pod)
 }
 
-func TrackAllForeignPods() {
-	onlyExclusiveResources = false
-}
-
-func RegisterSchedulerProfileName(lh logr.Logger, schedProfileName string) {
-	lh.Info("setting up detection", "profile", schedProfileName)
-	schedProfileNames.Insert(schedProfileName)
-
-	lh.V(5).Info("registered scheduler profiles", "names", schedProfileNames.List())
-}
+// SetupReconciler is like SetupNotifier, but lets the cache account the resources
+// of the foreign pods instead of marking the nodes running them as dirty.
+func (fd *ForeignPodsDetector) SetupReconciler(lh logr.Logger, podInformer k8scache.SharedInformer, fr ForeignPodsReconciler) {
+	reconcile := func(obj interface{}) {
+		pod, ok := obj.(*corev1.Pod)
+		if !ok {
+			lh.V(3).Info("unsupported object", "kind", fmt.Sprintf("%T", obj))
+			return
+		}
+		if !fd.IsForeignPod(pod) {
+			return
+		}
 
-func IsForeignPod(pod *corev1.Pod) bool {
-	if pod.Spec.NodeName == "" {
-		// nothing to do yet
-		return false
+		fr.ReconcileForeignPod(pod.Spec.NodeName, pod)
+		lh.V(6).Info("reconciled foreign pod", logging.KeyPod, klog.KObj(pod), logging.KeyPodUID, logging.PodUID(pod), logging.KeyNode, pod.Spec.NodeName)
 	}
-	if schedProfileNames.Has(pod.Spec.SchedulerName) {
-		// nothing to do here - we know already about this pod
-		return false
-	}
-	if !onlyExclusiveResources {
-		return true
-	}
-	return resourcerequests.AreExclusiveForPod(pod)
-}
 
-// for testing only; NOT thread safe
-func CleanRegisteredSchedulerProfileNames() {
-	schedProfileNames = sets.String{}
+	podInformer.AddEventHandler(k8scache.ResourceEventHandlerFuncs{
+		AddFunc: reconcile,
+		UpdateFunc: func(oldObj, newObj interface{}) {
+			reconcile(newObj)
+		},
+		DeleteFunc: func(obj interface{}) {
+			if tombstone, ok := obj.(k8scache.DeletedFinalStateUnknown); ok {
+				obj = tombstone.Obj
+			}
+			pod, ok := obj.(*corev1.Pod)
+			if !ok {
+				lh.V(3).Info("unsupported object", "kind", fmt.Sprintf("%T", obj))
+				return
+			}
+			if !fd.IsForeignPod(pod) {
+				return
+			}
+
+			fr.ForgetForeignPod(pod.Spec.NodeName, pod)
+			lh.V(6).Info("forgot foreign pod", logging.KeyPod, klog.KObj(pod), logging.KeyPodUID, logging.PodUID(pod), logging.KeyNode, pod.Spec.NodeName)
+		},
+	})
 }
@@ -195,15 +195,52 @@ func TestIsForeignPod(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			for _, profileName := range tt.profileNames {
-				RegisterSchedulerProfileName(klog.Background(), profileName)
-			}
-			defer CleanRegisteredSchedulerProfileNames()
+			fd := NewForeignPodsDetector(klog.Background(), false, tt.profileNames...)
 
-			got := IsForeignPod(tt.pod)
+			got := fd.IsForeignPod(tt.pod)
 			if got != tt.expected {
 				t.Errorf("%s: pod %q foreign status got %v expected %v", tt.name, tt.pod.Name, got, tt.expected)
 			}
 		})
 	}
 }
+
+func TestForeignPodsDetectorsAreIndependent(t *testing.T) {
+	// burstable pod scheduled by the default scheduler: no exclusive resources
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "pod",
+			Namespace: "default",
+		},
+		Spec: corev1.PodSpec{
+			NodeName:      "random-node",
+			SchedulerName: "default-scheduler",
+			Containers: []corev1.Container{
+				{
+					Name: "cnt",
+					Resources: corev1.ResourceRequirements{
+						Requests: corev1.ResourceList{
+							corev1.ResourceCPU:    resource.MustParse("1"),
+							corev1.ResourceMemory: resource.MustParse("1Gi"),
+						},
+					},
+				},
+			},
+		},
+	}
+
+	fdAll := NewForeignPodsDetector(klog.Background(), false, "profile-all")
+	fdExcl := NewForeignPodsDetector(klog.Background(), true, "profile-exclusive")
+
+	if !fdAll.IsForeignPod(pod) {
+		t.Errorf("pod not foreign for the detector tracking all pods")
+	}
+	if fdExcl.IsForeignPod(pod) {
+		t.Errorf("pod foreign for the detector tracking only exclusive resources")
+	}
+
+	pod.Spec.SchedulerName = "profile-exclusive"
+	if !fdAll.IsForeignPod(pod) {
+		t.Errorf("pod scheduled by another profile not foreign for %q", "profile-all")
+	}
+}
@@ -0,0 +1,191 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cache
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+type gangMember struct {
+	nodeName string
+	pod      *corev1.Pod
+}
+
+// gangReservation tracks the reservations of the members of a PodGroup (gang).
+// Until the first member is permitted, the gang can still be rejected as whole (e.g. on Permit timeout),
+// and then all its reservations are released together.
+type gangReservation struct {
+	// key: namespace + "/" name
+	members map[string]gangMember
+	// membersPerNode counts the members reserved on each node
+	membersPerNode map[string]int
+	// nodesMarked are the nodes marked maybe over-reserved while holding reservations of this gang
+	nodesMarked sets.Set[string]
+	committed   bool
+}
+
+// gangStore tracks the gang reservations. Not thread safe, the caller must serialize the access.
+type gangStore struct {
+	// key: namespace + "/" PodGroup name
+	gangs map[string]*gangReservation
+	// gangsPerNode indexes the gangs holding reservations on each node,
+	// so marking a node doesn't need to scan all the gangs.
+	gangsPerNode map[string]sets.Set[string]
+}
+
+func newGangStore() *gangStore {
+	return &gangStore{
+		gangs:        make(map[string]*gangReservation),
+		gangsPerNode: make(map[string]sets.Set[string]),
+	}
+}
+
+func (gs *gangStore) AddPod(gangName, nodeName string, pod *corev1.Pod) {
+	gr, ok := gs.gangs[gangName]
+	if !ok {
+		gr = &gangReservation{
+			members:        make(map[string]gangMember),
+			membersPerNode: make(map[string]int),
+			nodesMarked:    sets.New[string](),
+		}
+		gs.gangs[gangName] = gr
+	}
+	key := pod.Namespace + "/" + pod.Name
+	if member, ok := gr.members[key]; ok {
+		gs.untrackMember(gangName, gr, member.nodeName)
+	}
+	gr.members[key] = gangMember{
+		nodeName: nodeName,
+		pod:      pod,
+	}
+	gs.trackMember(gangName, gr, nodeName)
+}
+
+// MarkNode records the given node was marked maybe over-reserved while holding reservations of one or more gangs
+// not committed yet, which may have been the reason why the node was filtered out.
+func (gs *gangStore) MarkNode(nodeName string) {
+	for gangName := range gs.gangsPerNode[nodeName] {
+		gr := gs.gangs[gangName]
+		if gr.committed {
+			continue
+		}
+		gr.nodesMarked.Insert(nodeName)
+	}
+}
+
+// CommitPod records a member of the gang is permitted, so the gang can't be released as whole anymore:
+// the siblings permitted along with it may be binding already.
+func (gs *gangStore) CommitPod(gangName string, pod *corev1.Pod) {
+	gr, ok := gs.gangs[gangName]
+	if !ok {
+		return
+	}
+	gr.committed = true
+	gs.deleteMember(gangName, gr, pod)
+}
+
+// ReleasePod returns the reservations to drop because of the unreserve of the given pod, and the nodes whose
+// maybe over-reserved mark can be cleared because of them. If the gang is not committed yet, these are the reservations
+// of all its members, otherwise only the reservation of the given pod. Returns false if the pod is not tracked.
+func (gs *gangStore) ReleasePod(gangName string, pod *corev1.Pod) ([]gangMember, sets.Set[string], bool) {
+	gr, ok := gs.gangs[gangName]
+	if !ok {
+		return nil, nil, false
+	}
+	member, ok := gr.members[pod.Namespace+"/"+pod.Name]
+	if !ok {
+		return nil, nil, false
+	}
+	if gr.committed {
+		gs.deleteMember(gangName, gr, pod)
+		return []gangMember{member}, nil, true
+	}
+	delete(gs.gangs, gangName)
+	for nodeName := range gr.membersPerNode {
+		gs.unindexGang(gangName, nodeName)
+	}
+	members := make([]gangMember, 0, len(gr.members))
+	for _, member := range gr.members {
+		members = append(members, member)
+	}
+	// the mark can be cleared only if the released reservations were on the node, and if no other gang
+	// which held reservations on the node when it was marked still holds them.
+	nodesCleared := sets.New[string]()
+	for nodeName := range gr.nodesMarked {
+		if gr.holdsNode(nodeName) && !gs.isNodeMarked(nodeName) {
+			nodesCleared.Insert(nodeName)
+		}
+	}
+	return members, nodesCleared, true
+}
+
+func (gs *gangStore) isNodeMarked(nodeName string) bool {
+	for gangName := range gs.gangsPerNode[nodeName] {
+		if gs.gangs[gangName].nodesMarked.Has(nodeName) {
+			return true
+		}
+	}
+	return false
+}
+
+func (gs *gangStore) deleteMember(gangName string, gr *gangReservation, pod *corev1.Pod) {
+	key := pod.Namespace + "/" + pod.Name
+	member, ok := gr.members[key]
+	if !ok {
+		return
+	}
+	delete(gr.members, key)
+	gs.untrackMember(gangName, gr, member.nodeName)
+	if len(gr.members) == 0 {
+		delete(gs.gangs, gangName)
+	}
+}
+
+func (gs *gangStore) trackMember(gangName string, gr *gangReservation, nodeName string) {
+	gr.membersPerNode[nodeName]++
+	gangNames, ok := gs.gangsPerNode[nodeName]
+	if !ok {
+		gangNames = sets.New[string]()
+		gs.gangsPerNode[nodeName] = gangNames
+	}
+	gangNames.Insert(gangName)
+}
+
+func (gs *gangStore) untrackMember(gangName string, gr *gangReservation, nodeName string) {
+	gr.membersPerNode[nodeName]--
+	if gr.membersPerNode[nodeName] > 0 {
+		return
+	}
+	delete(gr.membersPerNode, nodeName)
+	gs.unindexGang(gangName, nodeName)
+}
+
+func (gs *gangStore) unindexGang(gangName, nodeName string) {
+	gangNames, ok := gs.gangsPerNode[nodeName]
+	if !ok {
+		return
+	}
+	gangNames.Delete(gangName)
+	if gangNames.Len() == 0 {
+		delete(gs.gangsPerNode, nodeName)
+	}
+}
+
+func (gr *gangReservation) holdsNode(nodeName string) bool {
+	return gr.membersPerNode[nodeName] > 0
+}
@@ -0,0 +1,116 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cache
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+func TestGangStoreReleasePodNodesCleared(t *testing.T) {
+	makePod := func(name string) *corev1.Pod {
+		return &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: name}}
+	}
+
+	testCases := []struct {
+		name                 string
+		otherGangNode        string
+		expectedNodesCleared sets.Set[string]
+	}{
+		{
+			name:                 "nodes holding the released reservations are cleared",
+			expectedNodesCleared: sets.New[string]("node1", "node2"),
+		},
+		{
+			name:                 "nodes holding reservations of other gangs are not cleared",
+			otherGangNode:        "node2",
+			expectedNodesCleared: sets.New[string]("node1"),
+		},
+		{
+			name:                 "nodes not holding the released reservations are not cleared",
+			otherGangNode:        "node3",
+			expectedNodesCleared: sets.New[string]("node1", "node2"),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			gs := newGangStore()
+			gs.AddPod("ns/pg1", "node1", makePod("pg1-0"))
+			gs.AddPod("ns/pg1", "node2", makePod("pg1-1"))
+			if tc.otherGangNode != "" {
+				gs.AddPod("ns/pg2", tc.otherGangNode, makePod("pg2-0"))
+			}
+			for _, nodeName := range []string{"node1", "node2", "node3"} {
+				gs.MarkNode(nodeName)
+			}
+
+			members, nodesCleared, ok := gs.ReleasePod("ns/pg1", makePod("pg1-0"))
+			if !ok {
+				t.Fatalf("pod not tracked")
+			}
+			if len(members) != 2 {
+				t.Errorf("expected 2 members released, got %d", len(members))
+			}
+			if !nodesCleared.Equal(tc.expectedNodesCleared) {
+				t.Errorf("got=%v expected=%v", sets.List(nodesCleared), sets.List(tc.expectedNodesCleared))
+			}
+		})
+	}
+}
+
+func TestGangStoreNodeIndex(t *testing.T) {
+	makePod := func(name string) *corev1.Pod {
+		return &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: name}}
+	}
+
+	gs := newGangStore()
+	gs.AddPod("ns/pg1", "node1", makePod("pg1-0"))
+	gs.AddPod("ns/pg1", "node1", makePod("pg1-1"))
+	gs.AddPod("ns/pg2", "node1", makePod("pg2-0"))
+	gs.AddPod("ns/pg2", "node2", makePod("pg2-1"))
+	if got := gs.gangsPerNode["node1"]; !got.Equal(sets.New[string]("ns/pg1", "ns/pg2")) {
+		t.Fatalf("unexpected gangs on node1: %v", sets.List(got))
+	}
+
+	// the committed gangs don't get the mark
+	gs.CommitPod("ns/pg2", makePod("pg2-1"))
+	if _, ok := gs.gangsPerNode["node2"]; ok {
+		t.Errorf("node2 still indexed after its only member was committed")
+	}
+	gs.MarkNode("node1")
+	if !gs.gangs["ns/pg1"].nodesMarked.Has("node1") || gs.gangs["ns/pg2"].nodesMarked.Has("node1") {
+		t.Errorf("unexpected marks: pg1=%v pg2=%v", sets.List(gs.gangs["ns/pg1"].nodesMarked), sets.List(gs.gangs["ns/pg2"].nodesMarked))
+	}
+
+	// a member moved to another node is indexed only on the new one
+	gs.AddPod("ns/pg1", "node3", makePod("pg1-1"))
+	if !gs.gangs["ns/pg1"].holdsNode("node1") || !gs.gangs["ns/pg1"].holdsNode("node3") {
+		t.Errorf("unexpected nodes held by pg1: %v", gs.gangs["ns/pg1"].membersPerNode)
+	}
+
+	if _, _, ok := gs.ReleasePod("ns/pg1", makePod("pg1-0")); !ok {
+		t.Fatalf("pod not tracked")
+	}
+	gs.CommitPod("ns/pg2", makePod("pg2-0"))
+	if len(gs.gangs) != 0 || len(gs.gangsPerNode) != 0 {
+		t.Errorf("leftover gangs=%v index=%v", gs.gangs, gs.gangsPerNode)
+	}
+}
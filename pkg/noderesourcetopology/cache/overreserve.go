@@ -21,14 +21,17 @@ import (
 	"errors"
 	"fmt"
 	"sync"
+	"time"
 
 	"github.com/go-logr/logr"
 	topologyv1alpha2 "github.com/k8stopologyawareschedwg/noderesourcetopology-api/pkg/apis/topology/v1alpha2"
 	"github.com/k8stopologyawareschedwg/podfingerprint"
 
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
 	podlisterv1 "k8s.io/client-go/listers/core/v1"
 	"k8s.io/klog/v2"
 
@@ -36,13 +39,22 @@ import (
 
 	apiconfig "sigs.k8s.io/scheduler-plugins/apis/config"
 	"sigs.k8s.io/scheduler-plugins/pkg/noderesourcetopology/logging"
+	"sigs.k8s.io/scheduler-plugins/pkg/noderesourcetopology/metrics"
 	"sigs.k8s.io/scheduler-plugins/pkg/noderesourcetopology/podprovider"
 	"sigs.k8s.io/scheduler-plugins/pkg/noderesourcetopology/resourcerequests"
 	"sigs.k8s.io/scheduler-plugins/pkg/noderesourcetopology/stringify"
+	"sigs.k8s.io/scheduler-plugins/pkg/util"
+)
+
+var (
+	errNoPodsForNode      = errors.New("cannot find any pod for node")
+	errMissingFingerprint = errors.New("missing NodeTopology podset fingerprint data")
+	errResizesPending     = errors.New("NodeTopology not updated since the pods were resized")
 )
 
 type OverReserve struct {
 	lh               logr.Logger
+	profileName      string // used only to label the metrics
 	client           ctrlclient.Reader
 	lock             sync.Mutex
 	generation       uint64
@@ -52,14 +64,31 @@ type OverReserve struct {
 	// to resync nodes. See The documentation of Resync() below for more details.
 	nodesMaybeOverreserved counter
 	nodesWithForeignPods   counter
-	nodesWithAttrUpdate    counter
-	podLister              podlisterv1.PodLister
-	resyncMethod           apiconfig.CacheResyncMethod
-	resyncScope            apiconfig.CacheResyncScope
-	isPodRelevant          podprovider.PodFilterFunc
+	// nodesWithForeignReservations counts the foreign pods accounted like reservations. Unlike nodesWithForeignPods,
+	// these nodes can still be used, but they need a resync to confirm the pessimistic accounting.
+	nodesWithForeignReservations counter
+	// foreignPods tracks the foreign pods already accounted, by node, to not account them again
+	// on pod updates, even after the node is flushed.
+	foreignPods map[string]sets.Set[string]
+	// gangs tracks the reservations of the PodGroup members, to release them together if the group is rejected.
+	gangs *gangStore
+	// resizes tracks, by node, the pods resized in place after the NRT data accounted them.
+	resizes             map[string]*nodeResizes
+	nodesWithAttrUpdate counter
+	podLister           podlisterv1.PodLister
+	resyncMethod        apiconfig.CacheResyncMethod
+	resyncScope         apiconfig.CacheResyncScope
+	isPodRelevant       podprovider.PodFilterFunc
+	checkpointer        Checkpointer
+	// lastResync tracks the outcome of the last resync attempt per node. Used only for troubleshooting.
+	lastResync map[string]ResyncResult
+	// resyncer is not nil only if the event-driven resync is enabled
+	resyncer *nodeResyncer
+	// lastCheckpoint is the last checkpoint successfully saved. Accessed only by SaveCheckpoint.
+	lastCheckpoint *Checkpoint
 }
 
-func NewOverReserve(ctx context.Context, lh logr.Logger, cfg *apiconfig.NodeResourceTopologyCache, client ctrlclient.WithWatch, podLister podlisterv1.PodLister, isPodRelevant podprovider.PodFilterFunc) (*OverReserve, error) {
+func NewOverReserve(ctx context.Context, lh logr.Logger, profileName string, cfg *apiconfig.NodeResourceTopologyCache, client ctrlclient.WithWatch, podLister podlisterv1.PodLister, isPodRelevant podprovider.PodFilterFunc) (*OverReserve, error) {
 	if client == nil || podLister == nil {
 		return nil, fmt.Errorf("received nil references")
 	}
@@ -71,26 +100,61 @@ func NewOverReserve(ctx context.Context, lh logr.Logger, cfg *apiconfig.NodeReso
 
 	resyncMethod := getCacheResyncMethod(lh, cfg)
 	resyncScope := getCacheResyncScope(lh, cfg)
+	resyncTrigger := getCacheResyncTrigger(lh, cfg)
+
+	checkpointer, err := NewCheckpointerFromConfig(lh, cfg, client)
+	if err != nil {
+		return nil, err
+	}
 
-	lh.V(2).Info("initializing", "noderesourcetopologies", len(nrtObjs.Items), "method", resyncMethod, "scope", resyncScope)
+	lh.V(2).Info("initializing", "noderesourcetopologies", len(nrtObjs.Items), "method", resyncMethod, "scope", resyncScope, "trigger", resyncTrigger)
 	obj := &OverReserve{
-		lh:                     lh,
-		client:                 client,
-		nrts:                   newNrtStore(lh, nrtObjs.Items),
-		assumedResources:       make(map[string]*resourceStore),
-		nodesMaybeOverreserved: newCounter(),
-		nodesWithForeignPods:   newCounter(),
-		nodesWithAttrUpdate:    newCounter(),
-		podLister:              podLister,
-		resyncMethod:           resyncMethod,
-		isPodRelevant:          isPodRelevant,
-	}
-
-	if resyncScope == apiconfig.CacheResyncScopeAll {
+		lh:                           lh,
+		profileName:                  profileName,
+		client:                       client,
+		nrts:                         newNrtStore(lh, nrtObjs.Items),
+		assumedResources:             make(map[string]*resourceStore),
+		nodesMaybeOverreserved:       newCounter(),
+		nodesWithForeignPods:         newCounter(),
+		nodesWithForeignReservations: newCounter(),
+		foreignPods:                  make(map[string]sets.Set[string]),
+		resizes:                      make(map[string]*nodeResizes),
+		gangs:                        newGangStore(),
+		nodesWithAttrUpdate:          newCounter(),
+		lastResync:                   make(map[string]ResyncResult),
+		podLister:                    podLister,
+		resyncMethod:                 resyncMethod,
+		isPodRelevant:                isPodRelevant,
+		checkpointer:                 checkpointer,
+	}
+
+	if checkpointer != nil {
+		ckpt, err := checkpointer.Load(ctx)
+		if err != nil {
+			// not fatal: we just start from a clean state, like we would do without checkpoints
+			lh.Error(err, "cannot load the cache checkpoint, ignoring")
+		} else if ckpt != nil {
+			obj.RestoreCheckpoint(lh, ckpt)
+		}
+	}
+
+	if resyncTrigger == apiconfig.CacheResyncTriggerEvent {
+		obj.resyncer = newNodeResyncer(lh.WithName(logging.FlowCacheSync), obj)
+		go obj.resyncer.Run(ctx)
+	}
+
+	if resyncScope == apiconfig.CacheResyncScopeAll || obj.resyncer != nil {
 		wt := Watcher{
-			lh:    obj.lh,
-			nrts:  obj.nrts,
-			nodes: obj.nodesWithAttrUpdate,
+			lh:   obj.lh,
+			nrts: obj.nrts,
+		}
+		if resyncScope == apiconfig.CacheResyncScopeAll {
+			wt.nodes = obj.nodesWithAttrUpdate
+		}
+		if obj.resyncer != nil {
+			wt.onUpdate = func(nodeName string) {
+				obj.requestNodeResync(nodeName, "nrtUpdated")
+			}
 		}
 		go wt.NodeResourceTopologies(ctx, client)
 	}
@@ -131,7 +195,12 @@ func (ov *OverReserve) NodeMaybeOverReserved(nodeName string, pod *corev1.Pod) {
 	ov.lock.Lock()
 	defer ov.lock.Unlock()
 	val := ov.nodesMaybeOverreserved.Incr(nodeName)
+	ov.gangs.MarkNode(nodeName)
 	ov.lh.V(4).Info("mark discarded", logging.KeyNode, nodeName, "count", val)
+	if ov.resyncer != nil {
+		// the NRT data may be already up to date, no need to wait for further events
+		ov.resyncer.Enqueue(nodeName, "discarded")
+	}
 }
 
 func (ov *OverReserve) NodeHasForeignPods(nodeName string, pod *corev1.Pod) {
@@ -143,10 +212,79 @@ func (ov *OverReserve) NodeHasForeignPods(nodeName string, pod *corev1.Pod) {
 		return
 	}
 	val := ov.nodesWithForeignPods.Incr(nodeName)
+	metrics.CacheForeignPodsDetected.WithLabelValues(ov.profileName).Inc()
 	lh.V(2).Info("marked with foreign pods", logging.KeyNode, nodeName, "count", val)
 }
 
-func (ov *OverReserve) ReserveNodeResources(nodeName string, pod *corev1.Pod) {
+// ReconcileForeignPod accounts the exclusive resources of a foreign pod like a reservation, on all the NUMA zones
+// because its placement is unknown, and marks the node for resync. The node can still be used meanwhile.
+// Pods without exclusive resources don't change the NUMA zones availability, so they are ignored.
+func (ov *OverReserve) ReconcileForeignPod(nodeName string, pod *corev1.Pod) {
+	lh := ov.lh.WithValues(logging.KeyPod, klog.KObj(pod), logging.KeyPodUID, logging.PodUID(pod), logging.KeyNode, nodeName)
+	if !resourcerequests.AreExclusiveForPod(pod) {
+		lh.V(6).Info("ignoring foreign pod", "exclusiveResources", false)
+		return
+	}
+	ov.lock.Lock()
+	defer ov.lock.Unlock()
+	if !ov.nrts.Contains(nodeName) {
+		lh.V(5).Info("ignoring foreign pods", "nrtinfo", "missing")
+		return
+	}
+	podKey := pod.Namespace + "/" + pod.Name
+	tracked, ok := ov.foreignPods[nodeName]
+	if !ok {
+		tracked = sets.New[string]()
+		ov.foreignPods[nodeName] = tracked
+	}
+	if tracked.Has(podKey) {
+		// already accounted, maybe already confirmed by a resync
+		return
+	}
+	tracked.Insert(podKey)
+
+	nodeAssumedResources, ok := ov.assumedResources[nodeName]
+	if !ok {
+		nodeAssumedResources = newResourceStore(ov.lh)
+		ov.assumedResources[nodeName] = nodeAssumedResources
+	}
+	nodeAssumedResources.AddPod(pod, nil)
+	metrics.CacheReservedPods.WithLabelValues(ov.profileName, nodeName).Set(float64(len(nodeAssumedResources.data)))
+	metrics.CacheForeignPodsDetected.WithLabelValues(ov.profileName).Inc()
+
+	val := ov.nodesWithForeignReservations.Incr(nodeName)
+	lh.V(2).Info("reconciled foreign pod", "count", val, "assumedResources", nodeAssumedResources.String())
+	if ov.resyncer != nil {
+		ov.resyncer.Enqueue(nodeName, "foreignPod")
+	}
+}
+
+// ForgetForeignPod drops the accounting of a foreign pod which is gone. If a resync already confirmed the pod,
+// there is nothing left to drop: the next NRT update will report the released resources.
+func (ov *OverReserve) ForgetForeignPod(nodeName string, pod *corev1.Pod) {
+	lh := ov.lh.WithValues(logging.KeyPod, klog.KObj(pod), logging.KeyPodUID, logging.PodUID(pod), logging.KeyNode, nodeName)
+	ov.lock.Lock()
+	defer ov.lock.Unlock()
+	podKey := pod.Namespace + "/" + pod.Name
+	tracked, ok := ov.foreignPods[nodeName]
+	if !ok || !tracked.Has(podKey) {
+		return
+	}
+	tracked.Delete(podKey)
+	if tracked.Len() == 0 {
+		delete(ov.foreignPods, nodeName)
+	}
+
+	nodeAssumedResources, ok := ov.assumedResources[nodeName]
+	if !ok {
+		return
+	}
+	nodeAssumedResources.DeletePod(pod)
+	metrics.CacheReservedPods.WithLabelValues(ov.profileName, nodeName).Set(float64(len(nodeAssumedResources.data)))
+	lh.V(2).Info("forgot foreign pod", "assumedResources", nodeAssumedResources.String())
+}
+
+func (ov *OverReserve) ReserveNodeResources(nodeName string, pod *corev1.Pod, assignment NUMAAssignment) {
 	lh := ov.lh.WithValues(logging.KeyPod, klog.KObj(pod), logging.KeyPodUID, logging.PodUID(pod), logging.KeyNode, nodeName)
 	ov.lock.Lock()
 	defer ov.lock.Unlock()
@@ -156,9 +294,15 @@ func (ov *OverReserve) ReserveNodeResources(nodeName string, pod *corev1.Pod) {
 		ov.assumedResources[nodeName] = nodeAssumedResources
 	}
 
-	nodeAssumedResources.AddPod(pod)
+	nodeAssumedResources.AddPod(pod, assignment)
+	metrics.CacheReservedPods.WithLabelValues(ov.profileName, nodeName).Set(float64(len(nodeAssumedResources.data)))
 	lh.V(2).Info("post reserve", logging.KeyNode, nodeName, "assumedResources", nodeAssumedResources.String())
 
+	if gangName := util.GetPodGroupFullName(pod); gangName != "" {
+		ov.gangs.AddPod(gangName, nodeName, pod)
+		lh.V(4).Info("tracking gang reservation", logging.KeyPodGroup, gangName)
+	}
+
 	ov.nodesMaybeOverreserved.Delete(nodeName)
 	lh.V(6).Info("reset discard counter", logging.KeyNode, nodeName)
 }
@@ -167,6 +311,13 @@ func (ov *OverReserve) UnreserveNodeResources(nodeName string, pod *corev1.Pod)
 	lh := ov.lh.WithValues(logging.KeyPod, klog.KObj(pod), logging.KeyPodUID, logging.PodUID(pod), logging.KeyNode, nodeName)
 	ov.lock.Lock()
 	defer ov.lock.Unlock()
+	if gangName := util.GetPodGroupFullName(pod); gangName != "" {
+		if members, nodesCleared, ok := ov.gangs.ReleasePod(gangName, pod); ok {
+			ov.releaseGangMembers(lh.WithValues(logging.KeyPodGroup, gangName), members, nodesCleared)
+			return
+		}
+	}
+
 	nodeAssumedResources, ok := ov.assumedResources[nodeName]
 	if !ok {
 		// this should not happen, so we're vocal about it
@@ -176,9 +327,128 @@ func (ov *OverReserve) UnreserveNodeResources(nodeName string, pod *corev1.Pod)
 	}
 
 	nodeAssumedResources.DeletePod(pod)
+	metrics.CacheReservedPods.WithLabelValues(ov.profileName, nodeName).Set(float64(len(nodeAssumedResources.data)))
 	lh.V(2).Info("post unreserve", logging.KeyNode, nodeName, "assumedResources", nodeAssumedResources.String())
 }
 
+// releaseGangMembers drops the reservations of the given gang members at once. The given nodes, marked maybe over-reserved
+// while holding these reservations, are cleared, because the resources which likely made them fail are released.
+// Note this is not different from what ReserveNodeResources does when a node is able to handle a pod.
+func (ov *OverReserve) releaseGangMembers(lh logr.Logger, members []gangMember, nodesCleared sets.Set[string]) {
+	for _, member := range members {
+		nodeAssumedResources, ok := ov.assumedResources[member.nodeName]
+		if !ok {
+			lh.V(2).Info("no resources tracked", logging.KeyNode, member.nodeName)
+			continue
+		}
+		nodeAssumedResources.DeletePod(member.pod)
+		metrics.CacheReservedPods.WithLabelValues(ov.profileName, member.nodeName).Set(float64(len(nodeAssumedResources.data)))
+		lh.V(2).Info("post unreserve", logging.KeyNode, member.nodeName, "member", klog.KObj(member.pod), "assumedResources", nodeAssumedResources.String())
+	}
+	for nodeName := range nodesCleared {
+		ov.nodesMaybeOverreserved.Delete(nodeName)
+		lh.V(6).Info("reset discard counter", logging.KeyNode, nodeName)
+	}
+	lh.V(4).Info("released gang reservations", "members", len(members), "nodesCleared", nodesCleared.Len())
+}
+
+// nodeResizes tracks the pods of a node resized in place after the NRT data accounted them. The growth of their
+// resources is accounted like a reservation until the NRT data is updated. The pods fingerprint doesn't change
+// on resize, so the NRT data can't be trusted until its resourceVersion changes from the one seen at the first
+// resync attempt after the resizes.
+type nodeResizes struct {
+	// key: namespace + "/" name. The resources accounted in the NRT data before the first resize.
+	baselines  map[string]corev1.ResourceList
+	nrtVersion string
+}
+
+// ResizePod refreshes the resources assumed for a pod resized in place. If the pod is not tracked anymore,
+// its previous resources are already reported in the NRT data, which will be stale until the next update,
+// so the growth of its resources is accounted like a reservation and the node is marked for resync.
+func (ov *OverReserve) ResizePod(nodeName string, oldPod, newPod *corev1.Pod) {
+	lh := ov.lh.WithValues(logging.KeyPod, klog.KObj(newPod), logging.KeyPodUID, logging.PodUID(newPod), logging.KeyNode, nodeName)
+	ov.lock.Lock()
+	defer ov.lock.Unlock()
+	podKey := newPod.Namespace + "/" + newPod.Name
+	nr, resized := ov.resizes[nodeName]
+	if resized {
+		_, resized = nr.baselines[podKey]
+	}
+	nodeAssumedResources, ok := ov.assumedResources[nodeName]
+	if ok && !resized && nodeAssumedResources.ResizePod(newPod) {
+		lh.V(2).Info("post resize", "assumedResources", nodeAssumedResources.String())
+		return
+	}
+	if !ov.nrts.Contains(nodeName) {
+		return
+	}
+	if nr == nil {
+		nr = &nodeResizes{
+			baselines: make(map[string]corev1.ResourceList),
+		}
+		ov.resizes[nodeName] = nr
+	}
+	baseline, ok := nr.baselines[podKey]
+	if !ok {
+		baseline = resourcerequests.ForPod(oldPod)
+		nr.baselines[podKey] = baseline
+	}
+	if nodeAssumedResources == nil {
+		nodeAssumedResources = newResourceStore(ov.lh)
+		ov.assumedResources[nodeName] = nodeAssumedResources
+	}
+	nodeAssumedResources.SetResources(podKey, resourcesGrowth(baseline, resourcerequests.ForPod(newPod)))
+	metrics.CacheReservedPods.WithLabelValues(ov.profileName, nodeName).Set(float64(len(nodeAssumedResources.data)))
+
+	val := ov.nodesMaybeOverreserved.Incr(nodeName)
+	lh.V(4).Info("mark resized", "count", val, "assumedResources", nodeAssumedResources.String())
+	if ov.resyncer != nil {
+		ov.resyncer.Enqueue(nodeName, "podResized")
+	}
+}
+
+// tracksNode tells if the cache holds NRT data or reservations for the given node.
+func (ov *OverReserve) tracksNode(nodeName string) bool {
+	ov.lock.Lock()
+	defer ov.lock.Unlock()
+	_, ok := ov.assumedResources[nodeName]
+	return ok || ov.nrts.Contains(nodeName)
+}
+
+// checkResizes returns error if the given NRT data may not account yet the resizes of the pods of its node.
+func (ov *OverReserve) checkResizes(nrt *topologyv1alpha2.NodeResourceTopology) error {
+	ov.lock.Lock()
+	defer ov.lock.Unlock()
+	nr, ok := ov.resizes[nrt.Name]
+	if !ok {
+		return nil
+	}
+	if nr.nrtVersion == "" {
+		nr.nrtVersion = nrt.ResourceVersion
+		return errResizesPending
+	}
+	if nr.nrtVersion == nrt.ResourceVersion {
+		return errResizesPending
+	}
+	return nil
+}
+
+// resourcesGrowth returns the resources of `cur` exceeding the ones in `prev`. The resources which shrunk
+// are not released until the NRT data reports them, like for pods being deleted.
+func resourcesGrowth(prev, cur corev1.ResourceList) corev1.ResourceList {
+	growth := make(corev1.ResourceList)
+	for name, qty := range cur {
+		delta := qty.DeepCopy()
+		if prevQty, ok := prev[name]; ok {
+			delta.Sub(prevQty)
+		}
+		if delta.Sign() > 0 {
+			growth[name] = delta
+		}
+	}
+	return growth
+}
+
 type DesyncedNodes struct {
 	Generation        uint64
 	MaybeOverReserved []string
@@ -223,6 +493,9 @@ func (ov *OverReserve) GetDesyncedNodes(lh logr.Logger) DesyncedNodes {
 	// the node selection logic later on to make the resync procedure less aggressive but
 	// still correct.
 	nodes := ov.nodesWithForeignPods.Clone()
+	for _, node := range ov.nodesWithForeignReservations.Keys() {
+		nodes.Incr(node)
+	}
 	foreignCount := nodes.Len()
 
 	overreservedCount := ov.nodesMaybeOverreserved.Len()
@@ -234,6 +507,10 @@ func (ov *OverReserve) GetDesyncedNodes(lh logr.Logger) DesyncedNodes {
 	configChangeNodes := ov.nodesWithAttrUpdate.Clone()
 	configChangeCount := configChangeNodes.Len()
 
+	metrics.CacheDesyncedNodes.WithLabelValues(ov.profileName, metrics.ReasonForeignPods).Set(float64(foreignCount))
+	metrics.CacheDesyncedNodes.WithLabelValues(ov.profileName, metrics.ReasonMaybeOverReserved).Set(float64(overreservedCount))
+	metrics.CacheDesyncedNodes.WithLabelValues(ov.profileName, metrics.ReasonConfigChanged).Set(float64(configChangeCount))
+
 	if nodes.Len() > 0 {
 		lh.V(4).Info("found dirty nodes", "foreign", foreignCount, "discarded", overreservedCount, "configChange", configChangeCount, "total", nodes.Len())
 	}
@@ -258,6 +535,11 @@ func (ov *OverReserve) Resync() {
 	lh_.V(4).Info(logging.FlowBegin)
 	defer lh_.V(4).Info(logging.FlowEnd)
 
+	startTime := time.Now()
+	defer func() {
+		metrics.CacheResyncDuration.WithLabelValues(ov.profileName, metrics.TriggerPeriodic).Observe(metrics.SinceInSeconds(startTime))
+	}()
+
 	nodes := ov.GetDesyncedNodes(lh_)
 	// we start without because chicken/egg problem. This is the earliest we can use the generation value.
 	lh_ = lh_.WithValues(logging.KeyGeneration, nodes.Generation)
@@ -279,40 +561,9 @@ func (ov *OverReserve) Resync() {
 	for _, nodeName := range nodes.MaybeOverReserved {
 		lh := lh_.WithValues(logging.KeyNode, nodeName)
 
-		nrtCandidate := &topologyv1alpha2.NodeResourceTopology{}
-		if err := ov.client.Get(context.Background(), types.NamespacedName{Name: nodeName}, nrtCandidate); err != nil {
-			lh.V(2).Info("failed to get NodeTopology", "error", err)
-			continue
-		}
-		if nrtCandidate == nil {
-			lh.V(2).Info("missing NodeTopology")
-			continue
-		}
-
-		objs, ok := nodeToObjsMap[nodeName]
-		if !ok {
-			// this really should never happen
-			lh.Info("cannot find any pod for node")
-			continue
-		}
-
-		pfpExpected, onlyExclRes := podFingerprintForNodeTopology(nrtCandidate, ov.resyncMethod)
-		if pfpExpected == "" {
-			lh.V(2).Info("missing NodeTopology podset fingerprint data")
-			continue
-		}
-
-		lh.V(4).Info("trying to sync NodeTopology", "fingerprint", pfpExpected, "onlyExclusiveResources", onlyExclRes)
-
-		err = checkPodFingerprintForNode(lh, objs, nodeName, pfpExpected, onlyExclRes)
-		if errors.Is(err, podfingerprint.ErrSignatureMismatch) {
-			// can happen, not critical
-			lh.V(4).Info("NodeTopology podset fingerprint mismatch")
-			continue
-		}
+		nrtCandidate, err := ov.getNRTMatchingPods(lh, nodeName, nodeToObjsMap[nodeName])
+		ov.recordResyncResult(nodeName, metrics.TriggerPeriodic, err)
 		if err != nil {
-			// should never happen, let's be vocal
-			lh.Error(err, "checking NodeTopology podset fingerprint")
 			continue
 		}
 
@@ -323,13 +574,9 @@ func (ov *OverReserve) Resync() {
 	for _, nodeName := range nodes.ConfigChanged {
 		lh := lh_.WithValues(logging.KeyNode, nodeName)
 
-		nrtCandidate := &topologyv1alpha2.NodeResourceTopology{}
-		if err := ov.client.Get(context.Background(), types.NamespacedName{Name: nodeName}, nrtCandidate); err != nil {
-			lh.V(2).Info("failed to get NodeTopology", "error", err)
-			continue
-		}
-		if nrtCandidate == nil {
-			lh.V(2).Info("missing NodeTopology")
+		nrtCandidate, err := ov.getNRT(lh, nodeName)
+		ov.recordResyncResult(nodeName, metrics.TriggerPeriodic, err)
+		if err != nil {
 			continue
 		}
 
@@ -340,6 +587,135 @@ func (ov *OverReserve) Resync() {
 	ov.FlushNodes(lh_, nrtUpdates...)
 }
 
+// ResyncNode is the single-node version of Resync(), used by the event-driven resync.
+// Returns nil if the node was flushed or if it doesn't need a resync anymore, error otherwise.
+func (ov *OverReserve) ResyncNode(nodeName string) error {
+	lh := ov.lh.WithName(logging.FlowCacheSync).WithValues(logging.KeyNode, nodeName)
+	lh.V(4).Info(logging.FlowBegin)
+	defer lh.V(4).Info(logging.FlowEnd)
+
+	startTime := time.Now()
+	defer func() {
+		metrics.CacheResyncDuration.WithLabelValues(ov.profileName, metrics.TriggerEvent).Observe(metrics.SinceInSeconds(startTime))
+	}()
+
+	maybeOverReserved, configChanged := ov.isNodeDesynced(nodeName)
+
+	var nrtCandidate *topologyv1alpha2.NodeResourceTopology
+	var err error
+	var reason string
+	switch {
+	case configChanged:
+		nrtCandidate, err = ov.getNRT(lh, nodeName)
+		reason = "configChanged"
+	case maybeOverReserved:
+		var objs []podData
+		objs, err = makeNodePodData(lh, ov.podLister, ov.isPodRelevant, nodeName)
+		if err != nil {
+			lh.Error(err, "cannot find the running pods of the node")
+			return err
+		}
+		nrtCandidate, err = ov.getNRTMatchingPods(lh, nodeName, objs)
+		reason = "resynced"
+	default:
+		lh.V(5).Info("node not dirty")
+		return nil
+	}
+	ov.recordResyncResult(nodeName, metrics.TriggerEvent, err)
+	if err != nil {
+		return err
+	}
+
+	lh.V(4).Info("overriding cached info", "reason", reason)
+	ov.FlushNodes(lh, nrtCandidate)
+	return nil
+}
+
+func (ov *OverReserve) isNodeDesynced(nodeName string) (bool, bool) {
+	ov.lock.Lock()
+	defer ov.lock.Unlock()
+	maybeOverReserved := ov.nodesMaybeOverreserved.IsSet(nodeName) || ov.nodesWithForeignPods.IsSet(nodeName) || ov.nodesWithForeignReservations.IsSet(nodeName)
+	return maybeOverReserved, ov.nodesWithAttrUpdate.IsSet(nodeName)
+}
+
+func (ov *OverReserve) recordResyncResult(nodeName, trigger string, err error) {
+	res := ResyncResult{
+		Time:    time.Now(),
+		Trigger: trigger,
+		Success: err == nil,
+	}
+	if err != nil {
+		res.Error = err.Error()
+	}
+	ov.lock.Lock()
+	defer ov.lock.Unlock()
+	ov.lastResync[nodeName] = res
+}
+
+// requestNodeResync queues a resync of the given node, if the event-driven resync is enabled and the node is dirty.
+func (ov *OverReserve) requestNodeResync(nodeName, reason string) {
+	if ov.resyncer == nil {
+		return
+	}
+	maybeOverReserved, configChanged := ov.isNodeDesynced(nodeName)
+	if !maybeOverReserved && !configChanged {
+		return
+	}
+	ov.resyncer.Enqueue(nodeName, reason)
+}
+
+// getNRT fetches the latest NRT object for the given node from the apiserver.
+func (ov *OverReserve) getNRT(lh logr.Logger, nodeName string) (*topologyv1alpha2.NodeResourceTopology, error) {
+	nrtCandidate := &topologyv1alpha2.NodeResourceTopology{}
+	if err := ov.client.Get(context.Background(), types.NamespacedName{Name: nodeName}, nrtCandidate); err != nil {
+		lh.V(2).Info("failed to get NodeTopology", "error", err)
+		return nil, err
+	}
+	if err := ov.checkResizes(nrtCandidate); err != nil {
+		lh.V(4).Info("NodeTopology not updated yet", "resourceVersion", nrtCandidate.ResourceVersion)
+		return nil, err
+	}
+	return nrtCandidate, nil
+}
+
+// getNRTMatchingPods fetches the latest NRT object for the given node, and returns it only if its
+// podset fingerprint matches the pods the scheduler expects running on the node; only in this case
+// the NRT object reflects all the scheduling decisions made so far, and the node state can be flushed.
+func (ov *OverReserve) getNRTMatchingPods(lh logr.Logger, nodeName string, objs []podData) (*topologyv1alpha2.NodeResourceTopology, error) {
+	nrtCandidate, err := ov.getNRT(lh, nodeName)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(objs) == 0 {
+		// this really should never happen
+		lh.Info("cannot find any pod for node")
+		return nil, errNoPodsForNode
+	}
+
+	pfpExpected, onlyExclRes := podFingerprintForNodeTopology(nrtCandidate, ov.resyncMethod)
+	if pfpExpected == "" {
+		lh.V(2).Info("missing NodeTopology podset fingerprint data")
+		return nil, errMissingFingerprint
+	}
+
+	lh.V(4).Info("trying to sync NodeTopology", "fingerprint", pfpExpected, "onlyExclusiveResources", onlyExclRes)
+
+	err = checkPodFingerprintForNode(lh, objs, nodeName, pfpExpected, onlyExclRes)
+	if errors.Is(err, podfingerprint.ErrSignatureMismatch) {
+		// can happen, not critical
+		lh.V(4).Info("NodeTopology podset fingerprint mismatch")
+		metrics.CacheFingerprintMismatches.WithLabelValues(ov.profileName).Inc()
+		return nil, err
+	}
+	if err != nil {
+		// should never happen, let's be vocal
+		lh.Error(err, "checking NodeTopology podset fingerprint")
+		return nil, err
+	}
+	return nrtCandidate, nil
+}
+
 // FlushNodes drops all the cached information about a given node, resetting its state clean.
 func (ov *OverReserve) FlushNodes(lh logr.Logger, nrts ...*topologyv1alpha2.NodeResourceTopology) {
 	ov.lock.Lock()
@@ -349,10 +725,14 @@ func (ov *OverReserve) FlushNodes(lh logr.Logger, nrts ...*topologyv1alpha2.Node
 		lh.V(2).Info("flushing", logging.KeyNode, nrt.Name)
 		ov.nrts.Update(nrt)
 		delete(ov.assumedResources, nrt.Name)
+		delete(ov.resizes, nrt.Name)
 		ov.nodesMaybeOverreserved.Delete(nrt.Name)
 		ov.nodesWithForeignPods.Delete(nrt.Name)
+		ov.nodesWithForeignReservations.Delete(nrt.Name)
 		ov.nodesWithAttrUpdate.Delete(nrt.Name)
+		metrics.CacheReservedPods.Delete(map[string]string{"profile": ov.profileName, "node": nrt.Name})
 	}
+	metrics.CacheFlushedNodes.WithLabelValues(ov.profileName).Add(float64(len(nrts)))
 
 	if len(nrts) == 0 {
 		return
@@ -363,6 +743,102 @@ func (ov *OverReserve) FlushNodes(lh logr.Logger, nrts ...*topologyv1alpha2.Node
 	lh.V(2).Info("generation", "new", ov.generation)
 }
 
+// MakeCheckpoint returns a snapshot of the cache state which can be persisted and later restored.
+func (ov *OverReserve) MakeCheckpoint() *Checkpoint {
+	ov.lock.Lock()
+	defer ov.lock.Unlock()
+
+	ckpt := &Checkpoint{
+		Generation:             ov.generation,
+		AssumedResources:       make(map[string]map[string]corev1.ResourceList, len(ov.assumedResources)),
+		NUMAAssignments:        make(map[string]map[string]NUMAAssignment),
+		NodesMaybeOverReserved: ov.nodesMaybeOverreserved.Clone(),
+	}
+	for nodeName, rs := range ov.assumedResources {
+		if len(rs.data) == 0 {
+			continue
+		}
+		podsRes := make(map[string]corev1.ResourceList, len(rs.data))
+		for podKey, res := range rs.data {
+			podsRes[podKey] = res.DeepCopy()
+		}
+		ckpt.AssumedResources[nodeName] = podsRes
+		if len(rs.assignments) == 0 {
+			continue
+		}
+		podsAssignment := make(map[string]NUMAAssignment, len(rs.assignments))
+		for podKey, assignment := range rs.assignments {
+			podsAssignment[podKey] = assignment.DeepCopy()
+		}
+		ckpt.NUMAAssignments[nodeName] = podsAssignment
+	}
+	return ckpt
+}
+
+// RestoreCheckpoint merges a previously saved checkpoint into the cache state.
+// Data about nodes the cache doesn't know about is skipped. All the restored nodes
+// are marked as maybe overreserved, so the next Resync() will verify them against
+// the NRT data and flush them as soon as possible.
+func (ov *OverReserve) RestoreCheckpoint(lh logr.Logger, ckpt *Checkpoint) {
+	ov.lock.Lock()
+	defer ov.lock.Unlock()
+
+	restored := 0
+	for nodeName, podsRes := range ckpt.AssumedResources {
+		if !ov.nrts.Contains(nodeName) {
+			lh.V(4).Info("checkpoint: skipping unknown node", logging.KeyNode, nodeName)
+			continue
+		}
+		rs, ok := ov.assumedResources[nodeName]
+		if !ok {
+			rs = newResourceStore(ov.lh)
+			ov.assumedResources[nodeName] = rs
+		}
+		podsAssignment := ckpt.NUMAAssignments[nodeName]
+		for podKey, res := range podsRes {
+			rs.data[podKey] = res.DeepCopy()
+			if assignment, ok := podsAssignment[podKey]; ok {
+				rs.assignments[podKey] = assignment.DeepCopy()
+			} else {
+				delete(rs.assignments, podKey)
+			}
+		}
+		ov.nodesMaybeOverreserved.Incr(nodeName)
+		restored++
+	}
+	for nodeName, count := range ckpt.NodesMaybeOverReserved {
+		if !ov.nrts.Contains(nodeName) {
+			continue
+		}
+		ov.nodesMaybeOverreserved[nodeName] += count
+	}
+
+	if ckpt.Generation > ov.generation {
+		ov.generation = ckpt.Generation
+	}
+	lh.V(2).Info("checkpoint restored", "nodes", restored, "generation", ov.generation)
+}
+
+// SaveCheckpoint persists the cache state using the configured Checkpointer, if any.
+// The state is not saved again if it didn't change since the last successful save.
+// Meant to be called periodically by a single goroutine.
+func (ov *OverReserve) SaveCheckpoint() {
+	if ov.checkpointer == nil {
+		return
+	}
+	ckpt := ov.MakeCheckpoint()
+	if ov.lastCheckpoint != nil && equality.Semantic.DeepEqual(ov.lastCheckpoint, ckpt) {
+		ov.lh.V(6).Info("checkpoint unchanged, skipped", logging.KeyGeneration, ckpt.Generation)
+		return
+	}
+	if err := ov.checkpointer.Save(context.Background(), ckpt); err != nil {
+		ov.lh.Error(err, "cannot save the cache checkpoint")
+		return
+	}
+	ov.lastCheckpoint = ckpt
+	ov.lh.V(4).Info("checkpoint saved", logging.KeyGeneration, ckpt.Generation, "nodes", len(ckpt.AssumedResources))
+}
+
 // to be used only in tests
 func (ov *OverReserve) Store() *nrtStore {
 	return ov.nrts
@@ -379,16 +855,29 @@ func makeNodeToPodDataMap(lh logr.Logger, podLister podlisterv1.PodLister, isPod
 			continue
 		}
 		nodeObjs := nodeToObjsMap[pod.Spec.NodeName]
-		nodeObjs = append(nodeObjs, podData{
-			Namespace:             pod.Namespace,
-			Name:                  pod.Name,
-			HasExclusiveResources: resourcerequests.AreExclusiveForPod(pod),
-		})
+		nodeObjs = append(nodeObjs, makePodData(pod))
 		nodeToObjsMap[pod.Spec.NodeName] = nodeObjs
 	}
 	return nodeToObjsMap, nil
 }
 
+// makeNodePodData is like makeNodeToPodDataMap, but collects only the pods running on the given node,
+// to not build the data of all the nodes when resyncing a single one.
+func makeNodePodData(lh logr.Logger, podLister podlisterv1.PodLister, isPodRelevant podprovider.PodFilterFunc, nodeName string) ([]podData, error) {
+	pods, err := podLister.List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+	var objs []podData
+	for _, pod := range pods {
+		if pod.Spec.NodeName != nodeName || !isPodRelevant(lh, pod) {
+			continue
+		}
+		objs = append(objs, makePodData(pod))
+	}
+	return objs, nil
+}
+
 func getCacheResyncMethod(lh logr.Logger, cfg *apiconfig.NodeResourceTopologyCache) apiconfig.CacheResyncMethod {
 	var resyncMethod apiconfig.CacheResyncMethod
 	if cfg != nil && cfg.ResyncMethod != nil {
@@ -411,4 +900,27 @@ func getCacheResyncScope(lh logr.Logger, cfg *apiconfig.NodeResourceTopologyCach
 	return resyncScope
 }
 
+func getCacheResyncTrigger(lh logr.Logger, cfg *apiconfig.NodeResourceTopologyCache) apiconfig.CacheResyncTrigger {
+	var resyncTrigger apiconfig.CacheResyncTrigger
+	if cfg != nil && cfg.ResyncTrigger != nil {
+		resyncTrigger = *cfg.ResyncTrigger
+	} else { // explicitly set to nil?
+		resyncTrigger = apiconfig.CacheResyncTriggerPeriodic
+		lh.Info("cache resync trigger missing", "fallback", resyncTrigger)
+	}
+	return resyncTrigger
+}
+
+// PreBind commits the gang of the pod, if any: from now on its members are released one by one, like other pods.
+// Once a member is permitted its siblings are too, so they must keep their reservations if the member fails to bind.
+func (ov *OverReserve) PreBind(nodeName string, pod *corev1.Pod) {
+	gangName := util.GetPodGroupFullName(pod)
+	if gangName == "" {
+		return
+	}
+	ov.lock.Lock()
+	defer ov.lock.Unlock()
+	ov.gangs.CommitPod(gangName, pod)
+}
+
 func (ov *OverReserve) PostBind(nodeName string, pod *corev1.Pod) {}
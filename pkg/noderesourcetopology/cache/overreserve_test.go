@@ -18,6 +18,7 @@ package cache
 
 import (
 	"context"
+	"errors"
 	"reflect"
 	"sort"
 	"testing"
@@ -31,11 +32,15 @@ import (
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	podlisterv1 "k8s.io/client-go/listers/core/v1"
+	basemetrics "k8s.io/component-base/metrics"
+	"k8s.io/component-base/metrics/testutil"
 	"k8s.io/klog/v2"
 
 	ctrlclient "sigs.k8s.io/controller-runtime/pkg/client"
 
 	apiconfig "sigs.k8s.io/scheduler-plugins/apis/config"
+	"sigs.k8s.io/scheduler-plugins/apis/scheduling/v1alpha1"
+	"sigs.k8s.io/scheduler-plugins/pkg/noderesourcetopology/metrics"
 	"sigs.k8s.io/scheduler-plugins/pkg/noderesourcetopology/podprovider"
 	tu "sigs.k8s.io/scheduler-plugins/test/util"
 )
@@ -105,12 +110,12 @@ func TestInitEmptyLister(t *testing.T) {
 
 	fakePodLister := &fakePodLister{}
 	ctx := context.Background()
-	_, err = NewOverReserve(ctx, klog.Background(), nil, nil, fakePodLister, podprovider.IsPodRelevantAlways)
+	_, err = NewOverReserve(ctx, klog.Background(), "", nil, nil, fakePodLister, podprovider.IsPodRelevantAlways)
 	if err == nil {
 		t.Fatalf("accepted nil lister")
 	}
 
-	_, err = NewOverReserve(ctx, klog.Background(), nil, fakeClient, nil, podprovider.IsPodRelevantAlways)
+	_, err = NewOverReserve(ctx, klog.Background(), "", nil, fakeClient, nil, podprovider.IsPodRelevantAlways)
 	if err == nil {
 		t.Fatalf("accepted nil indexer")
 	}
@@ -147,7 +152,7 @@ func TestDirtyNodesMarkDiscarded(t *testing.T) {
 	}
 
 	for _, nodeName := range expectedNodes {
-		nrtCache.ReserveNodeResources(nodeName, &corev1.Pod{})
+		nrtCache.ReserveNodeResources(nodeName, &corev1.Pod{}, nil)
 	}
 
 	dirtyNodes := nrtCache.GetDesyncedNodes(klog.Background())
@@ -183,7 +188,7 @@ func TestDirtyNodesUnmarkedOnReserve(t *testing.T) {
 	}
 
 	for _, nodeName := range availNodes {
-		nrtCache.ReserveNodeResources(nodeName, &corev1.Pod{})
+		nrtCache.ReserveNodeResources(nodeName, &corev1.Pod{}, nil)
 	}
 
 	dirtyNodes := nrtCache.GetDesyncedNodes(klog.Background())
@@ -196,7 +201,7 @@ func TestDirtyNodesUnmarkedOnReserve(t *testing.T) {
 	}
 
 	// assume noe update which unblocks node-4
-	nrtCache.ReserveNodeResources("node-4", &corev1.Pod{})
+	nrtCache.ReserveNodeResources("node-4", &corev1.Pod{}, nil)
 
 	expectedNodes := []string{
 		"node-1",
@@ -229,7 +234,7 @@ func TestOverreserveGetCachedNRTCopy(t *testing.T) {
 	checkGetCachedNRTCopy(
 		t,
 		func(client ctrlclient.WithWatch, podLister podlisterv1.PodLister) (Interface, error) {
-			return NewOverReserve(context.Background(), klog.Background(), nil, client, podLister, podprovider.IsPodRelevantAlways)
+			return NewOverReserve(context.Background(), klog.Background(), "", nil, client, podLister, podprovider.IsPodRelevantAlways)
 		},
 		testCases...,
 	)
@@ -268,7 +273,7 @@ func TestGetCachedNRTCopyReserve(t *testing.T) {
 			},
 		},
 	}
-	nrtCache.ReserveNodeResources("node1", testPod)
+	nrtCache.ReserveNodeResources("node1", testPod, nil)
 
 	nrtObj, _ := nrtCache.GetCachedNRTCopy(context.Background(), "node1", testPod)
 	for _, zone := range nrtObj.Zones {
@@ -361,7 +366,7 @@ func TestGetCachedNRTCopyReserveRelease(t *testing.T) {
 			},
 		},
 	}
-	nrtCache.ReserveNodeResources("node1", testPod)
+	nrtCache.ReserveNodeResources("node1", testPod, nil)
 	nrtCache.UnreserveNodeResources("node1", testPod)
 
 	nrtObj, _ := nrtCache.GetCachedNRTCopy(context.Background(), "node1", testPod)
@@ -404,7 +409,7 @@ func TestFlush(t *testing.T) {
 		},
 	}
 
-	nrtCache.ReserveNodeResources("node1", testPod)
+	nrtCache.ReserveNodeResources("node1", testPod, nil)
 	nrtCache.NodeMaybeOverReserved("node1", testPod)
 
 	expectedNodeTopology := &topologyv1alpha2.NodeResourceTopology{
@@ -484,7 +489,7 @@ func TestResyncNoPodFingerprint(t *testing.T) {
 			},
 		},
 	}
-	nrtCache.ReserveNodeResources("node1", testPod)
+	nrtCache.ReserveNodeResources("node1", testPod, nil)
 	nrtCache.NodeMaybeOverReserved("node1", testPod)
 
 	expectedNodeTopology := &topologyv1alpha2.NodeResourceTopology{
@@ -563,7 +568,7 @@ func TestResyncMatchFingerprint(t *testing.T) {
 			},
 		},
 	}
-	nrtCache.ReserveNodeResources("node1", testPod)
+	nrtCache.ReserveNodeResources("node1", testPod, nil)
 	nrtCache.NodeMaybeOverReserved("node1", testPod)
 
 	expectedNodeTopology := &topologyv1alpha2.NodeResourceTopology{
@@ -726,8 +731,305 @@ func TestNodeWithForeignPods(t *testing.T) {
 	}
 }
 
+func TestNodeWithReconciledForeignPods(t *testing.T) {
+	fakeClient, err := tu.NewFakeClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fakePodLister := &fakePodLister{}
+
+	nrtCache := mustOverReserve(t, fakeClient, fakePodLister)
+
+	nodeTopologies := makeDefaultTestTopology()
+	for _, obj := range nodeTopologies {
+		nrtCache.Store().Update(obj)
+	}
+
+	makeForeignPod := func(name, cpus string) *corev1.Pod {
+		res := corev1.ResourceList{
+			corev1.ResourceCPU:    resource.MustParse(cpus),
+			corev1.ResourceMemory: resource.MustParse("4Gi"),
+		}
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: name},
+			Spec: corev1.PodSpec{
+				NodeName:      "node1",
+				SchedulerName: "default-scheduler",
+				Containers: []corev1.Container{
+					{
+						Resources: corev1.ResourceRequirements{
+							Limits:   res,
+							Requests: res,
+						},
+					},
+				},
+			},
+		}
+	}
+	expectCPUs := func(step, expected string) {
+		t.Helper()
+		nrtObj, info := nrtCache.GetCachedNRTCopy(context.Background(), "node1", &corev1.Pod{})
+		if !info.Fresh {
+			t.Fatalf("%s: node with reconciled foreign pods not usable", step)
+		}
+		for _, zone := range nrtObj.Zones {
+			for _, res := range zone.Resources {
+				if res.Name == cpu && res.Available.Cmp(resource.MustParse(expected)) != 0 {
+					t.Errorf("%s: zone %s: unexpected available cpus %s expected %s", step, zone.Name, res.Available.String(), expected)
+				}
+			}
+		}
+	}
+
+	exclusivePod := makeForeignPod("exclusive", "4")
+	nrtCache.ReconcileForeignPod("node1", exclusivePod)
+	// pod updates must not be accounted again
+	nrtCache.ReconcileForeignPod("node1", exclusivePod)
+	// burstable pods don't change the NUMA zones availability
+	burstablePod := makeForeignPod("burstable", "2")
+	burstablePod.Spec.Containers[0].Resources.Limits = nil
+	nrtCache.ReconcileForeignPod("node1", burstablePod)
+	expectCPUs("reconciled", "26")
+
+	nodes := nrtCache.GetDesyncedNodes(klog.Background())
+	if nodes.Len() != 1 || nodes.MaybeOverReserved[0] != "node1" {
+		t.Errorf("unexpected dirty nodes: %v", nodes.MaybeOverReserved)
+	}
+
+	// the NRT update confirmed the foreign pod
+	nrtCache.FlushNodes(klog.Background(), nodeTopologies[0])
+	nrtCache.ReconcileForeignPod("node1", exclusivePod)
+	expectCPUs("flushed", "30")
+	if nodes := nrtCache.GetDesyncedNodes(klog.Background()); nodes.Len() != 0 {
+		t.Errorf("unexpected dirty nodes after flush: %v", nodes.MaybeOverReserved)
+	}
+
+	// a new pod with the same name is a different pod
+	nrtCache.ForgetForeignPod("node1", exclusivePod)
+	nrtCache.ReconcileForeignPod("node1", exclusivePod)
+	expectCPUs("recreated", "26")
+	nrtCache.ForgetForeignPod("node1", exclusivePod)
+	expectCPUs("deleted", "30")
+
+	nrtCache.ReconcileForeignPod("node-bogus", exclusivePod)
+	if _, ok := nrtCache.DumpNode("node-bogus"); ok {
+		t.Errorf("unexpected state for non-existent node")
+	}
+}
+
+func TestResizePod(t *testing.T) {
+	fakeClient, err := tu.NewFakeClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fakePodLister := &fakePodLister{}
+
+	nrtCache := mustOverReserve(t, fakeClient, fakePodLister)
+
+	nodeTopologies := makeDefaultTestTopology()
+	for _, obj := range nodeTopologies {
+		nrtCache.Store().Update(obj)
+	}
+
+	res := corev1.ResourceList{
+		corev1.ResourceCPU:    resource.MustParse("4"),
+		corev1.ResourceMemory: resource.MustParse("4Gi"),
+	}
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "resized"},
+		Spec: corev1.PodSpec{
+			NodeName: "node1",
+			Containers: []corev1.Container{
+				{
+					Name: "cnt",
+					Resources: corev1.ResourceRequirements{
+						Limits:   res,
+						Requests: res,
+					},
+				},
+			},
+		},
+	}
+	expectCPUs := func(step string, expected ...string) {
+		t.Helper()
+		nrtObj, _ := nrtCache.GetCachedNRTCopy(context.Background(), "node1", &corev1.Pod{})
+		for idx, zone := range nrtObj.Zones {
+			for _, res := range zone.Resources {
+				if res.Name == cpu && res.Available.Cmp(resource.MustParse(expected[idx])) != 0 {
+					t.Errorf("%s: zone %s: unexpected available cpus %s expected %s", step, zone.Name, res.Available.String(), expected[idx])
+				}
+			}
+		}
+	}
+
+	nrtCache.ReserveNodeResources("node1", pod, NUMAAssignment{0: res})
+	expectCPUs("reserved", "26", "30")
+
+	// resize in progress: the kubelet still holds the allocated resources, which are larger
+	resized := pod.DeepCopy()
+	resized.Spec.Containers[0].Resources.Requests[corev1.ResourceCPU] = resource.MustParse("2")
+	resized.Spec.Containers[0].Resources.Limits[corev1.ResourceCPU] = resource.MustParse("2")
+	resized.Status.ContainerStatuses = []corev1.ContainerStatus{
+		{
+			Name:               "cnt",
+			AllocatedResources: res,
+		},
+	}
+	nrtCache.ResizePod("node1", pod, resized)
+	expectCPUs("resize pending", "26", "30")
+
+	// resize actuated
+	actuated := resized.DeepCopy()
+	actuated.Status.ContainerStatuses[0].AllocatedResources = actuated.Spec.Containers[0].Resources.Requests
+	nrtCache.ResizePod("node1", resized, actuated)
+	expectCPUs("resize done", "28", "30")
+	if nodes := nrtCache.GetDesyncedNodes(klog.Background()); nodes.Len() != 0 {
+		t.Errorf("unexpected dirty nodes: %v", nodes.MaybeOverReserved)
+	}
+
+	// once flushed, the NRT data reports the resources of the pod, and it's stale after a resize:
+	// the growth of the resources is accounted on all the NUMA zones until the NRT data is updated
+	nrtCache.FlushNodes(klog.Background(), nodeTopologies[0])
+	grown := pod.DeepCopy()
+	grown.Spec.Containers[0].Resources.Requests[corev1.ResourceCPU] = resource.MustParse("6")
+	grown.Spec.Containers[0].Resources.Limits[corev1.ResourceCPU] = resource.MustParse("6")
+	nrtCache.ResizePod("node1", pod, grown)
+	expectCPUs("resize after flush", "28", "28")
+	nodes := nrtCache.GetDesyncedNodes(klog.Background())
+	if nodes.Len() != 1 || nodes.MaybeOverReserved[0] != "node1" {
+		t.Errorf("unexpected dirty nodes: %v", nodes.MaybeOverReserved)
+	}
+
+	// the baseline is the resources reported in the NRT data, not the ones of the previous resize
+	regrown := grown.DeepCopy()
+	regrown.Spec.Containers[0].Resources.Requests[corev1.ResourceCPU] = resource.MustParse("8")
+	regrown.Spec.Containers[0].Resources.Limits[corev1.ResourceCPU] = resource.MustParse("8")
+	nrtCache.ResizePod("node1", grown, regrown)
+	expectCPUs("resize again after flush", "26", "26")
+
+	// the pods fingerprint doesn't change on resize, so only an updated NRT object is trusted
+	nrtObj := nodeTopologies[0].DeepCopy()
+	nrtObj.ResourceVersion = "1"
+	if err := nrtCache.checkResizes(nrtObj); !errors.Is(err, errResizesPending) {
+		t.Errorf("first attempt: unexpected error %v", err)
+	}
+	if err := nrtCache.checkResizes(nrtObj); !errors.Is(err, errResizesPending) {
+		t.Errorf("NRT not updated: unexpected error %v", err)
+	}
+	nrtObj.ResourceVersion = "2"
+	if err := nrtCache.checkResizes(nrtObj); err != nil {
+		t.Errorf("NRT updated: unexpected error %v", err)
+	}
+}
+
+func TestGangReservations(t *testing.T) {
+	res := corev1.ResourceList{
+		corev1.ResourceCPU:    resource.MustParse("4"),
+		corev1.ResourceMemory: resource.MustParse("4Gi"),
+	}
+	makeGangPod := func(name string) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: "ns",
+				Name:      name,
+				Labels: map[string]string{
+					v1alpha1.PodGroupLabel: "pg1",
+				},
+			},
+			Spec: corev1.PodSpec{
+				Containers: []corev1.Container{
+					{
+						Name: "cnt",
+						Resources: corev1.ResourceRequirements{
+							Limits:   res,
+							Requests: res,
+						},
+					},
+				},
+			},
+		}
+	}
+
+	testCases := []struct {
+		name          string
+		permitted     []int
+		bound         []int
+		expectedCPUs  []string
+		expectedDirty []string
+	}{
+		{
+			name:          "rejected gang releases all the reservations",
+			expectedCPUs:  []string{"30", "30"},
+			expectedDirty: []string{},
+		},
+		{
+			name:          "bound gang releases the reservations one by one",
+			permitted:     []int{0},
+			bound:         []int{0},
+			expectedCPUs:  []string{"22", "30"},
+			expectedDirty: []string{"node1"},
+		},
+		{
+			name:          "member failing to bind keeps the reservations of the siblings still binding",
+			permitted:     []int{0, 1},
+			expectedCPUs:  []string{"22", "30"},
+			expectedDirty: []string{"node1"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			fakeClient, err := tu.NewFakeClient()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			nrtCache := mustOverReserve(t, fakeClient, &fakePodLister{})
+			for _, obj := range makeDefaultTestTopology() {
+				nrtCache.Store().Update(obj)
+			}
+
+			pods := []*corev1.Pod{makeGangPod("member-0"), makeGangPod("member-1"), makeGangPod("member-2")}
+			for idx, pod := range pods {
+				nrtCache.ReserveNodeResources("node1", pod, NUMAAssignment{idx % 2: res})
+			}
+			// another pod is filtered out because of the reservations held by the gang
+			nrtCache.NodeMaybeOverReserved("node1", &corev1.Pod{})
+
+			for _, idx := range tc.permitted {
+				nrtCache.PreBind("node1", pods[idx])
+			}
+			for _, idx := range tc.bound {
+				nrtCache.PostBind("node1", pods[idx])
+			}
+			nrtCache.UnreserveNodeResources("node1", pods[1])
+			if len(tc.permitted) == 0 {
+				// the other members are rejected later, and have nothing to release
+				nrtCache.UnreserveNodeResources("node1", pods[2])
+				nrtCache.UnreserveNodeResources("node1", pods[0])
+			}
+
+			nrtObj, _ := nrtCache.GetCachedNRTCopy(context.Background(), "node1", &corev1.Pod{})
+			for idx, zone := range nrtObj.Zones {
+				for _, zoneRes := range zone.Resources {
+					if zoneRes.Name == cpu && zoneRes.Available.Cmp(resource.MustParse(tc.expectedCPUs[idx])) != 0 {
+						t.Errorf("zone %s: unexpected available cpus %s expected %s", zone.Name, zoneRes.Available.String(), tc.expectedCPUs[idx])
+					}
+				}
+			}
+
+			dirtyNodes := nrtCache.GetDesyncedNodes(klog.Background())
+			if !reflect.DeepEqual(dirtyNodes.MaybeOverReserved, tc.expectedDirty) {
+				t.Errorf("got=%v expected=%v", dirtyNodes.MaybeOverReserved, tc.expectedDirty)
+			}
+		})
+	}
+}
+
 func mustOverReserve(t *testing.T, client ctrlclient.WithWatch, podLister podlisterv1.PodLister) *OverReserve {
-	obj, err := NewOverReserve(context.Background(), klog.Background(), nil, client, podLister, podprovider.IsPodRelevantAlways)
+	obj, err := NewOverReserve(context.Background(), klog.Background(), "", nil, client, podLister, podprovider.IsPodRelevantAlways)
 	if err != nil {
 		t.Fatalf("unexpected error creating cache: %v", err)
 	}
@@ -968,6 +1270,103 @@ func TestMakeNodeToPodDataMap(t *testing.T) {
 			if diff := cmp.Diff(got, tcase.expected); diff != "" {
 				t.Errorf("unexpected result: %v", diff)
 			}
+			// the single node version must agree with the full map
+			for nodeName, expected := range tcase.expected {
+				objs, err := makeNodePodData(klog.Background(), podLister, tcase.isPodRelevant, nodeName)
+				if err != nil {
+					t.Errorf("node %q: unexpected error %v", nodeName, err)
+				}
+				if diff := cmp.Diff(objs, expected); diff != "" {
+					t.Errorf("node %q: unexpected result: %v", nodeName, diff)
+				}
+			}
 		})
 	}
 }
+
+func TestOverReserveMetrics(t *testing.T) {
+	metrics.Register()
+
+	fakeClient, err := tu.NewFakeClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fakePodLister := &fakePodLister{}
+	nrtCache, err := NewOverReserve(context.Background(), klog.Background(), "profile-a", nil, fakeClient, fakePodLister, podprovider.IsPodRelevantAlways)
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherCache, err := NewOverReserve(context.Background(), klog.Background(), "profile-b", nil, fakeClient, fakePodLister, podprovider.IsPodRelevantAlways)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, obj := range makeDefaultTestTopology() {
+		nrtCache.Store().Update(obj)
+		otherCache.Store().Update(obj)
+	}
+
+	testPod := makeResyncTestPod()
+	nrtCache.ReserveNodeResources("node1", testPod, nil)
+	// the caches of different profiles must not overwrite each other
+	otherCache.ReserveNodeResources("node1", makeResyncTestPod(), nil)
+	otherCache.UnreserveNodeResources("node1", testPod)
+	if val := mustGetGauge(t, metrics.CacheReservedPods.WithLabelValues("profile-a", "node1")); val != 1 {
+		t.Errorf("unexpected reserved pods: %v", val)
+	}
+	if val := mustGetGauge(t, metrics.CacheReservedPods.WithLabelValues("profile-b", "node1")); val != 0 {
+		t.Errorf("unexpected reserved pods for the other profile: %v", val)
+	}
+
+	nrtCache.NodeMaybeOverReserved("node1", testPod)
+	nrtCache.NodeHasForeignPods("node1", testPod)
+	nrtCache.GetDesyncedNodes(klog.Background())
+	if val := mustGetGauge(t, metrics.CacheDesyncedNodes.WithLabelValues("profile-a", metrics.ReasonMaybeOverReserved)); val != 1 {
+		t.Errorf("unexpected maybe overreserved nodes: %v", val)
+	}
+	if val := mustGetGauge(t, metrics.CacheDesyncedNodes.WithLabelValues("profile-a", metrics.ReasonForeignPods)); val != 1 {
+		t.Errorf("unexpected nodes with foreign pods: %v", val)
+	}
+
+	resyncCount, err := testutil.GetHistogramMetricCount(metrics.CacheResyncDuration.WithLabelValues("profile-a", metrics.TriggerPeriodic))
+	if err != nil {
+		t.Fatal(err)
+	}
+	flushed, err := testutil.GetCounterMetricValue(metrics.CacheFlushedNodes.WithLabelValues("profile-a"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	runningPod := testPod.DeepCopy()
+	runningPod.Status.Phase = corev1.PodRunning
+	fakePodLister.AddPod(runningPod)
+	if err := fakeClient.Create(context.Background(), makeResyncTestNRT()); err != nil {
+		t.Fatal(err)
+	}
+
+	nrtCache.Resync()
+
+	newResyncCount, err := testutil.GetHistogramMetricCount(metrics.CacheResyncDuration.WithLabelValues("profile-a", metrics.TriggerPeriodic))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if newResyncCount != resyncCount+1 {
+		t.Errorf("resync not observed: %d -> %d", resyncCount, newResyncCount)
+	}
+	newFlushed, err := testutil.GetCounterMetricValue(metrics.CacheFlushedNodes.WithLabelValues("profile-a"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if newFlushed != flushed+1 {
+		t.Errorf("flush not counted: %v -> %v", flushed, newFlushed)
+	}
+}
+
+func mustGetGauge(t *testing.T, m basemetrics.GaugeMetric) float64 {
+	t.Helper()
+	val, err := testutil.GetGaugeMetricValue(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return val
+}
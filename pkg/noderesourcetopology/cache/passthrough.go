@@ -51,8 +51,10 @@ func (pt Passthrough) GetCachedNRTCopy(ctx context.Context, nodeName string, _ *
 	return nrt, info
 }
 
-func (pt Passthrough) NodeMaybeOverReserved(nodeName string, pod *corev1.Pod)  {}
-func (pt Passthrough) NodeHasForeignPods(nodeName string, pod *corev1.Pod)     {}
-func (pt Passthrough) ReserveNodeResources(nodeName string, pod *corev1.Pod)   {}
+func (pt Passthrough) NodeMaybeOverReserved(nodeName string, pod *corev1.Pod) {}
+func (pt Passthrough) NodeHasForeignPods(nodeName string, pod *corev1.Pod)    {}
+func (pt Passthrough) ReserveNodeResources(nodeName string, pod *corev1.Pod, assignment NUMAAssignment) {
+}
 func (pt Passthrough) UnreserveNodeResources(nodeName string, pod *corev1.Pod) {}
+func (pt Passthrough) PreBind(nodeName string, pod *corev1.Pod)                {}
 func (pt Passthrough) PostBind(nodeName string, pod *corev1.Pod)               {}
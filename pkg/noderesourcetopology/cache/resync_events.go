@@ -0,0 +1,227 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cache
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/wait"
+	k8scache "k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/util/workqueue"
+
+	"sigs.k8s.io/scheduler-plugins/pkg/noderesourcetopology/logging"
+	"sigs.k8s.io/scheduler-plugins/pkg/noderesourcetopology/resourcerequests"
+)
+
+const (
+	// eventResyncMinInterval is the minimum time between two resync attempts of the same node
+	eventResyncMinInterval = 1 * time.Second
+	// eventResyncBaseDelay and eventResyncMaxDelay bound the per-node exponential backoff
+	// applied when the NRT data of a node is not yet in sync with the expected pods.
+	eventResyncBaseDelay = 500 * time.Millisecond
+	eventResyncMaxDelay  = 30 * time.Second
+	// eventResyncMaxRetries is how many times we retry a node before leaving it to the periodic resync
+	eventResyncMaxRetries = 8
+)
+
+// nodeResyncer resyncs individual dirty nodes as soon as an event suggests their NRT data may be
+// in sync again, instead of waiting for the next periodic Resync().
+// Node names are processed through a rate limited workqueue, so the same node is never resynced
+// concurrently, and each node is retried with exponential backoff while its NRT data is still stale.
+// The requests received while a node is already queued are coalesced, because a busy node can be
+// filtered out, and so trigger a resync request, by every scheduling cycle.
+type nodeResyncer struct {
+	lh    logr.Logger
+	ov    *OverReserve
+	queue workqueue.RateLimitingInterface
+	// lastAttempt tracks the last resync attempt per node, to enforce eventResyncMinInterval
+	lock        sync.Mutex
+	lastAttempt map[string]time.Time
+	// queued tracks the nodes queued and not processed yet
+	queued sets.Set[string]
+	now    func() time.Time
+}
+
+func newNodeResyncer(lh logr.Logger, ov *OverReserve) *nodeResyncer {
+	return &nodeResyncer{
+		lh: lh,
+		ov: ov,
+		queue: workqueue.NewRateLimitingQueueWithConfig(
+			workqueue.NewItemExponentialFailureRateLimiter(eventResyncBaseDelay, eventResyncMaxDelay),
+			workqueue.RateLimitingQueueConfig{Name: "nrtcache-resync"},
+		),
+		lastAttempt: make(map[string]time.Time),
+		queued:      sets.New[string](),
+		now:         time.Now,
+	}
+}
+
+// Enqueue requests a resync of the given node, honoring the per-node rate limiting.
+func (nr *nodeResyncer) Enqueue(nodeName, reason string) {
+	if nr.queue.NumRequeues(nodeName) > 0 {
+		// already backing off, the scheduled retry will take care of it
+		return
+	}
+
+	nr.lock.Lock()
+	if nr.queued.Has(nodeName) {
+		nr.lock.Unlock()
+		return
+	}
+	nr.queued.Insert(nodeName)
+	last, ok := nr.lastAttempt[nodeName]
+	nr.lock.Unlock()
+
+	nr.lh.V(5).Info("resync requested", logging.KeyNode, nodeName, "reason", reason)
+	if ok {
+		if elapsed := nr.now().Sub(last); elapsed < eventResyncMinInterval {
+			nr.queue.AddAfter(nodeName, eventResyncMinInterval-elapsed)
+			return
+		}
+	}
+	nr.queue.Add(nodeName)
+}
+
+// Run processes the queued nodes until the context is cancelled.
+func (nr *nodeResyncer) Run(ctx context.Context) {
+	defer nr.queue.ShutDown()
+	go wait.UntilWithContext(ctx, func(_ context.Context) {
+		for nr.processNextItem() {
+		}
+	}, time.Second)
+	<-ctx.Done()
+}
+
+func (nr *nodeResyncer) processNextItem() bool {
+	item, quit := nr.queue.Get()
+	if quit {
+		return false
+	}
+	defer nr.queue.Done(item)
+
+	nodeName := item.(string)
+	nr.lock.Lock()
+	nr.lastAttempt[nodeName] = nr.now()
+	nr.queued.Delete(nodeName)
+	nr.lock.Unlock()
+
+	err := nr.ov.ResyncNode(nodeName)
+	if err == nil {
+		nr.queue.Forget(nodeName)
+		return true
+	}
+
+	if retries := nr.queue.NumRequeues(nodeName); retries < eventResyncMaxRetries {
+		nr.lh.V(4).Info("resync failed, retrying", logging.KeyNode, nodeName, "retries", retries, "error", err)
+		nr.queue.AddRateLimited(nodeName)
+		return true
+	}
+
+	nr.lh.V(3).Info("resync failed, giving up until the next periodic resync", logging.KeyNode, nodeName, "error", err)
+	nr.queue.Forget(nodeName)
+	return true
+}
+
+// SetupResyncOnPodDelete requests a resync of the node a pod was running on when the pod is deleted,
+// because the NRT data of the node is expected to be updated soon after.
+// Does nothing if the event-driven resync is not enabled.
+func SetupResyncOnPodDelete(lh logr.Logger, podInformer k8scache.SharedInformer, ov *OverReserve) {
+	if ov.resyncer == nil {
+		return
+	}
+
+	podInformer.AddEventHandler(k8scache.ResourceEventHandlerFuncs{
+		DeleteFunc: func(obj interface{}) {
+			if tombstone, ok := obj.(k8scache.DeletedFinalStateUnknown); ok {
+				obj = tombstone.Obj
+			}
+			pod, ok := obj.(*corev1.Pod)
+			if !ok {
+				lh.V(3).Info("unsupported object", "kind", fmt.Sprintf("%T", obj))
+				return
+			}
+			if pod.Spec.NodeName == "" {
+				return
+			}
+			ov.requestNodeResync(pod.Spec.NodeName, "podDeleted")
+		},
+	})
+}
+
+// SetupPodResizeHandler keeps the resources assumed for the pods in sync with their in-place resizes.
+// Only the updates which change the requests or the allocated resources of the pods running on the nodes
+// tracked by the cache are considered.
+func SetupPodResizeHandler(lh logr.Logger, podInformer k8scache.SharedInformer, ov *OverReserve) {
+	podInformer.AddEventHandler(k8scache.ResourceEventHandlerFuncs{
+		UpdateFunc: func(oldObj, newObj interface{}) {
+			oldPod, ok := oldObj.(*corev1.Pod)
+			if !ok {
+				lh.V(3).Info("unsupported object", "kind", fmt.Sprintf("%T", oldObj))
+				return
+			}
+			newPod, ok := newObj.(*corev1.Pod)
+			if !ok {
+				lh.V(3).Info("unsupported object", "kind", fmt.Sprintf("%T", newObj))
+				return
+			}
+			if newPod.Spec.NodeName == "" || oldPod.Spec.NodeName != newPod.Spec.NodeName {
+				// not running yet, nothing to resize
+				return
+			}
+			if !podResourcesChanged(oldPod, newPod) || !ov.tracksNode(newPod.Spec.NodeName) {
+				return
+			}
+			if equality.Semantic.DeepEqual(resourcerequests.ForPod(oldPod), resourcerequests.ForPod(newPod)) {
+				return
+			}
+			ov.ResizePod(newPod.Spec.NodeName, oldPod, newPod)
+		},
+	})
+}
+
+// podResourcesChanged tells if the requests or the resources allocated by the kubelet changed for any container.
+// This is much cheaper than comparing the effective requests of the pods, and filters out most of the pod updates.
+func podResourcesChanged(oldPod, newPod *corev1.Pod) bool {
+	return !equality.Semantic.DeepEqual(containersRequests(oldPod.Spec.InitContainers), containersRequests(newPod.Spec.InitContainers)) ||
+		!equality.Semantic.DeepEqual(containersRequests(oldPod.Spec.Containers), containersRequests(newPod.Spec.Containers)) ||
+		!equality.Semantic.DeepEqual(allocatedResources(oldPod.Status.InitContainerStatuses), allocatedResources(newPod.Status.InitContainerStatuses)) ||
+		!equality.Semantic.DeepEqual(allocatedResources(oldPod.Status.ContainerStatuses), allocatedResources(newPod.Status.ContainerStatuses))
+}
+
+func containersRequests(ctrs []corev1.Container) []corev1.ResourceList {
+	reqs := make([]corev1.ResourceList, 0, len(ctrs))
+	for idx := range ctrs {
+		reqs = append(reqs, ctrs[idx].Resources.Requests)
+	}
+	return reqs
+}
+
+func allocatedResources(statuses []corev1.ContainerStatus) []corev1.ResourceList {
+	allocated := make([]corev1.ResourceList, 0, len(statuses))
+	for idx := range statuses {
+		allocated = append(allocated, statuses[idx].AllocatedResources)
+	}
+	return allocated
+}
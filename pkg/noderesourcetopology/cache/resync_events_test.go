@@ -0,0 +1,283 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	topologyv1alpha2 "github.com/k8stopologyawareschedwg/noderesourcetopology-api/pkg/apis/topology/v1alpha2"
+	"github.com/k8stopologyawareschedwg/podfingerprint"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/klog/v2"
+
+	tu "sigs.k8s.io/scheduler-plugins/test/util"
+)
+
+func makeResyncTestPod() *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "pod1",
+			Namespace: "namespace1",
+		},
+		Spec: corev1.PodSpec{
+			NodeName: "node1",
+			Containers: []corev1.Container{
+				{
+					Resources: corev1.ResourceRequirements{
+						Limits: corev1.ResourceList{
+							corev1.ResourceCPU:    resource.MustParse("8"),
+							corev1.ResourceMemory: resource.MustParse("16Gi"),
+						},
+						Requests: corev1.ResourceList{
+							corev1.ResourceCPU:    resource.MustParse("8"),
+							corev1.ResourceMemory: resource.MustParse("16Gi"),
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func makeResyncTestNRT() *topologyv1alpha2.NodeResourceTopology {
+	// the fingerprint matches makeResyncTestPod()
+	return &topologyv1alpha2.NodeResourceTopology{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "node1",
+			Annotations: map[string]string{
+				podfingerprint.Annotation: "pfp0v0019e0420efb37746c6",
+			},
+		},
+		TopologyPolicies: []string{string(topologyv1alpha2.SingleNUMANodeContainerLevel)},
+		Zones: topologyv1alpha2.ZoneList{
+			{
+				Name: "node-0",
+				Type: "Node",
+				Resources: topologyv1alpha2.ResourceInfoList{
+					MakeTopologyResInfo(cpu, "32", "30"),
+					MakeTopologyResInfo(memory, "64Gi", "60Gi"),
+				},
+			},
+			{
+				Name: "node-1",
+				Type: "Node",
+				Resources: topologyv1alpha2.ResourceInfoList{
+					MakeTopologyResInfo(cpu, "32", "22"),
+					MakeTopologyResInfo(memory, "64Gi", "44Gi"),
+				},
+			},
+		},
+	}
+}
+
+func TestResyncNode(t *testing.T) {
+	fakeClient, err := tu.NewFakeClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fakePodLister := &fakePodLister{}
+
+	nrtCache := mustOverReserve(t, fakeClient, fakePodLister)
+	for _, obj := range makeDefaultTestTopology() {
+		nrtCache.Store().Update(obj)
+	}
+
+	if err := nrtCache.ResyncNode("node1"); err != nil {
+		t.Fatalf("unexpected error resyncing clean node: %v", err)
+	}
+
+	testPod := makeResyncTestPod()
+	nrtCache.ReserveNodeResources("node1", testPod, nil)
+	nrtCache.NodeMaybeOverReserved("node1", testPod)
+
+	runningPod := testPod.DeepCopy()
+	runningPod.Status.Phase = corev1.PodRunning
+	fakePodLister.AddPod(runningPod)
+
+	// the NRT object was not updated yet
+	if err := nrtCache.ResyncNode("node1"); err == nil {
+		t.Fatalf("unexpected success resyncing node without NRT data")
+	}
+	if dirtyNodes := nrtCache.GetDesyncedNodes(klog.Background()); dirtyNodes.Len() != 1 {
+		t.Fatalf("node not dirty after failed resync: %v", dirtyNodes)
+	}
+
+	if err := fakeClient.Create(context.Background(), makeResyncTestNRT()); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := nrtCache.ResyncNode("node1"); err != nil {
+		t.Fatalf("unexpected error resyncing node with good data: %v", err)
+	}
+	if dirtyNodes := nrtCache.GetDesyncedNodes(klog.Background()); dirtyNodes.Len() > 0 {
+		t.Errorf("node still dirty after resyncing with good data: %v", dirtyNodes)
+	}
+}
+
+func TestNodeResyncerRateLimit(t *testing.T) {
+	fakeClient, err := tu.NewFakeClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	nrtCache := mustOverReserve(t, fakeClient, &fakePodLister{})
+	for _, obj := range makeDefaultTestTopology() {
+		nrtCache.Store().Update(obj)
+	}
+
+	now := time.Now()
+	nr := newNodeResyncer(klog.Background(), nrtCache)
+	nr.now = func() time.Time { return now }
+	defer nr.queue.ShutDown()
+
+	nr.Enqueue("node1", "test")
+	nr.Enqueue("node1", "test")
+	if nr.queue.Len() != 1 {
+		t.Fatalf("expected requests to be deduplicated, got queue len %d", nr.queue.Len())
+	}
+
+	// node1 is not dirty, so the resync succeeds trivially
+	nr.processNextItem()
+	if nr.queue.NumRequeues("node1") != 0 {
+		t.Fatalf("unexpected requeues after successful resync")
+	}
+
+	nr.Enqueue("node1", "test")
+	if nr.queue.Len() != 0 {
+		t.Fatalf("resync not delayed within the minimum interval")
+	}
+
+	// the delayed resync is still pending, so the further requests are coalesced into it
+	nr.Enqueue("node1", "test")
+	if nr.queue.Len() != 0 || !nr.queued.Has("node1") {
+		t.Fatalf("resync request not coalesced with the pending one")
+	}
+
+	nr.Enqueue("node2", "test")
+	nr.processNextItem()
+	now = now.Add(2 * eventResyncMinInterval)
+	nr.Enqueue("node2", "test")
+	if nr.queue.Len() != 1 {
+		t.Fatalf("resync not queued after the minimum interval")
+	}
+}
+
+func TestNodeResyncerBackoff(t *testing.T) {
+	fakeClient, err := tu.NewFakeClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fakePodLister := &fakePodLister{}
+	nrtCache := mustOverReserve(t, fakeClient, fakePodLister)
+	for _, obj := range makeDefaultTestTopology() {
+		nrtCache.Store().Update(obj)
+	}
+
+	testPod := makeResyncTestPod()
+	nrtCache.ReserveNodeResources("node1", testPod, nil)
+	nrtCache.NodeMaybeOverReserved("node1", testPod)
+	fakePodLister.AddPod(testPod)
+
+	nr := newNodeResyncer(klog.Background(), nrtCache)
+	defer nr.queue.ShutDown()
+
+	nr.Enqueue("node1", "test")
+	// no NRT data available, so the resync fails and the node must be retried later
+	nr.processNextItem()
+	if nr.queue.NumRequeues("node1") != 1 {
+		t.Fatalf("expected node to be requeued with backoff, got %d requeues", nr.queue.NumRequeues("node1"))
+	}
+
+	nr.Enqueue("node1", "test")
+	if nr.queue.Len() != 0 {
+		t.Fatalf("resync requested while backing off should be ignored")
+	}
+}
+
+func TestWatcherOnUpdate(t *testing.T) {
+	var updated []string
+	wt := Watcher{
+		lh:   klog.Background(),
+		nrts: newNrtStore(klog.Background(), nil),
+		onUpdate: func(nodeName string) {
+			updated = append(updated, nodeName)
+		},
+	}
+
+	wt.ProcessEvent(watch.Event{Type: watch.Added, Object: makeResyncTestNRT()})
+	wt.ProcessEvent(watch.Event{Type: watch.Modified, Object: makeResyncTestNRT()})
+
+	if len(updated) != 1 || updated[0] != "node1" {
+		t.Fatalf("unexpected update notifications: %v", updated)
+	}
+}
+
+func TestPodResourcesChanged(t *testing.T) {
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "pod"},
+		Spec: corev1.PodSpec{
+			NodeName: "node1",
+			Containers: []corev1.Container{
+				{
+					Name: "cnt",
+					Resources: corev1.ResourceRequirements{
+						Requests: corev1.ResourceList{
+							corev1.ResourceCPU: resource.MustParse("2"),
+						},
+					},
+				},
+			},
+		},
+		Status: corev1.PodStatus{
+			ContainerStatuses: []corev1.ContainerStatus{
+				{
+					Name: "cnt",
+					AllocatedResources: corev1.ResourceList{
+						corev1.ResourceCPU: resource.MustParse("2"),
+					},
+				},
+			},
+		},
+	}
+
+	relabeled := pod.DeepCopy()
+	relabeled.Labels = map[string]string{"foo": "bar"}
+	relabeled.Status.Phase = corev1.PodRunning
+	if podResourcesChanged(pod, relabeled) {
+		t.Errorf("unexpected change detected on metadata and phase update")
+	}
+
+	resized := pod.DeepCopy()
+	resized.Spec.Containers[0].Resources.Requests[corev1.ResourceCPU] = resource.MustParse("4")
+	if !podResourcesChanged(pod, resized) {
+		t.Errorf("missing change of the requests")
+	}
+
+	actuated := resized.DeepCopy()
+	actuated.Status.ContainerStatuses[0].AllocatedResources[corev1.ResourceCPU] = resource.MustParse("4")
+	if !podResourcesChanged(resized, actuated) {
+		t.Errorf("missing change of the allocated resources")
+	}
+}
@@ -25,12 +25,13 @@ import (
 	"github.com/go-logr/logr"
 	topologyv1alpha2 "github.com/k8stopologyawareschedwg/noderesourcetopology-api/pkg/apis/topology/v1alpha2"
 	topologyv1alpha2attr "github.com/k8stopologyawareschedwg/noderesourcetopology-api/pkg/apis/topology/v1alpha2/helper/attribute"
+	"github.com/k8stopologyawareschedwg/noderesourcetopology-api/pkg/apis/topology/v1alpha2/helper/numanode"
 	"github.com/k8stopologyawareschedwg/podfingerprint"
 
 	apiconfig "sigs.k8s.io/scheduler-plugins/apis/config"
 	"sigs.k8s.io/scheduler-plugins/pkg/noderesourcetopology/logging"
+	"sigs.k8s.io/scheduler-plugins/pkg/noderesourcetopology/resourcerequests"
 	"sigs.k8s.io/scheduler-plugins/pkg/noderesourcetopology/stringify"
-	"sigs.k8s.io/scheduler-plugins/pkg/util"
 )
 
 // nrtStore maps the NRT data by node name. It is not thread safe and needs to be protected by a lock.
@@ -79,13 +80,16 @@ func (nrs *nrtStore) Update(nrt *topologyv1alpha2.NodeResourceTopology) {
 type resourceStore struct {
 	// key: namespace + "/" name
 	data map[string]corev1.ResourceList
-	lh   logr.Logger
+	// key: namespace + "/" name. Pods missing here have unknown NUMA placement.
+	assignments map[string]NUMAAssignment
+	lh          logr.Logger
 }
 
 func newResourceStore(lh logr.Logger) *resourceStore {
 	return &resourceStore{
-		data: make(map[string]corev1.ResourceList),
-		lh:   lh,
+		data:        make(map[string]corev1.ResourceList),
+		assignments: make(map[string]NUMAAssignment),
+		lh:          lh,
 	}
 }
 
@@ -97,20 +101,67 @@ func (rs *resourceStore) String() string {
 	return sb.String()
 }
 
-// AddPod returns true if updating existing pod, false if adding for the first time
-func (rs *resourceStore) AddPod(pod *corev1.Pod) bool {
+// AddPod returns true if updating existing pod, false if adding for the first time.
+// If not nil, `assignment` tells from which NUMA cells the pod resources are expected to be allocated.
+func (rs *resourceStore) AddPod(pod *corev1.Pod, assignment NUMAAssignment) bool {
 	key := pod.Namespace + "/" + pod.Name
 	_, ok := rs.data[key]
 	if ok {
 		// should not happen, so we log with a low level
 		rs.lh.V(4).Info("updating existing entry", "key", key)
 	}
-	resData := util.GetPodEffectiveRequest(pod)
+	resData := resourcerequests.ForPod(pod)
 	rs.lh.V(5).Info("resourcestore ADD", stringify.ResourceListToLoggable(resData)...)
 	rs.data[key] = resData
+	if assignment != nil {
+		rs.assignments[key] = assignment.DeepCopy()
+	} else {
+		delete(rs.assignments, key)
+	}
 	return ok
 }
 
+// ResizePod updates the resources of a tracked pod after an in-place resize. Returns false if the pod is not tracked.
+// A pod assigned to a single NUMA cell is still expected there, because the kubelet never moves running containers.
+// The new resources of a pod spanning more NUMA cells can't be split among them, so the assignment is dropped
+// and the pod is pessimistically accounted on all the NUMA cells, like pods with unknown placement.
+func (rs *resourceStore) ResizePod(pod *corev1.Pod) bool {
+	key := pod.Namespace + "/" + pod.Name
+	if _, ok := rs.data[key]; !ok {
+		return false
+	}
+	resData := resourcerequests.ForPod(pod)
+	rs.lh.V(5).Info("resourcestore RESIZE", stringify.ResourceListToLoggable(resData)...)
+	rs.data[key] = resData
+	assignment, ok := rs.assignments[key]
+	if !ok {
+		return true
+	}
+	if len(assignment) != 1 {
+		delete(rs.assignments, key)
+		return true
+	}
+	for numaID, numaRes := range assignment {
+		// only the resources deemed NUMA-affine at filter time were assigned
+		newRes := make(corev1.ResourceList, len(numaRes))
+		for name := range numaRes {
+			if qty, ok := resData[name]; ok {
+				newRes[name] = qty.DeepCopy()
+			}
+		}
+		rs.assignments[key] = NUMAAssignment{numaID: newRes}
+	}
+	return true
+}
+
+// SetResources accounts the given resources for the given key, on all the NUMA cells. Used to account
+// resources not requested by a pod being scheduled, like the growth of the resources of a resized pod.
+func (rs *resourceStore) SetResources(key string, res corev1.ResourceList) {
+	rs.lh.V(5).Info("resourcestore SET", stringify.ResourceListToLoggable(res)...)
+	rs.data[key] = res
+	delete(rs.assignments, key)
+}
+
 // DeletePod returns true if deleted an existing pod, false otherwise
 func (rs *resourceStore) DeletePod(pod *corev1.Pod) bool {
 	key := pod.Namespace + "/" + pod.Name
@@ -121,23 +172,38 @@ func (rs *resourceStore) DeletePod(pod *corev1.Pod) bool {
 	}
 	rs.lh.V(5).Info("resourcestore DEL", stringify.ResourceListToLoggable(rs.data[key])...)
 	delete(rs.data, key)
+	delete(rs.assignments, key)
 	return ok
 }
 
-// UpdateNRT updates the provided Node Resource Topology object with the resources tracked in this store,
-// performing pessimistic overallocation across all the NUMA zones.
+// UpdateNRT updates the provided Node Resource Topology object with the resources tracked in this store.
+// The resources of pods with known NUMA assignment are deducted only from the assigned NUMA zones, otherwise
+// the code performs pessimistic overallocation across all the NUMA zones.
 func (rs *resourceStore) UpdateNRT(nrt *topologyv1alpha2.NodeResourceTopology, logKeysAndValues ...any) {
 	for key, res := range rs.data {
-		// We cannot predict on which Zone the workload will be placed.
-		// And we should totally not guess. So the only safe (and conservative)
-		// choice is to decrement the available resources from *all* the zones.
+		assignment, hasAssignment := rs.assignments[key]
+		// Unless the filter step computed the NUMA assignment, we cannot predict on which Zone
+		// the workload will be placed. And we should totally not guess. So the only safe
+		// (and conservative) choice is to decrement the available resources from *all* the zones.
 		// This can cause false negatives, but will never cause false positives,
 		// which are much worse.
 		for zi := 0; zi < len(nrt.Zones); zi++ {
 			zone := &nrt.Zones[zi] // shortcut
+			zoneRes := res
+			if hasAssignment {
+				numaID, err := numanode.NameToID(zone.Name)
+				if err != nil || zone.Type != "Node" {
+					continue
+				}
+				var ok bool
+				zoneRes, ok = assignment[numaID]
+				if !ok {
+					continue
+				}
+			}
 			for ri := 0; ri < len(zone.Resources); ri++ {
 				zr := &zone.Resources[ri] // shortcut
-				qty, ok := res[corev1.ResourceName(zr.Name)]
+				qty, ok := zoneRes[corev1.ResourceName(zr.Name)]
 				if !ok {
 					// this is benign; it is totally possible some resources are not
 					// available on some zones (think PCI devices), hence we don't
@@ -232,13 +298,7 @@ type podData struct {
 // describing the failure
 func checkPodFingerprintForNode(lh logr.Logger, objs []podData, nodeName, pfpExpected string, onlyExclRes bool) error {
 	st := podfingerprint.MakeStatus(nodeName)
-	pfp := podfingerprint.NewTracingFingerprint(len(objs), &st)
-	for _, obj := range objs {
-		if onlyExclRes && !obj.HasExclusiveResources {
-			continue
-		}
-		pfp.Add(obj.Namespace, obj.Name)
-	}
+	pfp := fingerprintPodData(objs, onlyExclRes, &st)
 	pfpComputed := pfp.Sign()
 
 	lh.V(4).Info("podset fingerprint check", "expected", pfpExpected, "computed", pfpComputed, "onlyExclusiveResources", onlyExclRes)
@@ -248,3 +308,22 @@ func checkPodFingerprintForNode(lh logr.Logger, objs []podData, nodeName, pfpExp
 	podfingerprint.MarkCompleted(st)
 	return err
 }
+
+func fingerprintPodData(objs []podData, onlyExclRes bool, st *podfingerprint.Status) *podfingerprint.TracingFingerprint {
+	pfp := podfingerprint.NewTracingFingerprint(len(objs), st)
+	for _, obj := range objs {
+		if onlyExclRes && !obj.HasExclusiveResources {
+			continue
+		}
+		pfp.Add(obj.Namespace, obj.Name)
+	}
+	return pfp
+}
+
+func makePodData(pod *corev1.Pod) podData {
+	return podData{
+		Namespace:             pod.Namespace,
+		Name:                  pod.Name,
+		HasExclusiveResources: resourcerequests.AreExclusiveForPod(pod),
+	}
+}
@@ -25,6 +25,7 @@ import (
 
 	topologyv1alpha2 "github.com/k8stopologyawareschedwg/noderesourcetopology-api/pkg/apis/topology/v1alpha2"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
@@ -411,11 +412,11 @@ func TestResourceStoreAddPod(t *testing.T) {
 	}
 
 	rs := newResourceStore(klog.Background())
-	existed := rs.AddPod(&pod)
+	existed := rs.AddPod(&pod, nil)
 	if existed {
 		t.Fatalf("replaced a pod into a empty resourceStore")
 	}
-	existed = rs.AddPod(&pod)
+	existed = rs.AddPod(&pod, nil)
 	if !existed {
 		t.Fatalf("added pod twice")
 	}
@@ -447,13 +448,65 @@ func TestResourceStoreDeletePod(t *testing.T) {
 	if existed {
 		t.Fatalf("deleted a pod into a empty resourceStore")
 	}
-	rs.AddPod(&pod)
+	rs.AddPod(&pod, nil)
 	existed = rs.DeletePod(&pod)
 	if !existed {
 		t.Fatalf("deleted a pod which was not supposed to be present")
 	}
 }
 
+func TestResourceStoreResizePod(t *testing.T) {
+	pod := corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "ns-0",
+			Name:      "pod-0",
+		},
+		Spec: corev1.PodSpec{
+			Containers: []corev1.Container{
+				{
+					Name: "cnt-0",
+					Resources: corev1.ResourceRequirements{
+						Requests: corev1.ResourceList{
+							corev1.ResourceCPU:    resource.MustParse("16"),
+							corev1.ResourceMemory: resource.MustParse("4Gi"),
+						},
+					},
+				},
+			},
+		},
+	}
+	resized := pod.DeepCopy()
+	resized.Spec.Containers[0].Resources.Requests[corev1.ResourceCPU] = resource.MustParse("8")
+
+	rs := newResourceStore(klog.Background())
+	if rs.ResizePod(resized) {
+		t.Fatalf("resized a pod into a empty resourceStore")
+	}
+
+	rs.AddPod(&pod, NUMAAssignment{1: {corev1.ResourceCPU: resource.MustParse("16")}})
+	if !rs.ResizePod(resized) {
+		t.Fatalf("failed to resize a tracked pod")
+	}
+	expectedRes := corev1.ResourceList{
+		corev1.ResourceCPU:    resource.MustParse("8"),
+		corev1.ResourceMemory: resource.MustParse("4Gi"),
+	}
+	if !equality.Semantic.DeepEqual(rs.data["ns-0/pod-0"], expectedRes) {
+		t.Errorf("unexpected resources: %v", rs.data["ns-0/pod-0"])
+	}
+	expectedAssignment := NUMAAssignment{1: {corev1.ResourceCPU: resource.MustParse("8")}}
+	if !equality.Semantic.DeepEqual(rs.assignments["ns-0/pod-0"], expectedAssignment) {
+		t.Errorf("unexpected assignment: %v", rs.assignments["ns-0/pod-0"])
+	}
+
+	// the new resources can't be split among many NUMA cells
+	rs.AddPod(&pod, NUMAAssignment{0: {corev1.ResourceCPU: resource.MustParse("8")}, 1: {corev1.ResourceCPU: resource.MustParse("8")}})
+	rs.ResizePod(resized)
+	if _, ok := rs.assignments["ns-0/pod-0"]; ok {
+		t.Errorf("unexpected assignment after resize: %v", rs.assignments["ns-0/pod-0"])
+	}
+}
+
 func TestResourceStoreUpdate(t *testing.T) {
 	nrt := &topologyv1alpha2.NodeResourceTopology{
 		ObjectMeta:       metav1.ObjectMeta{Name: "node"},
@@ -510,7 +563,7 @@ func TestResourceStoreUpdate(t *testing.T) {
 	}
 
 	rs := newResourceStore(klog.Background())
-	existed := rs.AddPod(&pod)
+	existed := rs.AddPod(&pod, nil)
 	if existed {
 		t.Fatalf("replacing a pod into a empty resourceStore")
 	}
@@ -565,6 +618,79 @@ func TestResourceStoreUpdate(t *testing.T) {
 	}
 }
 
+func TestResourceStoreUpdateWithNUMAAssignment(t *testing.T) {
+	nrt := &topologyv1alpha2.NodeResourceTopology{
+		ObjectMeta:       metav1.ObjectMeta{Name: "node"},
+		TopologyPolicies: []string{string(topologyv1alpha2.SingleNUMANodePodLevel)},
+		Zones: topologyv1alpha2.ZoneList{
+			{
+				Name: "node-0",
+				Type: "Node",
+				Resources: topologyv1alpha2.ResourceInfoList{
+					MakeTopologyResInfo(cpu, "20", "20"),
+					MakeTopologyResInfo(memory, "32Gi", "32Gi"),
+				},
+			},
+			{
+				Name: "node-1",
+				Type: "Node",
+				Resources: topologyv1alpha2.ResourceInfoList{
+					MakeTopologyResInfo(cpu, "20", "20"),
+					MakeTopologyResInfo(memory, "32Gi", "32Gi"),
+				},
+			},
+		},
+	}
+
+	podRes := corev1.ResourceList{
+		corev1.ResourceCPU:    resource.MustParse("16"),
+		corev1.ResourceMemory: resource.MustParse("4Gi"),
+	}
+	pod := corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "ns-0",
+			Name:      "pod-0",
+		},
+		Spec: corev1.PodSpec{
+			Containers: []corev1.Container{
+				{
+					Name: "cnt-0",
+					Resources: corev1.ResourceRequirements{
+						Requests: podRes,
+					},
+				},
+			},
+		},
+	}
+
+	rs := newResourceStore(klog.Background())
+	rs.AddPod(&pod, NUMAAssignment{1: podRes})
+	rs.UpdateNRT(nrt, "logID", "testResourceStoreUpdateWithNUMAAssignment")
+
+	expected := []struct {
+		zone   int
+		name   string
+		amount string
+	}{
+		{zone: 0, name: cpu, amount: "20"},
+		{zone: 0, name: memory, amount: "32Gi"},
+		{zone: 1, name: cpu, amount: "4"},
+		{zone: 1, name: memory, amount: "28Gi"},
+	}
+	for _, exp := range expected {
+		info := findResourceInfo(nrt.Zones[exp.zone].Resources, exp.name)
+		if info.Available.Cmp(resource.MustParse(exp.amount)) != 0 {
+			t.Errorf("bad availability for resource %q on zone %d: expected %v got %v", exp.name, exp.zone, exp.amount, info.Available)
+		}
+	}
+
+	// updating the pod without assignment must restore the pessimistic accounting
+	rs.AddPod(&pod, nil)
+	if _, ok := rs.assignments["ns-0/pod-0"]; ok {
+		t.Errorf("stale NUMA assignment after update")
+	}
+}
+
 func TestCheckPodFingerprintForNode(t *testing.T) {
 	tcases := []struct {
 		description string
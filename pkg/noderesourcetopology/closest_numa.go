@@ -0,0 +1,155 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package noderesourcetopology
+
+import (
+	v1 "k8s.io/api/core/v1"
+	v1qos "k8s.io/kubernetes/pkg/apis/core/v1/helper/qos"
+	"k8s.io/kubernetes/pkg/scheduler/framework"
+
+	"github.com/go-logr/logr"
+	topologyv1alpha2 "github.com/k8stopologyawareschedwg/noderesourcetopology-api/pkg/apis/topology/v1alpha2"
+	"gonum.org/v1/gonum/stat/combin"
+
+	"sigs.k8s.io/scheduler-plugins/pkg/noderesourcetopology/resourcerequests"
+)
+
+const (
+	// 10 is the distance of a NUMA node from itself as defined by ACPI SLIT, all the other distances are relative to it
+	localDistanceValue = 10
+)
+
+func closestNUMAContainerScopeScore(lh logr.Logger, pod *v1.Pod, zones topologyv1alpha2.ZoneList) (int64, *framework.Status) {
+	nodes := createNUMANodeList(lh, zones)
+	qos := v1qos.GetPodQOS(pod)
+
+	maxDistance := 0
+	// the order how TopologyManager asks for hint is important so doing it in the same order
+	// https://github.com/kubernetes/kubernetes/blob/master/pkg/kubelet/cm/topologymanager/scope_container.go#L52
+	for idx, container := range append(pod.Spec.InitContainers, pod.Spec.Containers...) {
+		// if a container requests only non NUMA just continue
+		if onlyNonNUMAResources(nodes, container.Resources.Requests) {
+			continue
+		}
+		// the regular init containers release their resources once completed, unlike the sidecars
+		holdsResources := idx >= len(pod.Spec.InitContainers) || resourcerequests.IsRestartableInitContainer(&container)
+		combination, distance := closestNUMANodesCombination(lh, qos, nodes, container.Resources.Requests)
+		if combination == nil {
+			// score plugin should be running after resource filter plugin so we should always find a suitable combination
+			lh.Info("cannot find a suitable NUMA nodes combination", "container", container.Name)
+			return framework.MinNodeScore, nil
+		}
+
+		// the score is as good as the worst aligned container
+		if distance > maxDistance {
+			maxDistance = distance
+		}
+
+		if !holdsResources {
+			continue
+		}
+		// subtract the resources requested by the container from the given NUMA.
+		// this is necessary, so we won't allocate the same resources for the upcoming containers
+		subtractFromNUMAs(container.Resources.Requests, nodes, combination...)
+	}
+
+	return normalizeDistanceScore(maxDistance), nil
+}
+
+func closestNUMAPodScopeScore(lh logr.Logger, pod *v1.Pod, zones topologyv1alpha2.ZoneList) (int64, *framework.Status) {
+	nodes := createNUMANodeList(lh, zones)
+	qos := v1qos.GetPodQOS(pod)
+
+	resources := resourcerequests.ForPod(pod)
+	// if a pod requests only non NUMA resources return max score
+	if onlyNonNUMAResources(nodes, resources) {
+		return framework.MaxNodeScore, nil
+	}
+
+	combination, distance := closestNUMANodesCombination(lh, qos, nodes, resources)
+	if combination == nil {
+		// score plugin should be running after resource filter plugin so we should always find a suitable combination
+		lh.Info("cannot find a suitable NUMA nodes combination")
+		return framework.MinNodeScore, nil
+	}
+
+	return normalizeDistanceScore(distance), nil
+}
+
+// normalizeDistanceScore maps the total distance of a NUMA nodes combination to a node score.
+// A single NUMA node has distance 0 and gets the max score; the score then decreases with the total
+// distance, measured in units of the local distance, so a combination of two NUMA nodes
+// with the typical remote distance of 20 or 21 scores about a third of the max score.
+func normalizeDistanceScore(totalDistance int) int64 {
+	return framework.MaxNodeScore * localDistanceValue / int64(localDistanceValue+totalDistance)
+}
+
+// closestNUMANodesCombination returns the indexes of the NUMA nodes which can hold the given resources
+// with the lowest total distance, or nil if the resources can't fit. Like the Topology Manager, only
+// the combinations with the least amount of NUMA nodes are considered.
+// Unlike numaNodesRequired, the NUMA nodes in the combination are not required to expose all
+// the requested resources, so a workload can span a NUMA node with memory and another one with a device.
+// The distance between these NUMA nodes then weighs the device-to-NUMA affinity.
+func closestNUMANodesCombination(lh logr.Logger, qos v1.PodQOSClass, numaNodes NUMANodeList, resources v1.ResourceList) ([]int, int) {
+	for combinationLen := 1; combinationLen <= len(numaNodes); combinationLen++ {
+		var (
+			bestCombination []int
+			bestDistance    int
+		)
+		for _, combination := range combin.Combinations(len(numaNodes), combinationLen) {
+			if !checkResourcesFit(lh, qos, resources, combineResources(numaNodes, combination)) {
+				continue
+			}
+			distance := nodesTotalDistance(lh, numaNodes, combination...)
+			// combinations are generated from the lowest value, so on ties we pick what the Topology Manager would
+			if bestCombination == nil || distance < bestDistance {
+				bestCombination = combination
+				bestDistance = distance
+			}
+		}
+		if bestCombination != nil {
+			lh.V(6).Info("closest NUMA nodes", "numaCells", combinationToNUMAIDs(numaNodes, bestCombination), "distance", bestDistance)
+			return bestCombination, bestDistance
+		}
+	}
+	return nil, 0
+}
+
+// nodesTotalDistance returns the sum of the distances between each pair of different NUMA nodes.
+func nodesTotalDistance(lh logr.Logger, numaNodes NUMANodeList, nodes ...int) int {
+	total := 0
+	for i := 0; i < len(nodes); i++ {
+		for j := i + 1; j < len(nodes); j++ {
+			cost, ok := numaNodes[nodes[i]].Costs[numaNodes[nodes[j]].NUMAID]
+			// we couldn't read Costs assign maxDistanceValue
+			if !ok {
+				lh.Info("cannot retrieve Costs information", "nodeID", numaNodes[nodes[i]].NUMAID)
+				cost = maxDistanceValue
+			}
+			total += cost
+		}
+	}
+	return total
+}
+
+func combinationToNUMAIDs(numaNodes NUMANodeList, combination []int) []int {
+	numaIDs := make([]int, 0, len(combination))
+	for _, nodeIdx := range combination {
+		numaIDs = append(numaIDs, numaNodes[nodeIdx].NUMAID)
+	}
+	return numaIDs
+}
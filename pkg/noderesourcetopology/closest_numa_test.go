@@ -0,0 +1,204 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package noderesourcetopology
+
+import (
+	"fmt"
+	"testing"
+
+	topologyv1alpha2 "github.com/k8stopologyawareschedwg/noderesourcetopology-api/pkg/apis/topology/v1alpha2"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/klog/v2"
+)
+
+// makeDistanceTestZones creates four NUMA zones: 0-1 and 2-3 are close to each other.
+// The NIC is available only on the zones listed in nicZones.
+func makeDistanceTestZones(cpus []string, nicZones ...int) topologyv1alpha2.ZoneList {
+	distances := [][]int64{
+		{10, 11, 21, 21},
+		{11, 10, 21, 21},
+		{21, 21, 10, 12},
+		{21, 21, 12, 10},
+	}
+	zones := topologyv1alpha2.ZoneList{}
+	for i, zoneCPUs := range cpus {
+		zone := topologyv1alpha2.Zone{
+			Name: fmt.Sprintf("node-%d", i),
+			Type: "Node",
+			Resources: topologyv1alpha2.ResourceInfoList{
+				MakeTopologyResInfo(cpu, zoneCPUs, zoneCPUs),
+				MakeTopologyResInfo(memory, "32Gi", "32Gi"),
+			},
+		}
+		for _, nicZone := range nicZones {
+			if nicZone == i {
+				zone.Resources = append(zone.Resources, MakeTopologyResInfo(nicResourceName, "2", "2"))
+			}
+		}
+		for j, distance := range distances[i][:len(cpus)] {
+			zone.Costs = append(zone.Costs, topologyv1alpha2.CostInfo{
+				Name:  fmt.Sprintf("node-%d", j),
+				Value: distance,
+			})
+		}
+		zones = append(zones, zone)
+	}
+	return zones
+}
+
+func TestClosestNUMAPodScopeScore(t *testing.T) {
+	testCases := []struct {
+		description   string
+		zones         topologyv1alpha2.ZoneList
+		podResources  v1.ResourceList
+		expectedScore int64
+	}{
+		{
+			description: "fits on a single NUMA node",
+			zones:       makeDistanceTestZones([]string{"8", "8", "8", "8"}),
+			podResources: v1.ResourceList{
+				v1.ResourceCPU:    resource.MustParse("4"),
+				v1.ResourceMemory: resource.MustParse("4Gi"),
+			},
+			expectedScore: 100,
+		},
+		{
+			description: "spans the closest pair of NUMA nodes",
+			zones:       makeDistanceTestZones([]string{"8", "8", "8", "8"}),
+			podResources: v1.ResourceList{
+				v1.ResourceCPU:    resource.MustParse("12"),
+				v1.ResourceMemory: resource.MustParse("4Gi"),
+			},
+			// distance 11
+			expectedScore: 47,
+		},
+		{
+			description: "only remote NUMA nodes have enough free resources",
+			zones:       makeDistanceTestZones([]string{"8", "2", "2", "8"}),
+			podResources: v1.ResourceList{
+				v1.ResourceCPU:    resource.MustParse("12"),
+				v1.ResourceMemory: resource.MustParse("4Gi"),
+			},
+			// distance 21
+			expectedScore: 32,
+		},
+		{
+			description: "device on the NUMA node close to the CPUs",
+			zones:       makeDistanceTestZones([]string{"8", "2", "2", "2"}, 1, 2),
+			podResources: v1.ResourceList{
+				v1.ResourceCPU:    resource.MustParse("6"),
+				v1.ResourceMemory: resource.MustParse("4Gi"),
+				nicResourceName:   resource.MustParse("1"),
+			},
+			// zones 0 and 1, distance 11
+			expectedScore: 47,
+		},
+		{
+			description: "device on a NUMA node far from the CPUs",
+			zones:       makeDistanceTestZones([]string{"8", "2", "2", "2"}, 2),
+			podResources: v1.ResourceList{
+				v1.ResourceCPU:    resource.MustParse("6"),
+				v1.ResourceMemory: resource.MustParse("4Gi"),
+				nicResourceName:   resource.MustParse("1"),
+			},
+			// zones 0 and 2, distance 21
+			expectedScore: 32,
+		},
+		{
+			description: "not enough resources",
+			zones:       makeDistanceTestZones([]string{"2", "2", "2", "2"}),
+			podResources: v1.ResourceList{
+				v1.ResourceCPU:    resource.MustParse("12"),
+				v1.ResourceMemory: resource.MustParse("4Gi"),
+			},
+			expectedScore: 0,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			pod := makePod("testpod", withMultiContainers([]v1.ResourceList{tc.podResources}))
+			score, status := closestNUMAPodScopeScore(klog.Background(), pod, tc.zones)
+			if status != nil {
+				t.Fatalf("unexpected status: %v", status)
+			}
+			if score != tc.expectedScore {
+				t.Errorf("wrong score: got %d expected %d", score, tc.expectedScore)
+			}
+		})
+	}
+}
+
+func TestClosestNUMAContainerScopeScore(t *testing.T) {
+	zones := makeDistanceTestZones([]string{"8", "8", "8", "8"})
+	pod := makePod("testpod", withMultiContainers([]v1.ResourceList{
+		{v1.ResourceCPU: resource.MustParse("4"), v1.ResourceMemory: resource.MustParse("4Gi")},
+		{v1.ResourceCPU: resource.MustParse("6"), v1.ResourceMemory: resource.MustParse("4Gi")},
+	}))
+	// each container fits on a single NUMA node
+	score, status := closestNUMAContainerScopeScore(klog.Background(), pod, zones)
+	if status != nil {
+		t.Fatalf("unexpected status: %v", status)
+	}
+	if score != 100 {
+		t.Errorf("wrong score: got %d expected 100", score)
+	}
+
+	pod = makePod("testpod", withMultiContainers([]v1.ResourceList{
+		{v1.ResourceCPU: resource.MustParse("4"), v1.ResourceMemory: resource.MustParse("4Gi")},
+		{v1.ResourceCPU: resource.MustParse("12"), v1.ResourceMemory: resource.MustParse("4Gi")},
+	}))
+	// the second container must span NUMA nodes 0 and 1, after the first took half of NUMA node 0
+	score, status = closestNUMAContainerScopeScore(klog.Background(), pod, zones)
+	if status != nil {
+		t.Fatalf("unexpected status: %v", status)
+	}
+	if score != 47 {
+		t.Errorf("wrong score: got %d expected 47", score)
+	}
+
+	zones = makeDistanceTestZones([]string{"8", "4"})
+	pod = makePod("testpod",
+		withMultiInitContainers([]v1.ResourceList{
+			{v1.ResourceCPU: resource.MustParse("6"), v1.ResourceMemory: resource.MustParse("4Gi")},
+		}),
+		withMultiContainers([]v1.ResourceList{
+			{v1.ResourceCPU: resource.MustParse("6"), v1.ResourceMemory: resource.MustParse("4Gi")},
+		}),
+	)
+	// the init container releases NUMA node 0 once completed
+	score, status = closestNUMAContainerScopeScore(klog.Background(), pod, zones)
+	if status != nil {
+		t.Fatalf("unexpected status: %v", status)
+	}
+	if score != 100 {
+		t.Errorf("wrong score: got %d expected 100", score)
+	}
+
+	restartAlways := v1.ContainerRestartPolicyAlways
+	pod.Spec.InitContainers[0].RestartPolicy = &restartAlways
+	// the sidecar holds most of NUMA node 0, so the app container must span NUMA nodes 0 and 1
+	score, status = closestNUMAContainerScopeScore(klog.Background(), pod, zones)
+	if status != nil {
+		t.Fatalf("unexpected status: %v", status)
+	}
+	if score != 47 {
+		t.Errorf("wrong score: got %d expected 47", score)
+	}
+}
@@ -0,0 +1,113 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package noderesourcetopology
+
+import (
+	"k8s.io/kubernetes/pkg/scheduler/framework"
+
+	nrtcache "sigs.k8s.io/scheduler-plugins/pkg/noderesourcetopology/cache"
+)
+
+// numaAssignmentState carries the NUMA assignment computed by Filter for a node to Reserve.
+// Filter runs concurrently on many nodes, so we use a key per node, which avoids any further locking.
+type numaAssignmentState struct {
+	assignment nrtcache.NUMAAssignment
+}
+
+func (s *numaAssignmentState) Clone() framework.StateData {
+	return &numaAssignmentState{
+		assignment: s.assignment.DeepCopy(),
+	}
+}
+
+func numaAssignmentStateKey(nodeName string) framework.StateKey {
+	return framework.StateKey(Name + "/numaAssignment/" + nodeName)
+}
+
+func writeNUMAAssignment(state *framework.CycleState, nodeName string, assignment nrtcache.NUMAAssignment) {
+	if state == nil {
+		return
+	}
+	state.Write(numaAssignmentStateKey(nodeName), &numaAssignmentState{
+		assignment: assignment,
+	})
+}
+
+func deleteNUMAAssignment(state *framework.CycleState, nodeName string) {
+	if state == nil {
+		return
+	}
+	state.Delete(numaAssignmentStateKey(nodeName))
+}
+
+// readNUMAAssignment returns the NUMA assignment computed by Filter for the given node, or nil if unknown.
+func readNUMAAssignment(state *framework.CycleState, nodeName string) nrtcache.NUMAAssignment {
+	if state == nil {
+		return nil
+	}
+	data, err := state.Read(numaAssignmentStateKey(nodeName))
+	if err != nil {
+		return nil
+	}
+	st, ok := data.(*numaAssignmentState)
+	if !ok {
+		return nil
+	}
+	return st.assignment
+}
+
+// numaReleasedState carries the resources the preemption would release on the NUMA zones of a node
+// by evicting the victims selected so far. Filter credits them back on top of the NRT data.
+type numaReleasedState struct {
+	released nrtcache.NUMAAssignment
+}
+
+func (s *numaReleasedState) Clone() framework.StateData {
+	return &numaReleasedState{
+		released: s.released.DeepCopy(),
+	}
+}
+
+func numaReleasedStateKey(nodeName string) framework.StateKey {
+	return framework.StateKey(Name + "/numaReleased/" + nodeName)
+}
+
+func writeNUMAReleased(state *framework.CycleState, nodeName string, released nrtcache.NUMAAssignment) {
+	if state == nil {
+		return
+	}
+	state.Write(numaReleasedStateKey(nodeName), &numaReleasedState{
+		released: released,
+	})
+}
+
+// readNUMAReleased returns the resources the preemption would release on the given node, or nil if
+// we are not simulating a preemption.
+func readNUMAReleased(state *framework.CycleState, nodeName string) nrtcache.NUMAAssignment {
+	if state == nil {
+		return nil
+	}
+	data, err := state.Read(numaReleasedStateKey(nodeName))
+	if err != nil {
+		return nil
+	}
+	st, ok := data.(*numaReleasedState)
+	if !ok {
+		return nil
+	}
+	return st.released
+}
@@ -0,0 +1,111 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package noderesourcetopology
+
+import (
+	v1 "k8s.io/api/core/v1"
+	kubeletconfig "k8s.io/kubernetes/pkg/kubelet/apis/config"
+
+	"github.com/go-logr/logr"
+	topologyv1alpha2 "github.com/k8stopologyawareschedwg/noderesourcetopology-api/pkg/apis/topology/v1alpha2"
+
+	"sigs.k8s.io/scheduler-plugins/pkg/noderesourcetopology/resourcerequests"
+)
+
+// deviceAffinityScore scales the given score by the ratio of the device requests which can be allocated
+// from a single NUMA zone together with their CPUs and memory, so the nodes on which the devices
+// requested by the pod share a NUMA zone with its CPUs and memory are preferred.
+// Only the extended resources declared NUMA-affine in the configuration are considered devices;
+// if the pod requests none of them, the score is returned unchanged.
+func deviceAffinityScore(lh logr.Logger, pod *v1.Pod, zones topologyv1alpha2.ZoneList, scope string, ra resourceAffinity, score int64) int64 {
+	if len(ra.numaAffine) == 0 {
+		return score
+	}
+
+	var requests []v1.ResourceList
+	if scope == kubeletconfig.PodTopologyManagerScope {
+		requests = append(requests, resourcerequests.ForPod(pod))
+	} else {
+		// the regular init containers release their devices once completed, while the sidecars
+		// hold them for the pod lifetime like the app containers do
+		for idx := range pod.Spec.InitContainers {
+			if resourcerequests.IsRestartableInitContainer(&pod.Spec.InitContainers[idx]) {
+				requests = append(requests, pod.Spec.InitContainers[idx].Resources.Requests)
+			}
+		}
+		for _, container := range pod.Spec.Containers {
+			requests = append(requests, container.Resources.Requests)
+		}
+	}
+
+	nodes := createNUMANodeList(lh, zones)
+	withDevices, aligned := 0, 0
+	for _, request := range requests {
+		if !requestsDevices(request, ra) {
+			continue
+		}
+		withDevices++
+
+		numaID, ok := numaNodeWithDevices(nodes, request, ra)
+		if !ok {
+			lh.V(4).Info("devices cannot share a NUMA zone with CPUs and memory")
+			continue
+		}
+		aligned++
+		// like in the filter, account the request so the upcoming ones see the remaining resources
+		subtractFromNUMAs(request.DeepCopy(), nodes, numaID)
+	}
+
+	if withDevices == 0 {
+		return score
+	}
+	lh.V(4).Info("device affinity", "requests", withDevices, "aligned", aligned)
+	return score * int64(aligned) / int64(withDevices)
+}
+
+func requestsDevices(request v1.ResourceList, ra resourceAffinity) bool {
+	for resource, quantity := range request {
+		if ra.isDevice(resource) && !quantity.IsZero() {
+			return true
+		}
+	}
+	return false
+}
+
+// numaNodeWithDevices returns the index of the first NUMA node which can hold all the NUMA-affine
+// resources in the request, if any.
+func numaNodeWithDevices(nodes NUMANodeList, request v1.ResourceList, ra resourceAffinity) (int, bool) {
+	for idx, node := range nodes {
+		if numaNodeFits(node, request, ra) {
+			return idx, true
+		}
+	}
+	return -1, false
+}
+
+func numaNodeFits(node NUMANode, request v1.ResourceList, ra resourceAffinity) bool {
+	for resource, quantity := range request {
+		if quantity.IsZero() || !ra.isNUMAAffine(resource) {
+			continue
+		}
+		available, ok := node.Resources[resource]
+		if !ok || available.Cmp(quantity) < 0 {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,255 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package noderesourcetopology
+
+import (
+	"context"
+	"testing"
+
+	topologyv1alpha2 "github.com/k8stopologyawareschedwg/noderesourcetopology-api/pkg/apis/topology/v1alpha2"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog/v2"
+	kubeletconfig "k8s.io/kubernetes/pkg/kubelet/apis/config"
+	"k8s.io/kubernetes/pkg/scheduler/framework"
+
+	apiconfig "sigs.k8s.io/scheduler-plugins/apis/config"
+	nrtcache "sigs.k8s.io/scheduler-plugins/pkg/noderesourcetopology/cache"
+	"sigs.k8s.io/scheduler-plugins/pkg/noderesourcetopology/nodeconfig"
+	tu "sigs.k8s.io/scheduler-plugins/test/util"
+)
+
+const gpuResourceName = "vendor.com/gpu"
+
+func makeDeviceTestZones() topologyv1alpha2.ZoneList {
+	return topologyv1alpha2.ZoneList{
+		{
+			Name: "node-0",
+			Type: "Node",
+			Resources: topologyv1alpha2.ResourceInfoList{
+				MakeTopologyResInfo(cpu, "4", "4"),
+				MakeTopologyResInfo(memory, "4Gi", "4Gi"),
+			},
+		},
+		{
+			Name: "node-1",
+			Type: "Node",
+			Resources: topologyv1alpha2.ResourceInfoList{
+				MakeTopologyResInfo(cpu, "4", "4"),
+				MakeTopologyResInfo(memory, "4Gi", "4Gi"),
+				MakeTopologyResInfo(gpuResourceName, "1", "1"),
+			},
+		},
+	}
+}
+
+func TestDeviceAffinityScore(t *testing.T) {
+	gpuAffine := newResourceAffinity(&apiconfig.NodeResourceTopologyResourceAffinity{
+		NUMAAffine: []v1.ResourceName{gpuResourceName},
+	})
+	makeSidecarPod := func(restartPolicy *v1.ContainerRestartPolicy) *v1.Pod {
+		pod := makePod("testpod",
+			withMultiInitContainers([]v1.ResourceList{
+				{v1.ResourceCPU: resource.MustParse("1"), v1.ResourceMemory: resource.MustParse("1Gi"), gpuResourceName: resource.MustParse("1")},
+			}),
+			withMultiContainers([]v1.ResourceList{
+				{v1.ResourceCPU: resource.MustParse("1"), v1.ResourceMemory: resource.MustParse("1Gi"), gpuResourceName: resource.MustParse("1")},
+			}),
+		)
+		pod.Spec.InitContainers[0].RestartPolicy = restartPolicy
+		return pod
+	}
+	restartAlways := v1.ContainerRestartPolicyAlways
+
+	testCases := []struct {
+		name     string
+		pod      *v1.Pod
+		scope    string
+		ra       resourceAffinity
+		expected int64
+	}{
+		{
+			name: "no devices declared",
+			pod: makePod("testpod", withMultiContainers([]v1.ResourceList{
+				{v1.ResourceCPU: resource.MustParse("6"), v1.ResourceMemory: resource.MustParse("1Gi"), gpuResourceName: resource.MustParse("1")},
+			})),
+			scope:    kubeletconfig.PodTopologyManagerScope,
+			expected: 80,
+		},
+		{
+			name: "no devices requested",
+			pod: makePod("testpod", withMultiContainers([]v1.ResourceList{
+				{v1.ResourceCPU: resource.MustParse("6"), v1.ResourceMemory: resource.MustParse("1Gi")},
+			})),
+			scope:    kubeletconfig.PodTopologyManagerScope,
+			ra:       gpuAffine,
+			expected: 80,
+		},
+		{
+			name: "pod devices aligned with CPUs and memory",
+			pod: makePod("testpod", withMultiContainers([]v1.ResourceList{
+				{v1.ResourceCPU: resource.MustParse("2"), v1.ResourceMemory: resource.MustParse("1Gi"), gpuResourceName: resource.MustParse("1")},
+			})),
+			scope:    kubeletconfig.PodTopologyManagerScope,
+			ra:       gpuAffine,
+			expected: 80,
+		},
+		{
+			name: "pod devices cannot be aligned with CPUs",
+			pod: makePod("testpod", withMultiContainers([]v1.ResourceList{
+				{v1.ResourceCPU: resource.MustParse("6"), v1.ResourceMemory: resource.MustParse("1Gi"), gpuResourceName: resource.MustParse("1")},
+			})),
+			scope:    kubeletconfig.PodTopologyManagerScope,
+			ra:       gpuAffine,
+			expected: 0,
+		},
+		{
+			name: "half of the containers devices aligned",
+			pod: makePod("testpod", withMultiContainers([]v1.ResourceList{
+				{v1.ResourceCPU: resource.MustParse("1"), v1.ResourceMemory: resource.MustParse("1Gi"), gpuResourceName: resource.MustParse("1")},
+				{v1.ResourceCPU: resource.MustParse("1"), v1.ResourceMemory: resource.MustParse("1Gi"), gpuResourceName: resource.MustParse("1")},
+				{v1.ResourceCPU: resource.MustParse("1"), v1.ResourceMemory: resource.MustParse("1Gi")},
+			})),
+			scope:    kubeletconfig.ContainerTopologyManagerScope,
+			ra:       gpuAffine,
+			expected: 40,
+		},
+		{
+			name:     "init container releases its devices",
+			pod:      makeSidecarPod(nil),
+			scope:    kubeletconfig.ContainerTopologyManagerScope,
+			ra:       gpuAffine,
+			expected: 80,
+		},
+		{
+			name:     "sidecar holds its devices",
+			pod:      makeSidecarPod(&restartAlways),
+			scope:    kubeletconfig.ContainerTopologyManagerScope,
+			ra:       gpuAffine,
+			expected: 40,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := deviceAffinityScore(klog.Background(), tc.pod, makeDeviceTestZones(), tc.scope, tc.ra, 80)
+			if got != tc.expected {
+				t.Errorf("unexpected score: got %d expected %d", got, tc.expected)
+			}
+		})
+	}
+}
+
+func TestFilterWithResourceAffinity(t *testing.T) {
+	nrt := &topologyv1alpha2.NodeResourceTopology{
+		ObjectMeta: metav1.ObjectMeta{Name: "node-devices"},
+		Attributes: topologyv1alpha2.AttributeList{
+			{Name: nodeconfig.AttributePolicy, Value: "single-numa-node"},
+			{Name: nodeconfig.AttributeScope, Value: "pod"},
+		},
+		Zones: topologyv1alpha2.ZoneList{
+			{
+				Name: "node-0",
+				Type: "Node",
+				Resources: topologyv1alpha2.ResourceInfoList{
+					MakeTopologyResInfo(cpu, "4", "4"),
+					MakeTopologyResInfo(memory, "4Gi", "4Gi"),
+					MakeTopologyResInfo(nicResourceName, "2", "0"),
+				},
+			},
+			{
+				Name: "node-1",
+				Type: "Node",
+				Resources: topologyv1alpha2.ResourceInfoList{
+					MakeTopologyResInfo(cpu, "4", "4"),
+					MakeTopologyResInfo(memory, "4Gi", "4Gi"),
+					MakeTopologyResInfo(nicResourceName, "2", "0"),
+				},
+			},
+		},
+	}
+
+	fakeClient, err := tu.NewFakeClient()
+	if err != nil {
+		t.Fatalf("failed to create fake client: %v", err)
+	}
+	if err := fakeClient.Create(context.Background(), nrt.DeepCopy()); err != nil {
+		t.Fatal(err)
+	}
+
+	node := makeNodeFromNodeResourceTopology(nrt)
+	// the GPUs are reported only at node level
+	node.Status.Allocatable[gpuResourceName] = resource.MustParse("2")
+	node.Status.Allocatable[nicResourceName] = resource.MustParse("4")
+	nodeInfo := framework.NewNodeInfo()
+	nodeInfo.SetNode(node)
+
+	gpuPod := makePod("gpupod", withMultiContainers([]v1.ResourceList{
+		{v1.ResourceCPU: resource.MustParse("2"), v1.ResourceMemory: resource.MustParse("1Gi"), gpuResourceName: resource.MustParse("1")},
+	}))
+	nicPod := makePod("nicpod", withMultiContainers([]v1.ResourceList{
+		{v1.ResourceCPU: resource.MustParse("2"), v1.ResourceMemory: resource.MustParse("1Gi"), nicResourceName: resource.MustParse("1")},
+	}))
+
+	testCases := []struct {
+		name        string
+		cfg         *apiconfig.NodeResourceTopologyResourceAffinity
+		pod         *v1.Pod
+		expectedFit bool
+	}{
+		{
+			name:        "device without NUMA affinity is host-level by default",
+			pod:         gpuPod,
+			expectedFit: true,
+		},
+		{
+			name: "NUMA-affine device must be reported in the NUMA zones",
+			cfg: &apiconfig.NodeResourceTopologyResourceAffinity{
+				NUMAAffine: []v1.ResourceName{gpuResourceName},
+			},
+			pod:         gpuPod,
+			expectedFit: false,
+		},
+		{
+			name:        "device reported in the NUMA zones must be aligned by default",
+			pod:         nicPod,
+			expectedFit: false,
+		},
+		{
+			name: "host-level device ignores the NUMA zones",
+			cfg: &apiconfig.NodeResourceTopologyResourceAffinity{
+				HostLevel: []v1.ResourceName{nicResourceName},
+			},
+			pod:         nicPod,
+			expectedFit: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tm := TopologyMatch{
+				nrtCache:         nrtcache.NewPassthrough(klog.Background(), fakeClient),
+				resourceAffinity: newResourceAffinity(tc.cfg),
+			}
+			gotStatus := tm.Filter(context.Background(), framework.NewCycleState(), tc.pod, nodeInfo)
+			if gotStatus.IsSuccess() != tc.expectedFit {
+				t.Errorf("unexpected filter status: %v", gotStatus)
+			}
+		})
+	}
+}
@@ -28,6 +28,7 @@ import (
 
 	"github.com/go-logr/logr"
 	topologyv1alpha2 "github.com/k8stopologyawareschedwg/noderesourcetopology-api/pkg/apis/topology/v1alpha2"
+	nrtcache "sigs.k8s.io/scheduler-plugins/pkg/noderesourcetopology/cache"
 	"sigs.k8s.io/scheduler-plugins/pkg/noderesourcetopology/logging"
 	"sigs.k8s.io/scheduler-plugins/pkg/noderesourcetopology/nodeconfig"
 	"sigs.k8s.io/scheduler-plugins/pkg/noderesourcetopology/resourcerequests"
@@ -41,7 +42,7 @@ const highestNUMAID = 8
 
 type PolicyHandler func(pod *v1.Pod, zoneMap topologyv1alpha2.ZoneList) *framework.Status
 
-func singleNUMAContainerLevelHandler(lh logr.Logger, pod *v1.Pod, zones topologyv1alpha2.ZoneList, nodeInfo *framework.NodeInfo) *framework.Status {
+func singleNUMAContainerLevelHandler(lh logr.Logger, pod *v1.Pod, zones topologyv1alpha2.ZoneList, nodeInfo *framework.NodeInfo, ra resourceAffinity) (nrtcache.NUMAAssignment, *framework.Status) {
 	lh.V(5).Info("container level single NUMA node handler")
 
 	// prepare NUMANodes list from zoneMap
@@ -51,31 +52,49 @@ func singleNUMAContainerLevelHandler(lh logr.Logger, pod *v1.Pod, zones topology
 	// Node() != nil already verified in Filter(), which is the only public entry point
 	logNumaNodes(lh, "container handler NUMA resources", nodeInfo.Node().Name, nodes)
 
+	// the init containers release their resources once completed, so only the sidecars and the app containers matter
+	assignment := nrtcache.NUMAAssignment{}
+
 	// the init containers are running SERIALLY and BEFORE the normal containers.
 	// https://kubernetes.io/docs/concepts/workloads/pods/init-containers/#understanding-init-containers
-	// therefore, we don't need to accumulate their resources together
-	for _, initContainer := range pod.Spec.InitContainers {
-		// TODO: handle sidecar explicitely (new kind)
-		clh := lh.WithValues(logging.KeyContainer, initContainer.Name, logging.KeyContainerKind, logging.KindContainerInit)
+	// therefore, we don't need to accumulate their resources together.
+	// The restartable init containers (sidecars) are the exception: they keep running alongside
+	// the next init containers and the app containers, so they hold their resources like app containers.
+	// https://kubernetes.io/docs/concepts/workloads/pods/sidecar-containers/
+	for idx := range pod.Spec.InitContainers {
+		initContainer := &pod.Spec.InitContainers[idx]
+		isSidecar := resourcerequests.IsRestartableInitContainer(initContainer)
+		clh := lh.WithValues(logging.KeyContainer, initContainer.Name, logging.KeyContainerKind, initContainerKind(isSidecar))
 		clh.V(6).Info("desired resources", stringify.ResourceListToLoggable(initContainer.Resources.Requests)...)
 
-		_, match := resourcesAvailableInAnyNUMANodes(clh, nodes, initContainer.Resources.Requests, qos, nodeInfo)
+		numaID, match := resourcesAvailableInAnyNUMANodes(clh, nodes, initContainer.Resources.Requests, qos, nodeInfo, ra)
 		if !match {
 			// we can't align init container, so definitely we can't align a pod
 			clh.V(2).Info("cannot align container")
-			return framework.NewStatus(framework.Unschedulable, "cannot align init container")
+			return nil, framework.NewStatus(framework.Unschedulable, "cannot align init container")
+		}
+		if !isSidecar {
+			continue
+		}
+
+		err := subtractResourcesFromNUMANodeList(clh, nodes, numaID, qos, initContainer.Resources.Requests)
+		if err != nil {
+			// this is an internal error which should never happen
+			return nil, framework.NewStatus(framework.Error, "inconsistent resource accounting", err.Error())
 		}
+		assignment.Add(numaID, initContainer.Resources.Requests)
+		clh.V(4).Info("container aligned", "numaCell", numaID)
 	}
 
 	for _, container := range pod.Spec.Containers {
 		clh := lh.WithValues(logging.KeyContainer, container.Name, logging.KeyContainerKind, logging.KindContainerApp)
 		clh.V(6).Info("container requests", stringify.ResourceListToLoggable(container.Resources.Requests)...)
 
-		numaID, match := resourcesAvailableInAnyNUMANodes(clh, nodes, container.Resources.Requests, qos, nodeInfo)
+		numaID, match := resourcesAvailableInAnyNUMANodes(clh, nodes, container.Resources.Requests, qos, nodeInfo, ra)
 		if !match {
 			// we can't align container, so definitely we can't align a pod
 			clh.V(2).Info("cannot align container")
-			return framework.NewStatus(framework.Unschedulable, "cannot align container")
+			return nil, framework.NewStatus(framework.Unschedulable, "cannot align container")
 		}
 
 		// subtract the resources requested by the container from the given NUMA.
@@ -83,16 +102,24 @@ func singleNUMAContainerLevelHandler(lh logr.Logger, pod *v1.Pod, zones topology
 		err := subtractResourcesFromNUMANodeList(clh, nodes, numaID, qos, container.Resources.Requests)
 		if err != nil {
 			// this is an internal error which should never happen
-			return framework.NewStatus(framework.Error, "inconsistent resource accounting", err.Error())
+			return nil, framework.NewStatus(framework.Error, "inconsistent resource accounting", err.Error())
 		}
+		assignment.Add(numaID, container.Resources.Requests)
 		clh.V(4).Info("container aligned", "numaCell", numaID)
 	}
-	return nil
+	return assignment, nil
+}
+
+func initContainerKind(isSidecar bool) string {
+	if isSidecar {
+		return logging.KindContainerSidecar
+	}
+	return logging.KindContainerInit
 }
 
 // resourcesAvailableInAnyNUMANodes checks for sufficient resource and return the NUMAID that would be selected by Kubelet.
 // this function requires NUMANodeList with properly populated NUMANode, NUMAID should be in range 0-63
-func resourcesAvailableInAnyNUMANodes(lh logr.Logger, numaNodes NUMANodeList, resources v1.ResourceList, qos v1.PodQOSClass, nodeInfo *framework.NodeInfo) (int, bool) {
+func resourcesAvailableInAnyNUMANodes(lh logr.Logger, numaNodes NUMANodeList, resources v1.ResourceList, qos v1.PodQOSClass, nodeInfo *framework.NodeInfo, ra resourceAffinity) (int, bool) {
 	numaID := highestNUMAID
 	bitmask := bm.NewEmptyBitMask()
 	// set all bits, each bit is a NUMA node, if resources couldn't be aligned
@@ -137,7 +164,7 @@ func resourcesAvailableInAnyNUMANodes(lh logr.Logger, numaNodes NUMANodeList, re
 
 		// non-native resources or ephemeral-storage may not expose NUMA affinity,
 		// but since they are available at node level, this is fine
-		if !hasNUMAAffinity && isHostLevelResource(resource) {
+		if ra.isHostLevel(resource, hasNUMAAffinity) {
 			lh.V(6).Info("resource available at host level (no NUMA affinity)", "resource", resource)
 			continue
 		}
@@ -160,10 +187,10 @@ func resourcesAvailableInAnyNUMANodes(lh logr.Logger, numaNodes NUMANodeList, re
 	return numaID, ret
 }
 
-func singleNUMAPodLevelHandler(lh logr.Logger, pod *v1.Pod, zones topologyv1alpha2.ZoneList, nodeInfo *framework.NodeInfo) *framework.Status {
+func singleNUMAPodLevelHandler(lh logr.Logger, pod *v1.Pod, zones topologyv1alpha2.ZoneList, nodeInfo *framework.NodeInfo, ra resourceAffinity) (nrtcache.NUMAAssignment, *framework.Status) {
 	lh.V(5).Info("pod level single NUMA node handler")
 
-	resources := util.GetPodEffectiveRequest(pod)
+	resources := resourcerequests.ForPod(pod)
 
 	nodes := createNUMANodeList(lh, zones)
 
@@ -171,16 +198,162 @@ func singleNUMAPodLevelHandler(lh logr.Logger, pod *v1.Pod, zones topologyv1alph
 	logNumaNodes(lh, "pod handler NUMA resources", nodeInfo.Node().Name, nodes)
 	lh.V(6).Info("pod desired resources", stringify.ResourceListToLoggable(resources)...)
 
-	numaID, match := resourcesAvailableInAnyNUMANodes(lh, createNUMANodeList(lh, zones), resources, v1qos.GetPodQOS(pod), nodeInfo)
+	numaID, match := resourcesAvailableInAnyNUMANodes(lh, createNUMANodeList(lh, zones), resources, v1qos.GetPodQOS(pod), nodeInfo, ra)
 	if !match {
 		lh.V(2).Info("cannot align pod", "name", pod.Name)
-		return framework.NewStatus(framework.Unschedulable, "cannot align pod")
+		return nil, framework.NewStatus(framework.Unschedulable, "cannot align pod")
 	}
 	lh.V(4).Info("all container placed", "numaCell", numaID)
-	return nil
+	return nrtcache.NUMAAssignment{numaID: resources}, nil
+}
+
+func restrictedContainerLevelHandler(lh logr.Logger, pod *v1.Pod, zones topologyv1alpha2.ZoneList, nodeInfo *framework.NodeInfo, opts nodeconfig.TopologyManagerPolicyOptions, ra resourceAffinity) (nrtcache.NUMAAssignment, *framework.Status) {
+	lh.V(5).Info("container level restricted handler")
+
+	nodes := createNUMANodeList(lh, zones)
+	allocNodes := createAllocatableNUMANodeList(lh, zones)
+	qos := v1qos.GetPodQOS(pod)
+
+	// Node() != nil already verified in Filter(), which is the only public entry point
+	logNumaNodes(lh, "container handler NUMA resources", nodeInfo.Node().Name, nodes)
+
+	assignment := nrtcache.NUMAAssignment{}
+
+	// like in the single-numa-node case, the init containers are running SERIALLY and BEFORE the normal containers,
+	// so we don't need to accumulate their resources together, besides the ones of the sidecars
+	for idx := range pod.Spec.InitContainers {
+		initContainer := &pod.Spec.InitContainers[idx]
+		isSidecar := resourcerequests.IsRestartableInitContainer(initContainer)
+		clh := lh.WithValues(logging.KeyContainer, initContainer.Name, logging.KeyContainerKind, initContainerKind(isSidecar))
+		clh.V(6).Info("desired resources", stringify.ResourceListToLoggable(initContainer.Resources.Requests)...)
+
+		numaNodes, numaRes, match := resourcesAvailableInPreferredNUMANodes(clh, nodes, allocNodes, initContainer.Resources.Requests, qos, nodeInfo, opts, ra)
+		if !match {
+			clh.V(2).Info("cannot align container")
+			return nil, framework.NewStatus(framework.Unschedulable, "cannot align init container")
+		}
+		if !isSidecar {
+			continue
+		}
+
+		// see below about the pessimistic accounting
+		for _, numaID := range numaNodes.GetBits() {
+			assignment.Add(numaID, numaRes)
+		}
+		subtractFromNUMAs(numaRes, nodes, numaNodes.GetBits()...)
+		clh.V(4).Info("container aligned", "numaCells", numaNodes.String())
+	}
+
+	for _, container := range pod.Spec.Containers {
+		clh := lh.WithValues(logging.KeyContainer, container.Name, logging.KeyContainerKind, logging.KindContainerApp)
+		clh.V(6).Info("container requests", stringify.ResourceListToLoggable(container.Resources.Requests)...)
+
+		numaNodes, numaRes, match := resourcesAvailableInPreferredNUMANodes(clh, nodes, allocNodes, container.Resources.Requests, qos, nodeInfo, opts, ra)
+		if !match {
+			clh.V(2).Info("cannot align container")
+			return nil, framework.NewStatus(framework.Unschedulable, "cannot align container")
+		}
+
+		// subtract the resources requested by the container from the given NUMA nodes.
+		// this is necessary, so we won't allocate the same resources for the upcoming containers
+		// we can't predict how the kubelet will split the resources among the NUMA nodes,
+		// so we pessimistically account all of them on each NUMA node.
+		for _, numaID := range numaNodes.GetBits() {
+			assignment.Add(numaID, numaRes)
+		}
+		subtractFromNUMAs(numaRes, nodes, numaNodes.GetBits()...)
+		clh.V(4).Info("container aligned", "numaCells", numaNodes.String())
+	}
+	return assignment, nil
+}
+
+func restrictedPodLevelHandler(lh logr.Logger, pod *v1.Pod, zones topologyv1alpha2.ZoneList, nodeInfo *framework.NodeInfo, opts nodeconfig.TopologyManagerPolicyOptions, ra resourceAffinity) (nrtcache.NUMAAssignment, *framework.Status) {
+	lh.V(5).Info("pod level restricted handler")
+
+	resources := resourcerequests.ForPod(pod)
+
+	nodes := createNUMANodeList(lh, zones)
+
+	// Node() != nil already verified in Filter(), which is the only public entry point
+	logNumaNodes(lh, "pod handler NUMA resources", nodeInfo.Node().Name, nodes)
+	lh.V(6).Info("pod desired resources", stringify.ResourceListToLoggable(resources)...)
+
+	numaNodes, numaRes, match := resourcesAvailableInPreferredNUMANodes(lh, nodes, createAllocatableNUMANodeList(lh, zones), resources, v1qos.GetPodQOS(pod), nodeInfo, opts, ra)
+	if !match {
+		lh.V(2).Info("cannot align pod", "name", pod.Name)
+		return nil, framework.NewStatus(framework.Unschedulable, "cannot align pod")
+	}
+	assignment := nrtcache.NUMAAssignment{}
+	// see restrictedContainerLevelHandler about the pessimistic accounting
+	for _, numaID := range numaNodes.GetBits() {
+		assignment.Add(numaID, numaRes)
+	}
+	lh.V(4).Info("all container placed", "numaCells", numaNodes.String())
+	return assignment, nil
 }
 
-// Filter Now only single-numa-node supported
+// resourcesAvailableInPreferredNUMANodes checks if the given resources can be allocated on the narrowest set of NUMA nodes
+// which could ever hold them, which is what the restricted policy of the TopologyManager requires to admit a workload.
+// The kubelet considers a topology hint preferred only if it spans the minimal amount of NUMA nodes required to satisfy
+// the request on the idle machine, so we compute the minimal set using the allocatable resources (`allocNodes`)
+// and compare it with the minimal set we can actually use, computed using the available resources (`availNodes`).
+// The policy options determine which NUMA nodes are picked among the preferred ones.
+// Returns the NUMA nodes which would be selected, the subset of the resources which are NUMA-affine, and a boolean
+// telling if the resources can be aligned.
+func resourcesAvailableInPreferredNUMANodes(lh logr.Logger, availNodes, allocNodes NUMANodeList, resources v1.ResourceList, qos v1.PodQOSClass, nodeInfo *framework.NodeInfo, opts nodeconfig.TopologyManagerPolicyOptions, ra resourceAffinity) (bm.BitMask, v1.ResourceList, bool) {
+	nodeResources := util.ResourceList(nodeInfo.Allocatable)
+	numaResources := v1.ResourceList{}
+
+	for resource, quantity := range resources {
+		if quantity.IsZero() {
+			// why bother? everything's fine from the perspective of this resource
+			lh.V(4).Info("ignoring zero-qty resource request", "resource", resource)
+			continue
+		}
+
+		if _, ok := nodeResources[resource]; !ok {
+			// see the comment in resourcesAvailableInAnyNUMANodes
+			lh.V(2).Info("early verdict: cannot meet request", "resource", resource, "suitable", "false")
+			return nil, nil, false
+		}
+
+		numaExposed := !onlyNonNUMAResources(availNodes, v1.ResourceList{resource: quantity})
+		if ra.isHostLevel(resource, numaExposed) {
+			lh.V(6).Info("resource available at host level (no NUMA affinity)", "resource", resource)
+			continue
+		}
+		if !numaExposed {
+			lh.V(2).Info("early verdict: missing NUMA affinity", "resource", resource, "suitable", "false")
+			return nil, nil, false
+		}
+
+		numaResources[resource] = quantity
+	}
+
+	if len(numaResources) == 0 {
+		lh.V(2).Info("final verdict: no NUMA-affine resources requested", "suitable", true)
+		return bm.NewEmptyBitMask(), numaResources, true
+	}
+
+	preferredNodes, _ := numaNodesRequired(lh, qos, allocNodes, numaResources)
+	if preferredNodes == nil {
+		lh.V(2).Info("final verdict: request exceeds NUMA allocatable", "suitable", false)
+		return nil, nil, false
+	}
+
+	numaNodes, _ := numaNodesRequiredWithOptions(lh, qos, availNodes, numaResources, opts)
+	if numaNodes == nil {
+		lh.V(2).Info("final verdict: request exceeds NUMA availability", "suitable", false)
+		return nil, nil, false
+	}
+
+	ret := numaNodes.Count() <= preferredNodes.Count()
+	lh.V(2).Info("final verdict", "suitable", ret, "numaCells", numaNodes.String(), "preferredCount", preferredNodes.Count())
+	return numaNodes, numaResources, ret
+}
+
+// Filter supports the single-numa-node and restricted policies. The best-effort and none policies
+// never cause the kubelet to reject a workload, so there is nothing to filter out.
 func (tm *TopologyMatch) Filter(ctx context.Context, cycleState *framework.CycleState, pod *v1.Pod, nodeInfo *framework.NodeInfo) *framework.Status {
 	if nodeInfo.Node() == nil {
 		return framework.NewStatus(framework.Error, "node not found")
@@ -206,30 +379,57 @@ func (tm *TopologyMatch) Filter(ctx context.Context, cycleState *framework.Cycle
 		return nil
 	}
 
+	released := readNUMAReleased(cycleState, nodeName)
+	if released != nil {
+		lh.V(4).Info("simulating preemption", "numaCells", len(released))
+		releaseZoneResources(nodeTopology.Zones, released)
+	}
+
 	conf := nodeconfig.TopologyManagerFromNodeResourceTopology(lh, nodeTopology)
 
 	lh.V(4).Info("found nrt data", "object", stringify.NodeResourceTopologyResources(nodeTopology), "conf", conf.String())
 
-	handler := filterHandlerFromTopologyManager(conf)
+	handler := filterHandlerFromTopologyManager(conf, tm.resourceAffinity)
 	if handler == nil {
 		return nil
 	}
-	status := handler(lh, pod, nodeTopology.Zones, nodeInfo)
+	assignment, status := handler(lh, pod, nodeTopology.Zones, nodeInfo)
 	if status != nil {
-		tm.nrtCache.NodeMaybeOverReserved(nodeName, pod)
+		if released == nil {
+			// a failure while simulating a preemption tells nothing about the cache state
+			tm.nrtCache.NodeMaybeOverReserved(nodeName, pod)
+		}
+		deleteNUMAAssignment(cycleState, nodeName)
+		return status
 	}
-	return status
+	writeNUMAAssignment(cycleState, nodeName, assignment)
+	return nil
 }
 
-func filterHandlerFromTopologyManager(conf nodeconfig.TopologyManager) filterFn {
-	if conf.Policy != kubeletconfig.SingleNumaNodeTopologyManagerPolicy {
-		return nil
-	}
-	if conf.Scope == kubeletconfig.PodTopologyManagerScope {
-		return singleNUMAPodLevelHandler
-	}
-	if conf.Scope == kubeletconfig.ContainerTopologyManagerScope {
-		return singleNUMAContainerLevelHandler
+func filterHandlerFromTopologyManager(conf nodeconfig.TopologyManager, ra resourceAffinity) filterFn {
+	switch conf.Policy {
+	case kubeletconfig.SingleNumaNodeTopologyManagerPolicy:
+		if conf.Scope == kubeletconfig.PodTopologyManagerScope {
+			return func(lh logr.Logger, pod *v1.Pod, zones topologyv1alpha2.ZoneList, nodeInfo *framework.NodeInfo) (nrtcache.NUMAAssignment, *framework.Status) {
+				return singleNUMAPodLevelHandler(lh, pod, zones, nodeInfo, ra)
+			}
+		}
+		if conf.Scope == kubeletconfig.ContainerTopologyManagerScope {
+			return func(lh logr.Logger, pod *v1.Pod, zones topologyv1alpha2.ZoneList, nodeInfo *framework.NodeInfo) (nrtcache.NUMAAssignment, *framework.Status) {
+				return singleNUMAContainerLevelHandler(lh, pod, zones, nodeInfo, ra)
+			}
+		}
+	case kubeletconfig.RestrictedTopologyManagerPolicy:
+		if conf.Scope == kubeletconfig.PodTopologyManagerScope {
+			return func(lh logr.Logger, pod *v1.Pod, zones topologyv1alpha2.ZoneList, nodeInfo *framework.NodeInfo) (nrtcache.NUMAAssignment, *framework.Status) {
+				return restrictedPodLevelHandler(lh, pod, zones, nodeInfo, conf.PolicyOptions, ra)
+			}
+		}
+		if conf.Scope == kubeletconfig.ContainerTopologyManagerScope {
+			return func(lh logr.Logger, pod *v1.Pod, zones topologyv1alpha2.ZoneList, nodeInfo *framework.NodeInfo) (nrtcache.NUMAAssignment, *framework.Status) {
+				return restrictedContainerLevelHandler(lh, pod, zones, nodeInfo, conf.PolicyOptions, ra)
+			}
+		}
 	}
-	return nil // cannot happen
+	return nil // best-effort and none never reject workloads
 }
@@ -25,12 +25,14 @@ import (
 	topologyv1alpha2 "github.com/k8stopologyawareschedwg/noderesourcetopology-api/pkg/apis/topology/v1alpha2"
 
 	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/klog/v2"
 	"k8s.io/kubernetes/pkg/scheduler/framework"
 
 	nrtcache "sigs.k8s.io/scheduler-plugins/pkg/noderesourcetopology/cache"
+	"sigs.k8s.io/scheduler-plugins/pkg/noderesourcetopology/nodeconfig"
 	tu "sigs.k8s.io/scheduler-plugins/test/util"
 )
 
@@ -1179,6 +1181,227 @@ func TestNodeResourceTopologyMultiContainerContainerScope(t *testing.T) {
 	}
 }
 
+func TestNodeResourceTopologyRestricted(t *testing.T) {
+	makeNRT := func(name, policy, scope, availNUMA0, availNUMA1 string) *topologyv1alpha2.NodeResourceTopology {
+		return &topologyv1alpha2.NodeResourceTopology{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Attributes: topologyv1alpha2.AttributeList{
+				{Name: nodeconfig.AttributePolicy, Value: policy},
+				{Name: nodeconfig.AttributeScope, Value: scope},
+			},
+			Zones: topologyv1alpha2.ZoneList{
+				{
+					Name: "node-0",
+					Type: "Node",
+					Resources: topologyv1alpha2.ResourceInfoList{
+						MakeTopologyResInfo(cpu, "16", availNUMA0),
+						MakeTopologyResInfo(memory, "32Gi", "32Gi"),
+					},
+				},
+				{
+					Name: "node-1",
+					Type: "Node",
+					Resources: topologyv1alpha2.ResourceInfoList{
+						MakeTopologyResInfo(cpu, "16", availNUMA1),
+						MakeTopologyResInfo(memory, "32Gi", "32Gi"),
+					},
+				},
+			},
+		}
+	}
+
+	nodeTopologies := []*topologyv1alpha2.NodeResourceTopology{
+		makeNRT("host-pod-unbalanced", "restricted", "pod", "4", "16"),
+		makeNRT("host-pod-balanced", "restricted", "pod", "8", "8"),
+		makeNRT("host-cnt-balanced", "restricted", "container", "8", "8"),
+		makeNRT("host-besteffort", "best-effort", "pod", "8", "8"),
+	}
+
+	nodes := make([]*v1.Node, len(nodeTopologies))
+	for i := range nodes {
+		nodes[i] = makeNodeFromNodeResourceTopology(nodeTopologies[i])
+	}
+
+	tests := []struct {
+		name       string
+		pod        *v1.Pod
+		node       *v1.Node
+		wantStatus *framework.Status
+	}{
+		{
+			name: "gu pod fits on a single NUMA node",
+			pod: makePod("testpod",
+				withMultiContainers([]v1.ResourceList{
+					{v1.ResourceCPU: resource.MustParse("10"), v1.ResourceMemory: resource.MustParse("4Gi")},
+				})),
+			node:       nodes[0],
+			wantStatus: nil,
+		},
+		{
+			name: "gu pod spanning NUMA nodes, minimal set available",
+			pod: makePod("testpod",
+				withMultiContainers([]v1.ResourceList{
+					{v1.ResourceCPU: resource.MustParse("18"), v1.ResourceMemory: resource.MustParse("4Gi")},
+				})),
+			node:       nodes[0],
+			wantStatus: nil,
+		},
+		{
+			name: "gu pod exceeding the NUMA allocatable",
+			pod: makePod("testpod",
+				withMultiContainers([]v1.ResourceList{
+					{v1.ResourceCPU: resource.MustParse("24"), v1.ResourceMemory: resource.MustParse("4Gi")},
+				})),
+			node:       nodes[0],
+			wantStatus: framework.NewStatus(framework.Unschedulable, "cannot align pod"),
+		},
+		{
+			name: "gu pod which would need more than the minimal NUMA nodes",
+			pod: makePod("testpod",
+				withMultiContainers([]v1.ResourceList{
+					{v1.ResourceCPU: resource.MustParse("10"), v1.ResourceMemory: resource.MustParse("4Gi")},
+				})),
+			node:       nodes[1],
+			wantStatus: framework.NewStatus(framework.Unschedulable, "cannot align pod"),
+		},
+		{
+			name: "gu pod with containers each fitting on a single NUMA node",
+			pod: makePod("testpod",
+				withMultiContainers([]v1.ResourceList{
+					{v1.ResourceCPU: resource.MustParse("6"), v1.ResourceMemory: resource.MustParse("4Gi")},
+					{v1.ResourceCPU: resource.MustParse("6"), v1.ResourceMemory: resource.MustParse("4Gi")},
+				})),
+			node:       nodes[2],
+			wantStatus: nil,
+		},
+		{
+			name: "gu pod with a container which would need more than the minimal NUMA nodes",
+			pod: makePod("testpod",
+				withMultiContainers([]v1.ResourceList{
+					{v1.ResourceCPU: resource.MustParse("6"), v1.ResourceMemory: resource.MustParse("4Gi")},
+					{v1.ResourceCPU: resource.MustParse("6"), v1.ResourceMemory: resource.MustParse("4Gi")},
+					{v1.ResourceCPU: resource.MustParse("3"), v1.ResourceMemory: resource.MustParse("4Gi")},
+				})),
+			node:       nodes[2],
+			wantStatus: framework.NewStatus(framework.Unschedulable, "cannot align container"),
+		},
+		{
+			name: "gu pod with a non-NUMA resource",
+			pod: makePod("testpod",
+				withMultiContainers([]v1.ResourceList{
+					{v1.ResourceCPU: resource.MustParse("2"), v1.ResourceMemory: resource.MustParse("4Gi"), v1.ResourceEphemeralStorage: resource.MustParse("1Gi")},
+				})),
+			node: func() *v1.Node {
+				node := nodes[2].DeepCopy()
+				node.Status.Allocatable[v1.ResourceEphemeralStorage] = resource.MustParse("10Gi")
+				return node
+			}(),
+			wantStatus: nil,
+		},
+		{
+			name: "gu pod never rejected with best-effort policy",
+			pod: makePod("testpod",
+				withMultiContainers([]v1.ResourceList{
+					{v1.ResourceCPU: resource.MustParse("10"), v1.ResourceMemory: resource.MustParse("4Gi")},
+				})),
+			node:       nodes[3],
+			wantStatus: nil,
+		},
+	}
+
+	fakeClient, err := tu.NewFakeClient()
+	if err != nil {
+		t.Fatalf("failed to create fake client: %v", err)
+	}
+	for _, obj := range nodeTopologies {
+		if err := fakeClient.Create(context.Background(), obj.DeepCopy()); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tm := TopologyMatch{
+		nrtCache: nrtcache.NewPassthrough(klog.Background(), fakeClient),
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			nodeInfo := framework.NewNodeInfo()
+			nodeInfo.SetNode(tt.node)
+			gotStatus := tm.Filter(context.Background(), framework.NewCycleState(), tt.pod, nodeInfo)
+
+			if !reflect.DeepEqual(gotStatus, tt.wantStatus) {
+				t.Errorf("status does not match: %v, want: %v", gotStatus, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestNodeResourceTopologyNUMAAssignment(t *testing.T) {
+	nrt := &topologyv1alpha2.NodeResourceTopology{
+		ObjectMeta: metav1.ObjectMeta{Name: "host-unbalanced"},
+		Attributes: topologyv1alpha2.AttributeList{
+			{Name: nodeconfig.AttributePolicy, Value: "single-numa-node"},
+			{Name: nodeconfig.AttributeScope, Value: "pod"},
+		},
+		Zones: topologyv1alpha2.ZoneList{
+			{
+				Name: "node-0",
+				Type: "Node",
+				Resources: topologyv1alpha2.ResourceInfoList{
+					MakeTopologyResInfo(cpu, "16", "4"),
+					MakeTopologyResInfo(memory, "32Gi", "32Gi"),
+				},
+			},
+			{
+				Name: "node-1",
+				Type: "Node",
+				Resources: topologyv1alpha2.ResourceInfoList{
+					MakeTopologyResInfo(cpu, "16", "16"),
+					MakeTopologyResInfo(memory, "32Gi", "32Gi"),
+				},
+			},
+		},
+	}
+
+	fakeClient, err := tu.NewFakeClient()
+	if err != nil {
+		t.Fatalf("failed to create fake client: %v", err)
+	}
+	if err := fakeClient.Create(context.Background(), nrt.DeepCopy()); err != nil {
+		t.Fatal(err)
+	}
+
+	tm := TopologyMatch{
+		nrtCache: nrtcache.NewPassthrough(klog.Background(), fakeClient),
+	}
+
+	nodeInfo := framework.NewNodeInfo()
+	nodeInfo.SetNode(makeNodeFromNodeResourceTopology(nrt))
+	state := framework.NewCycleState()
+
+	podRes := v1.ResourceList{v1.ResourceCPU: resource.MustParse("10"), v1.ResourceMemory: resource.MustParse("4Gi")}
+	pod := makePod("testpod", withMultiContainers([]v1.ResourceList{podRes}))
+	if gotStatus := tm.Filter(context.Background(), state, pod, nodeInfo); gotStatus != nil {
+		t.Fatalf("unexpected filter status: %v", gotStatus)
+	}
+
+	expected := nrtcache.NUMAAssignment{1: podRes}
+	got := readNUMAAssignment(state, nrt.Name)
+	if !equality.Semantic.DeepEqual(got, expected) {
+		t.Errorf("unexpected NUMA assignment: got %v expected %v", got, expected)
+	}
+
+	hugePod := makePod("hugepod", withMultiContainers([]v1.ResourceList{
+		{v1.ResourceCPU: resource.MustParse("20"), v1.ResourceMemory: resource.MustParse("4Gi")},
+	}))
+	if gotStatus := tm.Filter(context.Background(), state, hugePod, nodeInfo); gotStatus.IsSuccess() {
+		t.Fatalf("unexpected filter success for a pod exceeding the NUMA capacity")
+	}
+	if got := readNUMAAssignment(state, nrt.Name); got != nil {
+		t.Errorf("stale NUMA assignment after filter failure: %v", got)
+	}
+}
+
 func makeNodeFromNodeResourceTopology(nrt *topologyv1alpha2.NodeResourceTopology) *v1.Node {
 	res := makeResourceListFromZones(nrt.Zones)
 	return &v1.Node{
@@ -1286,3 +1509,85 @@ func parseState(error string) *framework.Status {
 
 	return framework.NewStatus(framework.Unschedulable, error)
 }
+
+func TestSingleNUMAContainerScopeSidecars(t *testing.T) {
+	nrt := &topologyv1alpha2.NodeResourceTopology{
+		ObjectMeta: metav1.ObjectMeta{Name: "node-sidecars"},
+		Attributes: topologyv1alpha2.AttributeList{
+			{Name: nodeconfig.AttributePolicy, Value: "single-numa-node"},
+			{Name: nodeconfig.AttributeScope, Value: "container"},
+		},
+		Zones: topologyv1alpha2.ZoneList{
+			{
+				Name: "node-0",
+				Type: "Node",
+				Resources: topologyv1alpha2.ResourceInfoList{
+					MakeTopologyResInfo(cpu, "4", "4"),
+					MakeTopologyResInfo(memory, "4Gi", "4Gi"),
+				},
+			},
+			{
+				Name: "node-1",
+				Type: "Node",
+				Resources: topologyv1alpha2.ResourceInfoList{
+					MakeTopologyResInfo(cpu, "4", "2"),
+					MakeTopologyResInfo(memory, "4Gi", "4Gi"),
+				},
+			},
+		},
+	}
+
+	fakeClient, err := tu.NewFakeClient()
+	if err != nil {
+		t.Fatalf("failed to create fake client: %v", err)
+	}
+	if err := fakeClient.Create(context.Background(), nrt.DeepCopy()); err != nil {
+		t.Fatal(err)
+	}
+
+	nodeInfo := framework.NewNodeInfo()
+	nodeInfo.SetNode(makeNodeFromNodeResourceTopology(nrt))
+
+	makeSidecarPod := func(restartPolicy *v1.ContainerRestartPolicy) *v1.Pod {
+		pod := makePod("testpod",
+			withMultiInitContainers([]v1.ResourceList{
+				{v1.ResourceCPU: resource.MustParse("3"), v1.ResourceMemory: resource.MustParse("1Gi")},
+			}),
+			withMultiContainers([]v1.ResourceList{
+				{v1.ResourceCPU: resource.MustParse("3"), v1.ResourceMemory: resource.MustParse("1Gi")},
+			}),
+		)
+		pod.Spec.InitContainers[0].RestartPolicy = restartPolicy
+		return pod
+	}
+	restartAlways := v1.ContainerRestartPolicyAlways
+
+	testCases := []struct {
+		name        string
+		pod         *v1.Pod
+		expectedFit bool
+	}{
+		{
+			name:        "init container releases its resources",
+			pod:         makeSidecarPod(nil),
+			expectedFit: true,
+		},
+		{
+			name:        "sidecar holds its resources",
+			pod:         makeSidecarPod(&restartAlways),
+			expectedFit: false,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tm := TopologyMatch{
+				nrtCache: nrtcache.NewPassthrough(klog.Background(), fakeClient),
+			}
+			gotStatus := tm.Filter(context.Background(), framework.NewCycleState(), tc.pod, nodeInfo)
+			if gotStatus.IsSuccess() != tc.expectedFit {
+				t.Errorf("unexpected filter status: %v", gotStatus)
+			}
+		})
+	}
+}
@@ -26,7 +26,8 @@ import (
 	topologyv1alpha2 "github.com/k8stopologyawareschedwg/noderesourcetopology-api/pkg/apis/topology/v1alpha2"
 	"gonum.org/v1/gonum/stat/combin"
 
-	"sigs.k8s.io/scheduler-plugins/pkg/util"
+	"sigs.k8s.io/scheduler-plugins/pkg/noderesourcetopology/nodeconfig"
+	"sigs.k8s.io/scheduler-plugins/pkg/noderesourcetopology/resourcerequests"
 )
 
 const (
@@ -34,7 +35,7 @@ const (
 	maxDistanceValue = 255
 )
 
-func leastNUMAContainerScopeScore(lh logr.Logger, pod *v1.Pod, zones topologyv1alpha2.ZoneList) (int64, *framework.Status) {
+func leastNUMAContainerScopeScore(lh logr.Logger, pod *v1.Pod, zones topologyv1alpha2.ZoneList, opts nodeconfig.TopologyManagerPolicyOptions) (int64, *framework.Status) {
 	nodes := createNUMANodeList(lh, zones)
 	qos := v1qos.GetPodQOS(pod)
 
@@ -42,12 +43,14 @@ func leastNUMAContainerScopeScore(lh logr.Logger, pod *v1.Pod, zones topologyv1a
 	allContainersMinAvgDistance := true
 	// the order how TopologyManager asks for hint is important so doing it in the same order
 	// https://github.com/kubernetes/kubernetes/blob/master/pkg/kubelet/cm/topologymanager/scope_container.go#L52
-	for _, container := range append(pod.Spec.InitContainers, pod.Spec.Containers...) {
+	for idx, container := range append(pod.Spec.InitContainers, pod.Spec.Containers...) {
 		// if a container requests only non NUMA just continue
 		if onlyNonNUMAResources(nodes, container.Resources.Requests) {
 			continue
 		}
-		numaNodes, isMinAvgDistance := numaNodesRequired(lh, qos, nodes, container.Resources.Requests)
+		// the regular init containers release their resources once completed, unlike the sidecars
+		holdsResources := idx >= len(pod.Spec.InitContainers) || resourcerequests.IsRestartableInitContainer(&container)
+		numaNodes, isMinAvgDistance := numaNodesRequiredWithOptions(lh, qos, nodes, container.Resources.Requests, opts)
 		// container's resources can't fit onto node, return MinNodeScore for whole pod
 		if numaNodes == nil {
 			// score plugin should be running after resource filter plugin so we should always find sufficient amount of NUMA nodes
@@ -63,6 +66,9 @@ func leastNUMAContainerScopeScore(lh logr.Logger, pod *v1.Pod, zones topologyv1a
 			maxNUMANodesCount = numaNodes.Count()
 		}
 
+		if !holdsResources {
+			continue
+		}
 		// subtract the resources requested by the container from the given NUMA.
 		// this is necessary, so we won't allocate the same resources for the upcoming containers
 		subtractFromNUMAs(container.Resources.Requests, nodes, numaNodes.GetBits()...)
@@ -72,20 +78,20 @@ func leastNUMAContainerScopeScore(lh logr.Logger, pod *v1.Pod, zones topologyv1a
 		return framework.MaxNodeScore, nil
 	}
 
-	return normalizeScore(maxNUMANodesCount, allContainersMinAvgDistance), nil
+	return normalizeScore(maxNUMANodesCount, allContainersMinAvgDistance, opts.GetMaxAllowableNUMANodes()), nil
 }
 
-func leastNUMAPodScopeScore(lh logr.Logger, pod *v1.Pod, zones topologyv1alpha2.ZoneList) (int64, *framework.Status) {
+func leastNUMAPodScopeScore(lh logr.Logger, pod *v1.Pod, zones topologyv1alpha2.ZoneList, opts nodeconfig.TopologyManagerPolicyOptions) (int64, *framework.Status) {
 	nodes := createNUMANodeList(lh, zones)
 	qos := v1qos.GetPodQOS(pod)
 
-	resources := util.GetPodEffectiveRequest(pod)
+	resources := resourcerequests.ForPod(pod)
 	// if a pod requests only non NUMA resources return max score
 	if onlyNonNUMAResources(nodes, resources) {
 		return framework.MaxNodeScore, nil
 	}
 
-	numaNodes, isMinAvgDistance := numaNodesRequired(lh, qos, nodes, resources)
+	numaNodes, isMinAvgDistance := numaNodesRequiredWithOptions(lh, qos, nodes, resources, opts)
 	// pod's resources can't fit onto node, return MinNodeScore
 	if numaNodes == nil {
 		// score plugin should be running after resource filter plugin so we should always find sufficient amount of NUMA nodes
@@ -93,11 +99,11 @@ func leastNUMAPodScopeScore(lh logr.Logger, pod *v1.Pod, zones topologyv1alpha2.
 		return framework.MinNodeScore, nil
 	}
 
-	return normalizeScore(numaNodes.Count(), isMinAvgDistance), nil
+	return normalizeScore(numaNodes.Count(), isMinAvgDistance, opts.GetMaxAllowableNUMANodes()), nil
 }
 
-func normalizeScore(numaNodesCount int, isMinAvgDistance bool) int64 {
-	numaNodeScore := framework.MaxNodeScore / highestNUMAID
+func normalizeScore(numaNodesCount int, isMinAvgDistance bool, maxNUMANodes int) int64 {
+	numaNodeScore := framework.MaxNodeScore / int64(maxNUMANodes)
 	score := framework.MaxNodeScore - int64(numaNodesCount)*numaNodeScore
 	if isMinAvgDistance {
 		// if distance between NUMA domains is optimal add half of numaNodeScore to make this node more favorable
@@ -165,9 +171,16 @@ func combineResources(numaNodes NUMANodeList, combination []int) v1.ResourceList
 // or nil when resources can't be fitted onto the worker node
 // second value returned is a boolean indicating if bitmask is optimal from distance perspective
 func numaNodesRequired(lh logr.Logger, qos v1.PodQOSClass, numaNodes NUMANodeList, resources v1.ResourceList) (bitmask.BitMask, bool) {
+	return numaNodesRequiredWithOptions(lh, qos, numaNodes, resources, nodeconfig.TopologyManagerPolicyOptions{})
+}
+
+// numaNodesRequiredWithOptions is like numaNodesRequired, but the "prefer-closest-numa-nodes" policy option,
+// if reported, controls which bitmask is returned among the ones with the same amount of NUMA nodes.
+func numaNodesRequiredWithOptions(lh logr.Logger, qos v1.PodQOSClass, numaNodes NUMANodeList, resources v1.ResourceList, opts nodeconfig.TopologyManagerPolicyOptions) (bitmask.BitMask, bool) {
+	preferClosest := opts.GetPreferClosestNUMA()
 	for bitmaskLen := 1; bitmaskLen <= len(numaNodes); bitmaskLen++ {
 		numaNodesCombination := combin.Combinations(len(numaNodes), bitmaskLen)
-		suitableCombination, isMinDistance := findSuitableCombination(lh, qos, numaNodes, resources, numaNodesCombination)
+		suitableCombination, isMinDistance := findSuitableCombination(lh, qos, numaNodes, resources, numaNodesCombination, preferClosest)
 		// we have found suitable combination for given bitmaskLen
 		if suitableCombination != nil {
 			bm := bitmask.NewEmptyBitMask()
@@ -183,8 +196,9 @@ func numaNodesRequired(lh logr.Logger, qos v1.PodQOSClass, numaNodes NUMANodeLis
 
 // findSuitableCombination returns combination from numaNodesCombination that can fit resources, otherwise return nil
 // second value returned is a boolean indicating if returned combination is optimal from distance perspective
-// this function will always return combination that provides minimal average distance between nodes in combination
-func findSuitableCombination(lh logr.Logger, qos v1.PodQOSClass, numaNodes NUMANodeList, resources v1.ResourceList, numaNodesCombination [][]int) ([]int, bool) {
+// if preferClosest is true, this function will always return combination that provides minimal average distance
+// between nodes in combination, otherwise it will return the first suitable combination like the Topology Manager does.
+func findSuitableCombination(lh logr.Logger, qos v1.PodQOSClass, numaNodes NUMANodeList, resources v1.ResourceList, numaNodesCombination [][]int, preferClosest bool) ([]int, bool) {
 	minAvgDistance := minAvgDistanceInCombinations(lh, numaNodes, numaNodesCombination)
 	var (
 		minDistanceCombination []int
@@ -200,6 +214,10 @@ func findSuitableCombination(lh logr.Logger, qos v1.PodQOSClass, numaNodes NUMAN
 
 		if resourcesFit {
 			distance := nodesAvgDistance(lh, numaNodes, combination...)
+			if !preferClosest {
+				// combinations are generated from the lowest value, which is what the Topology Manager picks
+				return combination, distance == minAvgDistance
+			}
 			if distance == minAvgDistance {
 				// return early if we can fit resources into combination and provide minDistance
 				return combination, true
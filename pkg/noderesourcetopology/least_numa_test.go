@@ -25,6 +25,9 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/klog/v2"
 	"k8s.io/kubernetes/pkg/kubelet/cm/topologymanager/bitmask"
+	"k8s.io/utils/ptr"
+
+	"sigs.k8s.io/scheduler-plugins/pkg/noderesourcetopology/nodeconfig"
 )
 
 const (
@@ -697,6 +700,66 @@ func TestNUMANodesRequired(t *testing.T) {
 	}
 }
 
+func TestNUMANodesRequiredPreferClosest(t *testing.T) {
+	makeNUMANode := func(numaID int, costs map[int]int) NUMANode {
+		return NUMANode{
+			NUMAID: numaID,
+			Resources: v1.ResourceList{
+				v1.ResourceCPU:    *resource.NewQuantity(4, resource.DecimalSI),
+				v1.ResourceMemory: resource.MustParse("5Gi"),
+			},
+			Costs: costs,
+		}
+	}
+	numaNodes := NUMANodeList{
+		makeNUMANode(0, map[int]int{0: 10, 1: 20, 2: 12, 3: 20}),
+		makeNUMANode(1, map[int]int{0: 20, 1: 10, 2: 20, 3: 12}),
+		makeNUMANode(2, map[int]int{0: 12, 1: 20, 2: 10, 3: 20}),
+		makeNUMANode(3, map[int]int{0: 20, 1: 12, 2: 20, 3: 10}),
+	}
+	podResources := v1.ResourceList{
+		v1.ResourceCPU:    *resource.NewQuantity(6, resource.DecimalSI),
+		v1.ResourceMemory: resource.MustParse("2Gi"),
+	}
+
+	testCases := []struct {
+		description         string
+		preferClosest       *bool
+		expectedBitmask     bitmask.BitMask
+		expectedMinDistance bool
+	}{
+		{
+			description:         "lowest NUMA nodes picked regardless of the distance",
+			preferClosest:       ptr.To(false),
+			expectedBitmask:     NewTestBitmask(0, 1),
+			expectedMinDistance: false,
+		},
+		{
+			description:         "closest NUMA nodes picked",
+			preferClosest:       ptr.To(true),
+			expectedBitmask:     NewTestBitmask(0, 2),
+			expectedMinDistance: true,
+		},
+		{
+			description:         "closest NUMA nodes picked if the option is not reported",
+			expectedBitmask:     NewTestBitmask(0, 2),
+			expectedMinDistance: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			bm, isMinDistance := numaNodesRequiredWithOptions(klog.Background(), v1.PodQOSGuaranteed, numaNodes, podResources, nodeconfig.TopologyManagerPolicyOptions{PreferClosestNUMA: tc.preferClosest})
+			if bm == nil || !bm.IsEqual(tc.expectedBitmask) {
+				t.Errorf("wrong bitmask expected: %d got: %d", tc.expectedBitmask, bm)
+			}
+			if isMinDistance != tc.expectedMinDistance {
+				t.Errorf("wrong isMinDistance expected: %t got: %t", tc.expectedMinDistance, isMinDistance)
+			}
+		})
+	}
+}
+
 func NewTestBitmask(bits ...int) bitmask.BitMask {
 	bm, _ := bitmask.NewBitMask(bits...)
 	return bm
@@ -706,47 +769,67 @@ func TestNormalizeScore(t *testing.T) {
 	tcases := []struct {
 		description     string
 		score           int
+		maxNUMANodes    int
 		expectedScore   int64
 		optimalDistance bool
 	}{
 		{
 			description:   "1 numa node, non optimal distance",
+			maxNUMANodes:  highestNUMAID,
 			score:         1,
 			expectedScore: 88,
 		},
 		{
 			description:   "2 numa nodes, non optimal distance",
+			maxNUMANodes:  highestNUMAID,
 			score:         2,
 			expectedScore: 76,
 		},
 		{
 			description:   "8 numa nodes, non optimal distance",
+			maxNUMANodes:  highestNUMAID,
 			score:         8,
 			expectedScore: 4,
 		},
 		{
 			description:     "1 numa node, optimal distance",
+			maxNUMANodes:    highestNUMAID,
 			score:           1,
 			expectedScore:   94,
 			optimalDistance: true,
 		},
 		{
 			description:     "2 numa nodes, optimal distance",
+			maxNUMANodes:    highestNUMAID,
 			score:           2,
 			expectedScore:   82,
 			optimalDistance: true,
 		},
 		{
 			description:     "8 numa nodes, optimal distance",
+			maxNUMANodes:    highestNUMAID,
 			score:           8,
 			expectedScore:   10,
 			optimalDistance: true,
 		},
+		{
+			description:     "2 numa nodes, optimal distance, 16 max allowable numa nodes",
+			maxNUMANodes:    16,
+			score:           2,
+			expectedScore:   91,
+			optimalDistance: true,
+		},
+		{
+			description:   "16 numa nodes, non optimal distance, 16 max allowable numa nodes",
+			maxNUMANodes:  16,
+			score:         16,
+			expectedScore: 4,
+		},
 	}
 
 	for _, tc := range tcases {
 		t.Run(tc.description, func(t *testing.T) {
-			normalizedScore := normalizeScore(tc.score, tc.optimalDistance)
+			normalizedScore := normalizeScore(tc.score, tc.optimalDistance, tc.maxNUMANodes)
 			if normalizedScore != tc.expectedScore {
 				t.Errorf("Expected normalizedScore to be %d not %d", tc.expectedScore, normalizedScore)
 			}
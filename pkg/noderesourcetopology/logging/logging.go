@@ -32,6 +32,7 @@ const (
 	KeyContainer     string = "container"
 	KeyContainerKind string = "kind"
 	KeyGeneration    string = "generation"
+	KeyPodGroup      string = "podGroup"
 )
 
 const (
@@ -44,8 +45,9 @@ const (
 )
 
 const (
-	KindContainerInit string = "init"
-	KindContainerApp  string = "app"
+	KindContainerInit    string = "init"
+	KindContainerSidecar string = "sidecar"
+	KindContainerApp     string = "app"
 )
 
 const (
@@ -0,0 +1,133 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metrics
+
+import (
+	"sync"
+	"time"
+
+	"k8s.io/component-base/metrics"
+	"k8s.io/component-base/metrics/legacyregistry"
+)
+
+const (
+	// SchedulerSubsystem - subsystem name used by scheduler
+	SchedulerSubsystem = "scheduler"
+)
+
+// resync triggers
+const (
+	TriggerPeriodic = "periodic"
+	TriggerEvent    = "event"
+)
+
+// desync reasons
+const (
+	ReasonMaybeOverReserved = "maybe_overreserved"
+	ReasonForeignPods       = "foreign_pods"
+	ReasonConfigChanged     = "config_changed"
+)
+
+// All the NRT cache metrics are labeled by the scheduler profile owning the cache, because each profile
+// enabling the plugin has its own cache.
+var (
+	CacheResyncDuration = metrics.NewHistogramVec(
+		&metrics.HistogramOpts{
+			Subsystem:      SchedulerSubsystem,
+			Name:           "nrt_cache_resync_duration_seconds",
+			Help:           "Duration of the NodeResourceTopology cache resync attempts, by trigger.",
+			Buckets:        metrics.ExponentialBuckets(0.001, 2, 15),
+			StabilityLevel: metrics.ALPHA,
+		},
+		[]string{"profile", "trigger"},
+	)
+
+	CacheDesyncedNodes = metrics.NewGaugeVec(
+		&metrics.GaugeOpts{
+			Subsystem:      SchedulerSubsystem,
+			Name:           "nrt_cache_desynced_nodes",
+			Help:           "Number of nodes whose NodeResourceTopology cached data is not in sync, by reason.",
+			StabilityLevel: metrics.ALPHA,
+		},
+		[]string{"profile", "reason"},
+	)
+
+	CacheFingerprintMismatches = metrics.NewCounterVec(
+		&metrics.CounterOpts{
+			Subsystem:      SchedulerSubsystem,
+			Name:           "nrt_cache_fingerprint_mismatches_total",
+			Help:           "Number of resync attempts failed because the podset fingerprint did not match.",
+			StabilityLevel: metrics.ALPHA,
+		},
+		[]string{"profile"},
+	)
+
+	CacheFlushedNodes = metrics.NewCounterVec(
+		&metrics.CounterOpts{
+			Subsystem:      SchedulerSubsystem,
+			Name:           "nrt_cache_flushed_nodes_total",
+			Help:           "Number of nodes whose NodeResourceTopology cached data was flushed.",
+			StabilityLevel: metrics.ALPHA,
+		},
+		[]string{"profile"},
+	)
+
+	CacheReservedPods = metrics.NewGaugeVec(
+		&metrics.GaugeOpts{
+			Subsystem:      SchedulerSubsystem,
+			Name:           "nrt_cache_reserved_pods",
+			Help:           "Number of pods whose resources are assumed on a node, waiting for the NodeResourceTopology data to catch up.",
+			StabilityLevel: metrics.ALPHA,
+		},
+		[]string{"profile", "node"},
+	)
+
+	CacheForeignPodsDetected = metrics.NewCounterVec(
+		&metrics.CounterOpts{
+			Subsystem:      SchedulerSubsystem,
+			Name:           "nrt_cache_foreign_pods_detected_total",
+			Help:           "Number of foreign pods detected on nodes tracked by the NodeResourceTopology cache.",
+			StabilityLevel: metrics.ALPHA,
+		},
+		[]string{"profile"},
+	)
+
+	metricsList = []metrics.Registerable{
+		CacheResyncDuration,
+		CacheDesyncedNodes,
+		CacheFingerprintMismatches,
+		CacheFlushedNodes,
+		CacheReservedPods,
+		CacheForeignPodsDetected,
+	}
+)
+
+var registerMetrics sync.Once
+
+// Register all metrics.
+func Register() {
+	registerMetrics.Do(func() {
+		for _, metric := range metricsList {
+			legacyregistry.MustRegister(metric)
+		}
+	})
+}
+
+// SinceInSeconds gets the time since the specified start in seconds.
+func SinceInSeconds(start time.Time) float64 {
+	return time.Since(start).Seconds()
+}
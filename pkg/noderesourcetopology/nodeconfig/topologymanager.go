@@ -18,6 +18,7 @@ package nodeconfig
 
 import (
 	"fmt"
+	"strconv"
 
 	kubeletconfig "k8s.io/kubernetes/pkg/kubelet/apis/config"
 
@@ -28,9 +29,18 @@ import (
 const (
 	AttributeScope  = "topologyManagerScope"
 	AttributePolicy = "topologyManagerPolicy"
+
+	// topologyManagerPolicyOptions are expanded in one attribute per option, see README.md for the details.
+	AttributeOptionPreferClosestNUMANodes = "topologyManagerOptionPreferClosestNumaNodes"
+	AttributeOptionMaxAllowableNUMANodes  = "topologyManagerOptionMaxAllowableNumaNodes"
 )
 
-// TODO: handle topologyManagerPolicyOptions added in k8s 1.26
+const (
+	// DefaultMaxAllowableNUMANodes is the maximum number of NUMA nodes the kubelet Topology Manager allows,
+	// unless the "max-allowable-numa-nodes" policy option overrides it.
+	// https://kubernetes.io/docs/tasks/administer-cluster/topology-manager/#known-limitations
+	DefaultMaxAllowableNUMANodes = 8
+)
 
 func IsValidScope(scope string) bool {
 	if scope == kubeletconfig.ContainerTopologyManagerScope || scope == kubeletconfig.PodTopologyManagerScope {
@@ -47,9 +57,38 @@ func IsValidPolicy(policy string) bool {
 	return false
 }
 
+// TopologyManagerPolicyOptions mirrors the kubelet topologyManagerPolicyOptions. The zero value
+// represents the options not reported by the node.
+type TopologyManagerPolicyOptions struct {
+	// PreferClosestNUMA makes the Topology Manager favor, among the sets of NUMA nodes of the same size,
+	// the one with the lowest average distance between its NUMA nodes. Nil means unset.
+	PreferClosestNUMA *bool
+	// MaxAllowableNUMANodes overrides DefaultMaxAllowableNUMANodes. Zero means unset.
+	// The kubelet refuses to start on nodes with more NUMA nodes than this, so it only matters to scale the scores.
+	MaxAllowableNUMANodes int
+}
+
+// GetPreferClosestNUMA tells if the closest NUMA nodes are preferred. If the node doesn't report the option,
+// the closest NUMA nodes are preferred, which is how the scheduler behaved before the option was reported.
+func (opts TopologyManagerPolicyOptions) GetPreferClosestNUMA() bool {
+	if opts.PreferClosestNUMA == nil {
+		return true
+	}
+	return *opts.PreferClosestNUMA
+}
+
+// GetMaxAllowableNUMANodes returns the maximum number of NUMA nodes the Topology Manager handles.
+func (opts TopologyManagerPolicyOptions) GetMaxAllowableNUMANodes() int {
+	if opts.MaxAllowableNUMANodes <= 0 {
+		return DefaultMaxAllowableNUMANodes
+	}
+	return opts.MaxAllowableNUMANodes
+}
+
 type TopologyManager struct {
-	Scope  string
-	Policy string
+	Scope         string
+	Policy        string
+	PolicyOptions TopologyManagerPolicyOptions
 }
 
 func TopologyManagerDefaults() TopologyManager {
@@ -70,7 +109,7 @@ func TopologyManagerFromNodeResourceTopology(lh logr.Logger, nodeTopology *topol
 }
 
 func (conf TopologyManager) String() string {
-	return fmt.Sprintf("policy=%q scope=%q", conf.Policy, conf.Scope)
+	return fmt.Sprintf("policy=%q scope=%q preferClosestNUMA=%v maxAllowableNUMANodes=%d", conf.Policy, conf.Scope, conf.PolicyOptions.GetPreferClosestNUMA(), conf.PolicyOptions.GetMaxAllowableNUMANodes())
 }
 
 func (conf TopologyManager) Equal(other TopologyManager) bool {
@@ -80,6 +119,12 @@ func (conf TopologyManager) Equal(other TopologyManager) bool {
 	if conf.Policy != other.Policy {
 		return false
 	}
+	if conf.PolicyOptions.GetPreferClosestNUMA() != other.PolicyOptions.GetPreferClosestNUMA() {
+		return false
+	}
+	if conf.PolicyOptions.GetMaxAllowableNUMANodes() != other.PolicyOptions.GetMaxAllowableNUMANodes() {
+		return false
+	}
 	return true
 }
 
@@ -93,7 +138,19 @@ func (conf *TopologyManager) updateFromAttributes(attrs topologyv1alpha2.Attribu
 			conf.Policy = attr.Value
 			continue
 		}
-		// TODO: handle topologyManagerPolicyOptions added in k8s 1.26
+		if attr.Name == AttributeOptionPreferClosestNUMANodes {
+			if val, err := strconv.ParseBool(attr.Value); err == nil {
+				conf.PolicyOptions.PreferClosestNUMA = &val
+			}
+			continue
+		}
+		if attr.Name == AttributeOptionMaxAllowableNUMANodes {
+			// like kubelet does, we don't allow to lower the default limit
+			if val, err := strconv.Atoi(attr.Value); err == nil && val >= DefaultMaxAllowableNUMANodes {
+				conf.PolicyOptions.MaxAllowableNUMANodes = val
+			}
+			continue
+		}
 	}
 }
 
@@ -22,6 +22,7 @@ import (
 
 	"k8s.io/klog/v2"
 	kubeletconfig "k8s.io/kubernetes/pkg/kubelet/apis/config"
+	"k8s.io/utils/ptr"
 
 	topologyv1alpha2 "github.com/k8stopologyawareschedwg/noderesourcetopology-api/pkg/apis/topology/v1alpha2"
 )
@@ -205,6 +206,36 @@ func TestTopologyManagerEqual(t *testing.T) {
 			},
 			expected: false,
 		},
+		{
+			name: "options diff",
+			tmA: TopologyManager{
+				Scope:  "container",
+				Policy: "restricted",
+				PolicyOptions: TopologyManagerPolicyOptions{
+					PreferClosestNUMA: ptr.To(false),
+				},
+			},
+			tmB: TopologyManager{
+				Scope:  "container",
+				Policy: "restricted",
+			},
+			expected: false,
+		},
+		{
+			name: "options matching, explicit default",
+			tmA: TopologyManager{
+				Scope:  "container",
+				Policy: "restricted",
+				PolicyOptions: TopologyManagerPolicyOptions{
+					MaxAllowableNUMANodes: DefaultMaxAllowableNUMANodes,
+				},
+			},
+			tmB: TopologyManager{
+				Scope:  "container",
+				Policy: "restricted",
+			},
+			expected: true,
+		},
 	}
 
 	for _, tt := range tests {
@@ -292,6 +323,44 @@ func TestConfigFromAttributes(t *testing.T) {
 				Scope:  kubeletconfig.PodTopologyManagerScope,
 			},
 		},
+		{
+			name: "policy-options",
+			attrs: topologyv1alpha2.AttributeList{
+				{
+					Name:  "topologyManagerPolicy",
+					Value: "restricted",
+				},
+				{
+					Name:  "topologyManagerOptionPreferClosestNumaNodes",
+					Value: "true",
+				},
+				{
+					Name:  "topologyManagerOptionMaxAllowableNumaNodes",
+					Value: "16",
+				},
+			},
+			expected: TopologyManager{
+				Policy: kubeletconfig.RestrictedTopologyManagerPolicy,
+				PolicyOptions: TopologyManagerPolicyOptions{
+					PreferClosestNUMA:     ptr.To(true),
+					MaxAllowableNUMANodes: 16,
+				},
+			},
+		},
+		{
+			name: "policy-options-invalid",
+			attrs: topologyv1alpha2.AttributeList{
+				{
+					Name:  "topologyManagerOptionPreferClosestNumaNodes",
+					Value: "yes",
+				},
+				{
+					Name:  "topologyManagerOptionMaxAllowableNumaNodes",
+					Value: "4",
+				},
+			},
+			expected: TopologyManager{},
+		},
 		{
 			name: "error-case-1",
 			attrs: topologyv1alpha2.AttributeList{
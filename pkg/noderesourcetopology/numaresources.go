@@ -24,8 +24,10 @@ import (
 
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/util/sets"
 	v1helper "k8s.io/kubernetes/pkg/apis/core/v1/helper"
 
+	apiconfig "sigs.k8s.io/scheduler-plugins/apis/config"
 	"sigs.k8s.io/scheduler-plugins/pkg/noderesourcetopology/stringify"
 )
 
@@ -134,6 +136,45 @@ func isNUMAAffineResource(resource corev1.ResourceName) bool {
 	return false
 }
 
+// resourceAffinity refines the builtin classification of the resources with the extended resources
+// the admin declared NUMA-affine or host-level in the plugin configuration.
+type resourceAffinity struct {
+	numaAffine sets.Set[corev1.ResourceName]
+	hostLevel  sets.Set[corev1.ResourceName]
+}
+
+func newResourceAffinity(cfg *apiconfig.NodeResourceTopologyResourceAffinity) resourceAffinity {
+	if cfg == nil {
+		return resourceAffinity{}
+	}
+	return resourceAffinity{
+		numaAffine: sets.New[corev1.ResourceName](cfg.NUMAAffine...),
+		hostLevel:  sets.New[corev1.ResourceName](cfg.HostLevel...),
+	}
+}
+
+// isHostLevel tells if the resource can be satisfied at host level, ignoring the NUMA zones.
+// numaExposed tells if the node reports the resource in its NUMA zones.
+func (ra resourceAffinity) isHostLevel(resource corev1.ResourceName, numaExposed bool) bool {
+	if ra.hostLevel.Has(resource) {
+		return true
+	}
+	if numaExposed || ra.numaAffine.Has(resource) {
+		return false
+	}
+	return isHostLevelResource(resource)
+}
+
+// isNUMAAffine tells if the resource must be allocated from the same NUMA zones as the CPUs and the memory.
+func (ra resourceAffinity) isNUMAAffine(resource corev1.ResourceName) bool {
+	return ra.numaAffine.Has(resource) || isNUMAAffineResource(resource)
+}
+
+// isDevice tells if the resource is an extended resource declared NUMA-affine.
+func (ra resourceAffinity) isDevice(resource corev1.ResourceName) bool {
+	return ra.numaAffine.Has(resource)
+}
+
 func isResourceSetSuitable(qos corev1.PodQOSClass, resource corev1.ResourceName, quantity, numaQuantity resource.Quantity) bool {
 	if qos != corev1.PodQOSGuaranteed && isNUMAAffineResource(resource) {
 		return true
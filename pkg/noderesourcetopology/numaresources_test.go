@@ -24,6 +24,8 @@ import (
 	"k8s.io/klog/v2/ktesting"
 
 	corev1 "k8s.io/api/core/v1"
+
+	apiconfig "sigs.k8s.io/scheduler-plugins/apis/config"
 )
 
 func TestIsHostLevelResource(t *testing.T) {
@@ -114,6 +116,63 @@ func TestIsNUMAAffineResource(t *testing.T) {
 	}
 }
 
+func TestResourceAffinity(t *testing.T) {
+	ra := newResourceAffinity(&apiconfig.NodeResourceTopologyResourceAffinity{
+		NUMAAffine: []corev1.ResourceName{"awesome.com/gpu-for-ai"},
+		HostLevel:  []corev1.ResourceName{"vendor.io/fastest-nic"},
+	})
+
+	testCases := []struct {
+		resource           corev1.ResourceName
+		numaExposed        bool
+		expectedHostLevel  bool
+		expectedNUMAAffine bool
+	}{
+		{
+			resource:           corev1.ResourceCPU,
+			expectedHostLevel:  false,
+			expectedNUMAAffine: true,
+		},
+		{
+			resource:           corev1.ResourceEphemeralStorage,
+			expectedHostLevel:  true,
+			expectedNUMAAffine: false,
+		},
+		{
+			resource:           corev1.ResourceName("awesome.com/gpu-for-ai"),
+			expectedHostLevel:  false,
+			expectedNUMAAffine: true,
+		},
+		{
+			resource:           corev1.ResourceName("vendor.io/fastest-nic"),
+			numaExposed:        true,
+			expectedHostLevel:  true,
+			expectedNUMAAffine: false,
+		},
+		{
+			resource:           corev1.ResourceName("example.com/other-device"),
+			expectedHostLevel:  true,
+			expectedNUMAAffine: false,
+		},
+		{
+			resource:           corev1.ResourceName("example.com/other-device"),
+			numaExposed:        true,
+			expectedHostLevel:  false,
+			expectedNUMAAffine: false,
+		},
+	}
+	for _, testCase := range testCases {
+		t.Run(fmt.Sprintf("%s/exposed=%t", testCase.resource, testCase.numaExposed), func(t *testing.T) {
+			if got := ra.isHostLevel(testCase.resource, testCase.numaExposed); got != testCase.expectedHostLevel {
+				t.Errorf("host level: expected %t got %t", testCase.expectedHostLevel, got)
+			}
+			if got := ra.isNUMAAffine(testCase.resource); got != testCase.expectedNUMAAffine {
+				t.Errorf("NUMA affine: expected %t got %t", testCase.expectedNUMAAffine, got)
+			}
+		})
+	}
+}
+
 func TestSubtractResourcesFromNUMANodeList(t *testing.T) {
 	testCases := []struct {
 		name          string
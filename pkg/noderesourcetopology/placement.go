@@ -0,0 +1,116 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package noderesourcetopology
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/go-logr/logr"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	k8scache "k8s.io/client-go/tools/cache"
+	"k8s.io/klog/v2"
+
+	nrtcache "sigs.k8s.io/scheduler-plugins/pkg/noderesourcetopology/cache"
+	"sigs.k8s.io/scheduler-plugins/pkg/noderesourcetopology/logging"
+)
+
+// numaPlacements remembers the NUMA assignment of the pods placed by this scheduler instance,
+// so the preemption can tell which NUMA zones would be freed by evicting them.
+// Unlike the reservations in the NRT cache, the placements are not flushed when the NRT data
+// is resynced, but they are lost when the scheduler restarts.
+// A nil *numaPlacements is valid and tracks nothing.
+type numaPlacements struct {
+	lock sync.RWMutex
+	// nodeName -> podUID -> assignment
+	nodes map[string]map[types.UID]nrtcache.NUMAAssignment
+}
+
+func newNUMAPlacements() *numaPlacements {
+	return &numaPlacements{
+		nodes: make(map[string]map[types.UID]nrtcache.NUMAAssignment),
+	}
+}
+
+// Add records the assignment of the pod on the given node. A nil assignment forgets the pod.
+func (np *numaPlacements) Add(nodeName string, pod *corev1.Pod, assignment nrtcache.NUMAAssignment) {
+	if np == nil {
+		return
+	}
+	if assignment == nil {
+		np.Delete(nodeName, pod)
+		return
+	}
+	np.lock.Lock()
+	defer np.lock.Unlock()
+	pods, ok := np.nodes[nodeName]
+	if !ok {
+		pods = make(map[types.UID]nrtcache.NUMAAssignment)
+		np.nodes[nodeName] = pods
+	}
+	pods[pod.UID] = assignment.DeepCopy()
+}
+
+func (np *numaPlacements) Delete(nodeName string, pod *corev1.Pod) {
+	if np == nil {
+		return
+	}
+	np.lock.Lock()
+	defer np.lock.Unlock()
+	pods, ok := np.nodes[nodeName]
+	if !ok {
+		return
+	}
+	delete(pods, pod.UID)
+	if len(pods) == 0 {
+		delete(np.nodes, nodeName)
+	}
+}
+
+// Get returns the assignment of the pod on the given node, or nil if unknown.
+// The returned value must not be modified.
+func (np *numaPlacements) Get(nodeName string, pod *corev1.Pod) nrtcache.NUMAAssignment {
+	if np == nil {
+		return nil
+	}
+	np.lock.RLock()
+	defer np.lock.RUnlock()
+	return np.nodes[nodeName][pod.UID]
+}
+
+// setupPodEvents forgets the placement of the pods once they are deleted.
+func (np *numaPlacements) setupPodEvents(lh logr.Logger, podInformer k8scache.SharedInformer) {
+	podInformer.AddEventHandler(k8scache.ResourceEventHandlerFuncs{
+		DeleteFunc: func(obj interface{}) {
+			if tombstone, ok := obj.(k8scache.DeletedFinalStateUnknown); ok {
+				obj = tombstone.Obj
+			}
+			pod, ok := obj.(*corev1.Pod)
+			if !ok {
+				lh.V(3).Info("unsupported object", "kind", fmt.Sprintf("%T", obj))
+				return
+			}
+			if pod.Spec.NodeName == "" {
+				return
+			}
+			lh.V(6).Info("forgetting NUMA placement", logging.KeyPod, klog.KObj(pod), logging.KeyPodUID, logging.PodUID(pod), logging.KeyNode, pod.Spec.NodeName)
+			np.Delete(pod.Spec.NodeName, pod)
+		},
+	})
+}
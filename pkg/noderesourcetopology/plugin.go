@@ -30,6 +30,7 @@ import (
 	apiconfig "sigs.k8s.io/scheduler-plugins/apis/config"
 	"sigs.k8s.io/scheduler-plugins/apis/config/validation"
 	nrtcache "sigs.k8s.io/scheduler-plugins/pkg/noderesourcetopology/cache"
+	"sigs.k8s.io/scheduler-plugins/pkg/noderesourcetopology/metrics"
 
 	"github.com/go-logr/logr"
 	topologyapi "github.com/k8stopologyawareschedwg/noderesourcetopology-api/pkg/apis/topology"
@@ -48,7 +49,7 @@ func init() {
 	utilruntime.Must(topologyv1alpha2.AddToScheme(scheme))
 }
 
-type filterFn func(lh logr.Logger, pod *v1.Pod, zones topologyv1alpha2.ZoneList, nodeInfo *framework.NodeInfo) *framework.Status
+type filterFn func(lh logr.Logger, pod *v1.Pod, zones topologyv1alpha2.ZoneList, nodeInfo *framework.NodeInfo) (nrtcache.NUMAAssignment, *framework.Status)
 type scoringFn func(logr.Logger, *v1.Pod, topologyv1alpha2.ZoneList) (int64, *framework.Status)
 
 // TopologyMatch plugin which run simplified version of TopologyManager's admit handler
@@ -57,12 +58,19 @@ type TopologyMatch struct {
 	nrtCache            nrtcache.Interface
 	scoreStrategyFunc   scoreStrategyFn
 	scoreStrategyType   apiconfig.ScoringStrategyType
+	// numaScoreAggregation tells how the scores of the NUMA zones make the node score
+	numaScoreAggregation apiconfig.NUMAScoreAggregation
+	placements           *numaPlacements
+	resourceAffinity     resourceAffinity
+	foreignPods          *nrtcache.ForeignPodsDetector
+	fh                   framework.Handle
 }
 
 var _ framework.FilterPlugin = &TopologyMatch{}
 var _ framework.ReservePlugin = &TopologyMatch{}
 var _ framework.ScorePlugin = &TopologyMatch{}
 var _ framework.EnqueueExtensions = &TopologyMatch{}
+var _ framework.PreBindPlugin = &TopologyMatch{}
 var _ framework.PostBindPlugin = &TopologyMatch{}
 
 // Name returns name of the plugin. It is used in logs, etc.
@@ -70,6 +78,12 @@ func (tm *TopologyMatch) Name() string {
 	return Name
 }
 
+// NRTCache returns the NodeResourceTopology cache used by the plugin instance.
+// Meant for troubleshooting, like dumping the cache state.
+func (tm *TopologyMatch) NRTCache() nrtcache.Interface {
+	return tm.nrtCache
+}
+
 // New initializes a new plugin and returns it.
 func New(ctx context.Context, args runtime.Object, handle framework.Handle) (framework.Plugin, error) {
 	lh := klog.FromContext(ctx)
@@ -84,12 +98,29 @@ func New(ctx context.Context, args runtime.Object, handle framework.Handle) (fra
 		return nil, err
 	}
 
-	nrtCache, err := initNodeTopologyInformer(ctx, lh, tcfg, handle)
+	metrics.Register()
+
+	nrtCache, foreignPods, err := initNodeTopologyInformer(ctx, lh, tcfg, handle)
 	if err != nil {
 		lh.Error(err, "cannot create clientset for NodeTopologyResource", "kubeConfig", handle.KubeConfig())
 		return nil, err
 	}
 
+	topologyMatch, err := newTopologyMatch(tcfg, nrtCache)
+	if err != nil {
+		return nil, err
+	}
+	topologyMatch.foreignPods = foreignPods
+	topologyMatch.fh = handle
+	if informerFactory := handle.SharedInformerFactory(); informerFactory != nil {
+		topologyMatch.placements.setupPodEvents(lh, informerFactory.Core().V1().Pods().Informer())
+	}
+
+	return withPreemption(topologyMatch, tcfg.Preemption), nil
+}
+
+// newTopologyMatch creates a plugin instance using the given cache, not bound to any scheduler framework.
+func newTopologyMatch(tcfg *apiconfig.NodeResourceTopologyMatchArgs, nrtCache nrtcache.Interface) (*TopologyMatch, error) {
 	resToWeightMap := make(resourceToWeightMap)
 	for _, resource := range tcfg.ScoringStrategy.Resources {
 		resToWeightMap[v1.ResourceName(resource.Name)] = resource.Weight
@@ -105,20 +136,20 @@ func New(ctx context.Context, args runtime.Object, handle framework.Handle) (fra
 		return nil, err
 	}
 
-	topologyMatch := &TopologyMatch{
-		resourceToWeightMap: resToWeightMap,
-		nrtCache:            nrtCache,
-		scoreStrategyFunc:   strategy,
-		scoreStrategyType:   tcfg.ScoringStrategy.Type,
-	}
-
-	return topologyMatch, nil
+	return &TopologyMatch{
+		resourceToWeightMap:  resToWeightMap,
+		nrtCache:             nrtCache,
+		scoreStrategyFunc:    strategy,
+		scoreStrategyType:    tcfg.ScoringStrategy.Type,
+		numaScoreAggregation: tcfg.ScoringStrategy.NUMAAggregation,
+		placements:           newNUMAPlacements(),
+		resourceAffinity:     newResourceAffinity(tcfg.ResourceAffinity),
+	}, nil
 }
 
 // EventsToRegister returns the possible events that may make a Pod
 // failed by this plugin schedulable.
-// NOTE: if in-place-update (KEP 1287) gets implemented, then PodUpdate event
-// should be registered for this plugin since a Pod update may free up resources
+// PodUpdate is registered because an in-place resize (KEP 1287) may free up resources
 // that make other Pods schedulable.
 func (tm *TopologyMatch) EventsToRegister() []framework.ClusterEventWithHint {
 	// To register a custom event, follow the naming convention at:
@@ -126,7 +157,7 @@ func (tm *TopologyMatch) EventsToRegister() []framework.ClusterEventWithHint {
 	// Please follow: eventhandlers.go#L403-L410
 	nrtGVK := fmt.Sprintf("noderesourcetopologies.v1alpha2.%v", topologyapi.GroupName)
 	return []framework.ClusterEventWithHint{
-		{Event: framework.ClusterEvent{Resource: framework.Pod, ActionType: framework.Delete}},
+		{Event: framework.ClusterEvent{Resource: framework.Pod, ActionType: framework.Update | framework.Delete}},
 		{Event: framework.ClusterEvent{Resource: framework.Node, ActionType: framework.Add | framework.UpdateNodeAllocatable}},
 		{Event: framework.ClusterEvent{Resource: framework.GVK(nrtGVK), ActionType: framework.Add | framework.Update}},
 	}
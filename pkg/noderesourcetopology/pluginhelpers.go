@@ -43,64 +43,86 @@ const (
 )
 
 func initNodeTopologyInformer(ctx context.Context, lh logr.Logger,
-	tcfg *apiconfig.NodeResourceTopologyMatchArgs, handle framework.Handle) (nrtcache.Interface, error) {
+	tcfg *apiconfig.NodeResourceTopologyMatchArgs, handle framework.Handle) (nrtcache.Interface, *nrtcache.ForeignPodsDetector, error) {
 	client, err := ctrlclient.NewWithWatch(handle.KubeConfig(), ctrlclient.Options{Scheme: scheme})
 	if err != nil {
 		lh.Error(err, "cannot create client for NodeTopologyResource", "kubeConfig", handle.KubeConfig())
-		return nil, err
+		return nil, nil, err
 	}
 
 	if tcfg.DiscardReservedNodes {
-		return nrtcache.NewDiscardReserved(lh.WithName(logging.SubsystemNRTCache), client), nil
+		return nrtcache.NewDiscardReserved(lh.WithName(logging.SubsystemNRTCache), client), nil, nil
 	}
 
 	if tcfg.CacheResyncPeriodSeconds <= 0 {
-		return nrtcache.NewPassthrough(lh.WithName(logging.SubsystemNRTCache), client), nil
+		return nrtcache.NewPassthrough(lh.WithName(logging.SubsystemNRTCache), client), nil, nil
 	}
 
 	podSharedInformer, podLister, isPodRelevant := podprovider.NewFromHandle(lh, handle, tcfg.Cache)
 
-	nrtCache, err := nrtcache.NewOverReserve(ctx, lh.WithName(logging.SubsystemNRTCache), tcfg.Cache, client, podLister, isPodRelevant)
+	profileName := ""
+	if fwk, ok := handle.(framework.Framework); ok {
+		profileName = fwk.ProfileName()
+	}
+	nrtCache, err := nrtcache.NewOverReserve(ctx, lh.WithName(logging.SubsystemNRTCache), profileName, tcfg.Cache, client, podLister, isPodRelevant)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
-	initNodeTopologyForeignPodsDetection(lh, tcfg.Cache, handle, podSharedInformer, nrtCache)
+	foreignPods := initNodeTopologyForeignPodsDetection(lh, tcfg.Cache, handle, podSharedInformer, nrtCache)
+	nrtcache.SetupResyncOnPodDelete(lh.WithName(logging.SubsystemNRTCache), podSharedInformer, nrtCache)
+	nrtcache.SetupPodResizeHandler(lh.WithName(logging.SubsystemNRTCache), podSharedInformer, nrtCache)
 
 	resyncPeriod := time.Duration(tcfg.CacheResyncPeriodSeconds) * time.Second
 	go wait.Forever(nrtCache.Resync, resyncPeriod)
+	go wait.Forever(nrtCache.SaveCheckpoint, resyncPeriod)
 
 	lh.V(3).Info("enable NodeTopology cache (needs the Reserve plugin)", "resyncPeriod", resyncPeriod)
 
-	return nrtCache, nil
+	return nrtCache, foreignPods, nil
 }
 
-func initNodeTopologyForeignPodsDetection(lh logr.Logger, cfg *apiconfig.NodeResourceTopologyCache, handle framework.Handle, podSharedInformer k8scache.SharedInformer, nrtCache *nrtcache.OverReserve) {
+// initNodeTopologyForeignPodsDetection returns the foreign pods detector owned by the plugin instance,
+// or nil if the detection is disabled.
+func initNodeTopologyForeignPodsDetection(lh logr.Logger, cfg *apiconfig.NodeResourceTopologyCache, handle framework.Handle, podSharedInformer k8scache.SharedInformer, nrtCache *nrtcache.OverReserve) *nrtcache.ForeignPodsDetector {
 	foreignPodsDetect := getForeignPodsDetectMode(lh, cfg)
 
 	if foreignPodsDetect == apiconfig.ForeignPodsDetectNone {
 		lh.Info("foreign pods detection disabled by configuration")
-		return
+		return nil
 	}
 	fwk, ok := handle.(framework.Framework)
 	if !ok {
 		lh.Info("cannot determine the scheduler profile names - no foreign pod detection enabled")
-		return
+		return nil
 	}
 
 	profileName := fwk.ProfileName()
 	lh.Info("setting up foreign pods detection", "name", profileName, "mode", foreignPodsDetect)
 
-	if foreignPodsDetect == apiconfig.ForeignPodsDetectOnlyExclusiveResources {
-		nrtcache.TrackOnlyForeignPodsWithExclusiveResources()
-	} else {
-		nrtcache.TrackAllForeignPods()
+	onlyExclusiveResources := (foreignPodsDetect == apiconfig.ForeignPodsDetectOnlyExclusiveResources)
+	foreignPods := nrtcache.NewForeignPodsDetector(lh.WithName(logging.SubsystemForeignPods), onlyExclusiveResources, profileName)
+
+	if getForeignPodsAccountingMode(lh, cfg) == apiconfig.ForeignPodsAccountingReconcile {
+		lh.Info("foreign pods resources accounted by the cache", "name", profileName)
+		foreignPods.SetupReconciler(lh.WithName(logging.SubsystemForeignPods), podSharedInformer, nrtCache)
+		return foreignPods
 	}
-	nrtcache.RegisterSchedulerProfileName(lh.WithName(logging.SubsystemForeignPods), profileName)
-	nrtcache.SetupForeignPodsDetector(lh.WithName(logging.SubsystemForeignPods), profileName, podSharedInformer, nrtCache)
+	foreignPods.SetupNotifier(lh.WithName(logging.SubsystemForeignPods), podSharedInformer, nrtCache)
+	return foreignPods
 }
 
 func createNUMANodeList(lh logr.Logger, zones topologyv1alpha2.ZoneList) NUMANodeList {
+	return createNUMANodeListWithResources(lh, zones, extractResources)
+}
+
+// createAllocatableNUMANodeList is like createNUMANodeList, but it represents the NUMA nodes
+// as they would be on an idle machine, so using the allocatable resources.
+func createAllocatableNUMANodeList(lh logr.Logger, zones topologyv1alpha2.ZoneList) NUMANodeList {
+	return createNUMANodeListWithResources(lh, zones, extractAllocatableResources)
+}
+
+func createNUMANodeListWithResources(lh logr.Logger, zones topologyv1alpha2.ZoneList, extractFn func(topologyv1alpha2.Zone) corev1.ResourceList) NUMANodeList {
 	numaIDToZoneIDx := make([]int, maxNUMAId)
 	nodes := NUMANodeList{}
 	// filter non Node zones and create idToIdx lookup array
@@ -117,7 +139,7 @@ func createNUMANodeList(lh logr.Logger, zones topologyv1alpha2.ZoneList) NUMANod
 
 		numaIDToZoneIDx[numaID] = i
 
-		resources := extractResources(zone)
+		resources := extractFn(zone)
 		numaItems := []interface{}{"numaCell", numaID}
 		lh.V(6).Info("extracted NUMA resources", stringify.ResourceListToLoggableWithValues(numaItems, resources)...)
 		nodes = append(nodes, NUMANode{NUMAID: numaID, Resources: resources})
@@ -158,6 +180,19 @@ func extractResources(zone topologyv1alpha2.Zone) corev1.ResourceList {
 	return res
 }
 
+func extractAllocatableResources(zone topologyv1alpha2.Zone) corev1.ResourceList {
+	res := make(corev1.ResourceList)
+	for _, resInfo := range zone.Resources {
+		// allocatable is optional, capacity is the best approximation we can do
+		if resInfo.Allocatable.IsZero() {
+			res[corev1.ResourceName(resInfo.Name)] = resInfo.Capacity.DeepCopy()
+			continue
+		}
+		res[corev1.ResourceName(resInfo.Name)] = resInfo.Allocatable.DeepCopy()
+	}
+	return res
+}
+
 func onlyNonNUMAResources(numaNodes NUMANodeList, resources corev1.ResourceList) bool {
 	for resourceName := range resources {
 		for _, node := range numaNodes {
@@ -181,6 +216,17 @@ func getForeignPodsDetectMode(lh logr.Logger, cfg *apiconfig.NodeResourceTopolog
 	return foreignPodsDetect
 }
 
+func getForeignPodsAccountingMode(lh logr.Logger, cfg *apiconfig.NodeResourceTopologyCache) apiconfig.ForeignPodsAccountingMode {
+	var foreignPodsAccounting apiconfig.ForeignPodsAccountingMode
+	if cfg != nil && cfg.ForeignPodsAccounting != nil {
+		foreignPodsAccounting = *cfg.ForeignPodsAccounting
+	} else { // explicitly set to nil?
+		foreignPodsAccounting = apiconfig.ForeignPodsAccountingResync
+		lh.V(4).Info("foreign pods accounting value missing", "fallback", foreignPodsAccounting)
+	}
+	return foreignPodsAccounting
+}
+
 func logNumaNodes(lh logr.Logger, desc, nodeName string, nodes NUMANodeList) {
 	for _, numaNode := range nodes {
 		numaItems := []interface{}{"numaCell", numaNode.NUMAID}
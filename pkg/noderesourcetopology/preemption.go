@@ -0,0 +1,427 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package noderesourcetopology
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"sort"
+
+	topologyv1alpha2 "github.com/k8stopologyawareschedwg/noderesourcetopology-api/pkg/apis/topology/v1alpha2"
+	"github.com/k8stopologyawareschedwg/noderesourcetopology-api/pkg/apis/topology/v1alpha2/helper/numanode"
+
+	corev1 "k8s.io/api/core/v1"
+	policy "k8s.io/api/policy/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	corev1helpers "k8s.io/component-helpers/scheduling/corev1"
+	"k8s.io/klog/v2"
+	extenderv1 "k8s.io/kube-scheduler/extender/v1"
+	"k8s.io/kubernetes/pkg/scheduler/framework"
+	"k8s.io/kubernetes/pkg/scheduler/framework/preemption"
+	schedutil "k8s.io/kubernetes/pkg/scheduler/util"
+
+	apiconfig "sigs.k8s.io/scheduler-plugins/apis/config"
+	nrtcache "sigs.k8s.io/scheduler-plugins/pkg/noderesourcetopology/cache"
+	"sigs.k8s.io/scheduler-plugins/pkg/noderesourcetopology/logging"
+	"sigs.k8s.io/scheduler-plugins/pkg/noderesourcetopology/resourcerequests"
+)
+
+// preemptingTopologyMatch is the TopologyMatch plugin with the PostFilter extension point.
+// The framework registers the extension points a plugin implements, so the NUMA-aware preemption
+// needs its own type to be opt-in.
+type preemptingTopologyMatch struct {
+	*TopologyMatch
+	minCandidateNodesPercentage int32
+	minCandidateNodesAbsolute   int32
+}
+
+var _ framework.PostFilterPlugin = &preemptingTopologyMatch{}
+
+// withPreemption returns the plugin to register, which implements PostFilter only if the preemption is enabled.
+func withPreemption(tm *TopologyMatch, pe *apiconfig.NodeResourceTopologyPreemption) framework.Plugin {
+	if pe == nil || !pe.Enabled {
+		return tm
+	}
+	return &preemptingTopologyMatch{
+		TopologyMatch:               tm,
+		minCandidateNodesPercentage: pe.MinCandidateNodesPercentage,
+		minCandidateNodesAbsolute:   pe.MinCandidateNodesAbsolute,
+	}
+}
+
+// PostFilter preempts pods to make room for pods rejected by Filter because no NUMA zone could fit them.
+// The default preemption only considers the node-level free resources, so it either doesn't preempt,
+// or it evicts pods which don't free the NUMA zone the pod needs. Here we consider as victims only
+// the lower priority pods which hold exclusive resources on a known NUMA zone, one zone at a time,
+// and we pick the minimal set of victims which makes the preemptor fit.
+func (tm *preemptingTopologyMatch) PostFilter(ctx context.Context, state *framework.CycleState, pod *corev1.Pod, m framework.NodeToStatusMap) (*framework.PostFilterResult, *framework.Status) {
+	lh := klog.FromContext(ctx).WithValues(logging.KeyPod, klog.KObj(pod), logging.KeyPodUID, logging.PodUID(pod))
+	lh.V(4).Info(logging.FlowBegin)
+	defer lh.V(4).Info(logging.FlowEnd)
+
+	if tm.fh == nil {
+		return nil, framework.NewStatus(framework.Unschedulable, "preemption not available")
+	}
+	if !resourcerequests.AreExclusiveForPod(pod) {
+		// the pod can only be rejected for lack of shared resources, which the default preemption handles well
+		return nil, framework.NewStatus(framework.Unschedulable, "preemptor does not require exclusive resources")
+	}
+	if !rejectedByPlugin(m, tm.Name()) {
+		return nil, framework.NewStatus(framework.Unschedulable, "no node rejected by "+tm.Name())
+	}
+
+	pe := preemption.Evaluator{
+		PluginName: tm.Name(),
+		Handler:    tm.fh,
+		PodLister:  tm.fh.SharedInformerFactory().Core().V1().Pods().Lister(),
+		PdbLister:  tm.fh.SharedInformerFactory().Policy().V1().PodDisruptionBudgets().Lister(),
+		State:      state,
+		Interface: &numaPreemptor{
+			fh:                          tm.fh,
+			placements:                  tm.placements,
+			minCandidateNodesPercentage: tm.minCandidateNodesPercentage,
+			minCandidateNodesAbsolute:   tm.minCandidateNodesAbsolute,
+		},
+	}
+
+	return pe.Preempt(ctx, pod, m)
+}
+
+func rejectedByPlugin(m framework.NodeToStatusMap, pluginName string) bool {
+	for _, status := range m {
+		if status.Code() == framework.Unschedulable && status.Plugin() == pluginName {
+			return true
+		}
+	}
+	return false
+}
+
+// releaseZoneResources adds back to the zones the resources released by the preemption victims.
+// The available resources can't exceed the allocatable resources, falling back to the capacity.
+func releaseZoneResources(zones topologyv1alpha2.ZoneList, released nrtcache.NUMAAssignment) {
+	for zi := 0; zi < len(zones); zi++ {
+		zone := &zones[zi] // shortcut
+		if zone.Type != "Node" {
+			continue
+		}
+		numaID, err := numanode.NameToID(zone.Name)
+		if err != nil {
+			continue
+		}
+		res, ok := released[numaID]
+		if !ok {
+			continue
+		}
+		for ri := 0; ri < len(zone.Resources); ri++ {
+			zr := &zone.Resources[ri] // shortcut
+			qty, ok := res[corev1.ResourceName(zr.Name)]
+			if !ok {
+				continue
+			}
+			limit := zr.Allocatable
+			if limit.IsZero() {
+				limit = zr.Capacity
+			}
+			zr.Available.Add(qty)
+			if zr.Available.Cmp(limit) > 0 {
+				zr.Available = limit.DeepCopy()
+			}
+		}
+	}
+}
+
+type numaPreemptor struct {
+	fh                          framework.Handle
+	placements                  *numaPlacements
+	minCandidateNodesPercentage int32
+	minCandidateNodesAbsolute   int32
+}
+
+var _ preemption.Interface = &numaPreemptor{}
+
+// GetOffsetAndNumCandidates shortlists the candidates like the default preemption does.
+func (p *numaPreemptor) GetOffsetAndNumCandidates(numNodes int32) (int32, int32) {
+	return rand.Int31n(numNodes), p.calculateNumCandidates(numNodes)
+}
+
+// calculateNumCandidates returns the number of candidates to dry run the preemption on,
+// which is never greater than numNodes.
+func (p *numaPreemptor) calculateNumCandidates(numNodes int32) int32 {
+	n := (numNodes * p.minCandidateNodesPercentage) / 100
+	if n < p.minCandidateNodesAbsolute {
+		n = p.minCandidateNodesAbsolute
+	}
+	if n > numNodes {
+		n = numNodes
+	}
+	return n
+}
+
+func (p *numaPreemptor) CandidatesToVictimsMap(candidates []preemption.Candidate) map[string]*extenderv1.Victims {
+	m := make(map[string]*extenderv1.Victims)
+	for _, c := range candidates {
+		m[c.Name()] = c.Victims()
+	}
+	return m
+}
+
+func (p *numaPreemptor) OrderedScoreFuncs(ctx context.Context, nodesToVictims map[string]*extenderv1.Victims) []func(node string) int64 {
+	return nil
+}
+
+// PodEligibleToPreemptOthers mirrors the default preemption: if the pod already preempted
+// other pods which are still terminating on the nominated node, it should wait for them.
+func (p *numaPreemptor) PodEligibleToPreemptOthers(pod *corev1.Pod, nominatedNodeStatus *framework.Status) (bool, string) {
+	if pod.Spec.PreemptionPolicy != nil && *pod.Spec.PreemptionPolicy == corev1.PreemptNever {
+		return false, "not eligible due to preemptionPolicy=Never."
+	}
+
+	nomNodeName := pod.Status.NominatedNodeName
+	if len(nomNodeName) == 0 {
+		return true, ""
+	}
+	// If the pod's nominated node is considered as UnschedulableAndUnresolvable by the filters,
+	// then the pod should be considered for preempting again.
+	if nominatedNodeStatus.Code() == framework.UnschedulableAndUnresolvable {
+		return true, ""
+	}
+	nodeInfo, _ := p.fh.SnapshotSharedLister().NodeInfos().Get(nomNodeName)
+	if nodeInfo == nil {
+		return true, ""
+	}
+	podPriority := corev1helpers.PodPriority(pod)
+	for _, pi := range nodeInfo.Pods {
+		if pi.Pod.DeletionTimestamp != nil && corev1helpers.PodPriority(pi.Pod) < podPriority {
+			return false, "not eligible due to a terminating pod on the nominated node."
+		}
+	}
+	return true, ""
+}
+
+// SelectVictimsOnNode tries each NUMA zone of the node in turn, considering as victims only the pods
+// pinned to it, and returns the cheapest set of victims found.
+func (p *numaPreemptor) SelectVictimsOnNode(ctx context.Context, state *framework.CycleState, pod *corev1.Pod, nodeInfo *framework.NodeInfo, pdbs []*policy.PodDisruptionBudget) ([]*corev1.Pod, int, *framework.Status) {
+	nodeName := nodeInfo.Node().Name
+	lh := klog.FromContext(ctx).WithValues(logging.KeyPod, klog.KObj(pod), logging.KeyPodUID, logging.PodUID(pod), logging.KeyNode, nodeName)
+
+	podPriority := corev1helpers.PodPriority(pod)
+	zoneCandidates := make(map[int][]*framework.PodInfo)
+	for _, pi := range nodeInfo.Pods {
+		if corev1helpers.PodPriority(pi.Pod) >= podPriority || !resourcerequests.AreExclusiveForPod(pi.Pod) {
+			continue
+		}
+		// we can't tell which NUMA zones the pods placed by other schedulers, or before a restart, are using
+		assignment := p.placements.Get(nodeName, pi.Pod)
+		for numaID := range assignment {
+			zoneCandidates[numaID] = append(zoneCandidates[numaID], pi)
+		}
+	}
+	if len(zoneCandidates) == 0 {
+		msg := fmt.Sprintf("No victims pinned to NUMA zones found on node %v for preemptor pod %v", nodeName, pod.Name)
+		return nil, 0, framework.NewStatus(framework.UnschedulableAndUnresolvable, msg)
+	}
+
+	numaIDs := make([]int, 0, len(zoneCandidates))
+	for numaID := range zoneCandidates {
+		numaIDs = append(numaIDs, numaID)
+	}
+	sort.Ints(numaIDs)
+
+	var best *numaVictims
+	for _, numaID := range numaIDs {
+		// each attempt must start from the pristine state and node
+		vics, status := p.selectVictimsOnZone(ctx, state.Clone(), pod, nodeInfo.Snapshot(), zoneCandidates[numaID], pdbs)
+		if !status.IsSuccess() {
+			lh.V(5).Info("cannot make room", "numaCell", numaID, "reason", status.Message())
+			continue
+		}
+		lh.V(5).Info("can make room", "numaCell", numaID, "victims", len(vics.pods), "pdbViolations", vics.numViolating)
+		if best == nil || vics.lessThan(best) {
+			best = vics
+		}
+	}
+	if best == nil {
+		return nil, 0, framework.NewStatus(framework.Unschedulable, "cannot make room on any NUMA zone")
+	}
+	return best.pods, best.numViolating, framework.NewStatus(framework.Success)
+}
+
+type numaVictims struct {
+	pods         []*corev1.Pod
+	numViolating int
+}
+
+// lessThan tells if the victims are cheaper to evict than the other ones, using the same criteria
+// of the default preemption when comparing nodes.
+func (nv *numaVictims) lessThan(other *numaVictims) bool {
+	if nv.numViolating != other.numViolating {
+		return nv.numViolating < other.numViolating
+	}
+	if prio, otherPrio := nv.highestPriority(), other.highestPriority(); prio != otherPrio {
+		return prio < otherPrio
+	}
+	return len(nv.pods) < len(other.pods)
+}
+
+func (nv *numaVictims) highestPriority() int32 {
+	var ret int32
+	for idx, pod := range nv.pods {
+		if prio := corev1helpers.PodPriority(pod); idx == 0 || prio > ret {
+			ret = prio
+		}
+	}
+	return ret
+}
+
+// selectVictimsOnZone follows the default preemption logic: remove all the candidates, check the
+// preemptor fits, then reprieve as many candidates as possible, starting from the most important.
+// Filter learns about the removed candidates through the numaReleasedState.
+func (p *numaPreemptor) selectVictimsOnZone(ctx context.Context, state *framework.CycleState, pod *corev1.Pod, nodeInfo *framework.NodeInfo, candidates []*framework.PodInfo, pdbs []*policy.PodDisruptionBudget) (*numaVictims, *framework.Status) {
+	logger := klog.FromContext(ctx)
+	nodeName := nodeInfo.Node().Name
+	removed := make(map[*framework.PodInfo]struct{})
+
+	updateReleased := func() {
+		released := nrtcache.NUMAAssignment{}
+		for pi := range removed {
+			for numaID, res := range p.placements.Get(nodeName, pi.Pod) {
+				released.Add(numaID, res)
+			}
+		}
+		writeNUMAReleased(state, nodeName, released)
+	}
+	removePod := func(pi *framework.PodInfo) error {
+		if err := nodeInfo.RemovePod(logger, pi.Pod); err != nil {
+			return err
+		}
+		removed[pi] = struct{}{}
+		updateReleased()
+		status := p.fh.RunPreFilterExtensionRemovePod(ctx, state, pod, pi, nodeInfo)
+		return status.AsError()
+	}
+	addPod := func(pi *framework.PodInfo) error {
+		nodeInfo.AddPodInfo(pi)
+		delete(removed, pi)
+		updateReleased()
+		status := p.fh.RunPreFilterExtensionAddPod(ctx, state, pod, pi, nodeInfo)
+		return status.AsError()
+	}
+
+	for _, pi := range candidates {
+		if err := removePod(pi); err != nil {
+			return nil, framework.AsStatus(err)
+		}
+	}
+	// If the new pod does not fit after removing all the candidates, this zone is not suitable for preemption.
+	if status := p.fh.RunFilterPluginsWithNominatedPods(ctx, state, pod, nodeInfo); !status.IsSuccess() {
+		return nil, status
+	}
+
+	sort.Slice(candidates, func(i, j int) bool {
+		return schedutil.MoreImportantPod(candidates[i].Pod, candidates[j].Pod)
+	})
+	// Try to reprieve as many pods as possible. We first try to reprieve the PDB
+	// violating victims and then other non-violating ones. In both cases, we start
+	// from the highest priority victims.
+	violatingCandidates, nonViolatingCandidates := filterPodsWithPDBViolation(candidates, pdbs)
+	vics := &numaVictims{}
+	reprievePod := func(pi *framework.PodInfo) (bool, error) {
+		if err := addPod(pi); err != nil {
+			return false, err
+		}
+		fits := p.fh.RunFilterPluginsWithNominatedPods(ctx, state, pod, nodeInfo).IsSuccess()
+		if !fits {
+			if err := removePod(pi); err != nil {
+				return false, err
+			}
+			vics.pods = append(vics.pods, pi.Pod)
+			logger.V(5).Info("found a potential preemption victim", logging.KeyPod, klog.KObj(pi.Pod), logging.KeyNode, nodeName)
+		}
+		return fits, nil
+	}
+	for _, pi := range violatingCandidates {
+		fits, err := reprievePod(pi)
+		if err != nil {
+			return nil, framework.AsStatus(err)
+		}
+		if !fits {
+			vics.numViolating++
+		}
+	}
+	for _, pi := range nonViolatingCandidates {
+		if _, err := reprievePod(pi); err != nil {
+			return nil, framework.AsStatus(err)
+		}
+	}
+	if len(vics.pods) == 0 {
+		// should never happen: the preemptor was rejected in the first place
+		return nil, framework.NewStatus(framework.Unschedulable, "no victims needed")
+	}
+	return vics, framework.NewStatus(framework.Success)
+}
+
+// filterPodsWithPDBViolation groups the given "pods" into two groups of "violatingPods"
+// and "nonViolatingPods" based on whether their PDBs will be violated if they are
+// preempted.
+// This function is stable and does not change the order of received pods. So, if it
+// receives a sorted list, grouping will preserve the order of the input list.
+func filterPodsWithPDBViolation(podInfos []*framework.PodInfo, pdbs []*policy.PodDisruptionBudget) (violatingPods, nonViolatingPods []*framework.PodInfo) {
+	pdbsAllowed := make([]int32, len(pdbs))
+	for i, pdb := range pdbs {
+		pdbsAllowed[i] = pdb.Status.DisruptionsAllowed
+	}
+
+	for _, podInfo := range podInfos {
+		pod := podInfo.Pod
+		pdbForPodIsViolated := false
+		// A pod with no labels will not match any PDB. So, no need to check.
+		if len(pod.Labels) != 0 {
+			for i, pdb := range pdbs {
+				if pdb.Namespace != pod.Namespace {
+					continue
+				}
+				selector, err := metav1.LabelSelectorAsSelector(pdb.Spec.Selector)
+				if err != nil {
+					continue
+				}
+				// A PDB with a nil or empty selector matches nothing.
+				if selector.Empty() || !selector.Matches(labels.Set(pod.Labels)) {
+					continue
+				}
+				// Existing in DisruptedPods means it has been processed in API server,
+				// we don't treat it as a violating case.
+				if _, exist := pdb.Status.DisruptedPods[pod.Name]; exist {
+					continue
+				}
+				// Only decrement the matched pdb when it's not in its <DisruptedPods>;
+				// otherwise we may over-decrement the budget number.
+				pdbsAllowed[i]--
+				if pdbsAllowed[i] < 0 {
+					pdbForPodIsViolated = true
+				}
+			}
+		}
+		if pdbForPodIsViolated {
+			violatingPods = append(violatingPods, podInfo)
+		} else {
+			nonViolatingPods = append(nonViolatingPods, podInfo)
+		}
+	}
+	return violatingPods, nonViolatingPods
+}
@@ -0,0 +1,317 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package noderesourcetopology
+
+import (
+	"context"
+	"sort"
+	"testing"
+
+	topologyv1alpha2 "github.com/k8stopologyawareschedwg/noderesourcetopology-api/pkg/apis/topology/v1alpha2"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/klog/v2"
+	"k8s.io/kubernetes/pkg/scheduler/framework"
+	"k8s.io/kubernetes/pkg/scheduler/framework/plugins/defaultbinder"
+	"k8s.io/kubernetes/pkg/scheduler/framework/plugins/queuesort"
+	frameworkruntime "k8s.io/kubernetes/pkg/scheduler/framework/runtime"
+	tf "k8s.io/kubernetes/pkg/scheduler/testing/framework"
+
+	apiconfig "sigs.k8s.io/scheduler-plugins/apis/config"
+	nrtcache "sigs.k8s.io/scheduler-plugins/pkg/noderesourcetopology/cache"
+	"sigs.k8s.io/scheduler-plugins/pkg/noderesourcetopology/nodeconfig"
+	tu "sigs.k8s.io/scheduler-plugins/test/util"
+)
+
+func makePreemptionTestPod(name string, priority int32, cpus string, placement nrtcache.NUMAAssignment, placements *numaPlacements) *v1.Pod {
+	res := v1.ResourceList{
+		v1.ResourceCPU:    resource.MustParse(cpus),
+		v1.ResourceMemory: resource.MustParse("1Gi"),
+	}
+	pod := &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "default",
+			Name:      name,
+			UID:       types.UID(name),
+		},
+		Spec: v1.PodSpec{
+			NodeName: "node1",
+			Priority: &priority,
+			Containers: []v1.Container{
+				{
+					Name: "cnt",
+					Resources: v1.ResourceRequirements{
+						Requests: res,
+						Limits:   res,
+					},
+				},
+			},
+		},
+	}
+	if placement != nil {
+		placements.Add("node1", pod, placement)
+	}
+	return pod
+}
+
+func TestNUMAPreemptionSelectVictims(t *testing.T) {
+	nrt := &topologyv1alpha2.NodeResourceTopology{
+		ObjectMeta: metav1.ObjectMeta{Name: "node1"},
+		Attributes: topologyv1alpha2.AttributeList{
+			{Name: nodeconfig.AttributePolicy, Value: "single-numa-node"},
+			{Name: nodeconfig.AttributeScope, Value: "pod"},
+		},
+		Zones: topologyv1alpha2.ZoneList{
+			{
+				Name: "node-0",
+				Type: "Node",
+				Resources: topologyv1alpha2.ResourceInfoList{
+					MakeTopologyResInfo(cpu, "16", "4"),
+					MakeTopologyResInfo(memory, "32Gi", "30Gi"),
+				},
+			},
+			{
+				Name: "node-1",
+				Type: "Node",
+				Resources: topologyv1alpha2.ResourceInfoList{
+					MakeTopologyResInfo(cpu, "16", "4"),
+					MakeTopologyResInfo(memory, "32Gi", "29Gi"),
+				},
+			},
+		},
+	}
+
+	zoneRes := func(cpus string) v1.ResourceList {
+		return v1.ResourceList{
+			v1.ResourceCPU:    resource.MustParse(cpus),
+			v1.ResourceMemory: resource.MustParse("1Gi"),
+		}
+	}
+
+	tests := []struct {
+		name            string
+		preemptorCPUs   string
+		expectedVictims []string
+		expectedCode    framework.Code
+	}{
+		{
+			name:            "victims on the cheapest NUMA zone",
+			preemptorCPUs:   "10",
+			expectedVictims: []string{"low-numa1-a"},
+			expectedCode:    framework.Success,
+		},
+		{
+			name:            "all the victims on the NUMA zone are needed",
+			preemptorCPUs:   "14",
+			expectedVictims: []string{"low-numa1-a", "low-numa1-b"},
+			expectedCode:    framework.Success,
+		},
+		{
+			name:          "preemptor exceeding the NUMA zone capacity",
+			preemptorCPUs: "18",
+			expectedCode:  framework.Unschedulable,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			placements := newNUMAPlacements()
+			pods := []*v1.Pod{
+				makePreemptionTestPod("mid-numa0", 50, "12", nrtcache.NUMAAssignment{0: zoneRes("12")}, placements),
+				makePreemptionTestPod("low-numa1-a", 10, "6", nrtcache.NUMAAssignment{1: zoneRes("6")}, placements),
+				makePreemptionTestPod("low-numa1-b", 10, "6", nrtcache.NUMAAssignment{1: zoneRes("6")}, placements),
+				// placement unknown, can't be a victim
+				makePreemptionTestPod("low-unknown", 10, "2", nil, placements),
+				// higher priority than the preemptor, can't be a victim
+				makePreemptionTestPod("high-numa1", 200, "2", nrtcache.NUMAAssignment{1: zoneRes("2")}, placements),
+			}
+			node := makeNodeFromNodeResourceTopology(nrt)
+
+			fakeClient, err := tu.NewFakeClient()
+			if err != nil {
+				t.Fatalf("failed to create fake client: %v", err)
+			}
+			if err := fakeClient.Create(context.Background(), nrt.DeepCopy()); err != nil {
+				t.Fatal(err)
+			}
+			tm := &TopologyMatch{
+				nrtCache:   nrtcache.NewPassthrough(klog.Background(), fakeClient),
+				placements: placements,
+			}
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			fwk, err := tf.NewFramework(
+				ctx,
+				[]tf.RegisterPluginFunc{
+					tf.RegisterQueueSortPlugin(queuesort.Name, queuesort.New),
+					tf.RegisterBindPlugin(defaultbinder.Name, defaultbinder.New),
+					tf.RegisterFilterPlugin(Name, func(_ context.Context, _ runtime.Object, _ framework.Handle) (framework.Plugin, error) {
+						return tm, nil
+					}),
+				},
+				"default-scheduler",
+				frameworkruntime.WithPodNominator(tu.NewPodNominator(nil)),
+				frameworkruntime.WithSnapshotSharedLister(tu.NewFakeSharedLister(pods, []*v1.Node{node})),
+			)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			preemptor := makePreemptionTestPod("preemptor", 100, tt.preemptorCPUs, nil, placements)
+			preemptor.Spec.NodeName = ""
+
+			nodeInfo, err := fwk.SnapshotSharedLister().NodeInfos().Get("node1")
+			if err != nil {
+				t.Fatal(err)
+			}
+			state := framework.NewCycleState()
+			if status := fwk.RunFilterPlugins(ctx, state, preemptor, nodeInfo); status.IsSuccess() {
+				t.Fatalf("preemptor unexpectedly fits without preemption")
+			}
+
+			pr := &numaPreemptor{fh: fwk, placements: placements}
+			victims, numViolating, status := pr.SelectVictimsOnNode(ctx, state, preemptor, nodeInfo.Snapshot(), nil)
+			if status.Code() != tt.expectedCode {
+				t.Fatalf("unexpected status: %v expected code %v", status, tt.expectedCode)
+			}
+			if numViolating != 0 {
+				t.Errorf("unexpected PDB violations: %d", numViolating)
+			}
+
+			var got []string
+			for _, victim := range victims {
+				got = append(got, victim.Name)
+			}
+			sort.Strings(got)
+			if len(got) != len(tt.expectedVictims) {
+				t.Fatalf("unexpected victims: got %v expected %v", got, tt.expectedVictims)
+			}
+			for idx := range got {
+				if got[idx] != tt.expectedVictims[idx] {
+					t.Errorf("unexpected victims: got %v expected %v", got, tt.expectedVictims)
+				}
+			}
+		})
+	}
+}
+
+func TestNUMAPlacementsTracking(t *testing.T) {
+	placements := newNUMAPlacements()
+	pod := makePreemptionTestPod("pod", 10, "2", nil, placements)
+
+	if got := placements.Get("node1", pod); got != nil {
+		t.Fatalf("unexpected placement for untracked pod: %v", got)
+	}
+	placements.Add("node1", pod, nrtcache.NUMAAssignment{1: v1.ResourceList{v1.ResourceCPU: resource.MustParse("2")}})
+	if got := placements.Get("node1", pod); len(got) != 1 {
+		t.Fatalf("unexpected placement for tracked pod: %v", got)
+	}
+	placements.Add("node1", pod, nil)
+	if got := placements.Get("node1", pod); got != nil {
+		t.Fatalf("unexpected placement for forgotten pod: %v", got)
+	}
+
+	var nilPlacements *numaPlacements
+	nilPlacements.Add("node1", pod, nrtcache.NUMAAssignment{})
+	if got := nilPlacements.Get("node1", pod); got != nil {
+		t.Fatalf("unexpected placement from nil tracker: %v", got)
+	}
+}
+
+func TestWithPreemption(t *testing.T) {
+	tests := []struct {
+		name               string
+		preemption         *apiconfig.NodeResourceTopologyPreemption
+		expectedPostFilter bool
+	}{
+		{
+			name: "unset",
+		},
+		{
+			name:       "disabled",
+			preemption: &apiconfig.NodeResourceTopologyPreemption{MinCandidateNodesPercentage: 10, MinCandidateNodesAbsolute: 100},
+		},
+		{
+			name:               "enabled",
+			preemption:         &apiconfig.NodeResourceTopologyPreemption{Enabled: true, MinCandidateNodesPercentage: 10, MinCandidateNodesAbsolute: 100},
+			expectedPostFilter: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tm := &TopologyMatch{placements: newNUMAPlacements()}
+			pl := withPreemption(tm, tt.preemption)
+			if _, ok := pl.(framework.FilterPlugin); !ok {
+				t.Errorf("plugin does not implement Filter")
+			}
+			if _, ok := pl.(framework.PostFilterPlugin); ok != tt.expectedPostFilter {
+				t.Errorf("plugin implements PostFilter=%v expected %v", ok, tt.expectedPostFilter)
+			}
+		})
+	}
+}
+
+func TestCalculateNumCandidates(t *testing.T) {
+	tests := []struct {
+		name       string
+		percentage int32
+		absolute   int32
+		numNodes   int32
+		expected   int32
+	}{
+		{
+			name:       "absolute greater than percentage",
+			percentage: 10,
+			absolute:   100,
+			numNodes:   500,
+			expected:   100,
+		},
+		{
+			name:       "percentage greater than absolute",
+			percentage: 50,
+			absolute:   100,
+			numNodes:   500,
+			expected:   250,
+		},
+		{
+			name:       "capped by the number of nodes",
+			percentage: 10,
+			absolute:   100,
+			numNodes:   50,
+			expected:   50,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pr := &numaPreemptor{minCandidateNodesPercentage: tt.percentage, minCandidateNodesAbsolute: tt.absolute}
+			offset, got := pr.GetOffsetAndNumCandidates(tt.numNodes)
+			if got != tt.expected {
+				t.Errorf("unexpected candidates: got %d expected %d", got, tt.expected)
+			}
+			if offset < 0 || offset >= tt.numNodes {
+				t.Errorf("offset %d out of range [0, %d)", offset, tt.numNodes)
+			}
+		})
+	}
+}
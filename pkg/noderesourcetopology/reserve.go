@@ -31,7 +31,10 @@ func (tm *TopologyMatch) Reserve(ctx context.Context, state *framework.CycleStat
 	lh.V(4).Info(logging.FlowBegin)
 	defer lh.V(4).Info(logging.FlowEnd)
 
-	tm.nrtCache.ReserveNodeResources(nodeName, pod)
+	assignment := readNUMAAssignment(state, nodeName)
+	lh.V(4).Info("reserving", "numaAssignment", assignment != nil)
+	tm.nrtCache.ReserveNodeResources(nodeName, pod, assignment)
+	tm.placements.Add(nodeName, pod, assignment)
 	// can't fail
 	return framework.NewStatus(framework.Success, "")
 }
@@ -43,4 +46,5 @@ func (tm *TopologyMatch) Unreserve(ctx context.Context, state *framework.CycleSt
 	defer lh.V(4).Info(logging.FlowEnd)
 
 	tm.nrtCache.UnreserveNodeResources(nodeName, pod)
+	tm.placements.Delete(nodeName, pod)
 }
@@ -40,17 +40,28 @@ func IncludeNonNative(pod *corev1.Pod) bool {
 	}
 	return false
 }
+
+// AreExclusiveForPod tells if any container of the pod has exclusive resources,
+// considering the resources allocated by the kubelet while an in-place resize is in progress.
 func AreExclusiveForPod(pod *corev1.Pod) bool {
 	qos := v1qos.GetPodQOS(pod)
-	return areExclusiveForAnyContainer(qos, append(pod.Spec.InitContainers, pod.Spec.Containers...))
+	for idx := range pod.Spec.InitContainers {
+		if areExclusive(qos, ForContainer(&pod.Spec.InitContainers[idx], pod.Status.InitContainerStatuses)) {
+			return true
+		}
+	}
+	for idx := range pod.Spec.Containers {
+		if areExclusive(qos, ForContainer(&pod.Spec.Containers[idx], pod.Status.ContainerStatuses)) {
+			return true
+		}
+	}
+	return false
 }
 
-func areExclusiveForAnyContainer(qos corev1.PodQOSClass, containers []corev1.Container) bool {
-	for _, ctr := range containers {
-		for resource, quantity := range ctr.Resources.Requests {
-			if ok := IsExclusive(qos, resource, quantity); ok {
-				return true
-			}
+func areExclusive(qos corev1.PodQOSClass, requests corev1.ResourceList) bool {
+	for resource, quantity := range requests {
+		if ok := IsExclusive(qos, resource, quantity); ok {
+			return true
 		}
 	}
 	return false
@@ -0,0 +1,103 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resourcerequests
+
+import (
+	corev1 "k8s.io/api/core/v1"
+)
+
+// ForContainer returns the resource requests of the container as accounted by the kubelet.
+// While an in-place resize is in progress the kubelet keeps the resources allocated to the container,
+// which can be larger than the desired ones, so like the kube-scheduler we use the max of the two.
+func ForContainer(ctr *corev1.Container, statuses []corev1.ContainerStatus) corev1.ResourceList {
+	reqs := ctr.Resources.Requests.DeepCopy()
+	for idx := range statuses {
+		if statuses[idx].Name != ctr.Name {
+			continue
+		}
+		for name, allocated := range statuses[idx].AllocatedResources {
+			if desired, ok := reqs[name]; ok && desired.Cmp(allocated) >= 0 {
+				continue
+			}
+			if reqs == nil {
+				reqs = make(corev1.ResourceList)
+			}
+			reqs[name] = allocated.DeepCopy()
+		}
+		break
+	}
+	return reqs
+}
+
+// ForPod is like util.GetPodEffectiveRequest, but the requests of the containers are computed
+// using ForContainer, so the in-place resizes are accounted like the kubelet does.
+// The restartable init containers (sidecars) keep running alongside the next init containers
+// and the app containers, so their resources are added to both, again like the kubelet does.
+// NOTE: pod-level resources are not available in the core API we build against, so they are not considered.
+func ForPod(pod *corev1.Pod) corev1.ResourceList {
+	initResources := make(corev1.ResourceList)
+	sidecarResources := make(corev1.ResourceList)
+
+	for idx := range pod.Spec.InitContainers {
+		ctr := &pod.Spec.InitContainers[idx]
+		reqs := ForContainer(ctr, pod.Status.InitContainerStatuses)
+		if IsRestartableInitContainer(ctr) {
+			addResourceList(sidecarResources, reqs)
+			maxResourceList(initResources, sidecarResources)
+			continue
+		}
+		// the regular init containers run after the sidecars declared before them are started
+		running := make(corev1.ResourceList)
+		addResourceList(running, reqs)
+		addResourceList(running, sidecarResources)
+		maxResourceList(initResources, running)
+	}
+
+	resources := make(corev1.ResourceList)
+	for idx := range pod.Spec.Containers {
+		addResourceList(resources, ForContainer(&pod.Spec.Containers[idx], pod.Status.ContainerStatuses))
+	}
+	addResourceList(resources, sidecarResources)
+	maxResourceList(resources, initResources)
+	return resources
+}
+
+// IsRestartableInitContainer tells if the given init container is a restartable one (aka native sidecar),
+// which runs for the whole lifetime of the pod.
+func IsRestartableInitContainer(ctr *corev1.Container) bool {
+	return ctr.RestartPolicy != nil && *ctr.RestartPolicy == corev1.ContainerRestartPolicyAlways
+}
+
+func addResourceList(dst, src corev1.ResourceList) {
+	for name, quantity := range src {
+		if cur, ok := dst[name]; ok {
+			cur.Add(quantity)
+			dst[name] = cur
+			continue
+		}
+		dst[name] = quantity.DeepCopy()
+	}
+}
+
+func maxResourceList(dst, src corev1.ResourceList) {
+	for name, quantity := range src {
+		if cur, ok := dst[name]; ok && quantity.Cmp(cur) <= 0 {
+			continue
+		}
+		dst[name] = quantity.DeepCopy()
+	}
+}
@@ -33,8 +33,8 @@ import (
 	topologyv1alpha2 "github.com/k8stopologyawareschedwg/noderesourcetopology-api/pkg/apis/topology/v1alpha2"
 	"sigs.k8s.io/scheduler-plugins/pkg/noderesourcetopology/logging"
 	"sigs.k8s.io/scheduler-plugins/pkg/noderesourcetopology/nodeconfig"
+	"sigs.k8s.io/scheduler-plugins/pkg/noderesourcetopology/resourcerequests"
 	"sigs.k8s.io/scheduler-plugins/pkg/noderesourcetopology/stringify"
-	"sigs.k8s.io/scheduler-plugins/pkg/util"
 )
 
 const (
@@ -86,11 +86,16 @@ func (tm *TopologyMatch) Score(ctx context.Context, state *framework.CycleState,
 
 	lh.V(6).Info("found object", "noderesourcetopology", stringify.NodeResourceTopologyResources(nodeTopology))
 
-	handler := tm.scoringHandlerFromTopologyManagerConfig(nodeconfig.TopologyManagerFromNodeResourceTopology(lh, nodeTopology))
+	conf := nodeconfig.TopologyManagerFromNodeResourceTopology(lh, nodeTopology)
+	handler := tm.scoringHandlerFromTopologyManagerConfig(conf)
 	if handler == nil {
 		return 0, nil
 	}
-	return handler(lh, pod, nodeTopology.Zones)
+	score, status := handler(lh, pod, nodeTopology.Zones)
+	if status != nil {
+		return score, status
+	}
+	return deviceAffinityScore(lh, pod, nodeTopology.Zones, conf.Scope, tm.resourceAffinity, score), nil
 }
 
 func (tm *TopologyMatch) ScoreExtensions() framework.ScoreExtensions {
@@ -98,23 +103,89 @@ func (tm *TopologyMatch) ScoreExtensions() framework.ScoreExtensions {
 }
 
 // scoreForEachNUMANode will iterate over all NUMA zones of the node and invoke the scoreStrategyFn func for every zone.
-// it will return the minimal score of all the calculated NUMA's score, in order to avoid edge cases.
-func scoreForEachNUMANode(lh logr.Logger, requested v1.ResourceList, numaList NUMANodeList, score scoreStrategyFn, resourceToWeightMap resourceToWeightMap) int64 {
-	numaScores := make([]int64, len(numaList))
-	minScore := int64(0)
+// it will combine the calculated NUMA's scores using the given aggregation. By default it returns the minimal score,
+// in order to avoid edge cases.
+func scoreForEachNUMANode(lh logr.Logger, requested v1.ResourceList, numaList NUMANodeList, score scoreStrategyFn, resourceToWeightMap resourceToWeightMap, aggregation apiconfig.NUMAScoreAggregation) int64 {
+	numaScores := make(map[int]int64, len(numaList))
 
 	for _, numa := range numaList {
 		numaScore := score(requested, numa.Resources, resourceToWeightMap)
+		lh.V(6).Info("numa score result", "numaCell", numa.NUMAID, "score", numaScore)
 		// if NUMA's score is 0, i.e. not fit at all, it won't be taken under consideration by Kubelet.
-		if (minScore == 0) || (numaScore != 0 && numaScore < minScore) {
-			minScore = numaScore
+		if numaScore == 0 {
+			continue
 		}
 		numaScores[numa.NUMAID] = numaScore
-		lh.V(6).Info("numa score result", "numaCell", numa.NUMAID, "score", numaScore)
+	}
+	if len(numaScores) == 0 {
+		return 0
+	}
+
+	switch aggregation {
+	case apiconfig.NUMAScoreAggregationMax:
+		maxScore := int64(0)
+		for _, numaScore := range numaScores {
+			maxScore = max(maxScore, numaScore)
+		}
+		return maxScore
+	case apiconfig.NUMAScoreAggregationMean:
+		sumScore := int64(0)
+		for _, numaScore := range numaScores {
+			sumScore += numaScore
+		}
+		return sumScore / int64(len(numaScores))
+	case apiconfig.NUMAScoreAggregationBestFittingZone:
+		if numaID, ok := bestFittingNUMANode(requested, numaList); ok {
+			lh.V(6).Info("numa score best fitting", "numaCell", numaID, "score", numaScores[numaID])
+			return numaScores[numaID]
+		}
+		// the request can't fit in a single zone, so there is no single zone to score
+		lh.V(6).Info("numa score best fitting", "numaCell", "none")
+	}
+
+	minScore := int64(0)
+	for _, numaScore := range numaScores {
+		if minScore == 0 || numaScore < minScore {
+			minScore = numaScore
+		}
 	}
 	return minScore
 }
 
+// bestFittingNUMANode returns the ID of the NUMA zone the kubelet is expected to pick for the request, which
+// is the lowest-numbered zone which can hold all of it. Resources not exposed by any zone are not considered.
+func bestFittingNUMANode(requested v1.ResourceList, numaList NUMANodeList) (int, bool) {
+	bestID := -1
+	for _, numa := range numaList {
+		if bestID != -1 && numa.NUMAID > bestID {
+			continue
+		}
+		if numaNodeCanHold(numa, requested, numaList) {
+			bestID = numa.NUMAID
+		}
+	}
+	return bestID, bestID != -1
+}
+
+func numaNodeCanHold(numa NUMANode, requested v1.ResourceList, numaList NUMANodeList) bool {
+	for resourceName, quantity := range requested {
+		if quantity.IsZero() {
+			continue
+		}
+		available, ok := numa.Resources[resourceName]
+		if !ok {
+			if onlyNonNUMAResources(numaList, v1.ResourceList{resourceName: quantity}) {
+				continue
+			}
+			return false
+		}
+		if available.Cmp(quantity) < 0 {
+			return false
+		}
+	}
+	return true
+}
+
 func getScoringStrategyFunction(strategy apiconfig.ScoringStrategyType) (scoreStrategyFn, error) {
 	switch strategy {
 	case apiconfig.MostAllocated:
@@ -123,36 +194,52 @@ func getScoringStrategyFunction(strategy apiconfig.ScoringStrategyType) (scoreSt
 		return leastAllocatedScoreStrategy, nil
 	case apiconfig.BalancedAllocation:
 		return balancedAllocationScoreStrategy, nil
-	case apiconfig.LeastNUMANodes:
-		// this is a special case handled down the flow. We just need to NOT error out.
+	case apiconfig.LeastNUMANodes, apiconfig.ClosestNUMANodes:
+		// these are special cases handled down the flow. We just need to NOT error out.
 		return nil, nil
 	default:
 		return nil, fmt.Errorf("illegal scoring strategy found")
 	}
 }
 
-func podScopeScore(lh logr.Logger, pod *v1.Pod, zones topologyv1alpha2.ZoneList, scorerFn scoreStrategyFn, resourceToWeightMap resourceToWeightMap) (int64, *framework.Status) {
+func podScopeScore(lh logr.Logger, pod *v1.Pod, zones topologyv1alpha2.ZoneList, scorerFn scoreStrategyFn, resourceToWeightMap resourceToWeightMap, aggregation apiconfig.NUMAScoreAggregation) (int64, *framework.Status) {
 	// This code is in Admit implementation of pod scope
 	// https://github.com/kubernetes/kubernetes/blob/9ff3b7e744b34c099c1405d9add192adbef0b6b1/pkg/kubelet/cm/topologymanager/scope_pod.go#L52
 	// but it works with HintProviders, takes into account all possible allocations.
-	resources := util.GetPodEffectiveRequest(pod)
+	resources := resourcerequests.ForPod(pod)
 
 	allocatablePerNUMA := createNUMANodeList(lh, zones)
-	finalScore := scoreForEachNUMANode(lh, resources, allocatablePerNUMA, scorerFn, resourceToWeightMap)
+	finalScore := scoreForEachNUMANode(lh, resources, allocatablePerNUMA, scorerFn, resourceToWeightMap, aggregation)
 	lh.V(2).Info("pod scope scoring final node score", "finalScore", finalScore)
 	return finalScore, nil
 }
 
-func containerScopeScore(lh logr.Logger, pod *v1.Pod, zones topologyv1alpha2.ZoneList, scorerFn scoreStrategyFn, resourceToWeightMap resourceToWeightMap) (int64, *framework.Status) {
+func containerScopeScore(lh logr.Logger, pod *v1.Pod, zones topologyv1alpha2.ZoneList, scorerFn scoreStrategyFn, resourceToWeightMap resourceToWeightMap, aggregation apiconfig.NUMAScoreAggregation) (int64, *framework.Status) {
 	// This code is in Admit implementation of container scope
 	// https://github.com/kubernetes/kubernetes/blob/9ff3b7e744b34c099c1405d9add192adbef0b6b1/pkg/kubelet/cm/topologymanager/scope_container.go#L52
-	containers := append(pod.Spec.InitContainers, pod.Spec.Containers...)
-	contScore := make([]float64, len(containers))
+	// The regular init containers release their resources once completed, so only the sidecars,
+	// which keep running alongside the app containers, are scored together with the app containers.
+	// The requests account the in-place resizes like the kubelet does.
+	var names []string
+	var requests []v1.ResourceList
+	for idx := range pod.Spec.InitContainers {
+		ctr := &pod.Spec.InitContainers[idx]
+		if resourcerequests.IsRestartableInitContainer(ctr) {
+			names = append(names, ctr.Name)
+			requests = append(requests, resourcerequests.ForContainer(ctr, pod.Status.InitContainerStatuses))
+		}
+	}
+	for idx := range pod.Spec.Containers {
+		ctr := &pod.Spec.Containers[idx]
+		names = append(names, ctr.Name)
+		requests = append(requests, resourcerequests.ForContainer(ctr, pod.Status.ContainerStatuses))
+	}
+	contScore := make([]float64, len(requests))
 	allocatablePerNUMA := createNUMANodeList(lh, zones)
 
-	for i, container := range containers {
-		contScore[i] = float64(scoreForEachNUMANode(lh, container.Resources.Requests, allocatablePerNUMA, scorerFn, resourceToWeightMap))
-		lh.V(6).Info("container scope scoring", "container", container.Name, "score", contScore[i])
+	for i := range requests {
+		contScore[i] = float64(scoreForEachNUMANode(lh, requests[i], allocatablePerNUMA, scorerFn, resourceToWeightMap, aggregation))
+		lh.V(6).Info("container scope scoring", "container", names[i], "score", contScore[i])
 	}
 	finalScore := int64(stat.Mean(contScore, nil))
 	lh.V(2).Info("container scope scoring final node score", "finalScore", finalScore)
@@ -162,24 +249,39 @@ func containerScopeScore(lh logr.Logger, pod *v1.Pod, zones topologyv1alpha2.Zon
 func (tm *TopologyMatch) scoringHandlerFromTopologyManagerConfig(conf nodeconfig.TopologyManager) scoringFn {
 	if tm.scoreStrategyType == apiconfig.LeastNUMANodes {
 		if conf.Scope == kubeletconfig.PodTopologyManagerScope {
-			return leastNUMAPodScopeScore
+			return func(lh logr.Logger, pod *v1.Pod, zones topologyv1alpha2.ZoneList) (int64, *framework.Status) {
+				return leastNUMAPodScopeScore(lh, pod, zones, conf.PolicyOptions)
+			}
+		}
+		if conf.Scope == kubeletconfig.ContainerTopologyManagerScope {
+			return func(lh logr.Logger, pod *v1.Pod, zones topologyv1alpha2.ZoneList) (int64, *framework.Status) {
+				return leastNUMAContainerScopeScore(lh, pod, zones, conf.PolicyOptions)
+			}
+		}
+		return nil // cannot happen
+	}
+	if tm.scoreStrategyType == apiconfig.ClosestNUMANodes {
+		if conf.Scope == kubeletconfig.PodTopologyManagerScope {
+			return closestNUMAPodScopeScore
 		}
 		if conf.Scope == kubeletconfig.ContainerTopologyManagerScope {
-			return leastNUMAContainerScopeScore
+			return closestNUMAContainerScopeScore
 		}
 		return nil // cannot happen
 	}
-	if conf.Policy != kubeletconfig.SingleNumaNodeTopologyManagerPolicy {
+	// the best-effort policy never rejects workloads, so the Filter lets every node through;
+	// we still want to steer the pods towards the nodes on which they are most likely to be aligned.
+	if conf.Policy == kubeletconfig.NoneTopologyManagerPolicy {
 		return nil
 	}
 	if conf.Scope == kubeletconfig.PodTopologyManagerScope {
 		return func(lh logr.Logger, pod *v1.Pod, zones topologyv1alpha2.ZoneList) (int64, *framework.Status) {
-			return podScopeScore(lh, pod, zones, tm.scoreStrategyFunc, tm.resourceToWeightMap)
+			return podScopeScore(lh, pod, zones, tm.scoreStrategyFunc, tm.resourceToWeightMap, tm.numaScoreAggregation)
 		}
 	}
 	if conf.Scope == kubeletconfig.ContainerTopologyManagerScope {
 		return func(lh logr.Logger, pod *v1.Pod, zones topologyv1alpha2.ZoneList) (int64, *framework.Status) {
-			return containerScopeScore(lh, pod, zones, tm.scoreStrategyFunc, tm.resourceToWeightMap)
+			return containerScopeScore(lh, pod, zones, tm.scoreStrategyFunc, tm.resourceToWeightMap, tm.numaScoreAggregation)
 		}
 	}
 	return nil // cannot happen
@@ -21,6 +21,7 @@ import (
 	"reflect"
 	"testing"
 
+	"github.com/go-logr/logr"
 	topologyv1alpha2 "github.com/k8stopologyawareschedwg/noderesourcetopology-api/pkg/apis/topology/v1alpha2"
 
 	v1 "k8s.io/api/core/v1"
@@ -190,6 +191,209 @@ func TestNodeResourceScorePlugin(t *testing.T) {
 	}
 }
 
+func TestScoreForEachNUMANodeAggregation(t *testing.T) {
+	numaList := NUMANodeList{
+		{
+			NUMAID: 0,
+			Resources: v1.ResourceList{
+				v1.ResourceCPU: resource.MustParse("4"),
+			},
+		},
+		{
+			NUMAID: 1,
+			Resources: v1.ResourceList{
+				v1.ResourceCPU: resource.MustParse("16"),
+			},
+		},
+	}
+
+	// LeastAllocated scores for 2 CPUs: zone 0 (4-2)*100/4 = 50, zone 1 (16-2)*100/16 = 87
+	smallRequest := v1.ResourceList{v1.ResourceCPU: resource.MustParse("2")}
+	// only zone 1 can hold 8 CPUs: (16-8)*100/16 = 50
+	bigRequest := v1.ResourceList{v1.ResourceCPU: resource.MustParse("8")}
+	// no zone can hold 18 CPUs
+	hugeRequest := v1.ResourceList{v1.ResourceCPU: resource.MustParse("18")}
+
+	tests := []struct {
+		name        string
+		requested   v1.ResourceList
+		aggregation apiconfig.NUMAScoreAggregation
+		expected    int64
+	}{
+		{name: "default is min", requested: smallRequest, expected: 50},
+		{name: "min", requested: smallRequest, aggregation: apiconfig.NUMAScoreAggregationMin, expected: 50},
+		{name: "max", requested: smallRequest, aggregation: apiconfig.NUMAScoreAggregationMax, expected: 87},
+		{name: "mean", requested: smallRequest, aggregation: apiconfig.NUMAScoreAggregationMean, expected: 68},
+		{name: "best fitting zone is the lowest numbered", requested: smallRequest, aggregation: apiconfig.NUMAScoreAggregationBestFittingZone, expected: 50},
+		{name: "best fitting zone skips zones too small", requested: bigRequest, aggregation: apiconfig.NUMAScoreAggregationBestFittingZone, expected: 50},
+		{name: "mean ignores zones too small", requested: bigRequest, aggregation: apiconfig.NUMAScoreAggregationMean, expected: 50},
+		{name: "no zone fits", requested: hugeRequest, aggregation: apiconfig.NUMAScoreAggregationBestFittingZone, expected: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := scoreForEachNUMANode(klog.Background(), tt.requested, numaList, leastAllocatedScoreStrategy, resourceToWeightMap{}, tt.aggregation)
+			if got != tt.expected {
+				t.Errorf("unexpected score: got %d expected %d", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestScopeScoreSidecars(t *testing.T) {
+	restartAlways := v1.ContainerRestartPolicyAlways
+	makeTestPod := func(restartPolicy *v1.ContainerRestartPolicy) *v1.Pod {
+		pod := makePod("testpod",
+			withMultiInitContainers([]v1.ResourceList{
+				{v1.ResourceCPU: resource.MustParse("6")},
+			}),
+			withMultiContainers([]v1.ResourceList{
+				{v1.ResourceCPU: resource.MustParse("2")},
+			}),
+		)
+		pod.Spec.InitContainers[0].RestartPolicy = restartPolicy
+		return pod
+	}
+
+	// LeastAllocated scores on a 8 CPUs zone: 2 CPUs (8-2)*100/8 = 75, 6 CPUs (8-6)*100/8 = 25, 8 CPUs 0
+	tests := []struct {
+		name          string
+		pod           *v1.Pod
+		scoringFn     func(logr.Logger, *v1.Pod, topologyv1alpha2.ZoneList, scoreStrategyFn, resourceToWeightMap, apiconfig.NUMAScoreAggregation) (int64, *framework.Status)
+		expectedScore int64
+	}{
+		{
+			name:          "pod scope, the init container completes before the app container starts",
+			pod:           makeTestPod(nil),
+			scoringFn:     podScopeScore,
+			expectedScore: 25,
+		},
+		{
+			name:          "pod scope, the sidecar runs alongside the app container",
+			pod:           makeTestPod(&restartAlways),
+			scoringFn:     podScopeScore,
+			expectedScore: 0,
+		},
+		{
+			name:          "container scope, the init container is not scored",
+			pod:           makeTestPod(nil),
+			scoringFn:     containerScopeScore,
+			expectedScore: 75,
+		},
+		{
+			name:          "container scope, the sidecar is scored with the app container",
+			pod:           makeTestPod(&restartAlways),
+			scoringFn:     containerScopeScore,
+			expectedScore: 50,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			zones := makeDistanceTestZones([]string{"8", "8"})
+			score, status := tt.scoringFn(klog.Background(), tt.pod, zones, leastAllocatedScoreStrategy, resourceToWeightMap{}, apiconfig.NUMAScoreAggregationMin)
+			if status != nil {
+				t.Fatalf("unexpected status: %v", status)
+			}
+			if score != tt.expectedScore {
+				t.Errorf("wrong score: got %d expected %d", score, tt.expectedScore)
+			}
+		})
+	}
+}
+
+func TestScopeScoreResize(t *testing.T) {
+	// the container was resized down from 6 to 2 CPUs, but the kubelet did not actuate the resize yet
+	makeTestPod := func() *v1.Pod {
+		pod := makePod("testpod",
+			withMultiContainers([]v1.ResourceList{
+				{v1.ResourceCPU: resource.MustParse("2")},
+			}),
+		)
+		pod.Status.ContainerStatuses = []v1.ContainerStatus{
+			{
+				Name: pod.Spec.Containers[0].Name,
+				AllocatedResources: v1.ResourceList{
+					v1.ResourceCPU: resource.MustParse("6"),
+				},
+			},
+		}
+		return pod
+	}
+
+	// LeastAllocated score on a 8 CPUs zone for 6 CPUs: (8-6)*100/8 = 25
+	tests := []struct {
+		name          string
+		scoringFn     func(logr.Logger, *v1.Pod, topologyv1alpha2.ZoneList, scoreStrategyFn, resourceToWeightMap, apiconfig.NUMAScoreAggregation) (int64, *framework.Status)
+		expectedScore int64
+	}{
+		{
+			name:          "pod scope",
+			scoringFn:     podScopeScore,
+			expectedScore: 25,
+		},
+		{
+			name:          "container scope",
+			scoringFn:     containerScopeScore,
+			expectedScore: 25,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			zones := makeDistanceTestZones([]string{"8", "8"})
+			score, status := tt.scoringFn(klog.Background(), makeTestPod(), zones, leastAllocatedScoreStrategy, resourceToWeightMap{}, apiconfig.NUMAScoreAggregationMin)
+			if status != nil {
+				t.Fatalf("unexpected status: %v", status)
+			}
+			if score != tt.expectedScore {
+				t.Errorf("wrong score: got %d expected %d", score, tt.expectedScore)
+			}
+		})
+	}
+}
+
+func TestBalancedAllocationScoreStrategyWeights(t *testing.T) {
+	requested := v1.ResourceList{
+		v1.ResourceCPU:    resource.MustParse("2"),
+		v1.ResourceMemory: resource.MustParse("2Gi"),
+		gpu:               resource.MustParse("1"),
+	}
+	available := v1.ResourceList{
+		v1.ResourceCPU:    resource.MustParse("4"),
+		v1.ResourceMemory: resource.MustParse("4Gi"),
+		gpu:               resource.MustParse("4"),
+	}
+
+	unweighted := balancedAllocationScoreStrategy(requested, available, resourceToWeightMap{})
+	// CPU and memory are perfectly balanced, so weighting them more makes the gpu imbalance count less
+	weighted := balancedAllocationScoreStrategy(requested, available, resourceToWeightMap{
+		v1.ResourceCPU:    4,
+		v1.ResourceMemory: 4,
+	})
+	if weighted <= unweighted {
+		t.Errorf("weights ignored: weighted score %d unweighted score %d", weighted, unweighted)
+	}
+
+	// only the ratios between the weights matter
+	uniform := balancedAllocationScoreStrategy(requested, available, resourceToWeightMap{
+		v1.ResourceCPU:    3,
+		v1.ResourceMemory: 3,
+		gpu:               3,
+	})
+	if uniform != unweighted {
+		t.Errorf("uniform weights changed the score: got %d expected %d", uniform, unweighted)
+	}
+	scaled := balancedAllocationScoreStrategy(requested, available, resourceToWeightMap{
+		v1.ResourceCPU:    8,
+		v1.ResourceMemory: 8,
+		gpu:               2,
+	})
+	if scaled != weighted {
+		t.Errorf("scaled weights changed the score: got %d expected %d", scaled, weighted)
+	}
+}
+
 func TestNodeResourceScorePluginLeastNUMA(t *testing.T) {
 	testCases := []struct {
 		name        string
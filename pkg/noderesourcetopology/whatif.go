@@ -0,0 +1,180 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package noderesourcetopology
+
+import (
+	"context"
+	"sort"
+
+	topologyv1alpha2 "github.com/k8stopologyawareschedwg/noderesourcetopology-api/pkg/apis/topology/v1alpha2"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog/v2"
+	"k8s.io/kubernetes/pkg/scheduler/framework"
+
+	apiconfig "sigs.k8s.io/scheduler-plugins/apis/config"
+	"sigs.k8s.io/scheduler-plugins/apis/config/validation"
+	nrtcache "sigs.k8s.io/scheduler-plugins/pkg/noderesourcetopology/cache"
+	"sigs.k8s.io/scheduler-plugins/pkg/noderesourcetopology/nodeconfig"
+)
+
+// WhatIfResult tells how the plugin evaluates a pod on a node.
+type WhatIfResult struct {
+	NodeName string `json:"nodeName"`
+	// Policy and Scope are the Topology Manager configuration of the node, as reported by its NRT object.
+	Policy string `json:"policy,omitempty"`
+	Scope  string `json:"scope,omitempty"`
+	// HasTopology is false if there is no NRT object for the node, which is then never filtered out.
+	HasTopology bool `json:"hasTopology"`
+	Fits        bool `json:"fits"`
+	// Reasons explains why the node was filtered out.
+	Reasons []string `json:"reasons,omitempty"`
+	// NUMAAssignment is the expected NUMA placement of the pod, if the node fits and the policy determines it.
+	NUMAAssignment nrtcache.NUMAAssignment `json:"numaAssignment,omitempty"`
+	// Score is meaningful only if the node fits.
+	Score int64 `json:"score"`
+}
+
+// WhatIf runs the Filter and Score code paths of the plugin against a fixed set of NRT objects,
+// without any API server, to reproduce placement decisions offline.
+type WhatIf struct {
+	tm    *TopologyMatch
+	nrts  map[string]*topologyv1alpha2.NodeResourceTopology
+	nodes map[string]*corev1.Node
+}
+
+// NewWhatIf creates a WhatIf evaluator. The nodes are optional: if missing, they are synthesized
+// from the zones of the NRT object with the same name.
+func NewWhatIf(args *apiconfig.NodeResourceTopologyMatchArgs, nrts []*topologyv1alpha2.NodeResourceTopology, nodes []*corev1.Node) (*WhatIf, error) {
+	if err := validation.ValidateNodeResourceTopologyMatchArgs(nil, args); err != nil {
+		return nil, err
+	}
+	wi := &WhatIf{
+		nrts:  make(map[string]*topologyv1alpha2.NodeResourceTopology),
+		nodes: make(map[string]*corev1.Node),
+	}
+	for _, nrt := range nrts {
+		wi.nrts[nrt.Name] = nrt
+	}
+	for _, node := range nodes {
+		wi.nodes[node.Name] = node
+	}
+	tm, err := newTopologyMatch(args, staticNRTCache(wi.nrts))
+	if err != nil {
+		return nil, err
+	}
+	wi.tm = tm
+	return wi, nil
+}
+
+// Evaluate returns the evaluation of the pod on all the known nodes; the nodes which fit come first, by decreasing score.
+func (wi *WhatIf) Evaluate(ctx context.Context, pod *corev1.Pod) []WhatIfResult {
+	var results []WhatIfResult
+	for _, nodeName := range wi.nodeNames() {
+		results = append(results, wi.evaluateNode(ctx, pod, nodeName))
+	}
+	sort.SliceStable(results, func(i, j int) bool {
+		if results[i].Fits != results[j].Fits {
+			return results[i].Fits
+		}
+		return results[i].Score > results[j].Score
+	})
+	return results
+}
+
+func (wi *WhatIf) evaluateNode(ctx context.Context, pod *corev1.Pod, nodeName string) WhatIfResult {
+	lh := klog.FromContext(ctx)
+	result := WhatIfResult{
+		NodeName: nodeName,
+	}
+
+	nrt, ok := wi.nrts[nodeName]
+	if ok {
+		conf := nodeconfig.TopologyManagerFromNodeResourceTopology(lh, nrt)
+		result.HasTopology = true
+		result.Policy = conf.Policy
+		result.Scope = conf.Scope
+	}
+
+	node, ok := wi.nodes[nodeName]
+	if !ok {
+		node = &corev1.Node{
+			ObjectMeta: metav1.ObjectMeta{Name: nodeName},
+			Status: corev1.NodeStatus{
+				Capacity:    makeResourceListFromZones(nrt.Zones),
+				Allocatable: makeResourceListFromZones(nrt.Zones),
+			},
+		}
+	}
+	nodeInfo := framework.NewNodeInfo()
+	nodeInfo.SetNode(node)
+
+	state := framework.NewCycleState()
+	status := wi.tm.Filter(ctx, state, pod, nodeInfo)
+	if !status.IsSuccess() {
+		result.Reasons = status.Reasons()
+		return result
+	}
+	result.Fits = true
+	result.NUMAAssignment = readNUMAAssignment(state, nodeName)
+
+	score, status := wi.tm.Score(ctx, state, pod, nodeName)
+	if !status.IsSuccess() {
+		result.Reasons = status.Reasons()
+	}
+	result.Score = score
+	return result
+}
+
+func (wi *WhatIf) nodeNames() []string {
+	names := make(map[string]struct{})
+	for name := range wi.nrts {
+		names[name] = struct{}{}
+	}
+	for name := range wi.nodes {
+		names[name] = struct{}{}
+	}
+	ret := make([]string, 0, len(names))
+	for name := range names {
+		ret = append(ret, name)
+	}
+	sort.Strings(ret)
+	return ret
+}
+
+// staticNRTCache serves a fixed set of NRT objects, and ignores any reservation.
+type staticNRTCache map[string]*topologyv1alpha2.NodeResourceTopology
+
+var _ nrtcache.Interface = staticNRTCache{}
+
+func (sc staticNRTCache) GetCachedNRTCopy(ctx context.Context, nodeName string, _ *corev1.Pod) (*topologyv1alpha2.NodeResourceTopology, nrtcache.CachedNRTInfo) {
+	info := nrtcache.CachedNRTInfo{Fresh: true}
+	nrt, ok := sc[nodeName]
+	if !ok {
+		return nil, info
+	}
+	return nrt.DeepCopy(), info
+}
+
+func (sc staticNRTCache) NodeMaybeOverReserved(nodeName string, pod *corev1.Pod) {}
+func (sc staticNRTCache) NodeHasForeignPods(nodeName string, pod *corev1.Pod)    {}
+func (sc staticNRTCache) ReserveNodeResources(nodeName string, pod *corev1.Pod, assignment nrtcache.NUMAAssignment) {
+}
+func (sc staticNRTCache) UnreserveNodeResources(nodeName string, pod *corev1.Pod) {}
+func (sc staticNRTCache) PreBind(nodeName string, pod *corev1.Pod)                {}
+func (sc staticNRTCache) PostBind(nodeName string, pod *corev1.Pod)               {}
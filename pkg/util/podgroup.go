@@ -22,6 +22,8 @@ import (
 	"time"
 
 	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/util/strategicpatch"
 
 	"sigs.k8s.io/scheduler-plugins/apis/scheduling/v1alpha1"
@@ -74,3 +76,30 @@ func GetWaitTimeDuration(pg *v1alpha1.PodGroup, scheduleTimeout *time.Duration)
 	}
 	return DefaultWaitTime
 }
+
+// GetTopologyMode returns the topology mode of the given pg, defaulting to Required.
+// It returns an empty mode if the pg has no topology constraint.
+func GetTopologyMode(pg *v1alpha1.PodGroup) v1alpha1.TopologyMode {
+	if pg == nil || pg.Spec.TopologyConstraint == nil {
+		return ""
+	}
+	if pg.Spec.TopologyConstraint.Mode == "" {
+		return v1alpha1.TopologyModeRequired
+	}
+	return pg.Spec.TopologyConstraint.Mode
+}
+
+// GetPodGroupRole returns the name of the role of the given pg played by the pod, that is
+// the first role whose selector matches the pod labels, or an empty string if none.
+func GetPodGroupRole(pg *v1alpha1.PodGroup, pod *v1.Pod) string {
+	for _, role := range pg.Spec.Roles {
+		selector, err := metav1.LabelSelectorAsSelector(role.Selector)
+		if err != nil {
+			continue
+		}
+		if selector.Matches(labels.Set(pod.Labels)) {
+			return role.Name
+		}
+	}
+	return ""
+}
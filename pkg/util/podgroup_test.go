@@ -19,7 +19,11 @@ package util
 import (
 	"testing"
 
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/kubernetes/pkg/apis/core"
+
+	"sigs.k8s.io/scheduler-plugins/apis/scheduling/v1alpha1"
 )
 
 func TestCreateMergePatch(t *testing.T) {
@@ -70,3 +74,43 @@ func TestCreateMergePatch(t *testing.T) {
 		}
 	}
 }
+
+func TestGetPodGroupRole(t *testing.T) {
+	pg := &v1alpha1.PodGroup{
+		Spec: v1alpha1.PodGroupSpec{
+			Roles: []v1alpha1.PodGroupRole{
+				{
+					Name:     "ps",
+					Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"role": "ps"}},
+				},
+				{
+					Name: "worker",
+					Selector: &metav1.LabelSelector{MatchExpressions: []metav1.LabelSelectorRequirement{
+						{Key: "role", Operator: metav1.LabelSelectorOpIn, Values: []string{"ps", "worker"}},
+					}},
+				},
+				{
+					Name:     "invalid",
+					Selector: &metav1.LabelSelector{MatchExpressions: []metav1.LabelSelectorRequirement{{Key: "role", Operator: "Bad"}}},
+				},
+			},
+		},
+	}
+
+	tests := []struct {
+		labels   map[string]string
+		expected string
+	}{
+		{labels: map[string]string{"role": "ps"}, expected: "ps"},
+		{labels: map[string]string{"role": "worker"}, expected: "worker"},
+		{labels: map[string]string{"role": "chief"}, expected: ""},
+		{labels: nil, expected: ""},
+	}
+
+	for _, tcase := range tests {
+		pod := &v1.Pod{ObjectMeta: metav1.ObjectMeta{Labels: tcase.labels}}
+		if got := GetPodGroupRole(pg, pod); got != tcase.expected {
+			t.Errorf("labels %v: expected role %q got %q", tcase.labels, tcase.expected, got)
+		}
+	}
+}
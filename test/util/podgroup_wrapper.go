@@ -21,6 +21,7 @@ import (
 
 	v1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	"sigs.k8s.io/scheduler-plugins/apis/scheduling/v1alpha1"
 )
@@ -68,3 +69,27 @@ func (p *PodGroupWrapper) Phase(phase v1alpha1.PodGroupPhase) *PodGroupWrapper {
 	p.Status.Phase = phase
 	return p
 }
+
+func (p *PodGroupWrapper) TopologyConstraint(key string, mode v1alpha1.TopologyMode) *PodGroupWrapper {
+	p.Spec.TopologyConstraint = &v1alpha1.TopologyConstraint{
+		TopologyKey: key,
+		Mode:        mode,
+	}
+	return p
+}
+
+func (p *PodGroupWrapper) Role(name string, matchLabels map[string]string, minMember int32, minResources map[v1.ResourceName]string) *PodGroupWrapper {
+	role := v1alpha1.PodGroupRole{
+		Name:      name,
+		Selector:  &metav1.LabelSelector{MatchLabels: matchLabels},
+		MinMember: minMember,
+	}
+	if minResources != nil {
+		role.MinResources = make(v1.ResourceList)
+		for name, value := range minResources {
+			role.MinResources[name] = resource.MustParse(value)
+		}
+	}
+	p.Spec.Roles = append(p.Spec.Roles, role)
+	return p
+}